@@ -0,0 +1,386 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// k8s-read-tui is an interactive terminal browser over the read cache:
+//
+//	k8s-read-tui --file cluster.ndjson --changelog /var/cache/k8s-read/changelog
+//
+// It loads an NDJSON dump (as written by k8s-read-tail or pkg/ndjson),
+// lets you list kinds, filter objects by label selector or by a CEL
+// expression over their fields, view object YAML, walk references
+// (pod→node, pod→PVC→PV), and — when a changelog directory is given —
+// show an object's change history. Type "help" at
+// the prompt for commands. Everything is line-oriented and ANSI-plain so
+// it works in any terminal without extra dependencies.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/kubernetes/pkg/changelog"
+	"k8s.io/kubernetes/pkg/fieldquery"
+	"k8s.io/kubernetes/pkg/ndjson"
+)
+
+func main() {
+	var file, changelogDir string
+	flag.StringVar(&file, "file", "", "NDJSON dump to browse (required)")
+	flag.StringVar(&changelogDir, "changelog", "", "changelog directory for object history (optional)")
+	flag.Parse()
+
+	if file == "" {
+		fmt.Fprintln(os.Stderr, "--file is required")
+		os.Exit(1)
+	}
+	if err := run(file, changelogDir, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// browser holds the loaded cache and serves the command loop.
+type browser struct {
+	// objects maps kind → namespace/name → object.
+	objects map[string]map[string]*unstructured.Unstructured
+	log     *changelog.Log
+	out     io.Writer
+}
+
+func run(file, changelogDir string, in io.Reader, out io.Writer) error {
+	b := &browser{objects: map[string]map[string]*unstructured.Unstructured{}, out: out}
+	if err := b.load(file); err != nil {
+		return err
+	}
+	if changelogDir != "" {
+		// The browser only reads; the cap just has to be large enough
+		// that opening never prunes what the writer kept.
+		log, err := changelog.Open(changelogDir, 1<<40, unstructured.UnstructuredJSONScheme)
+		if err != nil {
+			return fmt.Errorf("opening changelog: %v", err)
+		}
+		defer log.Close()
+		b.log = log
+	}
+
+	fmt.Fprintf(out, "loaded %d kinds; type \"help\" for commands\n", len(b.objects))
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for {
+		fmt.Fprint(out, "k8s-read> ")
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "quit" || line == "exit" || line == "q" {
+			return nil
+		}
+		if err := b.dispatch(line); err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
+
+func (b *browser) load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 16*1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var envelope ndjson.Envelope
+		if err := json.Unmarshal(scanner.Bytes(), &envelope); err != nil {
+			return fmt.Errorf("malformed NDJSON line: %v", err)
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(envelope.Object); err != nil {
+			return fmt.Errorf("malformed object for %s/%s: %v", envelope.Kind, envelope.Name, err)
+		}
+		kind := obj.GetKind()
+		if kind == "" {
+			kind = envelope.Kind
+		}
+		byName := b.objects[kind]
+		if byName == nil {
+			byName = map[string]*unstructured.Unstructured{}
+			b.objects[kind] = byName
+		}
+		// Later lines win: a dump from a watch stream ends at the newest
+		// state, and Deleted events drop the object.
+		key := objectKey(obj.GetNamespace(), obj.GetName())
+		if envelope.Event == "DELETED" {
+			delete(byName, key)
+			continue
+		}
+		byName[key] = obj
+	}
+	return scanner.Err()
+}
+
+func objectKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func (b *browser) dispatch(line string) error {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "help":
+		fmt.Fprint(b.out, `commands:
+  kinds                       list loaded kinds with object counts
+  ls <kind> [selector] [where <expr>]
+                              list objects, optionally by label selector
+                              and/or CEL expression over "obj"
+  get <kind> <ns/name>        print the object as YAML
+  refs <kind> <ns/name>       list objects this one references
+  history <kind> <ns/name>    show the object's changelog entries
+  quit                        leave
+`)
+		return nil
+	case "kinds":
+		return b.kinds()
+	case "ls":
+		if len(fields) < 2 {
+			return fmt.Errorf("usage: ls <kind> [selector] [where <expression>]")
+		}
+		// Everything after "where" is a CEL expression over "obj";
+		// everything before it (if anything) is a label selector.
+		rest, expression := fields[2:], ""
+		for i, field := range rest {
+			if field == "where" {
+				expression = strings.Join(rest[i+1:], " ")
+				if expression == "" {
+					return fmt.Errorf("usage: ls <kind> [selector] [where <expression>]")
+				}
+				rest = rest[:i]
+				break
+			}
+		}
+		return b.list(fields[1], strings.Join(rest, " "), expression)
+	case "get", "refs", "history":
+		if len(fields) != 3 {
+			return fmt.Errorf("usage: %s <kind> <ns/name>", fields[0])
+		}
+		obj, err := b.find(fields[1], fields[2])
+		if err != nil {
+			return err
+		}
+		switch fields[0] {
+		case "get":
+			return b.show(obj)
+		case "refs":
+			return b.refs(obj)
+		default:
+			return b.history(obj)
+		}
+	default:
+		return fmt.Errorf("unknown command %q; try help", fields[0])
+	}
+}
+
+func (b *browser) kinds() error {
+	kinds := make([]string, 0, len(b.objects))
+	for kind := range b.objects {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(b.out, "%-30s %d\n", kind, len(b.objects[kind]))
+	}
+	return nil
+}
+
+func (b *browser) list(kind, selectorExpr, fieldExpr string) error {
+	byName := b.objects[kind]
+	if byName == nil {
+		return fmt.Errorf("no objects of kind %q loaded", kind)
+	}
+	selector := labels.Everything()
+	if selectorExpr != "" {
+		parsed, err := labels.Parse(selectorExpr)
+		if err != nil {
+			return fmt.Errorf("bad selector: %v", err)
+		}
+		selector = parsed
+	}
+	var query *fieldquery.Query
+	if fieldExpr != "" {
+		compiled, err := fieldquery.Compile("obj", fieldExpr)
+		if err != nil {
+			return fmt.Errorf("bad expression: %v", err)
+		}
+		query = compiled
+	}
+	keys := make([]string, 0, len(byName))
+	unevaluated := 0
+	for key, obj := range byName {
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		if query != nil {
+			matched, err := query.EvalBool(obj)
+			if err != nil {
+				// Objects without the referenced field simply don't
+				// match; guard with has() to tell the cases apart.
+				unevaluated++
+				continue
+			}
+			if !matched {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintln(b.out, key)
+	}
+	if unevaluated > 0 {
+		fmt.Fprintf(b.out, "(%d of %d; %d did not evaluate)\n", len(keys), len(byName), unevaluated)
+		return nil
+	}
+	fmt.Fprintf(b.out, "(%d of %d)\n", len(keys), len(byName))
+	return nil
+}
+
+func (b *browser) find(kind, key string) (*unstructured.Unstructured, error) {
+	byName := b.objects[kind]
+	if byName == nil {
+		return nil, fmt.Errorf("no objects of kind %q loaded", kind)
+	}
+	if obj := byName[key]; obj != nil {
+		return obj, nil
+	}
+	return nil, fmt.Errorf("%s %q not found", kind, key)
+}
+
+func (b *browser) show(obj *unstructured.Unstructured) error {
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	_, err = b.out.Write(data)
+	return err
+}
+
+// reference is one outgoing edge from an object.
+type reference struct {
+	kind, key string
+}
+
+// referencesOf extracts the edges the browser can jump across. This is a
+// browsing aid, not an exhaustive reference model; pkg/integrity owns the
+// full edge set.
+func referencesOf(obj *unstructured.Unstructured) []reference {
+	namespace := obj.GetNamespace()
+	var refs []reference
+	switch obj.GetKind() {
+	case "Pod":
+		if nodeName, _, _ := unstructured.NestedString(obj.Object, "spec", "nodeName"); nodeName != "" {
+			refs = append(refs, reference{"Node", nodeName})
+		}
+		if serviceAccount, _, _ := unstructured.NestedString(obj.Object, "spec", "serviceAccountName"); serviceAccount != "" {
+			refs = append(refs, reference{"ServiceAccount", objectKey(namespace, serviceAccount)})
+		}
+		volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumes")
+		for _, volume := range volumes {
+			volumeMap, ok := volume.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if claim, _, _ := unstructured.NestedString(volumeMap, "persistentVolumeClaim", "claimName"); claim != "" {
+				refs = append(refs, reference{"PersistentVolumeClaim", objectKey(namespace, claim)})
+			}
+			if name, _, _ := unstructured.NestedString(volumeMap, "configMap", "name"); name != "" {
+				refs = append(refs, reference{"ConfigMap", objectKey(namespace, name)})
+			}
+			if name, _, _ := unstructured.NestedString(volumeMap, "secret", "secretName"); name != "" {
+				refs = append(refs, reference{"Secret", objectKey(namespace, name)})
+			}
+		}
+	case "PersistentVolumeClaim":
+		if volumeName, _, _ := unstructured.NestedString(obj.Object, "spec", "volumeName"); volumeName != "" {
+			refs = append(refs, reference{"PersistentVolume", volumeName})
+		}
+	case "PersistentVolume":
+		if claimNamespace, _, _ := unstructured.NestedString(obj.Object, "spec", "claimRef", "namespace"); claimNamespace != "" {
+			claimName, _, _ := unstructured.NestedString(obj.Object, "spec", "claimRef", "name")
+			refs = append(refs, reference{"PersistentVolumeClaim", objectKey(claimNamespace, claimName)})
+		}
+	}
+	for _, owner := range obj.GetOwnerReferences() {
+		refs = append(refs, reference{owner.Kind, objectKey(namespace, owner.Name)})
+	}
+	return refs
+}
+
+func (b *browser) refs(obj *unstructured.Unstructured) error {
+	refs := referencesOf(obj)
+	if len(refs) == 0 {
+		fmt.Fprintln(b.out, "no references")
+		return nil
+	}
+	for _, ref := range refs {
+		status := "not in cache"
+		if byName := b.objects[ref.kind]; byName != nil && byName[ref.key] != nil {
+			status = "cached; get " + ref.kind + " " + ref.key
+		}
+		fmt.Fprintf(b.out, "%-25s %-40s %s\n", ref.kind, ref.key, status)
+	}
+	return nil
+}
+
+func (b *browser) history(obj *unstructured.Unstructured) error {
+	if b.log == nil {
+		return fmt.Errorf("no changelog loaded; rerun with --changelog")
+	}
+	entries, err := b.log.Query(changelog.Query{UID: obj.GetUID()})
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(b.out, "no recorded changes")
+		return nil
+	}
+	for _, entry := range entries {
+		changed := ""
+		if len(entry.Changed) > 0 {
+			changed = " " + strings.Join(entry.Changed, ",")
+		}
+		fmt.Fprintf(b.out, "%s %-7s%s\n", entry.Time.Format("2006-01-02T15:04:05Z"), entry.Verb, changed)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k8s.io/kubernetes/pkg/changelog"
+)
+
+// writeDump writes NDJSON lines for the browser to load.
+func writeDump(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dump.ndjson")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const (
+	webPodLine = `{"kind":"Pod","namespace":"default","name":"web","object":{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"web","uid":"uid-web","labels":{"app":"web"}},"spec":{"nodeName":"node-1","volumes":[{"name":"data","persistentVolumeClaim":{"claimName":"web-data"}}],"containers":[{"name":"app","image":"app:v1"}]},"status":{"phase":"Running"}}}`
+
+	dbPodLine = `{"kind":"Pod","namespace":"default","name":"db","object":{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"db","uid":"uid-db","labels":{"app":"db"}},"spec":{"containers":[{"name":"db","image":"db:v1"}]},"status":{"phase":"Running"}}}`
+
+	tuiNodeLine = `{"kind":"Node","name":"node-1","object":{"apiVersion":"v1","kind":"Node","metadata":{"name":"node-1","uid":"uid-node"}}}`
+
+	deletedPodLine = `{"event":"DELETED","kind":"Pod","namespace":"default","name":"db","object":{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"db","uid":"uid-db"}}}`
+)
+
+// script runs one REPL session and returns everything it printed.
+func script(t *testing.T, file, changelogDir string, commands ...string) string {
+	t.Helper()
+	in := strings.NewReader(strings.Join(commands, "\n") + "\nquit\n")
+	var out bytes.Buffer
+	if err := run(file, changelogDir, in, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestBrowseFilterShowSession(t *testing.T) {
+	dumpFile := writeDump(t, webPodLine, dbPodLine, tuiNodeLine)
+	text := script(t, dumpFile, "",
+		"kinds",
+		"ls Pod",
+		"ls Pod app=web",
+		"get Pod default/web",
+	)
+	if !strings.Contains(text, "loaded 2 kinds") {
+		t.Errorf("banner missing:\n%s", text)
+	}
+	// kinds: both kinds with counts.
+	if !strings.Contains(text, "Pod") || !strings.Contains(text, "Node") {
+		t.Errorf("kinds listing incomplete:\n%s", text)
+	}
+	// ls without selector sees both pods; the selector narrows to one.
+	if !strings.Contains(text, "(2 of 2)") || !strings.Contains(text, "(1 of 2)") {
+		t.Errorf("list counts missing:\n%s", text)
+	}
+	if !strings.Contains(text, "default/db") {
+		t.Errorf("unfiltered list missing default/db:\n%s", text)
+	}
+	// get prints the object as YAML.
+	if !strings.Contains(text, "name: web") || !strings.Contains(text, "nodeName: node-1") {
+		t.Errorf("YAML output missing:\n%s", text)
+	}
+}
+
+func TestFieldExpressionSession(t *testing.T) {
+	dumpFile := writeDump(t, webPodLine, dbPodLine)
+	text := script(t, dumpFile, "",
+		`ls Pod where obj.spec.nodeName == "node-1"`,
+		`ls Pod app=web where obj.status.phase == "Running"`,
+		`ls Pod where obj.spec.`,
+	)
+	// Only the web pod is scheduled to node-1; the unscheduled db pod
+	// has no spec.nodeName and counts as not evaluable, not an error.
+	if !strings.Contains(text, "default/web") || strings.Contains(text, "default/db") {
+		t.Errorf("field filter wrong:\n%s", text)
+	}
+	if !strings.Contains(text, "(1 of 2; 1 did not evaluate)") {
+		t.Errorf("unevaluated count missing:\n%s", text)
+	}
+	// Selector and expression compose.
+	if !strings.Contains(text, "(1 of 2)") {
+		t.Errorf("combined filter count missing:\n%s", text)
+	}
+	if !strings.Contains(text, "error: bad expression") {
+		t.Errorf("malformed expression not reported:\n%s", text)
+	}
+}
+
+func TestRefsSession(t *testing.T) {
+	dumpFile := writeDump(t, webPodLine, tuiNodeLine)
+	text := script(t, dumpFile, "", "refs Pod default/web")
+	// The node is in the cache, the claim is not.
+	if !strings.Contains(text, "Node") || !strings.Contains(text, "cached; get Node node-1") {
+		t.Errorf("node reference missing:\n%s", text)
+	}
+	if !strings.Contains(text, "default/web-data") || !strings.Contains(text, "not in cache") {
+		t.Errorf("claim reference missing:\n%s", text)
+	}
+}
+
+func TestDeletedEventDropsObject(t *testing.T) {
+	dumpFile := writeDump(t, webPodLine, dbPodLine, deletedPodLine)
+	text := script(t, dumpFile, "", "ls Pod", "get Pod default/db")
+	if !strings.Contains(text, "(1 of 1)") {
+		t.Errorf("deleted pod still listed:\n%s", text)
+	}
+	if !strings.Contains(text, `error: Pod "default/db" not found`) {
+		t.Errorf("get on deleted pod should fail:\n%s", text)
+	}
+}
+
+func TestHistorySession(t *testing.T) {
+	dumpFile := writeDump(t, webPodLine)
+
+	// Without a changelog the command explains itself.
+	text := script(t, dumpFile, "", "history Pod default/web")
+	if !strings.Contains(text, "no changelog loaded") {
+		t.Errorf("missing changelog hint:\n%s", text)
+	}
+
+	// Record one change under the pod's UID and read it back.
+	changelogDir := t.TempDir()
+	log, err := changelog.Open(changelogDir, 1<<20, unstructured.UnstructuredJSONScheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"namespace": "default", "name": "web", "uid": "uid-web",
+		},
+		"status": map[string]interface{}{"phase": "Running"},
+	}}
+	if err := log.Record(changelog.Update, nil, pod); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	text = script(t, dumpFile, changelogDir, "history Pod default/web")
+	if !strings.Contains(text, "update") {
+		t.Errorf("history entry missing:\n%s", text)
+	}
+}
+
+func TestBadCommands(t *testing.T) {
+	dumpFile := writeDump(t, webPodLine)
+	text := script(t, dumpFile, "",
+		"frobnicate",
+		"ls",
+		"ls Pod where",
+		"get Pod",
+		"ls Secret",
+	)
+	for _, want := range []string{
+		`unknown command "frobnicate"`,
+		"usage: ls <kind> [selector] [where <expression>]",
+		"usage: get <kind> <ns/name>",
+		`no objects of kind "Secret" loaded`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"k8s.io/kubernetes/pkg/cmsecretreport"
+	"k8s.io/kubernetes/pkg/ndjson"
+	"k8s.io/kubernetes/pkg/snapshotbackup"
+	"k8s.io/kubernetes/pkg/widerender"
+)
+
+// dump is a loaded NDJSON file, indexed by kind.
+type dump struct {
+	byKind map[string][]*unstructured.Unstructured
+}
+
+func loadDump(file string) (*dump, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d := &dump{byKind: map[string][]*unstructured.Unstructured{}}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 16*1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var envelope ndjson.Envelope
+		if err := json.Unmarshal(scanner.Bytes(), &envelope); err != nil {
+			return nil, fmt.Errorf("malformed NDJSON line: %v", err)
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(envelope.Object); err != nil {
+			return nil, fmt.Errorf("malformed object for %s/%s: %v", envelope.Kind, envelope.Name, err)
+		}
+		kind := obj.GetKind()
+		if kind == "" {
+			kind = envelope.Kind
+		}
+		d.byKind[kind] = append(d.byKind[kind], obj)
+	}
+	return d, scanner.Err()
+}
+
+// typedOf converts dump objects of one kind into their typed form.
+func typedOf[T any](d *dump, kind string) ([]*T, error) {
+	var out []*T
+	for _, obj := range d.byKind[kind] {
+		typed := new(T)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+			return nil, fmt.Errorf("converting %s %s: %v", kind, obj.GetName(), err)
+		}
+		out = append(out, typed)
+	}
+	return out, nil
+}
+
+func cmdSnapshot(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	file := flags.String("file", "", "NDJSON dump to archive (required)")
+	dir := flags.String("dir", "", "backup directory (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" || *dir == "" {
+		return fmt.Errorf("--file and --dir are required")
+	}
+	d, err := loadDump(*file)
+	if err != nil {
+		return err
+	}
+	store, err := snapshotbackup.NewFSStore(*dir)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	backuper, err := snapshotbackup.NewBackuper(ctx, store, unstructured.UnstructuredJSONScheme)
+	if err != nil {
+		return err
+	}
+	var objs []runtime.Object
+	for _, kind := range sortedKinds(d) {
+		for _, obj := range d.byKind[kind] {
+			objs = append(objs, obj)
+		}
+	}
+	manifest, err := backuper.Backup(ctx, objs)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "backup %d: %d objects, %d blobs uploaded\n", manifest.Sequence, len(manifest.Entries), manifest.Uploaded)
+	return nil
+}
+
+func cmdDiff(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	oldFile := flags.String("old", "", "older NDJSON dump (required)")
+	newFile := flags.String("new", "", "newer NDJSON dump (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *oldFile == "" || *newFile == "" {
+		return fmt.Errorf("--old and --new are required")
+	}
+	oldDump, err := loadDump(*oldFile)
+	if err != nil {
+		return err
+	}
+	newDump, err := loadDump(*newFile)
+	if err != nil {
+		return err
+	}
+
+	index := func(d *dump) map[string]*unstructured.Unstructured {
+		byKey := map[string]*unstructured.Unstructured{}
+		for kind, objs := range d.byKind {
+			for _, obj := range objs {
+				byKey[kind+" "+objectKey(obj.GetNamespace(), obj.GetName())] = obj
+			}
+		}
+		return byKey
+	}
+	oldByKey, newByKey := index(oldDump), index(newDump)
+
+	var lines []string
+	for key, newObj := range newByKey {
+		oldObj, ok := oldByKey[key]
+		if !ok {
+			lines = append(lines, "+ "+key)
+			continue
+		}
+		if oldObj.GetResourceVersion() != newObj.GetResourceVersion() {
+			lines = append(lines, fmt.Sprintf("~ %s (rv %s -> %s)", key, oldObj.GetResourceVersion(), newObj.GetResourceVersion()))
+		}
+	}
+	for key := range oldByKey {
+		if _, ok := newByKey[key]; !ok {
+			lines = append(lines, "- "+key)
+		}
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintf(out, "%d differences\n", len(lines))
+	return nil
+}
+
+func cmdNodeUsage(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("node-usage", flag.ContinueOnError)
+	file := flags.String("file", "", "NDJSON dump (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("--file is required")
+	}
+	d, err := loadDump(*file)
+	if err != nil {
+		return err
+	}
+	pods, err := typedOf[v1.Pod](d, "Pod")
+	if err != nil {
+		return err
+	}
+	nodes, err := typedOf[v1.Node](d, "Node")
+	if err != nil {
+		return err
+	}
+
+	type usage struct {
+		cpu, memory resource.Quantity
+		pods        int
+	}
+	perNode := map[string]*usage{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		u := perNode[pod.Spec.NodeName]
+		if u == nil {
+			u = &usage{}
+			perNode[pod.Spec.NodeName] = u
+		}
+		u.pods++
+		for _, container := range pod.Spec.Containers {
+			if cpu, ok := container.Resources.Requests[v1.ResourceCPU]; ok {
+				u.cpu.Add(cpu)
+			}
+			if memory, ok := container.Resources.Requests[v1.ResourceMemory]; ok {
+				u.memory.Add(memory)
+			}
+		}
+	}
+
+	allocatable := map[string]v1.ResourceList{}
+	names := make([]string, 0, len(perNode))
+	for _, node := range nodes {
+		allocatable[node.Name] = node.Status.Allocatable
+	}
+	for name := range perNode {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(out, 6, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tPODS\tCPU REQ\tMEM REQ\tCPU ALLOC\tMEM ALLOC")
+	for _, name := range names {
+		u := perNode[name]
+		cpuAlloc, memAlloc := "<unknown>", "<unknown>"
+		if alloc, ok := allocatable[name]; ok {
+			cpu := alloc[v1.ResourceCPU]
+			mem := alloc[v1.ResourceMemory]
+			cpuAlloc, memAlloc = cpu.String(), mem.String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", name, u.pods, u.cpu.String(), u.memory.String(), cpuAlloc, memAlloc)
+	}
+	return tw.Flush()
+}
+
+func cmdStorage(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("storage", flag.ContinueOnError)
+	file := flags.String("file", "", "NDJSON dump (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("--file is required")
+	}
+	d, err := loadDump(*file)
+	if err != nil {
+		return err
+	}
+	pvs, err := typedOf[v1.PersistentVolume](d, "PersistentVolume")
+	if err != nil {
+		return err
+	}
+	if len(pvs) > 0 {
+		fmt.Fprintln(out, "persistent volumes:")
+		if err := widerender.PersistentVolumeRenderer().Write(out, pvs, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	configMaps, err := typedOf[v1.ConfigMap](d, "ConfigMap")
+	if err != nil {
+		return err
+	}
+	secrets, err := typedOf[v1.Secret](d, "Secret")
+	if err != nil {
+		return err
+	}
+	pods, err := typedOf[v1.Pod](d, "Pod")
+	if err != nil {
+		return err
+	}
+	report := cmsecretreport.Summarize(configMaps, secrets, pods)
+	if len(report.Namespaces) > 0 {
+		fmt.Fprintln(out, "configmap/secret bytes per namespace:")
+		for _, total := range report.Namespaces {
+			fmt.Fprintf(out, "  %-20s %d configmaps, %d secrets, %d bytes\n",
+				total.Namespace, total.ConfigMaps, total.Secrets, total.Bytes)
+		}
+	}
+	for _, obj := range report.Objects {
+		if obj.NearLimit {
+			fmt.Fprintf(out, "WARNING: %s %s/%s is near the 1MiB limit (%d bytes)\n",
+				obj.Kind, obj.Namespace, obj.Name, obj.Bytes)
+		}
+	}
+	return nil
+}
+
+func cmdExplainPending(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("explain-pending", flag.ContinueOnError)
+	file := flags.String("file", "", "NDJSON dump (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("--file is required")
+	}
+	d, err := loadDump(*file)
+	if err != nil {
+		return err
+	}
+	pods, err := typedOf[v1.Pod](d, "Pod")
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodPending {
+			continue
+		}
+		pending++
+		reason := "no scheduling condition recorded"
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == v1.PodScheduled && condition.Status != v1.ConditionTrue {
+				reason = condition.Reason
+				if condition.Message != "" {
+					reason += ": " + condition.Message
+				}
+			}
+		}
+		fmt.Fprintf(out, "%s/%s\t%s\n", pod.Namespace, pod.Name, reason)
+	}
+	if pending == 0 {
+		fmt.Fprintln(out, "no pending pods")
+	}
+	return nil
+}
+
+func cmdTree(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("tree", flag.ContinueOnError)
+	file := flags.String("file", "", "NDJSON dump (required)")
+	namespace := flags.String("namespace", "", "namespace to render (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" || *namespace == "" {
+		return fmt.Errorf("--file and --namespace are required")
+	}
+	d, err := loadDump(*file)
+	if err != nil {
+		return err
+	}
+
+	type node struct {
+		kind, name string
+		children   []*node
+	}
+	nodes := map[string]*node{}
+	var order []string
+	for _, kind := range sortedKinds(d) {
+		for _, obj := range d.byKind[kind] {
+			if obj.GetNamespace() != *namespace {
+				continue
+			}
+			key := kind + "/" + obj.GetName()
+			nodes[key] = &node{kind: kind, name: obj.GetName()}
+			order = append(order, key)
+		}
+	}
+	roots := map[string]bool{}
+	for _, key := range order {
+		roots[key] = true
+	}
+	for _, kind := range sortedKinds(d) {
+		for _, obj := range d.byKind[kind] {
+			if obj.GetNamespace() != *namespace {
+				continue
+			}
+			key := kind + "/" + obj.GetName()
+			for _, owner := range obj.GetOwnerReferences() {
+				parent := nodes[owner.Kind+"/"+owner.Name]
+				if parent == nil {
+					continue
+				}
+				parent.children = append(parent.children, nodes[key])
+				delete(roots, key)
+			}
+		}
+	}
+
+	var render func(n *node, depth int)
+	render = func(n *node, depth int) {
+		fmt.Fprintf(out, "%s%s/%s\n", strings.Repeat("  ", depth), n.kind, n.name)
+		sort.Slice(n.children, func(i, j int) bool {
+			if n.children[i].kind != n.children[j].kind {
+				return n.children[i].kind < n.children[j].kind
+			}
+			return n.children[i].name < n.children[j].name
+		})
+		for _, child := range n.children {
+			render(child, depth+1)
+		}
+	}
+	for _, key := range order {
+		if roots[key] {
+			render(nodes[key], 0)
+		}
+	}
+	return nil
+}
+
+func sortedKinds(d *dump) []string {
+	kinds := make([]string, 0, len(d.byKind))
+	for kind := range d.byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func objectKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// k8s-read is the umbrella CLI over this package's read subsystems.
+// Offline subcommands work on an NDJSON dump (as written by "k8s-read
+// tail" or pkg/ndjson); tail talks to a live cluster:
+//
+//	k8s-read tail --resource pods > cluster.ndjson
+//	k8s-read snapshot --file cluster.ndjson --dir /var/backups/cluster
+//	k8s-read diff --old yesterday.ndjson --new cluster.ndjson
+//	k8s-read report node-usage --file cluster.ndjson
+//	k8s-read report storage --file cluster.ndjson
+//	k8s-read explain-pending --file cluster.ndjson
+//	k8s-read tree --file cluster.ndjson --namespace default
+//
+// Each subcommand is a thin wiring of its library package; the logic
+// lives and is tested there.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(1)
+	}
+	args := os.Args[2:]
+	var err error
+	switch os.Args[1] {
+	case "tail":
+		err = cmdTail(args)
+	case "snapshot":
+		err = cmdSnapshot(args, os.Stdout)
+	case "diff":
+		err = cmdDiff(args, os.Stdout)
+	case "report":
+		if len(args) < 1 {
+			err = fmt.Errorf("usage: k8s-read report {node-usage|storage} ...")
+			break
+		}
+		switch args[0] {
+		case "node-usage":
+			err = cmdNodeUsage(args[1:], os.Stdout)
+		case "storage":
+			err = cmdStorage(args[1:], os.Stdout)
+		default:
+			err = fmt.Errorf("unknown report %q", args[0])
+		}
+	case "explain-pending":
+		err = cmdExplainPending(args, os.Stdout)
+	case "tree":
+		err = cmdTree(args, os.Stdout)
+	case "help", "-h", "--help":
+		usage(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage(w *os.File) {
+	fmt.Fprint(w, `usage: k8s-read <subcommand> [flags]
+
+subcommands:
+  tail             stream a watch as NDJSON (live cluster)
+  snapshot         archive a dump into a content-addressed backup
+  diff             compare two dumps
+  report node-usage  requested resources per node
+  report storage   PV/PVC and ConfigMap/Secret storage summary
+  explain-pending  why pending pods are pending
+  tree             ownership tree of a namespace
+`)
+}
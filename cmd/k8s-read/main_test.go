@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDump writes NDJSON lines for the subcommand tests.
+func writeDump(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dump.ndjson")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const (
+	podLine = `{"kind":"Pod","namespace":"default","name":"web","object":{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"web","uid":"uid-web","resourceVersion":"10","ownerReferences":[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-rs","uid":"uid-rs","controller":true}]},"spec":{"nodeName":"node-1","containers":[{"name":"app","image":"app:v1","resources":{"requests":{"cpu":"100m","memory":"64Mi"}}}]},"status":{"phase":"Running"}}}`
+
+	pendingPodLine = `{"kind":"Pod","namespace":"default","name":"stuck","object":{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"stuck","uid":"uid-stuck","resourceVersion":"11"},"spec":{"containers":[{"name":"app","image":"app:v1"}]},"status":{"phase":"Pending","conditions":[{"type":"PodScheduled","status":"False","reason":"Unschedulable","message":"0/3 nodes are available"}]}}}`
+
+	replicaSetLine = `{"kind":"ReplicaSet","namespace":"default","name":"web-rs","object":{"apiVersion":"apps/v1","kind":"ReplicaSet","metadata":{"namespace":"default","name":"web-rs","uid":"uid-rs","resourceVersion":"5","ownerReferences":[{"apiVersion":"apps/v1","kind":"Deployment","name":"web-deploy","uid":"uid-deploy","controller":true}]}}}`
+
+	deploymentLine = `{"kind":"Deployment","namespace":"default","name":"web-deploy","object":{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"namespace":"default","name":"web-deploy","uid":"uid-deploy","resourceVersion":"4"}}}`
+
+	nodeLine = `{"kind":"Node","name":"node-1","object":{"apiVersion":"v1","kind":"Node","metadata":{"name":"node-1","uid":"uid-node","resourceVersion":"3"},"status":{"allocatable":{"cpu":"4","memory":"16Gi"}}}}`
+
+	pvLine = `{"kind":"PersistentVolume","name":"pv-1","object":{"apiVersion":"v1","kind":"PersistentVolume","metadata":{"name":"pv-1","uid":"uid-pv","resourceVersion":"7"},"spec":{"capacity":{"storage":"10Gi"},"accessModes":["ReadWriteOnce"],"persistentVolumeReclaimPolicy":"Retain","storageClassName":"fast"},"status":{"phase":"Bound"}}}`
+)
+
+func TestSnapshotSubcommand(t *testing.T) {
+	dumpFile := writeDump(t, podLine, nodeLine)
+	backupDir := t.TempDir()
+
+	var out bytes.Buffer
+	if err := cmdSnapshot([]string{"--file", dumpFile, "--dir", backupDir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 objects") {
+		t.Errorf("unexpected snapshot output: %q", out.String())
+	}
+	// A second run over the same dump uploads nothing new.
+	out.Reset()
+	if err := cmdSnapshot([]string{"--file", dumpFile, "--dir", backupDir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "0 blobs uploaded") {
+		t.Errorf("incremental snapshot re-uploaded: %q", out.String())
+	}
+}
+
+func TestDiffSubcommand(t *testing.T) {
+	oldFile := writeDump(t, podLine, nodeLine)
+	changedPod := strings.Replace(podLine, `"resourceVersion":"10"`, `"resourceVersion":"12"`, 1)
+	newFile := writeDump(t, changedPod, pvLine)
+
+	var out bytes.Buffer
+	if err := cmdDiff([]string{"--old", oldFile, "--new", newFile}, &out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, want := range []string{"+ PersistentVolume pv-1", "- Node node-1", "~ Pod default/web (rv 10 -> 12)", "3 differences"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("diff output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestNodeUsageSubcommand(t *testing.T) {
+	dumpFile := writeDump(t, podLine, pendingPodLine, nodeLine)
+	var out bytes.Buffer
+	if err := cmdNodeUsage([]string{"--file", dumpFile}, &out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "node-1") || !strings.Contains(text, "100m") || !strings.Contains(text, "16Gi") {
+		t.Errorf("unexpected node-usage output:\n%s", text)
+	}
+}
+
+func TestStorageSubcommand(t *testing.T) {
+	configMapLine := `{"kind":"ConfigMap","namespace":"default","name":"settings","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"default","name":"settings","uid":"uid-cm","resourceVersion":"8"},"data":{"key":"value"}}}`
+	dumpFile := writeDump(t, pvLine, configMapLine, podLine)
+	var out bytes.Buffer
+	if err := cmdStorage([]string{"--file", dumpFile}, &out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "pv-1") || !strings.Contains(text, "10Gi") {
+		t.Errorf("PV table missing:\n%s", text)
+	}
+	if !strings.Contains(text, "1 configmaps") {
+		t.Errorf("namespace totals missing:\n%s", text)
+	}
+}
+
+func TestExplainPendingSubcommand(t *testing.T) {
+	dumpFile := writeDump(t, podLine, pendingPodLine)
+	var out bytes.Buffer
+	if err := cmdExplainPending([]string{"--file", dumpFile}, &out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "default/stuck") || !strings.Contains(text, "Unschedulable: 0/3 nodes are available") {
+		t.Errorf("unexpected explain-pending output:\n%s", text)
+	}
+	if strings.Contains(text, "default/web") {
+		t.Errorf("running pod reported as pending:\n%s", text)
+	}
+}
+
+func TestTreeSubcommand(t *testing.T) {
+	dumpFile := writeDump(t, podLine, replicaSetLine, deploymentLine)
+	var out bytes.Buffer
+	if err := cmdTree([]string{"--file", dumpFile, "--namespace", "default"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "Deployment/web-deploy\n  ReplicaSet/web-rs\n    Pod/web\n"
+	if out.String() != want {
+		t.Errorf("tree = %q, want %q", out.String(), want)
+	}
+}
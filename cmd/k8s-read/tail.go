@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/kubernetes/pkg/ndjson"
+)
+
+// cmdTail is the standalone k8s-read-tail command as a subcommand, so
+// the umbrella CLI covers the live path too.
+func cmdTail(args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	kubeconfig := flags.String("kubeconfig", os.Getenv("KUBECONFIG"), "path to the kubeconfig file")
+	resourceArg := flags.String("resource", "", "resource to tail, as resource[.version][.group]")
+	namespace := flags.String("namespace", metav1.NamespaceAll, "namespace to tail; defaults to all namespaces")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *resourceArg == "" {
+		return fmt.Errorf("--resource is required")
+	}
+
+	gvr, gr := schema.ParseResourceArg(*resourceArg)
+	if gvr == nil {
+		if gr.Group == "" {
+			gvr = &schema.GroupVersionResource{Version: "v1", Resource: gr.Resource}
+		} else {
+			return fmt.Errorf("resource %q needs an explicit version, e.g. %s.v1.%s", *resourceArg, gr.Resource, gr.Group)
+		}
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		return fmt.Errorf("loading kubeconfig: %v", err)
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	list, err := client.Resource(*gvr).Namespace(*namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("listing %s: %v", gvr.String(), err)
+	}
+	watcher, err := client.Resource(*gvr).Namespace(*namespace).Watch(ctx, metav1.ListOptions{
+		ResourceVersion: list.GetResourceVersion(),
+	})
+	if err != nil {
+		return fmt.Errorf("watching %s: %v", gvr.String(), err)
+	}
+	defer watcher.Stop()
+
+	return ndjson.Copy(ndjson.NewWriter(os.Stdout), watcher.ResultChan())
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package affinitydryrun scores a candidate pod against a snapshot of
+// nodes and running pods, evaluating node affinity, pod affinity and
+// pod anti-affinity the way the scheduler's filter and score phases do,
+// but keeping the reason each node was rejected instead of discarding
+// it. The output answers "why won't this schedule" without running a
+// scheduler.
+//
+// Namespace selectors on affinity terms need namespace objects to
+// resolve; terms using only NamespaceSelector are treated as matching
+// no namespaces here, which is the conservative read for anti-affinity.
+package affinitydryrun
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+)
+
+// NodeResult is the dry-run outcome for one node.
+type NodeResult struct {
+	// Node is the node's name.
+	Node string
+	// Feasible is false when a required term rejects the node.
+	Feasible bool
+	// Score sums preferred term weights: node affinity and pod
+	// affinity add, anti-affinity subtracts. Only meaningful between
+	// feasible nodes.
+	Score int64
+	// Reasons explains each required-term rejection.
+	Reasons []string
+}
+
+// Evaluate dry-runs the candidate against every node. Existing pods
+// are consulted for pod (anti-)affinity; pods not yet assigned to a
+// node are ignored. Results sort by feasibility, then score, then name.
+func Evaluate(candidate *v1.Pod, nodes []*v1.Node, pods []*v1.Pod) ([]NodeResult, error) {
+	nodesByName := make(map[string]*v1.Node, len(nodes))
+	for _, node := range nodes {
+		nodesByName[node.Name] = node
+	}
+
+	required := nodeaffinity.GetRequiredNodeAffinity(candidate)
+	var preferred *nodeaffinity.PreferredSchedulingTerms
+	if affinity := candidate.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil &&
+		len(affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0 {
+		var err error
+		preferred, err = nodeaffinity.NewPreferredSchedulingTerms(affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferred node affinity: %v", err)
+		}
+	}
+
+	results := make([]NodeResult, 0, len(nodes))
+	for _, node := range nodes {
+		result := NodeResult{Node: node.Name, Feasible: true}
+
+		if match, err := required.Match(node); err != nil {
+			return nil, fmt.Errorf("node affinity against %s: %v", node.Name, err)
+		} else if !match {
+			result.Feasible = false
+			result.Reasons = append(result.Reasons, "required node affinity or nodeSelector does not match")
+		}
+		if preferred != nil {
+			result.Score += preferred.Score(node)
+		}
+
+		if affinity := candidate.Spec.Affinity; affinity != nil {
+			if err := evalPodAffinity(candidate, node, nodesByName, pods, affinity, &result); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Feasible != results[j].Feasible {
+			return results[i].Feasible
+		}
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Node < results[j].Node
+	})
+	return results, nil
+}
+
+func evalPodAffinity(candidate *v1.Pod, node *v1.Node, nodesByName map[string]*v1.Node, pods []*v1.Pod, affinity *v1.Affinity, result *NodeResult) error {
+	if affinity.PodAffinity != nil {
+		for i, term := range affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			matched, err := anyPodInDomain(candidate, node, nodesByName, pods, &term)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				result.Feasible = false
+				result.Reasons = append(result.Reasons,
+					fmt.Sprintf("required pod affinity term %d: no matching pod in topology domain %s", i, term.TopologyKey))
+			}
+		}
+		for _, weighted := range affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			matched, err := anyPodInDomain(candidate, node, nodesByName, pods, &weighted.PodAffinityTerm)
+			if err != nil {
+				return err
+			}
+			if matched {
+				result.Score += int64(weighted.Weight)
+			}
+		}
+	}
+	if affinity.PodAntiAffinity != nil {
+		for i, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			matched, err := anyPodInDomain(candidate, node, nodesByName, pods, &term)
+			if err != nil {
+				return err
+			}
+			if matched {
+				result.Feasible = false
+				result.Reasons = append(result.Reasons,
+					fmt.Sprintf("required pod anti-affinity term %d: matching pod present in topology domain %s", i, term.TopologyKey))
+			}
+		}
+		for _, weighted := range affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			matched, err := anyPodInDomain(candidate, node, nodesByName, pods, &weighted.PodAffinityTerm)
+			if err != nil {
+				return err
+			}
+			if matched {
+				result.Score -= int64(weighted.Weight)
+			}
+		}
+	}
+	return nil
+}
+
+// anyPodInDomain reports whether any existing pod matches the term and
+// runs in the same topology domain as the candidate node.
+func anyPodInDomain(candidate *v1.Pod, node *v1.Node, nodesByName map[string]*v1.Node, pods []*v1.Pod, term *v1.PodAffinityTerm) (bool, error) {
+	domain, ok := node.Labels[term.TopologyKey]
+	if !ok {
+		// A node without the topology label is in no domain; no pod
+		// can co-locate with the candidate there.
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid label selector in affinity term: %v", err)
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || !termNamespaceMatches(candidate, term, pod.Namespace) {
+			continue
+		}
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		podNode, ok := nodesByName[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		if podNode.Labels[term.TopologyKey] == domain {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func termNamespaceMatches(candidate *v1.Pod, term *v1.PodAffinityTerm, namespace string) bool {
+	if len(term.Namespaces) == 0 {
+		if term.NamespaceSelector != nil {
+			// Resolving a namespace selector needs namespace objects we
+			// do not have; match nothing rather than everything.
+			return false
+		}
+		return namespace == candidate.Namespace
+	}
+	for _, ns := range term.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package affinitydryrun
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const zoneKey = "topology.kubernetes.io/zone"
+
+func makeNode(name, zone string, extra map[string]string) *v1.Node {
+	nodeLabels := map[string]string{zoneKey: zone, "kubernetes.io/hostname": name}
+	for k, v := range extra {
+		nodeLabels[k] = v
+	}
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels}}
+}
+
+func makePod(name, node string, podLabels map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: podLabels},
+		Spec:       v1.PodSpec{NodeName: node},
+	}
+}
+
+func appSelector(app string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}
+}
+
+func TestEvaluateNodeAffinity(t *testing.T) {
+	candidate := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new"},
+		Spec: v1.PodSpec{
+			NodeSelector: map[string]string{"disktype": "ssd"},
+			Affinity: &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{{
+					Weight: 10,
+					Preference: v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+						Key: zoneKey, Operator: v1.NodeSelectorOpIn, Values: []string{"zone-a"},
+					}}},
+				}},
+			}},
+		},
+	}
+	nodes := []*v1.Node{
+		makeNode("ssd-a", "zone-a", map[string]string{"disktype": "ssd"}),
+		makeNode("ssd-b", "zone-b", map[string]string{"disktype": "ssd"}),
+		makeNode("hdd-a", "zone-a", map[string]string{"disktype": "hdd"}),
+	}
+	results, err := Evaluate(candidate, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Node != "ssd-a" || results[0].Score != 10 || !results[0].Feasible {
+		t.Errorf("best node: %+v", results[0])
+	}
+	if results[1].Node != "ssd-b" || results[1].Score != 0 {
+		t.Errorf("second node: %+v", results[1])
+	}
+	last := results[2]
+	if last.Node != "hdd-a" || last.Feasible {
+		t.Errorf("nodeSelector mismatch should be infeasible: %+v", last)
+	}
+	if len(last.Reasons) != 1 || !strings.Contains(last.Reasons[0], "nodeSelector") {
+		t.Errorf("missing rejection reason: %v", last.Reasons)
+	}
+}
+
+func TestEvaluatePodAffinity(t *testing.T) {
+	candidate := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.PodSpec{Affinity: &v1.Affinity{PodAffinity: &v1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{
+				LabelSelector: appSelector("cache"),
+				TopologyKey:   zoneKey,
+			}},
+		}}},
+	}
+	nodes := []*v1.Node{
+		makeNode("a1", "zone-a", nil),
+		makeNode("a2", "zone-a", nil),
+		makeNode("b1", "zone-b", nil),
+	}
+	pods := []*v1.Pod{makePod("cache-0", "a1", map[string]string{"app": "cache"})}
+
+	results, err := Evaluate(candidate, nodes, pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feasible := map[string]bool{}
+	for _, result := range results {
+		feasible[result.Node] = result.Feasible
+	}
+	// Both zone-a nodes co-locate with the cache pod; zone-b does not.
+	if !feasible["a1"] || !feasible["a2"] || feasible["b1"] {
+		t.Errorf("feasibility by node: %v", feasible)
+	}
+}
+
+func TestEvaluatePodAntiAffinity(t *testing.T) {
+	candidate := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-1", Labels: map[string]string{"app": "web"}},
+		Spec: v1.PodSpec{Affinity: &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{
+				LabelSelector: appSelector("web"),
+				TopologyKey:   "kubernetes.io/hostname",
+			}},
+			PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+				Weight: 50,
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: appSelector("web"),
+					TopologyKey:   zoneKey,
+				},
+			}},
+		}}},
+	}
+	nodes := []*v1.Node{
+		makeNode("a1", "zone-a", nil),
+		makeNode("a2", "zone-a", nil),
+		makeNode("b1", "zone-b", nil),
+	}
+	pods := []*v1.Pod{makePod("web-0", "a1", map[string]string{"app": "web"})}
+
+	results, err := Evaluate(candidate, nodes, pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byNode := map[string]NodeResult{}
+	for _, result := range results {
+		byNode[result.Node] = result
+	}
+	if byNode["a1"].Feasible {
+		t.Errorf("a1 hosts a web pod, required anti-affinity must reject it: %+v", byNode["a1"])
+	}
+	if !byNode["a2"].Feasible || byNode["a2"].Score != -50 {
+		t.Errorf("a2 shares the zone, preferred anti-affinity should cost 50: %+v", byNode["a2"])
+	}
+	if !byNode["b1"].Feasible || byNode["b1"].Score != 0 {
+		t.Errorf("b1 is clean: %+v", byNode["b1"])
+	}
+	if results[0].Node != "b1" {
+		t.Errorf("b1 should rank first, got %s", results[0].Node)
+	}
+}
+
+func TestEvaluateNamespaceHandling(t *testing.T) {
+	term := v1.PodAffinityTerm{LabelSelector: appSelector("db"), TopologyKey: zoneKey}
+	candidate := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "app"},
+		Spec: v1.PodSpec{Affinity: &v1.Affinity{PodAffinity: &v1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{term},
+		}}},
+	}
+	nodes := []*v1.Node{makeNode("a1", "zone-a", nil)}
+	otherNamespace := makePod("db-0", "a1", map[string]string{"app": "db"})
+	otherNamespace.Namespace = "staging"
+
+	results, err := Evaluate(candidate, nodes, []*v1.Pod{otherNamespace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Feasible {
+		t.Error("term without namespaces must only match the candidate's namespace")
+	}
+
+	candidate.Spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].Namespaces = []string{"staging"}
+	results, err = Evaluate(candidate, nodes, []*v1.Pod{otherNamespace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Feasible {
+		t.Error("explicit namespaces list should match the staging pod")
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package analyzerfuzz registers every analyzer behind one harness shape
+// so fuzzing can exercise all of them uniformly: schema-valid populations
+// go in (from pkg/loadgen), string-rendered findings come out. The fuzz
+// targets in this package assert the two robustness properties every
+// analyzer must hold: no panic on any valid population, and byte-for-byte
+// deterministic findings for the same input. New analyzers should be
+// added to DefaultAnalyzers to come under fuzz coverage.
+package analyzerfuzz
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/dnscheck"
+	"k8s.io/kubernetes/pkg/fingerprint"
+	"k8s.io/kubernetes/pkg/integrity"
+	"k8s.io/kubernetes/pkg/loadgen"
+	"k8s.io/kubernetes/pkg/rwopcheck"
+)
+
+// Population is the common input every analyzer draws from.
+type Population struct {
+	Pods  []*v1.Pod
+	Nodes []*v1.Node
+	PVCs  []*v1.PersistentVolumeClaim
+}
+
+// Generate synthesizes a population from a loadgen config.
+func Generate(config loadgen.Config) Population {
+	g := loadgen.New(config)
+	return Population{Pods: g.Pods(), Nodes: g.Nodes(), PVCs: g.PVCs()}
+}
+
+// Analyzer adapts one analyzer to the harness: findings are rendered to
+// strings so ordering stability is comparable across heterogeneous
+// finding types.
+type Analyzer struct {
+	Name    string
+	Analyze func(Population) []string
+}
+
+// DefaultAnalyzers returns the full analyzer inventory.
+func DefaultAnalyzers() []Analyzer {
+	return []Analyzer{
+		{
+			Name: "rwopcheck",
+			Analyze: func(p Population) []string {
+				var findings []string
+				for _, conflict := range rwopcheck.Check(p.Pods, p.PVCs, nil) {
+					findings = append(findings, fmt.Sprintf("%s %s %s", conflict.Type, conflict.Claim, conflict.Message))
+				}
+				return findings
+			},
+		},
+		{
+			Name: "integrity",
+			Analyze: func(p Population) []string {
+				snapshot := integrity.Snapshot{Pods: p.Pods, Nodes: p.Nodes, PVCs: p.PVCs}
+				var findings []string
+				for _, finding := range integrity.Check(snapshot) {
+					findings = append(findings, fmt.Sprintf("%s %s -> %s", finding.Type, finding.From, finding.To))
+				}
+				return findings
+			},
+		},
+		{
+			Name: "dnscheck",
+			Analyze: func(p Population) []string {
+				var findings []string
+				for _, finding := range dnscheck.Check(dnscheck.Inputs{Pods: p.Pods}) {
+					findings = append(findings, fmt.Sprintf("%s %s %s", finding.Type, finding.Pod, finding.Message))
+				}
+				return findings
+			},
+		},
+		{
+			Name: "fingerprint",
+			Analyze: func(p Population) []string {
+				workloads := make([]fingerprint.Workload, 0, len(p.Pods))
+				for _, pod := range p.Pods {
+					workloads = append(workloads, fingerprint.Workload{
+						Namespace: pod.Namespace,
+						Name:      pod.Name,
+						Spec:      &pod.Spec,
+					})
+				}
+				var findings []string
+				for _, cluster := range fingerprint.ClusterWorkloads(workloads) {
+					findings = append(findings, fmt.Sprintf("%s x%d consistent=%v", cluster.Shape, len(cluster.Workloads), cluster.Consistent))
+				}
+				return findings
+			},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzerfuzz
+
+import (
+	"testing"
+
+	"k8s.io/kubernetes/pkg/loadgen"
+)
+
+// configFor bounds fuzzed inputs to populations loadgen accepts and tests
+// can generate quickly.
+func configFor(seed int64, pods, nodes, pvcs uint8) loadgen.Config {
+	return loadgen.Config{
+		Seed:  seed,
+		Pods:  int(pods),
+		Nodes: int(nodes) % 32,
+		PVCs:  int(pvcs) % 64,
+	}
+}
+
+// FuzzAnalyzersStable asserts the harness invariants for every registered
+// analyzer: no panic on any schema-valid population, and identical
+// findings (content and order) across repeated runs on the same input.
+func FuzzAnalyzersStable(f *testing.F) {
+	f.Add(int64(0), uint8(0), uint8(0), uint8(0))
+	f.Add(int64(1), uint8(10), uint8(3), uint8(5))
+	f.Add(int64(42), uint8(200), uint8(20), uint8(40))
+	f.Add(int64(-7), uint8(255), uint8(255), uint8(255))
+
+	analyzers := DefaultAnalyzers()
+	f.Fuzz(func(t *testing.T, seed int64, pods, nodes, pvcs uint8) {
+		config := configFor(seed, pods, nodes, pvcs)
+		population := Generate(config)
+
+		for _, analyzer := range analyzers {
+			first := analyzer.Analyze(population)
+			second := analyzer.Analyze(population)
+			if len(first) != len(second) {
+				t.Fatalf("%s: finding count changed between runs: %d vs %d", analyzer.Name, len(first), len(second))
+			}
+			for i := range first {
+				if first[i] != second[i] {
+					t.Fatalf("%s: finding %d not deterministic:\n  %s\n  %s", analyzer.Name, i, first[i], second[i])
+				}
+			}
+		}
+	})
+}
+
+// FuzzAnalyzersAcrossGenerations additionally re-generates the population
+// from the same config, catching analyzers that depend on pointer
+// identity or mutate their input.
+func FuzzAnalyzersAcrossGenerations(f *testing.F) {
+	f.Add(int64(3), uint8(50), uint8(5), uint8(10))
+
+	analyzers := DefaultAnalyzers()
+	f.Fuzz(func(t *testing.T, seed int64, pods, nodes, pvcs uint8) {
+		config := configFor(seed, pods, nodes, pvcs)
+		first := Generate(config)
+		second := Generate(config)
+
+		for _, analyzer := range analyzers {
+			a := analyzer.Analyze(first)
+			b := analyzer.Analyze(second)
+			if len(a) != len(b) {
+				t.Fatalf("%s: finding count differs across identical generations: %d vs %d", analyzer.Name, len(a), len(b))
+			}
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("%s: finding %d differs across identical generations:\n  %s\n  %s", analyzer.Name, i, a[i], b[i])
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package anonymize pseudonymizes cluster exports for sharing outside the
+// organization. The same input always maps to the same pseudonym within
+// one Anonymizer (keyed HMAC, not a counter), so references between
+// objects — ownerReferences, claimRefs, PVC volume sources, node names —
+// still resolve after anonymization. Annotations are dropped rather than
+// rewritten: they are free-form and routinely hold secrets.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Anonymizer maps real identifiers to stable pseudonyms.
+type Anonymizer struct {
+	secret []byte
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// New returns an anonymizer keyed by secret. The same secret reproduces
+// the same pseudonyms, letting a multi-file export stay coherent across
+// runs; use a fresh random secret per recipient.
+func New(secret []byte) *Anonymizer {
+	return &Anonymizer{secret: secret, cache: map[string]string{}}
+}
+
+// pseudonym returns the stable pseudonym for value within a class. The
+// class keeps namespaces and names from colliding in the mapping.
+func (a *Anonymizer) pseudonym(class, prefix, value string) string {
+	if value == "" {
+		return ""
+	}
+	key := class + "\x00" + value
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if mapped, present := a.cache[key]; present {
+		return mapped
+	}
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(key))
+	mapped := prefix + "-" + hex.EncodeToString(mac.Sum(nil))[:10]
+	a.cache[key] = mapped
+	return mapped
+}
+
+// Name pseudonymizes an object name.
+func (a *Anonymizer) Name(name string) string {
+	return a.pseudonym("name", "obj", name)
+}
+
+// Namespace pseudonymizes a namespace, leaving the well-known system
+// namespaces intact so exports stay interpretable.
+func (a *Anonymizer) Namespace(namespace string) string {
+	switch namespace {
+	case "", "default", "kube-system", "kube-public", "kube-node-lease":
+		return namespace
+	}
+	return a.pseudonym("namespace", "ns", namespace)
+}
+
+// Node pseudonymizes a node name.
+func (a *Anonymizer) Node(name string) string {
+	return a.pseudonym("node", "node", name)
+}
+
+// LabelValue pseudonymizes a label value; keys are kept, values hidden.
+func (a *Anonymizer) LabelValue(value string) string {
+	return a.pseudonym("label", "v", value)
+}
+
+// IP maps an IP to a stable address in 240.0.0.0/8 (reserved space, so
+// pseudonyms cannot collide with real infrastructure).
+func (a *Anonymizer) IP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	key := "ip\x00" + ip
+	a.mu.Lock()
+	if mapped, present := a.cache[key]; present {
+		a.mu.Unlock()
+		return mapped
+	}
+	a.mu.Unlock()
+
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(key))
+	sum := mac.Sum(nil)
+	mapped := fmt.Sprintf("240.%d.%d.%d", sum[0], sum[1], sum[2])
+
+	a.mu.Lock()
+	a.cache[key] = mapped
+	a.mu.Unlock()
+	return mapped
+}
+
+// Object rewrites one unstructured object in place. It covers the
+// identifier fields shared by all kinds plus the pod and volume fields
+// that cross-reference other objects.
+func (a *Anonymizer) Object(obj map[string]interface{}) {
+	a.rewriteString(obj, a.Name, "metadata", "name")
+	a.rewriteString(obj, a.Namespace, "metadata", "namespace")
+	unstructured.RemoveNestedField(obj, "metadata", "annotations")
+
+	if labels, found, _ := unstructured.NestedStringMap(obj, "metadata", "labels"); found {
+		for key, value := range labels {
+			labels[key] = a.LabelValue(value)
+		}
+		_ = unstructured.SetNestedStringMap(obj, labels, "metadata", "labels")
+	}
+
+	if owners, found, _ := unstructured.NestedSlice(obj, "metadata", "ownerReferences"); found {
+		for _, owner := range owners {
+			ref, ok := owner.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, ok := ref["name"].(string); ok {
+				ref["name"] = a.Name(name)
+			}
+		}
+		_ = unstructured.SetNestedSlice(obj, owners, "metadata", "ownerReferences")
+	}
+
+	// Pod fields.
+	a.rewriteString(obj, a.Node, "spec", "nodeName")
+	a.rewriteString(obj, a.IP, "status", "podIP")
+	a.rewriteString(obj, a.IP, "status", "hostIP")
+	if podIPs, found, _ := unstructured.NestedSlice(obj, "status", "podIPs"); found {
+		for _, entry := range podIPs {
+			fields, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if ip, ok := fields["ip"].(string); ok {
+				fields["ip"] = a.IP(ip)
+			}
+		}
+		_ = unstructured.SetNestedSlice(obj, podIPs, "status", "podIPs")
+	}
+	if volumes, found, _ := unstructured.NestedSlice(obj, "spec", "volumes"); found {
+		for _, entry := range volumes {
+			volume, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if claim, ok := volume["persistentVolumeClaim"].(map[string]interface{}); ok {
+				if name, ok := claim["claimName"].(string); ok {
+					claim["claimName"] = a.Name(name)
+				}
+			}
+		}
+		_ = unstructured.SetNestedSlice(obj, volumes, "spec", "volumes")
+	}
+
+	// PersistentVolume fields.
+	a.rewriteString(obj, a.Name, "spec", "claimRef", "name")
+	a.rewriteString(obj, a.Namespace, "spec", "claimRef", "namespace")
+
+	// Service/endpoint fields.
+	a.rewriteString(obj, a.IP, "spec", "clusterIP")
+}
+
+func (a *Anonymizer) rewriteString(obj map[string]interface{}, mapping func(string) string, fields ...string) {
+	value, found, err := unstructured.NestedString(obj, fields...)
+	if !found || err != nil || value == "" {
+		return
+	}
+	_ = unstructured.SetNestedField(obj, mapping(value), fields...)
+}
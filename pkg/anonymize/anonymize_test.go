@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anonymize
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConsistency(t *testing.T) {
+	a := New([]byte("secret"))
+	if a.Name("web") != a.Name("web") {
+		t.Error("same input must map to same pseudonym")
+	}
+	if a.Name("web") == a.Name("db") {
+		t.Error("different inputs must map to different pseudonyms")
+	}
+	// Classes do not collide: a namespace and a name with the same
+	// spelling get distinct pseudonyms.
+	if a.Name("payments") == a.Namespace("payments") {
+		t.Error("name and namespace classes must be independent")
+	}
+	// A second anonymizer with the same secret reproduces the mapping; a
+	// different secret does not.
+	if New([]byte("secret")).Name("web") != a.Name("web") {
+		t.Error("same secret must reproduce pseudonyms")
+	}
+	if New([]byte("other")).Name("web") == a.Name("web") {
+		t.Error("different secrets must produce different pseudonyms")
+	}
+}
+
+func TestSystemNamespacesPreserved(t *testing.T) {
+	a := New([]byte("secret"))
+	for _, namespace := range []string{"default", "kube-system", "kube-public", "kube-node-lease", ""} {
+		if got := a.Namespace(namespace); got != namespace {
+			t.Errorf("expected %q preserved, got %q", namespace, got)
+		}
+	}
+	if a.Namespace("payments") == "payments" {
+		t.Error("user namespaces must be pseudonymized")
+	}
+}
+
+func TestIPMapping(t *testing.T) {
+	a := New([]byte("secret"))
+	mapped := a.IP("10.244.0.5")
+	if !strings.HasPrefix(mapped, "240.") {
+		t.Errorf("expected pseudonym in 240/8, got %q", mapped)
+	}
+	if mapped != a.IP("10.244.0.5") {
+		t.Error("same IP must map consistently")
+	}
+	if a.IP("") != "" {
+		t.Error("empty IP must stay empty")
+	}
+}
+
+func TestObjectReferentialIntegrity(t *testing.T) {
+	a := New([]byte("secret"))
+
+	pod := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"namespace":   "payments",
+			"name":        "web-abc123-x",
+			"annotations": map[string]interface{}{"internal.example.com/token": "hunter2"},
+			"labels":      map[string]interface{}{"app": "billing"},
+			"ownerReferences": []interface{}{
+				map[string]interface{}{"kind": "ReplicaSet", "name": "web-abc123"},
+			},
+		},
+		"spec": map[string]interface{}{
+			"nodeName": "node-1",
+			"volumes": []interface{}{
+				map[string]interface{}{
+					"name":                  "data",
+					"persistentVolumeClaim": map[string]interface{}{"claimName": "web-data"},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"podIP":  "10.244.0.5",
+			"podIPs": []interface{}{map[string]interface{}{"ip": "10.244.0.5"}},
+		},
+	}
+	replicaSet := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "ReplicaSet",
+		"metadata": map[string]interface{}{
+			"namespace": "payments",
+			"name":      "web-abc123",
+		},
+	}
+	claim := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata": map[string]interface{}{
+			"namespace": "payments",
+			"name":      "web-data",
+		},
+	}
+	volume := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolume",
+		"metadata":   map[string]interface{}{"name": "pv-1"},
+		"spec": map[string]interface{}{
+			"claimRef": map[string]interface{}{"namespace": "payments", "name": "web-data"},
+		},
+	}
+
+	for _, obj := range []map[string]interface{}{pod, replicaSet, claim, volume} {
+		a.Object(obj)
+	}
+
+	// Nothing original survives.
+	podName, _, _ := unstructured.NestedString(pod, "metadata", "name")
+	if podName == "web-abc123-x" {
+		t.Error("pod name not anonymized")
+	}
+	if _, found, _ := unstructured.NestedMap(pod, "metadata", "annotations"); found {
+		t.Error("annotations must be dropped")
+	}
+	labels, _, _ := unstructured.NestedStringMap(pod, "metadata", "labels")
+	if labels["app"] == "billing" {
+		t.Error("label value not anonymized")
+	}
+	podIP, _, _ := unstructured.NestedString(pod, "status", "podIP")
+	if podIP == "10.244.0.5" {
+		t.Error("pod IP not anonymized")
+	}
+
+	// References still resolve.
+	owners, _, _ := unstructured.NestedSlice(pod, "metadata", "ownerReferences")
+	ownerName := owners[0].(map[string]interface{})["name"].(string)
+	rsName, _, _ := unstructured.NestedString(replicaSet, "metadata", "name")
+	if ownerName != rsName {
+		t.Errorf("ownerReference %q does not resolve to ReplicaSet %q", ownerName, rsName)
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(pod, "spec", "volumes")
+	claimName := volumes[0].(map[string]interface{})["persistentVolumeClaim"].(map[string]interface{})["claimName"].(string)
+	pvcName, _, _ := unstructured.NestedString(claim, "metadata", "name")
+	if claimName != pvcName {
+		t.Errorf("pod claim reference %q does not resolve to PVC %q", claimName, pvcName)
+	}
+
+	claimRefName, _, _ := unstructured.NestedString(volume, "spec", "claimRef", "name")
+	if claimRefName != pvcName {
+		t.Errorf("PV claimRef %q does not resolve to PVC %q", claimRefName, pvcName)
+	}
+	claimRefNamespace, _, _ := unstructured.NestedString(volume, "spec", "claimRef", "namespace")
+	pvcNamespace, _, _ := unstructured.NestedString(claim, "metadata", "namespace")
+	if claimRefNamespace != pvcNamespace {
+		t.Errorf("PV claimRef namespace %q does not resolve to PVC namespace %q", claimRefNamespace, pvcNamespace)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// EffectivePullPolicy returns the pull policy the kubelet will apply for
+// the container, resolving the defaulting rule the type docs describe:
+// an unset policy is Always when the image is untagged or tagged :latest,
+// and IfNotPresent otherwise. Use this for display instead of reading the
+// field, which is empty on objects that have not passed through
+// apiserver defaulting.
+func EffectivePullPolicy(container *v1.Container) v1.PullPolicy {
+	if container.ImagePullPolicy != "" {
+		return container.ImagePullPolicy
+	}
+	if isLatestImage(container.Image) {
+		return v1.PullAlways
+	}
+	return v1.PullIfNotPresent
+}
+
+// isLatestImage reports whether the image reference would default to the
+// :latest tag: either it carries it explicitly or it has no tag and no
+// digest. The last slash separates the repository from the final
+// component, keeping registry ports (host:5000/img) from reading as tags.
+func isLatestImage(image string) bool {
+	if strings.Contains(image, "@") {
+		// Digest references are immutable; never :latest.
+		return false
+	}
+	last := image
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		last = image[i+1:]
+	}
+	tagIndex := strings.Index(last, ":")
+	if tagIndex < 0 {
+		return true
+	}
+	return last[tagIndex+1:] == "latest"
+}
+
+// RestartBehavior describes when the kubelet restarts a container.
+type RestartBehavior string
+
+const (
+	// RestartAlways restarts the container whenever it exits.
+	RestartAlways RestartBehavior = "Always"
+	// RestartOnFailure restarts the container only on non-zero exit.
+	RestartOnFailure RestartBehavior = "OnFailure"
+	// RestartNever leaves the container terminated.
+	RestartNever RestartBehavior = "Never"
+)
+
+// EffectiveRestartBehavior returns how the kubelet treats the named
+// container when it exits, resolving the rules scattered across type
+// docs: regular containers follow the pod's RestartPolicy (Always when
+// unset); init containers restart on failure unless the pod policy is
+// Never, and never restart on success (they are run-to-completion);
+// ephemeral containers never restart regardless of pod policy. Unknown
+// names fall back to regular-container semantics.
+func EffectiveRestartBehavior(pod *v1.Pod, containerName string) RestartBehavior {
+	policy := pod.Spec.RestartPolicy
+	if policy == "" {
+		policy = v1.RestartPolicyAlways
+	}
+
+	for i := range pod.Spec.EphemeralContainers {
+		if pod.Spec.EphemeralContainers[i].Name == containerName {
+			return RestartNever
+		}
+	}
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == containerName {
+			if policy == v1.RestartPolicyNever {
+				return RestartNever
+			}
+			// Always and OnFailure both mean "retry until success" for
+			// init containers.
+			return RestartOnFailure
+		}
+	}
+
+	switch policy {
+	case v1.RestartPolicyNever:
+		return RestartNever
+	case v1.RestartPolicyOnFailure:
+		return RestartOnFailure
+	default:
+		return RestartAlways
+	}
+}
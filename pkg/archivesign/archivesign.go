@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package archivesign signs cluster archives and changelog segments with
+// ed25519 so archived read state can stand as evidence in compliance
+// audits. Signatures are detached JSON documents carrying the payload
+// digest, the signing key's fingerprint and the signature itself —
+// verification needs only the public key and the two files. Plain
+// ed25519 keeps the trust model auditable: no certificate chains, no
+// network dependency at verification time.
+package archivesign
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/kubernetes/pkg/snapshotbackup"
+)
+
+// Algorithm is the only supported signature algorithm.
+const Algorithm = "ed25519"
+
+// SignatureExt is appended to a payload path for its detached signature.
+const SignatureExt = ".sig"
+
+// Signature is a detached signature document.
+type Signature struct {
+	// KeyID is the signing public key's fingerprint, for key rotation
+	// bookkeeping.
+	KeyID string `json:"keyID"`
+	// Algorithm is always "ed25519"; verification rejects anything else.
+	Algorithm string `json:"algorithm"`
+	// SHA256 is the hex digest of the signed payload.
+	SHA256 string `json:"sha256"`
+	// Signature is the ed25519 signature over the payload digest.
+	Signature []byte `json:"signature"`
+}
+
+// GenerateKey returns a fresh signing key pair.
+func GenerateKey() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// KeyID fingerprints a public key.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign produces the detached signature for a payload. The signature is
+// computed over the payload's SHA-256, so large archives are signed
+// without rereading them at verification beyond one hash pass.
+func Sign(priv ed25519.PrivateKey, payload []byte) Signature {
+	digest := sha256.Sum256(payload)
+	return Signature{
+		KeyID:     KeyID(priv.Public().(ed25519.PublicKey)),
+		Algorithm: Algorithm,
+		SHA256:    hex.EncodeToString(digest[:]),
+		Signature: ed25519.Sign(priv, digest[:]),
+	}
+}
+
+// Verify checks a detached signature against a payload and public key.
+func Verify(pub ed25519.PublicKey, payload []byte, sig Signature) error {
+	if sig.Algorithm != Algorithm {
+		return fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+	if sig.KeyID != KeyID(pub) {
+		return fmt.Errorf("signature key %s does not match verification key %s", sig.KeyID, KeyID(pub))
+	}
+	digest := sha256.Sum256(payload)
+	if hex.EncodeToString(digest[:]) != sig.SHA256 {
+		return fmt.Errorf("payload digest does not match signature: payload was modified")
+	}
+	if !ed25519.Verify(pub, digest[:], sig.Signature) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// Encode serializes a signature document.
+func Encode(sig Signature) ([]byte, error) {
+	return json.Marshal(sig)
+}
+
+// Decode parses a signature document.
+func Decode(data []byte) (Signature, error) {
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return Signature{}, fmt.Errorf("parsing signature: %v", err)
+	}
+	return sig, nil
+}
+
+// SignFile signs the file at path and writes the detached signature next
+// to it as path+".sig".
+func SignFile(priv ed25519.PrivateKey, path string) error {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := Encode(Sign(priv, payload))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+SignatureExt, data, 0o644)
+}
+
+// VerifyFile verifies the file at path against its detached signature.
+func VerifyFile(pub ed25519.PublicKey, path string) error {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sigData, err := os.ReadFile(path + SignatureExt)
+	if err != nil {
+		return fmt.Errorf("reading signature for %s: %v", path, err)
+	}
+	sig, err := Decode(sigData)
+	if err != nil {
+		return err
+	}
+	if err := Verify(pub, payload, sig); err != nil {
+		return fmt.Errorf("verifying %s: %v", path, err)
+	}
+	return nil
+}
+
+// SignStoreKey signs a blob already in the store and writes the detached
+// signature under key+".sig". Signing a backup's manifest key attests the
+// whole archive: the manifest lists every object by sha256, and restore
+// verifies blob digests against it.
+func SignStoreKey(ctx context.Context, store snapshotbackup.BlobStore, priv ed25519.PrivateKey, key string) error {
+	payload, err := store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := Encode(Sign(priv, payload))
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, key+SignatureExt, data)
+}
+
+// VerifyStoreKey verifies a blob in the store against its detached
+// signature.
+func VerifyStoreKey(ctx context.Context, store snapshotbackup.BlobStore, pub ed25519.PublicKey, key string) error {
+	payload, err := store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	sigData, err := store.Get(ctx, key+SignatureExt)
+	if err != nil {
+		return fmt.Errorf("reading signature for %s: %v", key, err)
+	}
+	sig, err := Decode(sigData)
+	if err != nil {
+		return err
+	}
+	if err := Verify(pub, payload, sig); err != nil {
+		return fmt.Errorf("verifying %s: %v", key, err)
+	}
+	return nil
+}
+
+// SignSegments signs every changelog segment in dir that has no signature
+// yet. Call it after rotation: sealed segments never change, so one
+// signature per segment is final. It returns the paths signed.
+func SignSegments(priv ed25519.PrivateKey, dir string) ([]string, error) {
+	segments, err := filepath.Glob(filepath.Join(dir, "changelog-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	var signed []string
+	for _, path := range segments {
+		if _, err := os.Stat(path + SignatureExt); err == nil {
+			continue
+		}
+		if err := SignFile(priv, path); err != nil {
+			return signed, err
+		}
+		signed = append(signed, path)
+	}
+	return signed, nil
+}
+
+// VerifySegments verifies every signed changelog segment in dir and
+// returns an error naming the first failure. Segments without a
+// signature are reported too — a pruned signature is as suspect as a
+// modified segment.
+func VerifySegments(pub ed25519.PublicKey, dir string) error {
+	segments, err := filepath.Glob(filepath.Join(dir, "changelog-*.log"))
+	if err != nil {
+		return err
+	}
+	for _, path := range segments {
+		if _, err := os.Stat(path + SignatureExt); err != nil {
+			return fmt.Errorf("segment %s has no signature", filepath.Base(path))
+		}
+		if err := VerifyFile(pub, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
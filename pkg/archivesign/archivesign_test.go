@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archivesign
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/snapshotbackup"
+)
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("archived cluster state")
+
+	sig := Sign(priv, payload)
+	if sig.Algorithm != Algorithm || sig.KeyID != KeyID(pub) {
+		t.Errorf("unexpected signature metadata: %+v", sig)
+	}
+	if err := Verify(pub, payload, sig); err != nil {
+		t.Errorf("round-trip verification failed: %v", err)
+	}
+
+	// Signatures survive serialization.
+	data, err := Encode(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(pub, payload, decoded); err != nil {
+		t.Errorf("verification after encode/decode failed: %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("archived cluster state")
+	good := Sign(priv, payload)
+
+	testCases := []struct {
+		name    string
+		payload []byte
+		sig     Signature
+		pub     []byte
+		wantErr string
+	}{
+		{
+			name:    "tampered payload",
+			payload: []byte("archived cluster state, amended"),
+			sig:     good,
+			pub:     pub,
+			wantErr: "modified",
+		},
+		{
+			name:    "wrong key",
+			payload: payload,
+			sig:     good,
+			pub:     otherPub,
+			wantErr: "does not match",
+		},
+		{
+			name:    "unsupported algorithm",
+			payload: payload,
+			sig:     Signature{Algorithm: "rsa-pss", KeyID: good.KeyID, SHA256: good.SHA256, Signature: good.Signature},
+			pub:     pub,
+			wantErr: "unsupported",
+		},
+		{
+			name:    "forged signature bytes",
+			payload: payload,
+			sig:     Signature{Algorithm: Algorithm, KeyID: good.KeyID, SHA256: good.SHA256, Signature: make([]byte, len(good.Signature))},
+			pub:     pub,
+			wantErr: "verification failed",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.pub, tc.payload, tc.sig)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSignStoreKey(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := snapshotbackup.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "manifests/000000001.json"
+	if err := store.Put(ctx, key, []byte(`{"sequence":1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SignStoreKey(ctx, store, priv, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyStoreKey(ctx, store, pub, key); err != nil {
+		t.Errorf("signed manifest failed verification: %v", err)
+	}
+
+	// A rewritten manifest no longer matches its signature.
+	if err := store.Put(ctx, key, []byte(`{"sequence":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyStoreKey(ctx, store, pub, key); err == nil {
+		t.Error("expected verification to fail for a rewritten manifest")
+	}
+}
+
+func TestSignSegments(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"changelog-000000001.log", "changelog-000000002.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name+" frames"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	signed, err := SignSegments(priv, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed) != 2 {
+		t.Fatalf("expected 2 segments signed, got %v", signed)
+	}
+	// A second pass finds nothing new to sign.
+	signed, err = SignSegments(priv, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed) != 0 {
+		t.Errorf("expected no re-signing, got %v", signed)
+	}
+	if err := VerifySegments(pub, dir); err != nil {
+		t.Errorf("signed segments failed verification: %v", err)
+	}
+
+	// Tampering with a sealed segment is detected.
+	tampered := filepath.Join(dir, "changelog-000000001.log")
+	if err := os.WriteFile(tampered, []byte("rewritten history"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySegments(pub, dir); err == nil {
+		t.Error("expected verification to fail for a tampered segment")
+	}
+
+	// So is a missing signature.
+	if err := os.WriteFile(tampered, []byte("changelog-000000001.log frames"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(tampered + SignatureExt); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySegments(pub, dir); err == nil || !strings.Contains(err.Error(), "no signature") {
+		t.Errorf("expected missing-signature error, got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backends joins a Service with its EndpointSlices (or legacy
+// Endpoints) and the backing Pods and Nodes to answer why traffic is
+// or is not flowing: which addresses are serving, and for each
+// not-ready backend whether the cause is a failing readiness probe, a
+// terminating pod or an unready node.
+package backends
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+)
+
+// Backend is one endpoint address of a service.
+type Backend struct {
+	Address string
+	// Pod names the backing pod, empty when the endpoint has no
+	// targetRef.
+	Pod string
+	// NodeName is the node hosting the backend, when recorded.
+	NodeName string
+	// Ready mirrors the endpoint's ready condition.
+	Ready bool
+	// Serving is true when the endpoint still serves during
+	// termination (the serving condition, defaulted to Ready).
+	Serving bool
+	// Terminating mirrors the endpoint's terminating condition.
+	Terminating bool
+	// Reasons explains a not-ready backend, most specific first.
+	Reasons []string
+}
+
+// Report is the readiness picture of one service.
+type Report struct {
+	Service string
+	// Backends is every endpoint address, ready first, then by
+	// address.
+	Backends []Backend
+	// ReadyCount is the number of ready backends.
+	ReadyCount int
+}
+
+// Resolve joins the service's endpoint slices with pods and nodes.
+// The pods and nodes inform the reasons only; the slice conditions
+// decide readiness, as they do for kube-proxy.
+func Resolve(service *v1.Service, slices []discovery.EndpointSlice, pods []*v1.Pod, nodes []*v1.Node) Report {
+	report := Report{Service: service.Namespace + "/" + service.Name}
+	podsByName := map[string]*v1.Pod{}
+	for _, pod := range pods {
+		podsByName[pod.Name] = pod
+	}
+	nodesByName := map[string]*v1.Node{}
+	for _, node := range nodes {
+		nodesByName[node.Name] = node
+	}
+	for _, slice := range slices {
+		if slice.Labels[discovery.LabelServiceName] != service.Name || slice.Namespace != service.Namespace {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			backend := Backend{
+				Ready:       endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready,
+				Terminating: endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating,
+			}
+			backend.Serving = backend.Ready
+			if endpoint.Conditions.Serving != nil {
+				backend.Serving = *endpoint.Conditions.Serving
+			}
+			if len(endpoint.Addresses) > 0 {
+				backend.Address = endpoint.Addresses[0]
+			}
+			if endpoint.NodeName != nil {
+				backend.NodeName = *endpoint.NodeName
+			}
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				backend.Pod = endpoint.TargetRef.Name
+			}
+			if !backend.Ready {
+				backend.Reasons = explain(backend, podsByName, nodesByName)
+			}
+			report.Backends = append(report.Backends, backend)
+		}
+	}
+	sort.Slice(report.Backends, func(i, j int) bool {
+		a, b := report.Backends[i], report.Backends[j]
+		if a.Ready != b.Ready {
+			return a.Ready
+		}
+		return a.Address < b.Address
+	})
+	for _, backend := range report.Backends {
+		if backend.Ready {
+			report.ReadyCount++
+		}
+	}
+	return report
+}
+
+// FromEndpoints converts a legacy Endpoints object into the slice
+// shape Resolve consumes, so both API families go through one join.
+func FromEndpoints(endpoints *v1.Endpoints) []discovery.EndpointSlice {
+	slice := discovery.EndpointSlice{}
+	slice.Namespace = endpoints.Namespace
+	slice.Labels = map[string]string{discovery.LabelServiceName: endpoints.Name}
+	ready := true
+	notReady := false
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			slice.Endpoints = append(slice.Endpoints, legacyEndpoint(address, &ready))
+		}
+		for _, address := range subset.NotReadyAddresses {
+			slice.Endpoints = append(slice.Endpoints, legacyEndpoint(address, &notReady))
+		}
+	}
+	return []discovery.EndpointSlice{slice}
+}
+
+func legacyEndpoint(address v1.EndpointAddress, ready *bool) discovery.Endpoint {
+	endpoint := discovery.Endpoint{
+		Addresses:  []string{address.IP},
+		Conditions: discovery.EndpointConditions{Ready: ready},
+		TargetRef:  address.TargetRef,
+		NodeName:   address.NodeName,
+	}
+	return endpoint
+}
+
+// explain derives reasons for a not-ready backend from its pod and
+// node, most specific first.
+func explain(backend Backend, pods map[string]*v1.Pod, nodes map[string]*v1.Node) []string {
+	var reasons []string
+	pod, havePod := pods[backend.Pod]
+	if backend.Pod == "" {
+		return []string{"endpoint has no target pod"}
+	}
+	if !havePod {
+		return []string{fmt.Sprintf("pod %s not found", backend.Pod)}
+	}
+	if pod.DeletionTimestamp != nil {
+		reasons = append(reasons, "pod is terminating")
+	}
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		reasons = append(reasons, fmt.Sprintf("pod phase is %s", pod.Status.Phase))
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			continue
+		}
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			reasons = append(reasons, fmt.Sprintf("container %s is waiting: %s", status.Name, status.State.Waiting.Reason))
+		} else if status.State.Running != nil {
+			reasons = append(reasons, fmt.Sprintf("container %s is running but failing its readiness probe", status.Name))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("container %s is not ready", status.Name))
+		}
+	}
+	if backend.NodeName != "" {
+		if node, ok := nodes[backend.NodeName]; !ok {
+			reasons = append(reasons, fmt.Sprintf("node %s not found", backend.NodeName))
+		} else if !nodeReady(node) {
+			reasons = append(reasons, fmt.Sprintf("node %s is not ready", backend.NodeName))
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "endpoint reported not ready")
+	}
+	return reasons
+}
+
+func nodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backends
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func boolPtr(b bool) *bool    { return &b }
+func strPtr(s string) *string { return &s }
+func now() *metav1.Time       { t := metav1.Now(); return &t }
+func webService() *v1.Service {
+	return &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}}
+}
+
+func webSlice(endpoints ...discovery.Endpoint) discovery.EndpointSlice {
+	return discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "web-abc",
+			Labels: map[string]string{discovery.LabelServiceName: "web"},
+		},
+		Endpoints: endpoints,
+	}
+}
+
+func podWithReadiness(name string, ready bool) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name: "app", Ready: ready,
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+}
+
+func TestResolveReadyAndProbeFailure(t *testing.T) {
+	slices := []discovery.EndpointSlice{webSlice(
+		discovery.Endpoint{
+			Addresses:  []string{"10.0.0.1"},
+			Conditions: discovery.EndpointConditions{Ready: boolPtr(true)},
+			TargetRef:  &v1.ObjectReference{Kind: "Pod", Name: "web-0"},
+		},
+		discovery.Endpoint{
+			Addresses:  []string{"10.0.0.2"},
+			Conditions: discovery.EndpointConditions{Ready: boolPtr(false)},
+			TargetRef:  &v1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		},
+	)}
+	pods := []*v1.Pod{podWithReadiness("web-0", true), podWithReadiness("web-1", false)}
+
+	report := Resolve(webService(), slices, pods, nil)
+	if report.ReadyCount != 1 || len(report.Backends) != 2 {
+		t.Fatalf("report: %+v", report)
+	}
+	// Ready backends sort first.
+	if !report.Backends[0].Ready || report.Backends[0].Address != "10.0.0.1" {
+		t.Errorf("first backend: %+v", report.Backends[0])
+	}
+	failing := report.Backends[1]
+	if len(failing.Reasons) != 1 || !strings.Contains(failing.Reasons[0], "readiness probe") {
+		t.Errorf("reasons: %v", failing.Reasons)
+	}
+}
+
+func TestResolveTerminatingAndNode(t *testing.T) {
+	terminating := podWithReadiness("web-0", false)
+	terminating.DeletionTimestamp = now()
+	slices := []discovery.EndpointSlice{webSlice(discovery.Endpoint{
+		Addresses: []string{"10.0.0.1"},
+		Conditions: discovery.EndpointConditions{
+			Ready:       boolPtr(false),
+			Serving:     boolPtr(true),
+			Terminating: boolPtr(true),
+		},
+		TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "web-0"},
+		NodeName:  strPtr("node-1"),
+	})}
+	nodes := []*v1.Node{{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{
+			Type: v1.NodeReady, Status: v1.ConditionFalse,
+		}}},
+	}}
+
+	report := Resolve(webService(), slices, []*v1.Pod{terminating}, nodes)
+	backend := report.Backends[0]
+	if backend.Ready || !backend.Serving || !backend.Terminating {
+		t.Errorf("conditions: %+v", backend)
+	}
+	joined := strings.Join(backend.Reasons, "; ")
+	if !strings.Contains(joined, "terminating") || !strings.Contains(joined, "node node-1 is not ready") {
+		t.Errorf("reasons: %v", backend.Reasons)
+	}
+}
+
+func TestResolveIgnoresOtherServices(t *testing.T) {
+	other := webSlice(discovery.Endpoint{Addresses: []string{"10.0.0.9"}})
+	other.Labels[discovery.LabelServiceName] = "other"
+	report := Resolve(webService(), []discovery.EndpointSlice{other}, nil, nil)
+	if len(report.Backends) != 0 {
+		t.Errorf("backends: %+v", report.Backends)
+	}
+}
+
+func TestFromEndpoints(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Subsets: []v1.EndpointSubset{{
+			Addresses: []v1.EndpointAddress{{
+				IP:        "10.0.0.1",
+				TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "web-0"},
+			}},
+			NotReadyAddresses: []v1.EndpointAddress{{
+				IP:        "10.0.0.2",
+				TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "web-1"},
+			}},
+		}},
+	}
+	pods := []*v1.Pod{podWithReadiness("web-0", true), podWithReadiness("web-1", false)}
+	report := Resolve(webService(), FromEndpoints(endpoints), pods, nil)
+	if report.ReadyCount != 1 || len(report.Backends) != 2 {
+		t.Fatalf("report: %+v", report)
+	}
+	if report.Backends[1].Pod != "web-1" || len(report.Backends[1].Reasons) == 0 {
+		t.Errorf("legacy not-ready backend: %+v", report.Backends[1])
+	}
+}
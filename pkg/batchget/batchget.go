@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package batchget answers "give me these N objects" without N
+// sequential round trips. Cache hits are taken first; the remaining
+// misses go to the API either as bounded-concurrency point gets or — when
+// the miss count crosses a threshold — as one list of the namespace,
+// whichever transfers less. Dashboards fetching tens of names per render
+// are the motivating caller; their latency is dominated by serial gets.
+package batchget
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Cache serves local lookups; a nil cache means every name is a miss.
+type Cache interface {
+	// Get returns the cached object and whether it was present.
+	Get(kind, namespace, name string) (runtime.Object, bool)
+}
+
+// GetFunc fetches one object from the API. NotFound errors partition the
+// name into Missing; other errors abort the batch.
+type GetFunc func(ctx context.Context, namespace, name string) (runtime.Object, error)
+
+// ListFunc lists a whole namespace, for when misses are numerous enough
+// that one list beats many point gets.
+type ListFunc func(ctx context.Context, namespace string) ([]runtime.Object, error)
+
+// Result partitions the requested names.
+type Result struct {
+	// Found maps name → object for every name that resolved.
+	Found map[string]runtime.Object
+	// Missing lists names that exist neither in cache nor upstream,
+	// sorted.
+	Missing []string
+}
+
+// Options tune the batching behavior; the zero value picks sane defaults.
+type Options struct {
+	Cache Cache
+	// Concurrency bounds parallel point gets; 0 means 8.
+	Concurrency int
+	// ListThreshold is the miss count at which one namespace list
+	// replaces point gets; 0 means 20. Set negative to never list.
+	ListThreshold int
+}
+
+// GetMany resolves a batch of names in one namespace, cache first, API
+// for the misses.
+func GetMany(ctx context.Context, kind, namespace string, names []string, get GetFunc, list ListFunc, opts Options) (Result, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	listThreshold := opts.ListThreshold
+	if listThreshold == 0 {
+		listThreshold = 20
+	}
+
+	result := Result{Found: map[string]runtime.Object{}}
+	var misses []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if opts.Cache != nil {
+			if obj, ok := opts.Cache.Get(kind, namespace, name); ok {
+				result.Found[name] = obj
+				continue
+			}
+		}
+		misses = append(misses, name)
+	}
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	if list != nil && listThreshold > 0 && len(misses) >= listThreshold {
+		if err := resolveByList(ctx, namespace, misses, list, &result); err != nil {
+			return Result{}, err
+		}
+		sort.Strings(result.Missing)
+		return result, nil
+	}
+
+	if err := resolveByGets(ctx, namespace, misses, get, concurrency, &result); err != nil {
+		return Result{}, err
+	}
+	sort.Strings(result.Missing)
+	return result, nil
+}
+
+func resolveByList(ctx context.Context, namespace string, misses []string, list ListFunc, result *Result) error {
+	objs, err := list(ctx, namespace)
+	if err != nil {
+		return fmt.Errorf("listing namespace %q: %w", namespace, err)
+	}
+	byName := make(map[string]runtime.Object, len(objs))
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		byName[accessor.GetName()] = obj
+	}
+	for _, name := range misses {
+		if obj, ok := byName[name]; ok {
+			result.Found[name] = obj
+		} else {
+			result.Missing = append(result.Missing, name)
+		}
+	}
+	return nil
+}
+
+func resolveByGets(ctx context.Context, namespace string, misses []string, get GetFunc, concurrency int, result *Result) error {
+	if get == nil {
+		result.Missing = append(result.Missing, misses...)
+		return nil
+	}
+	type outcome struct {
+		name string
+		obj  runtime.Object
+		err  error
+	}
+	work := make(chan string)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range work {
+				obj, err := get(ctx, namespace, name)
+				outcomes <- outcome{name: name, obj: obj, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, name := range misses {
+			work <- name
+		}
+		close(work)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var firstErr error
+	for o := range outcomes {
+		switch {
+		case o.err == nil:
+			result.Found[o.name] = o.obj
+		case apierrors.IsNotFound(o.err):
+			result.Missing = append(result.Missing, o.name)
+		case firstErr == nil:
+			firstErr = fmt.Errorf("getting %s/%s: %w", namespace, o.name, o.err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func namedPod(name string) runtime.Object {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+}
+
+type mapCache map[string]runtime.Object
+
+func (c mapCache) Get(kind, namespace, name string) (runtime.Object, bool) {
+	obj, ok := c[name]
+	return obj, ok
+}
+
+func notFound(name string) error {
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, name)
+}
+
+func TestGetManyCacheFirstThenGets(t *testing.T) {
+	var apiGets int64
+	get := func(_ context.Context, _, name string) (runtime.Object, error) {
+		atomic.AddInt64(&apiGets, 1)
+		if name == "ghost" {
+			return nil, notFound(name)
+		}
+		return namedPod(name), nil
+	}
+	cache := mapCache{"cached": namedPod("cached")}
+
+	result, err := GetMany(context.Background(), "Pod", "default",
+		[]string{"cached", "fresh", "ghost", "cached"}, get, nil, Options{Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt64(&apiGets) != 2 {
+		t.Errorf("expected 2 API gets (cache hit and duplicate skipped), got %d", apiGets)
+	}
+	if len(result.Found) != 2 || result.Found["cached"] == nil || result.Found["fresh"] == nil {
+		t.Errorf("unexpected found set: %v", result.Found)
+	}
+	if !reflect.DeepEqual(result.Missing, []string{"ghost"}) {
+		t.Errorf("missing = %v, want [ghost]", result.Missing)
+	}
+}
+
+func TestGetManyFallsBackToListForBigBatches(t *testing.T) {
+	listed := 0
+	list := func(context.Context, string) ([]runtime.Object, error) {
+		listed++
+		var objs []runtime.Object
+		for i := 0; i < 30; i++ {
+			objs = append(objs, namedPod(fmt.Sprintf("pod-%d", i)))
+		}
+		return objs, nil
+	}
+	get := func(context.Context, string, string) (runtime.Object, error) {
+		t.Error("point gets must not run above the list threshold")
+		return nil, nil
+	}
+
+	var names []string
+	for i := 0; i < 25; i++ {
+		names = append(names, fmt.Sprintf("pod-%d", i))
+	}
+	names = append(names, "absent")
+
+	result, err := GetMany(context.Background(), "Pod", "default", names, get, list, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed != 1 {
+		t.Errorf("expected one list, got %d", listed)
+	}
+	if len(result.Found) != 25 {
+		t.Errorf("found %d, want 25", len(result.Found))
+	}
+	if !reflect.DeepEqual(result.Missing, []string{"absent"}) {
+		t.Errorf("missing = %v", result.Missing)
+	}
+}
+
+func TestGetManyNeverListsWhenDisabled(t *testing.T) {
+	var gets int64
+	get := func(_ context.Context, _, name string) (runtime.Object, error) {
+		atomic.AddInt64(&gets, 1)
+		return namedPod(name), nil
+	}
+	list := func(context.Context, string) ([]runtime.Object, error) {
+		t.Error("list must not run with a negative threshold")
+		return nil, nil
+	}
+	var names []string
+	for i := 0; i < 50; i++ {
+		names = append(names, fmt.Sprintf("pod-%d", i))
+	}
+	result, err := GetMany(context.Background(), "Pod", "default", names, get, list,
+		Options{ListThreshold: -1, Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt64(&gets) != 50 || len(result.Found) != 50 {
+		t.Errorf("gets=%d found=%d, want 50/50", gets, len(result.Found))
+	}
+}
+
+func TestGetManyPropagatesHardErrors(t *testing.T) {
+	get := func(_ context.Context, _, name string) (runtime.Object, error) {
+		if name == "bad" {
+			return nil, errors.New("connection refused")
+		}
+		return namedPod(name), nil
+	}
+	_, err := GetMany(context.Background(), "Pod", "default", []string{"ok", "bad"}, get, nil, Options{})
+	if err == nil {
+		t.Fatal("expected a hard error to abort the batch")
+	}
+}
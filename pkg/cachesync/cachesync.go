@@ -0,0 +1,416 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cachesync coordinates the initial sync of multiple cached kinds.
+// Initial lists run concurrently, but a kind's view only activates once its
+// declared dependencies are warm (e.g. a Pods view that joins against Nodes
+// activates only after Nodes have synced). This replaces serial kind-by-kind
+// startup, which scales poorly with the number of cached kinds.
+package cachesync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/rerrors"
+)
+
+// SyncFunc performs the initial list for a kind. It should return once the
+// kind's store is warm, or with an error if the list failed.
+type SyncFunc func(ctx context.Context) error
+
+// Kind declares a cached kind, its initial sync and the kinds it depends on.
+type Kind struct {
+	// Name uniquely identifies the kind within the coordinator.
+	Name string
+	// DependsOn lists kinds that must be active before this kind's view
+	// activates. The kind's own sync still runs concurrently with them.
+	DependsOn []string
+	// Sync performs the kind's initial list.
+	Sync SyncFunc
+}
+
+// EventType describes a sync progress event.
+type EventType string
+
+const (
+	// EventSynced is emitted when a kind's initial list completes.
+	EventSynced EventType = "Synced"
+	// EventActive is emitted when a kind's view activates, i.e. the kind
+	// is synced and all of its dependencies are active.
+	EventActive EventType = "Active"
+	// EventFailed is emitted when a kind's sync returns an error.
+	EventFailed EventType = "Failed"
+	// EventUnavailable is emitted when a kind degrades instead of failing
+	// the run: its sync was forbidden, or a dependency is unavailable.
+	EventUnavailable EventType = "Unavailable"
+)
+
+// State is a kind's position in the sync lifecycle.
+type State string
+
+const (
+	// StatePending means the kind's initial sync has not completed.
+	StatePending State = "Pending"
+	// StateSynced means the initial list completed but dependencies are
+	// not all active yet.
+	StateSynced State = "Synced"
+	// StateActive means the kind's view is serving.
+	StateActive State = "Active"
+	// StateUnavailable means the kind is degraded: reads for it were
+	// forbidden, or it depends on an unavailable kind. Reprobing may
+	// recover it.
+	StateUnavailable State = "Unavailable"
+)
+
+// KindStatus is one kind's current state for the status API.
+type KindStatus struct {
+	State State
+	// Err explains an Unavailable state.
+	Err error
+}
+
+// Event is a progress notification delivered to the coordinator's callback.
+type Event struct {
+	Kind string
+	Type EventType
+	// Err is set for EventFailed.
+	Err error
+}
+
+// ProgressFunc receives progress events. It is called from sync goroutines
+// and must not block; a nil ProgressFunc disables notifications.
+type ProgressFunc func(Event)
+
+// Coordinator runs the initial sync of a set of interdependent kinds.
+type Coordinator struct {
+	kinds    map[string]*kindState
+	order    []string
+	progress ProgressFunc
+
+	// mu guards per-kind state; cond broadcasts state transitions to
+	// dependency waiters and reprobe passes.
+	mu   sync.Mutex
+	cond *sync.Cond
+}
+
+type kindState struct {
+	kind Kind
+	// active is closed when the kind's view activates.
+	active chan struct{}
+	// state and err are guarded by the coordinator's mu.
+	state State
+	err   error
+	// synced records that the kind's own list completed, independent of
+	// cascade unavailability from dependencies.
+	synced bool
+}
+
+// NewCoordinator validates the dependency graph and returns a coordinator
+// for the given kinds. It fails if a kind name is duplicated, a dependency
+// is not declared, or the graph contains a cycle.
+func NewCoordinator(kinds []Kind, progress ProgressFunc) (*Coordinator, error) {
+	c := &Coordinator{
+		kinds:    make(map[string]*kindState, len(kinds)),
+		progress: progress,
+	}
+	for _, k := range kinds {
+		if k.Name == "" {
+			return nil, fmt.Errorf("kind name must not be empty")
+		}
+		if k.Sync == nil {
+			return nil, fmt.Errorf("kind %q has no sync function", k.Name)
+		}
+		if _, ok := c.kinds[k.Name]; ok {
+			return nil, fmt.Errorf("duplicate kind %q", k.Name)
+		}
+		c.kinds[k.Name] = &kindState{kind: k, active: make(chan struct{}), state: StatePending}
+		c.order = append(c.order, k.Name)
+	}
+	for _, k := range kinds {
+		for _, dep := range k.DependsOn {
+			if _, ok := c.kinds[dep]; !ok {
+				return nil, fmt.Errorf("kind %q depends on undeclared kind %q", k.Name, dep)
+			}
+		}
+	}
+	if cycle := findCycle(c.kinds); cycle != "" {
+		return nil, fmt.Errorf("dependency cycle involving kind %q", cycle)
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c, nil
+}
+
+// Run starts every kind's sync concurrently and blocks until every view is
+// active or unavailable, the context is cancelled, or a sync fails outright.
+// A forbidden sync (403) does not fail the run: the kind degrades to
+// Unavailable — as do kinds depending on it — and the run completes
+// serving what it can; see Status and Reprobe. Any other sync error
+// cancels the context passed to the remaining syncs and is returned.
+func (c *Coordinator) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Wake dependency waiters on cancellation.
+	stopWatch := make(chan struct{})
+	defer close(stopWatch)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Hold the lock so the broadcast cannot slip between a
+			// waiter's ctx.Err() check and its cond.Wait().
+			c.mu.Lock()
+			c.cond.Broadcast()
+			c.mu.Unlock()
+		case <-stopWatch:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for _, name := range c.order {
+		state := c.kinds[name]
+		wg.Add(1)
+		go func(state *kindState) {
+			defer wg.Done()
+			if err := state.kind.Sync(ctx); err != nil {
+				if isForbidden(err) {
+					c.mu.Lock()
+					c.setUnavailableLocked(state, err)
+					c.mu.Unlock()
+					return
+				}
+				c.emit(Event{Kind: state.kind.Name, Type: EventFailed, Err: err})
+				fail(fmt.Errorf("syncing %q: %v", state.kind.Name, err))
+				return
+			}
+			c.mu.Lock()
+			state.synced = true
+			state.state = StateSynced
+			c.cond.Broadcast()
+			c.mu.Unlock()
+			c.emit(Event{Kind: state.kind.Name, Type: EventSynced})
+			c.awaitDepsAndActivate(ctx, state)
+		}(state)
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// awaitDepsAndActivate blocks until the kind's dependencies are all active
+// (then activates it) or one is unavailable (then degrades it).
+func (c *Coordinator) awaitDepsAndActivate(ctx context.Context, state *kindState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		ready := true
+		for _, dep := range state.kind.DependsOn {
+			switch c.kinds[dep].state {
+			case StateUnavailable:
+				c.setUnavailableLocked(state, fmt.Errorf("dependency %q is unavailable", dep))
+				return
+			case StateActive:
+			default:
+				ready = false
+			}
+		}
+		if ready {
+			c.activateLocked(state)
+			return
+		}
+		c.cond.Wait()
+	}
+}
+
+// setUnavailableLocked degrades a kind; callers hold mu.
+func (c *Coordinator) setUnavailableLocked(state *kindState, err error) {
+	state.state = StateUnavailable
+	state.err = err
+	c.cond.Broadcast()
+	c.emit(Event{Kind: state.kind.Name, Type: EventUnavailable, Err: err})
+}
+
+// activateLocked activates a synced kind; callers hold mu.
+func (c *Coordinator) activateLocked(state *kindState) {
+	if state.state == StateActive {
+		return
+	}
+	state.state = StateActive
+	state.err = nil
+	close(state.active)
+	c.cond.Broadcast()
+	c.emit(Event{Kind: state.kind.Name, Type: EventActive})
+}
+
+// isForbidden reports whether a sync error means "no permission" rather
+// than a transient failure.
+func isForbidden(err error) bool {
+	return apierrors.IsForbidden(err) || rerrors.IsClass(err, rerrors.ClassForbidden)
+}
+
+// Status returns every kind's current state, for the status API.
+func (c *Coordinator) Status() map[string]KindStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := make(map[string]KindStatus, len(c.kinds))
+	for name, state := range c.kinds {
+		status[name] = KindStatus{State: state.state, Err: state.err}
+	}
+	return status
+}
+
+// Reprobe retries the sync of kinds whose own list was forbidden and
+// re-resolves cascade unavailability, activating anything whose
+// permissions have been granted since. Call it periodically after Run
+// completes degraded; it returns the number of kinds that activated.
+func (c *Coordinator) Reprobe(ctx context.Context) int {
+	// Retry forbidden syncs outside the lock.
+	for _, name := range c.order {
+		state := c.kinds[name]
+		c.mu.Lock()
+		retry := state.state == StateUnavailable && !state.synced
+		c.mu.Unlock()
+		if !retry {
+			continue
+		}
+		if err := state.kind.Sync(ctx); err != nil {
+			c.mu.Lock()
+			state.err = err
+			c.mu.Unlock()
+			continue
+		}
+		c.mu.Lock()
+		state.synced = true
+		state.state = StateSynced
+		c.mu.Unlock()
+		c.emit(Event{Kind: state.kind.Name, Type: EventSynced})
+	}
+
+	// Resolve activations to a fixpoint: activating one kind can unblock
+	// kinds depending on it.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	activated := 0
+	for changed := true; changed; {
+		changed = false
+		for _, name := range c.order {
+			state := c.kinds[name]
+			if !state.synced || state.state == StateActive {
+				continue
+			}
+			ready := true
+			for _, dep := range state.kind.DependsOn {
+				if c.kinds[dep].state != StateActive {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				c.activateLocked(state)
+				activated++
+				changed = true
+			}
+		}
+	}
+	return activated
+}
+
+// Active reports whether the named kind's view has activated. Unknown kinds
+// report false.
+func (c *Coordinator) Active(name string) bool {
+	state, ok := c.kinds[name]
+	if !ok {
+		return false
+	}
+	select {
+	case <-state.active:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitForActive blocks until the named kind's view activates or the context
+// is cancelled.
+func (c *Coordinator) WaitForActive(ctx context.Context, name string) error {
+	state, ok := c.kinds[name]
+	if !ok {
+		return fmt.Errorf("unknown kind %q", name)
+	}
+	select {
+	case <-state.active:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Coordinator) emit(e Event) {
+	if c.progress != nil {
+		c.progress(e)
+	}
+}
+
+// findCycle returns the name of a kind participating in a dependency cycle,
+// or the empty string if the graph is acyclic.
+func findCycle(kinds map[string]*kindState) string {
+	const (
+		unvisited = 0
+		visiting  = 1
+		done      = 2
+	)
+	colors := make(map[string]int, len(kinds))
+	var visit func(name string) string
+	visit = func(name string) string {
+		switch colors[name] {
+		case visiting:
+			return name
+		case done:
+			return ""
+		}
+		colors[name] = visiting
+		for _, dep := range kinds[name].kind.DependsOn {
+			if found := visit(dep); found != "" {
+				return found
+			}
+		}
+		colors[name] = done
+		return ""
+	}
+	for name := range kinds {
+		if found := visit(name); found != "" {
+			return found
+		}
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cachesync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func noopSync(ctx context.Context) error { return nil }
+
+func TestNewCoordinatorValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		kinds   []Kind
+		wantErr bool
+	}{
+		{
+			name:  "valid graph",
+			kinds: []Kind{{Name: "nodes", Sync: noopSync}, {Name: "pods", DependsOn: []string{"nodes"}, Sync: noopSync}},
+		},
+		{
+			name:    "duplicate kind",
+			kinds:   []Kind{{Name: "pods", Sync: noopSync}, {Name: "pods", Sync: noopSync}},
+			wantErr: true,
+		},
+		{
+			name:    "undeclared dependency",
+			kinds:   []Kind{{Name: "pods", DependsOn: []string{"nodes"}, Sync: noopSync}},
+			wantErr: true,
+		},
+		{
+			name: "cycle",
+			kinds: []Kind{
+				{Name: "a", DependsOn: []string{"b"}, Sync: noopSync},
+				{Name: "b", DependsOn: []string{"a"}, Sync: noopSync},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing sync function",
+			kinds:   []Kind{{Name: "pods"}},
+			wantErr: true,
+		},
+		{
+			name:    "empty name",
+			kinds:   []Kind{{Name: "", Sync: noopSync}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewCoordinator(tc.kinds, nil)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRunActivatesInDependencyOrder(t *testing.T) {
+	var mu sync.Mutex
+	var activations []string
+	progress := func(e Event) {
+		if e.Type != EventActive {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		activations = append(activations, e.Kind)
+	}
+
+	// Nodes sync deliberately finishes last even though pods depend on it.
+	nodesReady := make(chan struct{})
+	kinds := []Kind{
+		{Name: "nodes", Sync: func(ctx context.Context) error {
+			<-nodesReady
+			return nil
+		}},
+		{Name: "pods", DependsOn: []string{"nodes"}, Sync: func(ctx context.Context) error {
+			close(nodesReady)
+			return nil
+		}},
+	}
+	c, err := NewCoordinator(kinds, progress)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	if len(activations) != 2 || activations[0] != "nodes" || activations[1] != "pods" {
+		t.Errorf("expected activation order [nodes pods], got %v", activations)
+	}
+	for _, name := range []string{"nodes", "pods"} {
+		if !c.Active(name) {
+			t.Errorf("expected %q to be active", name)
+		}
+	}
+}
+
+func TestRunFailurePropagates(t *testing.T) {
+	syncErr := errors.New("list failed")
+	kinds := []Kind{
+		{Name: "nodes", Sync: func(ctx context.Context) error { return syncErr }},
+		{Name: "pods", DependsOn: []string{"nodes"}, Sync: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+	c, err := NewCoordinator(kinds, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Run(context.Background()); err == nil {
+		t.Fatal("expected run error, got none")
+	}
+	if c.Active("pods") {
+		t.Error("pods should not activate after a dependency failure")
+	}
+}
+
+func TestWaitForActive(t *testing.T) {
+	release := make(chan struct{})
+	kinds := []Kind{{Name: "pods", Sync: func(ctx context.Context) error {
+		<-release
+		return nil
+	}}}
+	c, err := NewCoordinator(kinds, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Run(context.Background()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.WaitForActive(ctx, "pods"); err == nil {
+		t.Error("expected timeout waiting for unsynced kind")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+	if err := c.WaitForActive(context.Background(), "pods"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.WaitForActive(context.Background(), "unknown"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
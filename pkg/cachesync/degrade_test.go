@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cachesync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func forbiddenErr(resource string) error {
+	return apierrors.NewForbidden(schema.GroupResource{Resource: resource}, "", errors.New("RBAC denied"))
+}
+
+func TestForbiddenKindDegradesInsteadOfFailing(t *testing.T) {
+	var mu sync.Mutex
+	events := map[string][]EventType{}
+
+	c, err := NewCoordinator([]Kind{
+		{Name: "nodes", Sync: func(context.Context) error { return nil }},
+		{Name: "secrets", Sync: func(context.Context) error { return forbiddenErr("secrets") }},
+		{Name: "pods", DependsOn: []string{"nodes"}, Sync: func(context.Context) error { return nil }},
+	}, func(e Event) {
+		mu.Lock()
+		events[e.Kind] = append(events[e.Kind], e.Type)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("degraded run must not fail: %v", err)
+	}
+
+	status := c.Status()
+	if status["secrets"].State != StateUnavailable || status["secrets"].Err == nil {
+		t.Errorf("expected secrets unavailable, got %+v", status["secrets"])
+	}
+	if status["pods"].State != StateActive || status["nodes"].State != StateActive {
+		t.Errorf("expected unaffected kinds active, got %+v", status)
+	}
+	if !c.Active("pods") || c.Active("secrets") {
+		t.Error("Active must reflect degraded state")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events["secrets"]) != 1 || events["secrets"][0] != EventUnavailable {
+		t.Errorf("expected a single Unavailable event for secrets, got %v", events["secrets"])
+	}
+}
+
+func TestUnavailabilityCascadesToDependents(t *testing.T) {
+	c, err := NewCoordinator([]Kind{
+		{Name: "secrets", Sync: func(context.Context) error { return forbiddenErr("secrets") }},
+		{Name: "audit", DependsOn: []string{"secrets"}, Sync: func(context.Context) error { return nil }},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("degraded run must not fail: %v", err)
+	}
+
+	status := c.Status()
+	if status["audit"].State != StateUnavailable {
+		t.Fatalf("expected audit degraded with its dependency, got %+v", status["audit"])
+	}
+	if status["audit"].Err == nil {
+		t.Error("cascade unavailability must carry an explanation")
+	}
+}
+
+func TestNonForbiddenErrorStillFailsRun(t *testing.T) {
+	c, err := NewCoordinator([]Kind{
+		{Name: "pods", Sync: func(context.Context) error { return errors.New("connection refused") }},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err == nil {
+		t.Fatal("transient errors must still fail the run")
+	}
+}
+
+func TestReprobeRecoversPermissions(t *testing.T) {
+	var allowed atomic.Bool
+	c, err := NewCoordinator([]Kind{
+		{Name: "secrets", Sync: func(context.Context) error {
+			if !allowed.Load() {
+				return forbiddenErr("secrets")
+			}
+			return nil
+		}},
+		{Name: "audit", DependsOn: []string{"secrets"}, Sync: func(context.Context) error { return nil }},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// While still forbidden, reprobing changes nothing.
+	if activated := c.Reprobe(context.Background()); activated != 0 {
+		t.Fatalf("expected no activations while forbidden, got %d", activated)
+	}
+
+	// Permission granted: one reprobe recovers the kind and its
+	// dependents.
+	allowed.Store(true)
+	if activated := c.Reprobe(context.Background()); activated != 2 {
+		t.Fatalf("expected secrets and audit to activate, got %d", activated)
+	}
+	status := c.Status()
+	if status["secrets"].State != StateActive || status["audit"].State != StateActive {
+		t.Errorf("expected both kinds active after reprobe, got %+v", status)
+	}
+	if !c.Active("audit") {
+		t.Error("WaitForActive channel must be closed by reprobe activation")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package canread pre-flights the permissions a read plan needs. It issues
+// SelfSubjectAccessReview checks for every kind/verb the plan will touch
+// and consolidates the misses into one report, so a long export against a
+// partially-permissioned service account fails up front instead of midway.
+package canread
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+// Check is a single permission the plan requires.
+type Check struct {
+	// Group is the API group; empty for the core group.
+	Group string
+	// Resource is the lowercase plural resource name.
+	Resource string
+	// Namespace scopes the check; empty means cluster-wide (all
+	// namespaces for namespaced resources).
+	Namespace string
+	// Verb is the access verb, e.g. "list" or "watch".
+	Verb string
+}
+
+// String renders the check for report output.
+func (c Check) String() string {
+	resource := c.Resource
+	if c.Group != "" {
+		resource += "." + c.Group
+	}
+	if c.Namespace != "" {
+		return fmt.Sprintf("%s %s in %s", c.Verb, resource, c.Namespace)
+	}
+	return fmt.Sprintf("%s %s cluster-wide", c.Verb, resource)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Check Check
+	// Allowed is the server's decision.
+	Allowed bool
+	// Reason is the server's explanation, when provided.
+	Reason string
+}
+
+// Report consolidates the outcomes of a plan's checks.
+type Report struct {
+	// Results holds one entry per check, in check order.
+	Results []Result
+	// Missing holds the subset of Results that were not allowed.
+	Missing []Result
+}
+
+// Allowed reports whether every check passed.
+func (r *Report) Allowed() bool {
+	return len(r.Missing) == 0
+}
+
+// readVerbs are the verbs a read plan exercises per resource.
+var readVerbs = []string{"get", "list", "watch"}
+
+// PlanChecks expands the resources and namespaces of a read plan into the
+// full set of checks to pre-flight. An empty namespace list yields
+// cluster-wide checks.
+func PlanChecks(resources []schema.GroupVersionResource, namespaces []string) []Check {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	var checks []Check
+	for _, gvr := range resources {
+		for _, namespace := range namespaces {
+			for _, verb := range readVerbs {
+				checks = append(checks, Check{
+					Group:     gvr.Group,
+					Resource:  gvr.Resource,
+					Namespace: namespace,
+					Verb:      verb,
+				})
+			}
+		}
+	}
+	return checks
+}
+
+// CheckAccess issues a SelfSubjectAccessReview per check and returns the
+// consolidated report. An error is returned only if a review itself fails;
+// denials are part of the report.
+func CheckAccess(ctx context.Context, client authorizationv1client.SelfSubjectAccessReviewInterface, checks []Check) (*Report, error) {
+	report := &Report{}
+	for _, check := range checks {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:     check.Group,
+					Resource:  check.Resource,
+					Namespace: check.Namespace,
+					Verb:      check.Verb,
+				},
+			},
+		}
+		response, err := client.Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("reviewing %s: %v", check, err)
+		}
+		result := Result{
+			Check:   check,
+			Allowed: response.Status.Allowed,
+			Reason:  response.Status.Reason,
+		}
+		report.Results = append(report.Results, result)
+		if !result.Allowed {
+			report.Missing = append(report.Missing, result)
+		}
+	}
+	return report, nil
+}
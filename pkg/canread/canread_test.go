@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canread
+
+import (
+	"context"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestPlanChecks(t *testing.T) {
+	checks := PlanChecks([]schema.GroupVersionResource{
+		{Version: "v1", Resource: "pods"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+	}, []string{"team-a", "team-b"})
+	if len(checks) != 12 {
+		t.Fatalf("expected 2 resources * 2 namespaces * 3 verbs = 12 checks, got %d", len(checks))
+	}
+
+	clusterChecks := PlanChecks([]schema.GroupVersionResource{{Version: "v1", Resource: "nodes"}}, nil)
+	if len(clusterChecks) != 3 {
+		t.Fatalf("expected 3 cluster-wide checks, got %d", len(clusterChecks))
+	}
+	if clusterChecks[0].Namespace != "" {
+		t.Errorf("expected cluster-wide check, got %+v", clusterChecks[0])
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset()
+	// Deny secrets, allow everything else.
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		if attrs.Resource == "secrets" {
+			review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: false, Reason: "no secrets for you"}
+		} else {
+			review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: true}
+		}
+		return true, review, nil
+	})
+
+	checks := []Check{
+		{Resource: "pods", Namespace: "default", Verb: "list"},
+		{Resource: "secrets", Namespace: "default", Verb: "list"},
+		{Resource: "secrets", Namespace: "default", Verb: "watch"},
+	}
+	report, err := CheckAccess(context.Background(), client.AuthorizationV1().SelfSubjectAccessReviews(), checks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Allowed() {
+		t.Error("expected report with missing permissions")
+	}
+	if len(report.Results) != 3 {
+		t.Errorf("expected 3 results, got %d", len(report.Results))
+	}
+	if len(report.Missing) != 2 {
+		t.Fatalf("expected 2 missing, got %+v", report.Missing)
+	}
+	if report.Missing[0].Reason != "no secrets for you" {
+		t.Errorf("expected server reason to be carried, got %+v", report.Missing[0])
+	}
+	if got := report.Missing[0].Check.String(); got != "list secrets in default" {
+		t.Errorf("unexpected check rendering %q", got)
+	}
+}
+
+func TestCheckAccessAllAllowed(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+	report, err := CheckAccess(context.Background(), client.AuthorizationV1().SelfSubjectAccessReviews(),
+		PlanChecks([]schema.GroupVersionResource{{Version: "v1", Resource: "pods"}}, []string{"default"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Allowed() {
+		t.Errorf("expected all checks allowed, got %+v", report.Missing)
+	}
+}
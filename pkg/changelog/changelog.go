@@ -0,0 +1,437 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package changelog persists every observed create/update/delete of
+// selected kinds to an append-only, size-capped log on disk — an
+// "audit-lite" for clusters without API audit logging. Each record carries
+// a field-diff summary and the full object payload in length-prefixed
+// frames (protobuf when opened with a protobuf codec), and the log is
+// queryable by object UID and time range.
+package changelog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Verb is the kind of change that was observed.
+type Verb string
+
+const (
+	Create Verb = "create"
+	Update Verb = "update"
+	Delete Verb = "delete"
+)
+
+// header is the frame metadata, stored as JSON ahead of the object payload.
+type header struct {
+	Time      time.Time `json:"time"`
+	Verb      Verb      `json:"verb"`
+	Kind      string    `json:"kind,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+	// Changed lists the field paths an update touched, to at most two
+	// levels of depth (e.g. "spec.nodeName").
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Entry is one change read back from the log.
+type Entry struct {
+	Time      time.Time
+	Verb      Verb
+	Kind      string
+	Namespace string
+	Name      string
+	UID       types.UID
+	// Changed lists the field paths an update touched.
+	Changed []string
+	// Object is the recorded object: the new object for creates and
+	// updates, the last-seen object for deletes.
+	Object runtime.Object
+}
+
+// segmentPrefix names log segments; the numeric suffix orders them.
+const segmentPrefix = "changelog-"
+
+// Log is an append-only, size-capped change log. It is safe for concurrent
+// use.
+type Log struct {
+	dir      string
+	maxBytes int64
+	codec    runtime.Codec
+
+	mu          sync.Mutex
+	current     *os.File
+	currentSize int64
+	currentSeq  int
+}
+
+// Open opens (or creates) a log in dir, keeping at most roughly maxBytes on
+// disk by rotating and pruning segments. The codec encodes object payloads;
+// use a protobuf codec for compact frames.
+func Open(dir string, maxBytes int64, codec runtime.Codec) (*Log, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("maxBytes must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	l := &Log{dir: dir, maxBytes: maxBytes, codec: codec}
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 {
+		l.currentSeq = segments[len(segments)-1]
+	}
+	if err := l.openCurrent(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record appends one observed change. For creates old must be nil, for
+// deletes new must be nil; updates carry both so the field diff can be
+// summarized.
+func (l *Log) Record(verb Verb, old, new runtime.Object) error {
+	recorded := new
+	if recorded == nil {
+		recorded = old
+	}
+	if recorded == nil {
+		return fmt.Errorf("no object to record")
+	}
+	accessor, err := meta.Accessor(recorded)
+	if err != nil {
+		return err
+	}
+	h := header{
+		Time:      time.Now(),
+		Verb:      verb,
+		Kind:      recorded.GetObjectKind().GroupVersionKind().Kind,
+		Namespace: accessor.GetNamespace(),
+		Name:      accessor.GetName(),
+		UID:       accessor.GetUID(),
+	}
+	if verb == Update && old != nil && new != nil {
+		h.Changed, err = diffPaths(old, new)
+		if err != nil {
+			return fmt.Errorf("summarizing diff: %v", err)
+		}
+	}
+
+	headerBytes, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	payload, err := runtime.Encode(l.codec, recorded)
+	if err != nil {
+		return fmt.Errorf("encoding object: %v", err)
+	}
+
+	frame := make([]byte, 0, 8+len(headerBytes)+len(payload))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(headerBytes)))
+	frame = append(frame, headerBytes...)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.currentSize > 0 && l.currentSize+int64(len(frame)) > l.segmentCap() {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	if _, err := l.current.Write(frame); err != nil {
+		return err
+	}
+	l.currentSize += int64(len(frame))
+	return nil
+}
+
+// Query selects entries, all filters optional: a zero UID matches every
+// object, and zero times leave the range open on that side. Entries are
+// returned in append order.
+type Query struct {
+	UID   types.UID
+	Since time.Time
+	Until time.Time
+}
+
+// Query reads matching entries back from the log.
+func (l *Log) Query(q Query) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, seq := range segments {
+		if err := l.scanSegment(seq, func(h header, payload []byte) error {
+			if q.UID != "" && h.UID != q.UID {
+				return nil
+			}
+			if !q.Since.IsZero() && h.Time.Before(q.Since) {
+				return nil
+			}
+			if !q.Until.IsZero() && h.Time.After(q.Until) {
+				return nil
+			}
+			obj, err := runtime.Decode(l.codec, payload)
+			if err != nil {
+				return fmt.Errorf("decoding payload for %s: %v", h.UID, err)
+			}
+			entries = append(entries, Entry{
+				Time:      h.Time,
+				Verb:      h.Verb,
+				Kind:      h.Kind,
+				Namespace: h.Namespace,
+				Name:      h.Name,
+				UID:       h.UID,
+				Changed:   h.Changed,
+				Object:    obj,
+			})
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Close flushes and closes the current segment.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil {
+		return nil
+	}
+	err := l.current.Close()
+	l.current = nil
+	return err
+}
+
+// segmentCap bounds individual segments so pruning has reasonably sized
+// units to drop.
+func (l *Log) segmentCap() int64 {
+	c := l.maxBytes / 4
+	if c < 1 {
+		c = 1
+	}
+	return c
+}
+
+func (l *Log) segmentPath(seq int) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%s%09d.log", segmentPrefix, seq))
+}
+
+// segments returns the sequence numbers of existing segments, ascending.
+func (l *Log) segments() ([]int, error) {
+	names, err := filepath.Glob(filepath.Join(l.dir, segmentPrefix+"*.log"))
+	if err != nil {
+		return nil, err
+	}
+	var seqs []int
+	for _, name := range names {
+		base := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), segmentPrefix), ".log")
+		seq, err := strconv.Atoi(base)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	return seqs, nil
+}
+
+func (l *Log) openCurrent() error {
+	f, err := os.OpenFile(l.segmentPath(l.currentSeq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.current = f
+	l.currentSize = info.Size()
+	return nil
+}
+
+// rotate starts a new segment and prunes the oldest ones while the total
+// size exceeds the cap.
+func (l *Log) rotate() error {
+	if err := l.current.Close(); err != nil {
+		return err
+	}
+	l.currentSeq++
+	if err := l.openCurrent(); err != nil {
+		return err
+	}
+
+	segments, err := l.segments()
+	if err != nil {
+		return err
+	}
+	var total int64
+	sizes := make(map[int]int64, len(segments))
+	for _, seq := range segments {
+		info, err := os.Stat(l.segmentPath(seq))
+		if err != nil {
+			return err
+		}
+		sizes[seq] = info.Size()
+		total += info.Size()
+	}
+	for _, seq := range segments {
+		if total <= l.maxBytes || seq == l.currentSeq {
+			break
+		}
+		if err := os.Remove(l.segmentPath(seq)); err != nil {
+			return err
+		}
+		total -= sizes[seq]
+	}
+	return nil
+}
+
+func (l *Log) scanSegment(seq int, visit func(header, []byte) error) error {
+	f, err := os.Open(l.segmentPath(seq))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	for {
+		headerBytes, err := readFrame(f)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("segment %d: %v", seq, err)
+		}
+		payload, err := readFrame(f)
+		if err != nil {
+			return fmt.Errorf("segment %d: truncated payload: %v", seq, err)
+		}
+		var h header
+		if err := json.Unmarshal(headerBytes, &h); err != nil {
+			return fmt.Errorf("segment %d: malformed header: %v", seq, err)
+		}
+		if err := visit(h, payload); err != nil {
+			return err
+		}
+	}
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var lengthBytes [4]byte
+	if _, err := io.ReadFull(r, lengthBytes[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(lengthBytes[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// diffPaths summarizes which fields changed between two objects, to two
+// levels of depth, by comparing their JSON forms.
+func diffPaths(old, new runtime.Object) ([]string, error) {
+	oldMap, err := toMap(old)
+	if err != nil {
+		return nil, err
+	}
+	newMap, err := toMap(new)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]bool{}
+	diffMaps(oldMap, newMap, "", 0, paths)
+	var sorted []string
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+func toMap(obj runtime.Object) (map[string]interface{}, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+const maxDiffDepth = 2
+
+func diffMaps(old, new map[string]interface{}, prefix string, depth int, paths map[string]bool) {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	for k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		oldValue, oldOk := old[k]
+		newValue, newOk := new[k]
+		if oldOk && newOk && equalJSON(oldValue, newValue) {
+			continue
+		}
+		oldChild, oldIsMap := oldValue.(map[string]interface{})
+		newChild, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap && depth+1 < maxDiffDepth {
+			diffMaps(oldChild, newChild, path, depth+1, paths)
+			continue
+		}
+		paths[path] = true
+	}
+}
+
+func equalJSON(a, b interface{}) bool {
+	aRaw, errA := json.Marshal(a)
+	bRaw, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aRaw) == string(bRaw)
+}
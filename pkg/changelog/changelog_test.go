@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changelog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func testCodec() runtime.Codec {
+	info, _ := runtime.SerializerInfoForMediaType(clientsetscheme.Codecs.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	return clientsetscheme.Codecs.CodecForVersions(info.Serializer, info.Serializer, v1.SchemeGroupVersion, v1.SchemeGroupVersion)
+}
+
+func changelogPod(uid, node, phase string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", UID: types.UID(uid)},
+		Spec:       v1.PodSpec{NodeName: node},
+		Status:     v1.PodStatus{Phase: v1.PodPhase(phase)},
+	}
+}
+
+func TestRecordAndQuery(t *testing.T) {
+	log, err := Open(t.TempDir(), 1<<20, testCodec())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer log.Close()
+
+	before := changelogPod("uid-1", "", "Pending")
+	after := changelogPod("uid-1", "node-1", "Running")
+	if err := log.Record(Create, nil, before); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Record(Update, before, after); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Record(Delete, after, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Record(Create, nil, changelogPod("uid-2", "", "Pending")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := log.Query(Query{UID: "uid-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries for uid-1, got %d", len(entries))
+	}
+	if entries[0].Verb != Create || entries[1].Verb != Update || entries[2].Verb != Delete {
+		t.Errorf("unexpected verb order: %v %v %v", entries[0].Verb, entries[1].Verb, entries[2].Verb)
+	}
+	expectedChanged := []string{"spec.nodeName", "status.phase"}
+	if !reflect.DeepEqual(entries[1].Changed, expectedChanged) {
+		t.Errorf("expected changed paths %v, got %v", expectedChanged, entries[1].Changed)
+	}
+	pod, ok := entries[1].Object.(*v1.Pod)
+	if !ok || pod.Spec.NodeName != "node-1" {
+		t.Errorf("payload round trip lost data: %+v", entries[1].Object)
+	}
+
+	all, err := log.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected 4 entries overall, got %d", len(all))
+	}
+}
+
+func TestQueryTimeRange(t *testing.T) {
+	log, err := Open(t.TempDir(), 1<<20, testCodec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	if err := log.Record(Create, nil, changelogPod("uid-1", "", "Pending")); err != nil {
+		t.Fatal(err)
+	}
+	cut := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	if err := log.Record(Update, changelogPod("uid-1", "", "Pending"), changelogPod("uid-1", "n", "Running")); err != nil {
+		t.Fatal(err)
+	}
+
+	recent, err := log.Query(Query{Since: cut})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 || recent[0].Verb != Update {
+		t.Errorf("expected only the update after the cut, got %+v", recent)
+	}
+	old, err := log.Query(Query{Until: cut})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(old) != 1 || old[0].Verb != Create {
+		t.Errorf("expected only the create before the cut, got %+v", old)
+	}
+}
+
+func TestRotationAndPruning(t *testing.T) {
+	dir := t.TempDir()
+	// Small cap forces rotation; each record is several hundred bytes.
+	log, err := Open(dir, 4096, testCodec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	for i := 0; i < 50; i++ {
+		if err := log.Record(Create, nil, changelogPod("uid-1", "", "Pending")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	segments, err := log.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) < 2 {
+		t.Fatalf("expected rotation to produce multiple segments, got %v", segments)
+	}
+	if segments[0] == 0 {
+		t.Errorf("expected oldest segments to be pruned, still have %v", segments)
+	}
+
+	// Entries must still be readable after pruning.
+	entries, err := log.Query(Query{UID: "uid-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || len(entries) >= 50 {
+		t.Errorf("expected a pruned but non-empty log, got %d entries", len(entries))
+	}
+}
+
+func TestReopenAppends(t *testing.T) {
+	dir := t.TempDir()
+	log, err := Open(dir, 1<<20, testCodec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Record(Create, nil, changelogPod("uid-1", "", "Pending")); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(dir, 1<<20, testCodec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if err := reopened.Record(Delete, changelogPod("uid-1", "", "Pending"), nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := reopened.Query(Query{UID: "uid-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries across reopen, got %d", len(entries))
+	}
+}
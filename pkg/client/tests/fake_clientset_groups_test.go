@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// These tests pin the contract that fake.NewSimpleClientset works against
+// the clientsets generated from this repo's types, across API groups, so
+// downstream unit tests never need a separately versioned client-go.
+
+func TestFakeClientsetServesGeneratedGroups(t *testing.T) {
+	tc := clientsetfake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "nsA", Name: "web"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "nsA", Name: "migrate"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "nsA", Name: "conf"}},
+	)
+
+	deployment, err := tc.AppsV1().Deployments("nsA").Get(context.TODO(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Deployments.Get: %s", err)
+	}
+	if deployment.Name != "web" {
+		t.Fatalf("Expected deployment web, got %q", deployment.Name)
+	}
+
+	jobs, err := tc.BatchV1().Jobs(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Jobs.List: %s", err)
+	}
+	if actual, expected := len(jobs.Items), 1; expected != actual {
+		t.Fatalf("Expected %d jobs, got %d", expected, actual)
+	}
+
+	if _, err := tc.CoreV1().ConfigMaps("nsB").Get(context.TODO(), "conf", metav1.GetOptions{}); err == nil {
+		t.Fatal("Expected ConfigMaps.Get in wrong namespace to fail")
+	}
+}
+
+func TestFakeClientsetWatch(t *testing.T) {
+	tc := clientsetfake.NewSimpleClientset()
+
+	watcher, err := tc.CoreV1().Pods("nsA").Watch(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Pods.Watch: %s", err)
+	}
+	defer watcher.Stop()
+
+	if _, err := tc.CoreV1().Pods("nsA").Create(context.TODO(), testPod("nsA", "pod-1"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Pods.Create: %s", err)
+	}
+
+	event := <-watcher.ResultChan()
+	if event.Type != watch.Added {
+		t.Fatalf("Expected Added event, got %s", event.Type)
+	}
+	pod, ok := event.Object.(*corev1.Pod)
+	if !ok {
+		t.Fatalf("Expected *corev1.Pod event object, got %T", event.Object)
+	}
+	if pod.Namespace != "nsA" || pod.Name != "pod-1" {
+		t.Fatalf("Expected nsA/pod-1, got %s/%s", pod.Namespace, pod.Name)
+	}
+}
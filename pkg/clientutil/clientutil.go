@@ -0,0 +1,202 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clientutil adds blocking readiness primitives on top of the
+// typed clientset: WaitForPodReady, WaitForPVCBound and friends get a
+// consistent list-then-watch implementation so callers stop hand-rolling
+// polling loops with different bugs. Plain scoped reads live in
+// pkg/scopedclient; this package owns the waiting.
+package clientutil
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+// PodCondition reports whether the pod satisfies the caller's condition.
+// Returning an error aborts the wait; use it for states the pod can
+// never leave, so callers fail fast instead of burning their deadline.
+type PodCondition func(*v1.Pod) (bool, error)
+
+// PodReady is satisfied when the Ready condition is true. Terminal
+// phases abort the wait: a Succeeded or Failed pod will never be ready.
+func PodReady(pod *v1.Pod) (bool, error) {
+	switch pod.Status.Phase {
+	case v1.PodSucceeded, v1.PodFailed:
+		return false, fmt.Errorf("pod %s/%s is %s and will not become ready", pod.Namespace, pod.Name, pod.Status.Phase)
+	}
+	return podutil.IsPodReady(pod), nil
+}
+
+// PodScheduled is satisfied once the pod is bound to a node.
+func PodScheduled(pod *v1.Pod) (bool, error) {
+	return pod.Spec.NodeName != "", nil
+}
+
+// WaitForPodReady blocks until the named pod reports Ready, the pod
+// reaches a terminal phase, or the context expires. It returns the pod
+// in the state that satisfied the wait.
+func WaitForPodReady(ctx context.Context, client kubernetes.Interface, namespace, name string) (*v1.Pod, error) {
+	return WaitForPod(ctx, client, namespace, name, PodReady)
+}
+
+// WaitForPod blocks until the condition holds for the named pod. The
+// pod must exist; deletion during the wait is an error.
+func WaitForPod(ctx context.Context, client kubernetes.Interface, namespace, name string, condition PodCondition) (*v1.Pod, error) {
+	pods := client.CoreV1().Pods(namespace)
+	pod, err := pods.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := condition(pod); err != nil || ok {
+		return pod, err
+	}
+	watcher, err := pods.Watch(ctx, watchOptions(name, pod.ResourceVersion))
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+	err = consume(ctx, watcher, name, func(obj interface{}) (bool, error) {
+		updated, ok := obj.(*v1.Pod)
+		if !ok {
+			return false, nil
+		}
+		pod = updated
+		return condition(pod)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for pod %s/%s: %w", namespace, name, err)
+	}
+	return pod, nil
+}
+
+// WaitForPVCBound blocks until the named claim is bound to a volume.
+// A Lost claim aborts the wait.
+func WaitForPVCBound(ctx context.Context, client kubernetes.Interface, namespace, name string) (*v1.PersistentVolumeClaim, error) {
+	claims := client.CoreV1().PersistentVolumeClaims(namespace)
+	claim, err := claims.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	bound := func(claim *v1.PersistentVolumeClaim) (bool, error) {
+		if claim.Status.Phase == v1.ClaimLost {
+			return false, fmt.Errorf("claim %s/%s lost its volume", claim.Namespace, claim.Name)
+		}
+		return claim.Status.Phase == v1.ClaimBound, nil
+	}
+	if ok, err := bound(claim); err != nil || ok {
+		return claim, err
+	}
+	watcher, err := claims.Watch(ctx, watchOptions(name, claim.ResourceVersion))
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+	err = consume(ctx, watcher, name, func(obj interface{}) (bool, error) {
+		updated, ok := obj.(*v1.PersistentVolumeClaim)
+		if !ok {
+			return false, nil
+		}
+		claim = updated
+		return bound(claim)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for claim %s/%s: %w", namespace, name, err)
+	}
+	return claim, nil
+}
+
+// WaitForPVPhase blocks until the named persistent volume reaches the
+// phase. A Failed volume aborts waits for any other phase.
+func WaitForPVPhase(ctx context.Context, client kubernetes.Interface, name string, phase v1.PersistentVolumePhase) (*v1.PersistentVolume, error) {
+	volumes := client.CoreV1().PersistentVolumes()
+	pv, err := volumes.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	reached := func(pv *v1.PersistentVolume) (bool, error) {
+		if pv.Status.Phase == phase {
+			return true, nil
+		}
+		if pv.Status.Phase == v1.VolumeFailed {
+			return false, fmt.Errorf("volume %s failed: %s", pv.Name, pv.Status.Message)
+		}
+		return false, nil
+	}
+	if ok, err := reached(pv); err != nil || ok {
+		return pv, err
+	}
+	watcher, err := volumes.Watch(ctx, watchOptions(name, pv.ResourceVersion))
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+	err = consume(ctx, watcher, name, func(obj interface{}) (bool, error) {
+		updated, ok := obj.(*v1.PersistentVolume)
+		if !ok {
+			return false, nil
+		}
+		pv = updated
+		return reached(pv)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for volume %s: %w", name, err)
+	}
+	return pv, nil
+}
+
+// watchOptions scopes a watch to one object, resuming from the get that
+// established its current state so no update is missed in between.
+func watchOptions(name, resourceVersion string) metav1.ListOptions {
+	return metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+		ResourceVersion: resourceVersion,
+	}
+}
+
+// consume drains the watch until the check is satisfied, the object is
+// deleted, or the context expires. Objects with other names are skipped:
+// fake clients used in tests do not apply field selectors.
+func consume(ctx context.Context, watcher watch.Interface, name string, check func(interface{}) (bool, error)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch closed before the condition was met")
+			}
+			accessor, err := meta.Accessor(event.Object)
+			if err != nil || accessor.GetName() != name {
+				continue
+			}
+			if event.Type == watch.Deleted {
+				return fmt.Errorf("object was deleted before the condition was met")
+			}
+			if done, err := check(event.Object); err != nil || done {
+				return err
+			}
+		}
+	}
+}
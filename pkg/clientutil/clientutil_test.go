@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func pendingPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Status:     v1.PodStatus{Phase: v1.PodPending},
+	}
+}
+
+func readyPod() *v1.Pod {
+	pod := pendingPod()
+	pod.Status.Phase = v1.PodRunning
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	return pod
+}
+
+func TestWaitForPodReadyAlreadySatisfied(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset(readyPod())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pod, err := WaitForPodReady(ctx, client, "default", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod.Name != "web" {
+		t.Errorf("unexpected pod: %+v", pod)
+	}
+}
+
+func TestWaitForPodReadyObservesUpdate(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset(pendingPod())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := WaitForPodReady(ctx, client, "default", "web")
+		done <- err
+	}()
+
+	// Give the waiter a moment to establish its watch, then flip the pod.
+	time.Sleep(50 * time.Millisecond)
+	if _, err := client.CoreV1().Pods("default").UpdateStatus(ctx, readyPod(), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWaitForPodReadyTerminalPhaseFailsFast(t *testing.T) {
+	failed := pendingPod()
+	failed.Status.Phase = v1.PodFailed
+	client := clientsetfake.NewSimpleClientset(failed)
+
+	_, err := WaitForPodReady(context.Background(), client, "default", "web")
+	if err == nil || !strings.Contains(err.Error(), "will not become ready") {
+		t.Fatalf("expected terminal phase error, got %v", err)
+	}
+}
+
+func TestWaitForPodDeletedAborts(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset(pendingPod())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := WaitForPodReady(ctx, client, "default", "web")
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := client.CoreV1().Pods("default").Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "deleted") {
+		t.Fatalf("expected deletion error, got %v", err)
+	}
+}
+
+func TestWaitForPVCBound(t *testing.T) {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending},
+	}
+	client := clientsetfake.NewSimpleClientset(claim)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := WaitForPVCBound(ctx, client, "default", "data")
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	bound := claim.DeepCopy()
+	bound.Spec.VolumeName = "pv-1"
+	bound.Status.Phase = v1.ClaimBound
+	if _, err := client.CoreV1().PersistentVolumeClaims("default").UpdateStatus(ctx, bound, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWaitForPVCLostAborts(t *testing.T) {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimLost},
+	}
+	client := clientsetfake.NewSimpleClientset(claim)
+
+	_, err := WaitForPVCBound(context.Background(), client, "default", "data")
+	if err == nil || !strings.Contains(err.Error(), "lost") {
+		t.Fatalf("expected lost-claim error, got %v", err)
+	}
+}
+
+func TestWaitForPVPhase(t *testing.T) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Status:     v1.PersistentVolumeStatus{Phase: v1.VolumeAvailable},
+	}
+	client := clientsetfake.NewSimpleClientset(pv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := WaitForPVPhase(ctx, client, "pv-1", v1.VolumeAvailable)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status.Phase != v1.VolumeAvailable {
+		t.Errorf("unexpected volume: %+v", got)
+	}
+
+	failed := pv.DeepCopy()
+	failed.Status.Phase = v1.VolumeFailed
+	failed.Status.Message = "backend gone"
+	if _, err := client.CoreV1().PersistentVolumes().UpdateStatus(ctx, failed, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = WaitForPVPhase(ctx, client, "pv-1", v1.VolumeBound)
+	if err == nil || !strings.Contains(err.Error(), "backend gone") {
+		t.Fatalf("expected failed-volume error, got %v", err)
+	}
+}
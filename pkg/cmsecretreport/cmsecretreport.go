@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmsecretreport sizes ConfigMaps and Secrets and reports what
+// pods actually consume of them. Objects near the 1MiB etcd value limit
+// are flagged before writes start failing; keys no pod references via
+// env, envFrom or volumes are candidates for cleanup. etcd storage bloat
+// investigations end at these objects often enough that the report is
+// worth precomputing.
+package cmsecretreport
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// SizeLimit is the etcd-imposed cap on ConfigMap/Secret payloads.
+const SizeLimit = 1024 * 1024
+
+// nearLimitFraction of SizeLimit triggers the NearLimit flag.
+const nearLimitFraction = 0.8
+
+// ObjectReport describes one ConfigMap or Secret.
+type ObjectReport struct {
+	// Kind is "ConfigMap" or "Secret".
+	Kind      string
+	Namespace string
+	Name      string
+	// Bytes is the summed size of all values, data and binaryData.
+	Bytes int
+	// Keys counts entries across data and binaryData.
+	Keys int
+	// BinaryKeys counts entries in binaryData (for ConfigMaps) — a high
+	// ratio suggests the object is being used as a blob store.
+	BinaryKeys int
+	// NearLimit is set when Bytes exceeds 80% of SizeLimit.
+	NearLimit bool
+	// UnusedKeys lists keys no pod consumes, sorted. Only meaningful
+	// when pods were supplied; whole-object references (envFrom, volume
+	// without items) mark every key used.
+	UnusedKeys []string
+}
+
+// NamespaceTotal aggregates one namespace.
+type NamespaceTotal struct {
+	Namespace  string
+	ConfigMaps int
+	Secrets    int
+	Bytes      int
+}
+
+// Report is the full summary.
+type Report struct {
+	// Objects lists every ConfigMap and Secret, largest first.
+	Objects []ObjectReport
+	// Namespaces totals per namespace, sorted by name.
+	Namespaces []NamespaceTotal
+}
+
+// usage tracks which keys of one object pods consume. The allKeys flag
+// is set by whole-object references, where every key (present or future)
+// is live.
+type usage struct {
+	allKeys bool
+	keys    map[string]bool
+}
+
+type objectRef struct {
+	kind, namespace, name string
+}
+
+// collectUsage walks pod specs for every way a ConfigMap or Secret key
+// can be consumed.
+func collectUsage(pods []*v1.Pod) map[objectRef]*usage {
+	used := map[objectRef]*usage{}
+	touch := func(ref objectRef) *usage {
+		u := used[ref]
+		if u == nil {
+			u = &usage{keys: map[string]bool{}}
+			used[ref] = u
+		}
+		return u
+	}
+	for _, pod := range pods {
+		namespace := pod.Namespace
+		containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+		containers = append(containers, pod.Spec.Containers...)
+		for _, container := range containers {
+			for _, env := range container.Env {
+				if env.ValueFrom == nil {
+					continue
+				}
+				if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+					touch(objectRef{"ConfigMap", namespace, ref.Name}).keys[ref.Key] = true
+				}
+				if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+					touch(objectRef{"Secret", namespace, ref.Name}).keys[ref.Key] = true
+				}
+			}
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.ConfigMapRef != nil {
+					touch(objectRef{"ConfigMap", namespace, envFrom.ConfigMapRef.Name}).allKeys = true
+				}
+				if envFrom.SecretRef != nil {
+					touch(objectRef{"Secret", namespace, envFrom.SecretRef.Name}).allKeys = true
+				}
+			}
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if cm := volume.ConfigMap; cm != nil {
+				u := touch(objectRef{"ConfigMap", namespace, cm.Name})
+				markVolumeItems(u, cm.Items)
+			}
+			if secret := volume.Secret; secret != nil {
+				u := touch(objectRef{"Secret", namespace, secret.SecretName})
+				markVolumeItems(u, secret.Items)
+			}
+			if projected := volume.Projected; projected != nil {
+				for _, source := range projected.Sources {
+					if source.ConfigMap != nil {
+						markVolumeItems(touch(objectRef{"ConfigMap", namespace, source.ConfigMap.Name}), source.ConfigMap.Items)
+					}
+					if source.Secret != nil {
+						markVolumeItems(touch(objectRef{"Secret", namespace, source.Secret.Name}), source.Secret.Items)
+					}
+				}
+			}
+		}
+	}
+	return used
+}
+
+// markVolumeItems records item keys; a volume without items mounts every
+// key.
+func markVolumeItems(u *usage, items []v1.KeyToPath) {
+	if len(items) == 0 {
+		u.allKeys = true
+		return
+	}
+	for _, item := range items {
+		u.keys[item.Key] = true
+	}
+}
+
+func unusedKeys(keys []string, u *usage) []string {
+	if u != nil && u.allKeys {
+		return nil
+	}
+	var unused []string
+	for _, key := range keys {
+		if u == nil || !u.keys[key] {
+			unused = append(unused, key)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// Summarize builds the report from cached objects and pods.
+func Summarize(configMaps []*v1.ConfigMap, secrets []*v1.Secret, pods []*v1.Pod) Report {
+	used := collectUsage(pods)
+	totals := map[string]*NamespaceTotal{}
+	touchTotal := func(namespace string) *NamespaceTotal {
+		total := totals[namespace]
+		if total == nil {
+			total = &NamespaceTotal{Namespace: namespace}
+			totals[namespace] = total
+		}
+		return total
+	}
+
+	var objects []ObjectReport
+	for _, cm := range configMaps {
+		bytes, keys := 0, make([]string, 0, len(cm.Data)+len(cm.BinaryData))
+		for key, value := range cm.Data {
+			bytes += len(key) + len(value)
+			keys = append(keys, key)
+		}
+		for key, value := range cm.BinaryData {
+			bytes += len(key) + len(value)
+			keys = append(keys, key)
+		}
+		objects = append(objects, ObjectReport{
+			Kind:       "ConfigMap",
+			Namespace:  cm.Namespace,
+			Name:       cm.Name,
+			Bytes:      bytes,
+			Keys:       len(keys),
+			BinaryKeys: len(cm.BinaryData),
+			NearLimit:  float64(bytes) > nearLimitFraction*SizeLimit,
+			UnusedKeys: unusedKeys(keys, used[objectRef{"ConfigMap", cm.Namespace, cm.Name}]),
+		})
+		total := touchTotal(cm.Namespace)
+		total.ConfigMaps++
+		total.Bytes += bytes
+	}
+	for _, secret := range secrets {
+		bytes, keys := 0, make([]string, 0, len(secret.Data))
+		for key, value := range secret.Data {
+			bytes += len(key) + len(value)
+			keys = append(keys, key)
+		}
+		objects = append(objects, ObjectReport{
+			Kind:       "Secret",
+			Namespace:  secret.Namespace,
+			Name:       secret.Name,
+			Bytes:      bytes,
+			Keys:       len(keys),
+			BinaryKeys: len(secret.Data),
+			NearLimit:  float64(bytes) > nearLimitFraction*SizeLimit,
+			UnusedKeys: unusedKeys(keys, used[objectRef{"Secret", secret.Namespace, secret.Name}]),
+		})
+		total := touchTotal(secret.Namespace)
+		total.Secrets++
+		total.Bytes += bytes
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Bytes != objects[j].Bytes {
+			return objects[i].Bytes > objects[j].Bytes
+		}
+		if objects[i].Namespace != objects[j].Namespace {
+			return objects[i].Namespace < objects[j].Namespace
+		}
+		return objects[i].Name < objects[j].Name
+	})
+
+	report := Report{Objects: objects}
+	namespaces := make([]string, 0, len(totals))
+	for namespace := range totals {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		report.Namespaces = append(report.Namespaces, *totals[namespace])
+	}
+	return report
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmsecretreport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSummarizeSizesAndTotals(t *testing.T) {
+	configMaps := []*v1.ConfigMap{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "settings"},
+			Data:       map[string]string{"a": "1234", "b": "12"},
+			BinaryData: map[string][]byte{"blob": make([]byte, 10)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "huge"},
+			Data:       map[string]string{"payload": strings.Repeat("x", 900*1024)},
+		},
+	}
+	secrets := []*v1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "creds"},
+		Data:       map[string][]byte{"token": []byte("abcdef")},
+	}}
+
+	report := Summarize(configMaps, secrets, nil)
+	if len(report.Objects) != 3 {
+		t.Fatalf("expected 3 objects, got %+v", report.Objects)
+	}
+	// Largest first.
+	if report.Objects[0].Name != "huge" || !report.Objects[0].NearLimit {
+		t.Errorf("huge ConfigMap not flagged near limit: %+v", report.Objects[0])
+	}
+	settings := report.Objects[1]
+	if settings.Name != "settings" || settings.Bytes != 1+4+1+2+4+10 || settings.Keys != 3 || settings.BinaryKeys != 1 {
+		t.Errorf("unexpected settings report: %+v", settings)
+	}
+	if settings.NearLimit {
+		t.Error("small object flagged near limit")
+	}
+
+	wantTotals := []NamespaceTotal{
+		{Namespace: "default", ConfigMaps: 2, Bytes: settings.Bytes + report.Objects[0].Bytes},
+		{Namespace: "prod", Secrets: 1, Bytes: 11},
+	}
+	if !reflect.DeepEqual(report.Namespaces, wantTotals) {
+		t.Errorf("totals = %+v, want %+v", report.Namespaces, wantTotals)
+	}
+}
+
+func TestUnusedKeys(t *testing.T) {
+	configMaps := []*v1.ConfigMap{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "settings"},
+			Data:       map[string]string{"used-env": "1", "used-volume": "2", "orphan": "3"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wholesale"},
+			Data:       map[string]string{"anything": "1"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "untouched"},
+			Data:       map[string]string{"a": "1"},
+		},
+	}
+	secrets := []*v1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "creds"},
+		Data:       map[string][]byte{"token": []byte("x"), "stale": []byte("y")},
+	}}
+	pods := []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "app",
+				Env: []v1.EnvVar{
+					{Name: "A", ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: "settings"}, Key: "used-env",
+					}}},
+					{Name: "B", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: "creds"}, Key: "token",
+					}}},
+				},
+				EnvFrom: []v1.EnvFromSource{{
+					ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "wholesale"}},
+				}},
+			}},
+			Volumes: []v1.Volume{{
+				Name: "cfg",
+				VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{Name: "settings"},
+					Items:                []v1.KeyToPath{{Key: "used-volume", Path: "v"}},
+				}},
+			}},
+		},
+	}}
+
+	report := Summarize(configMaps, secrets, pods)
+	byName := map[string]ObjectReport{}
+	for _, obj := range report.Objects {
+		byName[obj.Kind+"/"+obj.Name] = obj
+	}
+	if got := byName["ConfigMap/settings"].UnusedKeys; !reflect.DeepEqual(got, []string{"orphan"}) {
+		t.Errorf("settings unused = %v, want [orphan]", got)
+	}
+	// envFrom consumes every key.
+	if got := byName["ConfigMap/wholesale"].UnusedKeys; got != nil {
+		t.Errorf("wholesale unused = %v, want none", got)
+	}
+	// Never referenced: all keys unused.
+	if got := byName["ConfigMap/untouched"].UnusedKeys; !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("untouched unused = %v, want [a]", got)
+	}
+	if got := byName["Secret/creds"].UnusedKeys; !reflect.DeepEqual(got, []string{"stale"}) {
+		t.Errorf("creds unused = %v, want [stale]", got)
+	}
+}
+
+func TestVolumeWithoutItemsUsesAllKeys(t *testing.T) {
+	configMaps := []*v1.ConfigMap{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mounted"},
+		Data:       map[string]string{"a": "1", "b": "2"},
+	}}
+	pods := []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{{
+				Name: "cfg",
+				VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{Name: "mounted"},
+				}},
+			}},
+		},
+	}}
+	report := Summarize(configMaps, nil, pods)
+	if got := report.Objects[0].UnusedKeys; got != nil {
+		t.Errorf("full mount must use all keys, got unused %v", got)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compressedstore provides an in-memory object store that keeps
+// objects encoded (typically protobuf) and compressed, decoding on access
+// through an LRU of hot decoded objects. It trades decode CPU for a large
+// reduction in resident memory on big caches.
+//
+// The compression algorithm is pluggable; NewFlateCompressor provides a
+// dependency-free default, and deployments that vendor a zstd
+// implementation can supply it through the Compressor interface for better
+// ratios at lower CPU cost. Hit and decode counters are exposed so the hot
+// LRU size can be tuned against the observed workload.
+package compressedstore
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/lru"
+)
+
+// Compressor compresses and decompresses encoded object payloads.
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// flateCompressor is the stdlib DEFLATE implementation of Compressor.
+type flateCompressor struct {
+	level int
+}
+
+// NewFlateCompressor returns a Compressor using stdlib DEFLATE at the given
+// level (flate.BestSpeed through flate.BestCompression).
+func NewFlateCompressor(level int) Compressor {
+	return &flateCompressor{level: level}
+}
+
+func (f *flateCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, f.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *flateCompressor) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Metrics are cumulative counters for tuning the memory/CPU tradeoff.
+type Metrics struct {
+	// HotHits counts Gets served from the decoded LRU.
+	HotHits uint64
+	// Decodes counts Gets that had to decompress and decode.
+	Decodes uint64
+	// Misses counts Gets for absent keys.
+	Misses uint64
+	// CompressedBytes is the current total size of stored payloads.
+	CompressedBytes int64
+	// UncompressedBytes is the current total encoded size before
+	// compression, for computing the achieved ratio.
+	UncompressedBytes int64
+}
+
+// Store is a compressed in-memory object store. It is safe for concurrent
+// use. Objects returned by Get are shared with the hot LRU and must be
+// treated as read-only, matching the informer cache contract.
+type Store struct {
+	codec      runtime.Codec
+	compressor Compressor
+
+	mu      sync.Mutex
+	items   map[string]entry
+	hot     *lru.Cache
+	metrics Metrics
+}
+
+type entry struct {
+	compressed   []byte
+	uncompressed int
+}
+
+// NewStore returns a store encoding objects with codec, compressing with
+// compressor, and keeping up to hotSize decoded objects in memory. A
+// hotSize of zero disables the hot LRU so every Get decodes.
+func NewStore(codec runtime.Codec, compressor Compressor, hotSize int) *Store {
+	s := &Store{
+		codec:      codec,
+		compressor: compressor,
+		items:      map[string]entry{},
+	}
+	if hotSize > 0 {
+		s.hot = lru.New(hotSize)
+	}
+	return s
+}
+
+// Add encodes, compresses and stores the object under key, replacing any
+// existing entry.
+func (s *Store) Add(key string, obj runtime.Object) error {
+	encoded, err := runtime.Encode(s.codec, obj)
+	if err != nil {
+		return fmt.Errorf("encoding %q: %v", key, err)
+	}
+	compressed, err := s.compressor.Compress(encoded)
+	if err != nil {
+		return fmt.Errorf("compressing %q: %v", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.items[key]; ok {
+		s.metrics.CompressedBytes -= int64(len(old.compressed))
+		s.metrics.UncompressedBytes -= int64(old.uncompressed)
+	}
+	s.items[key] = entry{compressed: compressed, uncompressed: len(encoded)}
+	s.metrics.CompressedBytes += int64(len(compressed))
+	s.metrics.UncompressedBytes += int64(len(encoded))
+	if s.hot != nil {
+		s.hot.Add(key, obj)
+	}
+	return nil
+}
+
+// Get returns the object stored under key, decoding it unless it is still
+// hot. The returned object must not be mutated.
+func (s *Store) Get(key string) (runtime.Object, bool, error) {
+	s.mu.Lock()
+	if s.hot != nil {
+		if obj, ok := s.hot.Get(key); ok {
+			s.metrics.HotHits++
+			s.mu.Unlock()
+			return obj.(runtime.Object), true, nil
+		}
+	}
+	item, ok := s.items[key]
+	if !ok {
+		s.metrics.Misses++
+		s.mu.Unlock()
+		return nil, false, nil
+	}
+	s.metrics.Decodes++
+	s.mu.Unlock()
+
+	encoded, err := s.compressor.Decompress(item.compressed)
+	if err != nil {
+		return nil, false, fmt.Errorf("decompressing %q: %v", key, err)
+	}
+	obj, err := runtime.Decode(s.codec, encoded)
+	if err != nil {
+		return nil, false, fmt.Errorf("decoding %q: %v", key, err)
+	}
+
+	s.mu.Lock()
+	if s.hot != nil {
+		s.hot.Add(key, obj)
+	}
+	s.mu.Unlock()
+	return obj, true, nil
+}
+
+// Delete removes the entry stored under key, if any.
+func (s *Store) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.items[key]; ok {
+		s.metrics.CompressedBytes -= int64(len(old.compressed))
+		s.metrics.UncompressedBytes -= int64(old.uncompressed)
+		delete(s.items, key)
+	}
+	if s.hot != nil {
+		s.hot.Remove(key)
+	}
+}
+
+// Len returns the number of stored objects.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// Metrics returns a snapshot of the store's counters.
+func (s *Store) Metrics() Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metrics
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compressedstore
+
+import (
+	"compress/flate"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func testCodec() runtime.Codec {
+	info, _ := runtime.SerializerInfoForMediaType(clientsetscheme.Codecs.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	return clientsetscheme.Codecs.CodecForVersions(info.Serializer, info.Serializer, v1.SchemeGroupVersion, v1.SchemeGroupVersion)
+}
+
+func testPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        name,
+			Annotations: map[string]string{"filler": strings.Repeat("x", 2048)},
+		},
+		Spec: v1.PodSpec{NodeName: "node-1"},
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	store := NewStore(testCodec(), NewFlateCompressor(flate.BestSpeed), 0)
+	if err := store.Add("default/web", testPod("web")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	obj, ok, err := store.Get("default/web")
+	if err != nil || !ok {
+		t.Fatalf("expected stored object, got ok=%v err=%v", ok, err)
+	}
+	pod, isPod := obj.(*v1.Pod)
+	if !isPod || pod.Name != "web" || pod.Spec.NodeName != "node-1" {
+		t.Errorf("round trip lost data: %+v", obj)
+	}
+
+	if _, ok, err := store.Get("default/absent"); ok || err != nil {
+		t.Errorf("expected miss, got ok=%v err=%v", ok, err)
+	}
+
+	store.Delete("default/web")
+	if store.Len() != 0 {
+		t.Errorf("expected empty store after delete, got %d", store.Len())
+	}
+	metrics := store.Metrics()
+	if metrics.CompressedBytes != 0 || metrics.UncompressedBytes != 0 {
+		t.Errorf("expected byte counters to return to zero, got %+v", metrics)
+	}
+}
+
+func TestStoreProtobufCodec(t *testing.T) {
+	serializer := protobuf.NewSerializer(clientsetscheme.Scheme, clientsetscheme.Scheme)
+	codec := clientsetscheme.Codecs.CodecForVersions(serializer, serializer, v1.SchemeGroupVersion, v1.SchemeGroupVersion)
+	store := NewStore(codec, NewFlateCompressor(flate.DefaultCompression), 4)
+	if err := store.Add("default/web", testPod("web")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj, ok, err := store.Get("default/web")
+	if err != nil || !ok {
+		t.Fatalf("expected stored object, got ok=%v err=%v", ok, err)
+	}
+	if pod := obj.(*v1.Pod); pod.Name != "web" {
+		t.Errorf("protobuf round trip lost data: %+v", pod)
+	}
+}
+
+func TestStoreHotLRUAndMetrics(t *testing.T) {
+	store := NewStore(testCodec(), NewFlateCompressor(flate.BestSpeed), 1)
+	if err := store.Add("a", testPod("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("b", testPod("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	// "b" is hot (LRU size 1 evicted "a" when "b" was added).
+	if _, _, err := store.Get("b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	// "a" is hot now.
+	if _, _, err := store.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	store.Get("absent")
+
+	metrics := store.Metrics()
+	if metrics.HotHits != 2 {
+		t.Errorf("expected 2 hot hits, got %+v", metrics)
+	}
+	if metrics.Decodes != 1 {
+		t.Errorf("expected 1 decode, got %+v", metrics)
+	}
+	if metrics.Misses != 1 {
+		t.Errorf("expected 1 miss, got %+v", metrics)
+	}
+	if metrics.UncompressedBytes <= metrics.CompressedBytes {
+		t.Errorf("expected compression to reduce size, got %+v", metrics)
+	}
+}
+
+func TestStoreReplaceAccountsBytes(t *testing.T) {
+	store := NewStore(testCodec(), NewFlateCompressor(flate.BestSpeed), 0)
+	if err := store.Add("a", testPod("a")); err != nil {
+		t.Fatal(err)
+	}
+	first := store.Metrics()
+	if err := store.Add("a", testPod("a")); err != nil {
+		t.Fatal(err)
+	}
+	second := store.Metrics()
+	if first.CompressedBytes != second.CompressedBytes || first.UncompressedBytes != second.UncompressedBytes {
+		t.Errorf("replacing an entry must not leak byte accounting: %+v vs %+v", first, second)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crashloop detects restarting containers and estimates where
+// they are in the kubelet's restart backoff. The kubelet doubles its
+// delay from 10s to a 5 minute cap and resets it after 10 minutes of
+// stable running; reconstructing that from RestartCount and the last
+// termination's timestamps lets a reader say not just "crash looping"
+// but "next attempt in 80s".
+package crashloop
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// backoffInitial and backoffCap mirror the kubelet's container
+	// restart backoff (10s doubling to 5m).
+	backoffInitial = 10 * time.Second
+	backoffCap     = 5 * time.Minute
+	// backoffReset is how long a container must run before the
+	// kubelet forgets its backoff.
+	backoffReset = 10 * time.Minute
+)
+
+// Analysis is the restart picture of one container.
+type Analysis struct {
+	Container    string
+	RestartCount int32
+	// CrashLooping is true when the container is in CrashLoopBackOff
+	// or terminated again within its reset window.
+	CrashLooping bool
+	// Backoff is the estimated current backoff window.
+	Backoff time.Duration
+	// NextRestart estimates when the kubelet tries again; zero when
+	// the container is running or not looping.
+	NextRestart time.Time
+	// LastExit describes the most recent termination, e.g.
+	// "OOMKilled (exit 137)".
+	LastExit string
+}
+
+// EstimateBackoff returns the kubelet's backoff window after the
+// given number of consecutive crashes.
+func EstimateBackoff(consecutiveCrashes int32) time.Duration {
+	backoff := backoffInitial
+	for i := int32(1); i < consecutiveCrashes; i++ {
+		backoff *= 2
+		if backoff >= backoffCap {
+			return backoffCap
+		}
+	}
+	return backoff
+}
+
+// Analyze inspects every container of the pod (init containers
+// included) and reports the restarting ones; containers that never
+// restarted and are running are omitted.
+func Analyze(pod *v1.Pod, now time.Time) []Analysis {
+	var results []Analysis
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if analysis, looping := analyzeContainer(status, now); looping {
+			results = append(results, analysis)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Container < results[j].Container })
+	return results
+}
+
+func analyzeContainer(status v1.ContainerStatus, now time.Time) (Analysis, bool) {
+	analysis := Analysis{Container: status.Name, RestartCount: status.RestartCount}
+	last := status.LastTerminationState.Terminated
+	if last != nil {
+		analysis.LastExit = describeExit(last)
+	}
+
+	waiting := status.State.Waiting
+	if waiting != nil && waiting.Reason == "CrashLoopBackOff" {
+		analysis.CrashLooping = true
+		// RestartCount counts completed restarts; the kubelet is now
+		// backing off before attempt RestartCount+1. Without per-crash
+		// history assume every restart counted toward the backoff,
+		// which over-estimates for long-lived pods but matches a
+		// fresh storm exactly.
+		analysis.Backoff = EstimateBackoff(status.RestartCount)
+		if last != nil {
+			analysis.NextRestart = last.FinishedAt.Add(analysis.Backoff)
+		}
+		return analysis, true
+	}
+
+	// A container that terminated recently and restarted before: if
+	// the previous run was shorter than the reset window the backoff
+	// is still compounding.
+	if status.RestartCount > 0 && last != nil {
+		ranFor := last.FinishedAt.Sub(last.StartedAt.Time)
+		sinceExit := now.Sub(last.FinishedAt.Time)
+		if ranFor < backoffReset && sinceExit < backoffReset && status.State.Running == nil {
+			analysis.CrashLooping = true
+			analysis.Backoff = EstimateBackoff(status.RestartCount)
+			analysis.NextRestart = last.FinishedAt.Add(analysis.Backoff)
+			return analysis, true
+		}
+	}
+	return analysis, false
+}
+
+func describeExit(terminated *v1.ContainerStateTerminated) string {
+	if terminated.Reason != "" {
+		return fmt.Sprintf("%s (exit %d)", terminated.Reason, terminated.ExitCode)
+	}
+	return fmt.Sprintf("exit %d", terminated.ExitCode)
+}
+
+// Storm is raised by the Monitor when restarts cluster in time.
+type Storm struct {
+	Pod       string
+	Container string
+	// Restarts is how many restarts landed inside the window.
+	Restarts int
+	Window   time.Duration
+}
+
+func (s Storm) String() string {
+	return fmt.Sprintf("%s/%s restarted %d times in %s", s.Pod, s.Container, s.Restarts, s.Window)
+}
+
+// Monitor consumes successive observations of pods — from a watch or
+// a poll — and reports restart storms as they develop. It tracks the
+// times at which restart counts were seen to increase, so it needs
+// only the objects themselves, not events.
+type Monitor struct {
+	// Threshold is how many restarts within Window raise a Storm.
+	Threshold int
+	Window    time.Duration
+
+	seen map[string]*containerHistory
+}
+
+type containerHistory struct {
+	lastCount int32
+	increases []time.Time
+}
+
+// NewMonitor returns a Monitor raising a Storm after threshold
+// restarts inside window.
+func NewMonitor(threshold int, window time.Duration) *Monitor {
+	return &Monitor{
+		Threshold: threshold,
+		Window:    window,
+		seen:      map[string]*containerHistory{},
+	}
+}
+
+// Observe folds in the pod's current status, as of now, and returns
+// any storms newly crossing the threshold.
+func (m *Monitor) Observe(pod *v1.Pod, now time.Time) []Storm {
+	var storms []Storm
+	podKey := pod.Namespace + "/" + pod.Name
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		key := podKey + "/" + status.Name
+		history, ok := m.seen[key]
+		if !ok {
+			m.seen[key] = &containerHistory{lastCount: status.RestartCount}
+			continue
+		}
+		if status.RestartCount <= history.lastCount {
+			continue
+		}
+		for i := history.lastCount; i < status.RestartCount; i++ {
+			history.increases = append(history.increases, now)
+		}
+		history.lastCount = status.RestartCount
+		history.increases = trim(history.increases, now.Add(-m.Window))
+		if len(history.increases) >= m.Threshold {
+			storms = append(storms, Storm{
+				Pod:       podKey,
+				Container: status.Name,
+				Restarts:  len(history.increases),
+				Window:    m.Window,
+			})
+			// Re-raise only after further restarts.
+			history.increases = nil
+		}
+	}
+	return storms
+}
+
+func trim(times []time.Time, cutoff time.Time) []time.Time {
+	kept := times[:0]
+	for _, t := range times {
+		if !t.Before(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crashloop
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testNow = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func loopingPod(restarts int32, waitingReason string) *v1.Pod {
+	status := v1.ContainerStatus{
+		Name:         "app",
+		RestartCount: restarts,
+		LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+			Reason:     "OOMKilled",
+			ExitCode:   137,
+			StartedAt:  metav1.NewTime(testNow.Add(-90 * time.Second)),
+			FinishedAt: metav1.NewTime(testNow.Add(-30 * time.Second)),
+		}},
+	}
+	if waitingReason != "" {
+		status.State.Waiting = &v1.ContainerStateWaiting{Reason: waitingReason}
+	} else {
+		status.State.Running = &v1.ContainerStateRunning{}
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-0"},
+		Status:     v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{status}},
+	}
+}
+
+func TestEstimateBackoff(t *testing.T) {
+	testCases := []struct {
+		crashes int32
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{6, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+	for _, tc := range testCases {
+		if got := EstimateBackoff(tc.crashes); got != tc.want {
+			t.Errorf("EstimateBackoff(%d) = %v, want %v", tc.crashes, got, tc.want)
+		}
+	}
+}
+
+func TestAnalyzeCrashLoopBackOff(t *testing.T) {
+	results := Analyze(loopingPod(4, "CrashLoopBackOff"), testNow)
+	if len(results) != 1 {
+		t.Fatalf("results: %+v", results)
+	}
+	analysis := results[0]
+	if !analysis.CrashLooping || analysis.Backoff != 80*time.Second {
+		t.Errorf("analysis: %+v", analysis)
+	}
+	wantNext := testNow.Add(-30 * time.Second).Add(80 * time.Second)
+	if !analysis.NextRestart.Equal(wantNext) {
+		t.Errorf("next restart %v, want %v", analysis.NextRestart, wantNext)
+	}
+	if analysis.LastExit != "OOMKilled (exit 137)" {
+		t.Errorf("last exit: %q", analysis.LastExit)
+	}
+}
+
+func TestAnalyzeStableContainer(t *testing.T) {
+	// Running with restarts in the past is not a loop.
+	if results := Analyze(loopingPod(4, ""), testNow); len(results) != 0 {
+		t.Errorf("running container flagged: %+v", results)
+	}
+
+	// No restarts at all.
+	pod := &v1.Pod{Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+		Name: "app", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+	}}}}
+	if results := Analyze(pod, testNow); len(results) != 0 {
+		t.Errorf("healthy container flagged: %+v", results)
+	}
+}
+
+func TestMonitorStorm(t *testing.T) {
+	monitor := NewMonitor(3, 10*time.Minute)
+
+	observe := func(restarts int32, at time.Time) []Storm {
+		return monitor.Observe(loopingPod(restarts, "CrashLoopBackOff"), at)
+	}
+
+	// First sight establishes the baseline.
+	if storms := observe(1, testNow); len(storms) != 0 {
+		t.Errorf("baseline should not alert: %+v", storms)
+	}
+	if storms := observe(2, testNow.Add(time.Minute)); len(storms) != 0 {
+		t.Errorf("below threshold: %+v", storms)
+	}
+	storms := observe(4, testNow.Add(2*time.Minute))
+	if len(storms) != 1 || storms[0].Restarts != 3 {
+		t.Fatalf("storms: %+v", storms)
+	}
+	if storms[0].Pod != "default/web-0" || storms[0].Container != "app" {
+		t.Errorf("storm identity: %+v", storms[0])
+	}
+
+	// The alert does not re-fire without new restarts.
+	if storms := observe(4, testNow.Add(3*time.Minute)); len(storms) != 0 {
+		t.Errorf("re-fired without restarts: %+v", storms)
+	}
+}
+
+func TestMonitorWindowExpiry(t *testing.T) {
+	monitor := NewMonitor(3, 5*time.Minute)
+	observe := func(restarts int32, at time.Time) []Storm {
+		return monitor.Observe(loopingPod(restarts, "CrashLoopBackOff"), at)
+	}
+	observe(0, testNow)
+	observe(1, testNow.Add(1*time.Minute))
+	observe(2, testNow.Add(2*time.Minute))
+	// The first two increases age out before the third lands.
+	if storms := observe(3, testNow.Add(20*time.Minute)); len(storms) != 0 {
+		t.Errorf("stale restarts should not count: %+v", storms)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package decodepool pools decode targets and scratch buffers for the
+// watch-consumer hot path. Decoding a Pod allocates the whole (large)
+// struct every time; profiles of our watch consumers are dominated by
+// exactly that. A Pool hands out zeroed, reusable structs to decode
+// into and takes them back after the event is processed. Objects that
+// must outlive the borrow escape through the generated DeepCopy, so
+// nothing a caller keeps ever aliases pooled memory.
+package decodepool
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Pool reuses decode targets of one concrete type.
+type Pool struct {
+	objs sync.Pool
+	bufs sync.Pool
+}
+
+// NewPool returns a pool producing fresh targets with newObj, e.g.
+// func() runtime.Object { return &v1.Pod{} }.
+func NewPool(newObj func() runtime.Object) *Pool {
+	return &Pool{
+		objs: sync.Pool{New: func() interface{} { return newObj() }},
+		bufs: sync.Pool{New: func() interface{} { return new(bytes.Buffer) }},
+	}
+}
+
+// Borrowed is a decoded object on loan from the pool. Exactly one of
+// Release or Escape must be called; after either, Object is invalid.
+type Borrowed struct {
+	obj  runtime.Object
+	pool *Pool
+	done bool
+}
+
+// Object returns the borrowed object. It must not be retained past
+// Release; use Escape for anything that outlives the borrow.
+func (b *Borrowed) Object() runtime.Object {
+	return b.obj
+}
+
+// Escape returns a deep copy the caller owns and returns the pooled
+// original for reuse.
+func (b *Borrowed) Escape() runtime.Object {
+	if b.done {
+		return nil
+	}
+	copied := b.obj.DeepCopyObject()
+	b.release()
+	return copied
+}
+
+// Release returns the object to the pool.
+func (b *Borrowed) Release() {
+	if !b.done {
+		b.release()
+	}
+}
+
+func (b *Borrowed) release() {
+	b.done = true
+	// Zero before pooling, not after borrowing: DecodeInto merges into
+	// existing fields, so a dirty struct would leak one object's fields
+	// into the next.
+	reflect.ValueOf(b.obj).Elem().SetZero()
+	b.pool.objs.Put(b.obj)
+	b.obj = nil
+}
+
+// Decode decodes data into a pooled target.
+func (p *Pool) Decode(decoder runtime.Decoder, data []byte) (*Borrowed, error) {
+	obj := p.objs.Get().(runtime.Object)
+	if err := runtime.DecodeInto(decoder, data, obj); err != nil {
+		reflect.ValueOf(obj).Elem().SetZero()
+		p.objs.Put(obj)
+		return nil, err
+	}
+	return &Borrowed{obj: obj, pool: p}, nil
+}
+
+// DecodeFrom reads one payload from r through a pooled scratch buffer
+// and decodes it, keeping the read path allocation-free as well.
+func (p *Pool) DecodeFrom(decoder runtime.Decoder, r io.Reader) (*Borrowed, error) {
+	buf := p.bufs.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer p.bufs.Put(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("reading payload: %v", err)
+	}
+	return p.Decode(decoder, buf.Bytes())
+}
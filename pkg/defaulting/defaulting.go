@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package defaulting applies the defaulter-gen output to hand-built
+// objects. A pod decoded from a manifest or constructed in code has
+// zero values where the apiserver would have written TCP protocols,
+// termination message paths and probe thresholds; comparing it against
+// live objects then reports phantom drift. Defaulting first makes both
+// sides speak the server's dialect. The SetDefaults_ functions
+// themselves are generated into pkg/apis/<group>/<version>; this is
+// only the read-side entry point.
+package defaulting
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	appsv1defaults "k8s.io/kubernetes/pkg/apis/apps/v1"
+	batchv1defaults "k8s.io/kubernetes/pkg/apis/batch/v1"
+	corev1defaults "k8s.io/kubernetes/pkg/apis/core/v1"
+)
+
+// scheme knows the clientset types plus the generated defaulting
+// functions for the groups this repo reads most.
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientsetscheme.AddToScheme(scheme))
+	utilruntime.Must(corev1defaults.RegisterDefaults(scheme))
+	utilruntime.Must(appsv1defaults.RegisterDefaults(scheme))
+	utilruntime.Must(batchv1defaults.RegisterDefaults(scheme))
+}
+
+// Apply runs the registered defaulting functions for obj's type in
+// place. Types without registered defaults are left untouched.
+func Apply(obj runtime.Object) {
+	scheme.Default(obj)
+}
+
+// ApplyAll defaults every object in the slice.
+func ApplyAll(objs []runtime.Object) {
+	for _, obj := range objs {
+		scheme.Default(obj)
+	}
+}
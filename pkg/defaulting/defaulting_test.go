@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaulting
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func intOrString(port int) intstr.IntOrString {
+	return intstr.FromInt(port)
+}
+
+func TestApplyPodDefaults(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:  "app",
+				Image: "nginx",
+				Ports: []v1.ContainerPort{{ContainerPort: 8080}},
+				ReadinessProbe: &v1.Probe{
+					ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/healthz", Port: intOrString(8080)}},
+				},
+			}},
+		},
+	}
+	Apply(pod)
+
+	if pod.Spec.RestartPolicy != v1.RestartPolicyAlways {
+		t.Errorf("restartPolicy = %q, want Always", pod.Spec.RestartPolicy)
+	}
+	if pod.Spec.DNSPolicy != v1.DNSClusterFirst {
+		t.Errorf("dnsPolicy = %q, want ClusterFirst", pod.Spec.DNSPolicy)
+	}
+	container := pod.Spec.Containers[0]
+	if container.Ports[0].Protocol != v1.ProtocolTCP {
+		t.Errorf("port protocol = %q, want TCP", container.Ports[0].Protocol)
+	}
+	if container.TerminationMessagePath != v1.TerminationMessagePathDefault {
+		t.Errorf("terminationMessagePath = %q", container.TerminationMessagePath)
+	}
+	probe := container.ReadinessProbe
+	if probe.PeriodSeconds != 10 || probe.SuccessThreshold != 1 || probe.FailureThreshold != 3 || probe.TimeoutSeconds != 1 {
+		t.Errorf("probe thresholds not defaulted: %+v", probe)
+	}
+}
+
+func TestApplyDeploymentDefaults(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+	}
+	Apply(deployment)
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 1 {
+		t.Errorf("replicas not defaulted: %+v", deployment.Spec.Replicas)
+	}
+	if deployment.Spec.Strategy.Type != appsv1.RollingUpdateDeploymentStrategyType {
+		t.Errorf("strategy = %q, want RollingUpdate", deployment.Spec.Strategy.Type)
+	}
+}
+
+func TestApplyAll(t *testing.T) {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 80}}},
+	}
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}}}
+	ApplyAll([]runtime.Object{service, pod})
+
+	if service.Spec.Ports[0].Protocol != v1.ProtocolTCP {
+		t.Errorf("service port protocol not defaulted: %+v", service.Spec.Ports[0])
+	}
+	if pod.Spec.SchedulerName == "" && pod.Spec.RestartPolicy == "" {
+		t.Errorf("pod defaults not applied: %+v", pod.Spec)
+	}
+}
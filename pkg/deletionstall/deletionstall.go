@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deletionstall finds objects whose deletion is not making
+// progress. An object with deletionTimestamp set only goes away once
+// its finalizers list empties, so a stall report names the finalizers
+// still present, how long the object has been terminating, and — for
+// the kinds whose finalizers have well-known owners — which component
+// is expected to clear each one.
+package deletionstall
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// wellKnownFinalizers maps finalizer names to the component
+// responsible for removing them, for the hints humans act on.
+var wellKnownFinalizers = map[string]string{
+	"kubernetes.io/pv-protection":  "the PV protection controller, waiting for the volume to be unbound",
+	"kubernetes.io/pvc-protection": "the PVC protection controller, waiting for pods using the claim to terminate",
+	"kubernetes":                   "the namespace controller, waiting for namespaced resources to be removed",
+	"foregroundDeletion":           "the garbage collector, waiting for dependents to be deleted",
+	"orphan":                       "the garbage collector, orphaning dependents",
+	"external-provisioner.volume.kubernetes.io/finalizer": "the CSI external-provisioner",
+}
+
+// Stall describes one object stuck in deletion.
+type Stall struct {
+	// Kind, Namespace and Name identify the object.
+	Kind      string
+	Namespace string
+	Name      string
+	// Terminating is how long the object has been past its
+	// deletionTimestamp.
+	Terminating time.Duration
+	// Finalizers are the names still holding the object, sorted.
+	Finalizers []string
+	// Hints explains well-known finalizers, aligned with Finalizers;
+	// empty string for unrecognized ones.
+	Hints []string
+}
+
+func (s Stall) String() string {
+	id := s.Kind + "/" + s.Name
+	if s.Namespace != "" {
+		id = s.Kind + "/" + s.Namespace + "/" + s.Name
+	}
+	return fmt.Sprintf("%s terminating for %s, blocked by %v", id, s.Terminating.Round(time.Second), s.Finalizers)
+}
+
+// Check inspects one object's metadata. It returns nil when the
+// object is not deleting, or is deleting with no finalizers left (the
+// apiserver will remove it imminently); minAge filters fresh
+// deletions that are likely still progressing.
+func Check(kind string, meta metav1.Object, now time.Time, minAge time.Duration) *Stall {
+	deleted := meta.GetDeletionTimestamp()
+	if deleted == nil {
+		return nil
+	}
+	finalizers := meta.GetFinalizers()
+	if len(finalizers) == 0 {
+		return nil
+	}
+	terminating := now.Sub(deleted.Time)
+	if terminating < minAge {
+		return nil
+	}
+	sorted := append([]string(nil), finalizers...)
+	sort.Strings(sorted)
+	hints := make([]string, len(sorted))
+	for i, finalizer := range sorted {
+		hints[i] = wellKnownFinalizers[finalizer]
+	}
+	return &Stall{
+		Kind:        kind,
+		Namespace:   meta.GetNamespace(),
+		Name:        meta.GetName(),
+		Terminating: terminating,
+		Finalizers:  sorted,
+		Hints:       hints,
+	}
+}
+
+// CheckPod adds pod-specific context to Check: a terminating pod
+// held not by finalizers but by a node that stopped reporting shows
+// up too, since that is the other common stuck-deletion shape.
+func CheckPod(pod *v1.Pod, now time.Time, minAge time.Duration) *Stall {
+	if stall := Check("Pod", pod, now, minAge); stall != nil {
+		return stall
+	}
+	deleted := pod.DeletionTimestamp
+	if deleted == nil {
+		return nil
+	}
+	terminating := now.Sub(deleted.Time)
+	if terminating < minAge {
+		return nil
+	}
+	// Past the grace period with no finalizers: the kubelet is not
+	// confirming termination.
+	grace := int64(30)
+	if pod.DeletionGracePeriodSeconds != nil {
+		grace = *pod.DeletionGracePeriodSeconds
+	}
+	if terminating <= time.Duration(grace)*time.Second {
+		return nil
+	}
+	return &Stall{
+		Kind:        "Pod",
+		Namespace:   pod.Namespace,
+		Name:        pod.Name,
+		Terminating: terminating,
+		Finalizers:  []string{},
+		Hints: []string{fmt.Sprintf(
+			"no finalizers; the kubelet on %s has not confirmed termination (grace period %ds elapsed)",
+			pod.Spec.NodeName, grace)},
+	}
+}
+
+// Scan runs Check over a mixed set of objects and returns the stalls
+// sorted by how long each has been terminating, longest first.
+func Scan(objects map[string][]metav1.Object, now time.Time, minAge time.Duration) []Stall {
+	var stalls []Stall
+	for kind, list := range objects {
+		for _, meta := range list {
+			if stall := Check(kind, meta, now, minAge); stall != nil {
+				stalls = append(stalls, *stall)
+			}
+		}
+	}
+	sort.Slice(stalls, func(i, j int) bool {
+		if stalls[i].Terminating != stalls[j].Terminating {
+			return stalls[i].Terminating > stalls[j].Terminating
+		}
+		return stalls[i].Name < stalls[j].Name
+	})
+	return stalls
+}
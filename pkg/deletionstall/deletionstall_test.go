@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deletionstall
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testNow = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func deletedAt(ago time.Duration) *metav1.Time {
+	t := metav1.NewTime(testNow.Add(-ago))
+	return &t
+}
+
+func TestCheck(t *testing.T) {
+	claim := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Namespace:         "default",
+		Name:              "data",
+		DeletionTimestamp: deletedAt(10 * time.Minute),
+		Finalizers:        []string{"kubernetes.io/pvc-protection"},
+	}}
+	stall := Check("PersistentVolumeClaim", claim, testNow, time.Minute)
+	if stall == nil {
+		t.Fatal("expected a stall")
+	}
+	if stall.Terminating != 10*time.Minute || stall.Finalizers[0] != "kubernetes.io/pvc-protection" {
+		t.Errorf("stall: %+v", stall)
+	}
+	if !strings.Contains(stall.Hints[0], "pods using the claim") {
+		t.Errorf("hint: %q", stall.Hints[0])
+	}
+	if !strings.Contains(stall.String(), "PersistentVolumeClaim/default/data") {
+		t.Errorf("String: %q", stall.String())
+	}
+}
+
+func TestCheckNotStalled(t *testing.T) {
+	live := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cfg"}}
+	if Check("ConfigMap", live, testNow, 0) != nil {
+		t.Error("object without deletionTimestamp is not stalled")
+	}
+
+	clearing := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:              "cfg",
+		DeletionTimestamp: deletedAt(time.Hour),
+	}}
+	if Check("ConfigMap", clearing, testNow, 0) != nil {
+		t.Error("no finalizers left means the apiserver will finish the delete")
+	}
+
+	fresh := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:              "cfg",
+		DeletionTimestamp: deletedAt(10 * time.Second),
+		Finalizers:        []string{"example.com/hook"},
+	}}
+	if Check("ConfigMap", fresh, testNow, time.Minute) != nil {
+		t.Error("deletions younger than minAge are filtered")
+	}
+}
+
+func TestCheckPodKubeletStall(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "web-0",
+			DeletionTimestamp: deletedAt(5 * time.Minute),
+		},
+		Spec: v1.PodSpec{NodeName: "node-1"},
+	}
+	stall := CheckPod(pod, testNow, time.Minute)
+	if stall == nil {
+		t.Fatal("expected a kubelet stall")
+	}
+	if len(stall.Finalizers) != 0 || !strings.Contains(stall.Hints[0], "node-1") {
+		t.Errorf("stall: %+v", stall)
+	}
+
+	// Within the grace period nothing is wrong.
+	pod.DeletionTimestamp = deletedAt(10 * time.Second)
+	if CheckPod(pod, testNow, 0) != nil {
+		t.Error("pod still within its grace period")
+	}
+}
+
+func TestScanOrdering(t *testing.T) {
+	objects := map[string][]metav1.Object{
+		"ConfigMap": {
+			&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:              "older",
+				DeletionTimestamp: deletedAt(2 * time.Hour),
+				Finalizers:        []string{"example.com/hook"},
+			}},
+			&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:              "newer",
+				DeletionTimestamp: deletedAt(time.Hour),
+				Finalizers:        []string{"example.com/hook"},
+			}},
+			&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "live"}},
+		},
+	}
+	stalls := Scan(objects, testNow, time.Minute)
+	if len(stalls) != 2 || stalls[0].Name != "older" || stalls[1].Name != "newer" {
+		t.Errorf("stalls: %+v", stalls)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deprecations scans objects for usage of deprecated and removed
+// features still representable in this package's vendored types —
+// in-tree volume plugins on their way out, beta annotations with GA
+// replacements, legacy field spellings. Each rule carries the release
+// that deprecated the feature, the release that removes it (where
+// scheduled), and what to use instead, so an upgrade plan can be ordered
+// by deadline instead of assembled from release notes.
+package deprecations
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Rule describes one deprecated feature.
+type Rule struct {
+	// ID names the rule stably, for suppressions and tracking.
+	ID string
+	// DeprecatedIn is the release that deprecated the feature.
+	DeprecatedIn string
+	// RemovedIn is the release that removes it; empty when unscheduled.
+	RemovedIn string
+	// Replacement says what to migrate to.
+	Replacement string
+	// check returns a detail string when the pod uses the feature,
+	// empty otherwise. Rules over other kinds use the dedicated check
+	// functions below.
+	check func(pod *v1.Pod) string
+}
+
+// Finding is one flagged usage.
+type Finding struct {
+	Rule      Rule
+	Kind      string
+	Namespace string
+	Name      string
+	// Detail points at the offending field, e.g. the volume name.
+	Detail string
+}
+
+// volumeRule builds a pod rule over volume sources.
+func volumeRule(id, deprecatedIn, removedIn, replacement string, uses func(v1.VolumeSource) bool) Rule {
+	return Rule{
+		ID:           id,
+		DeprecatedIn: deprecatedIn,
+		RemovedIn:    removedIn,
+		Replacement:  replacement,
+		check: func(pod *v1.Pod) string {
+			for _, volume := range pod.Spec.Volumes {
+				if uses(volume.VolumeSource) {
+					return "volume " + volume.Name
+				}
+			}
+			return ""
+		},
+	}
+}
+
+// podRules is the versioned rules table for pod specs. Releases refer to
+// upstream Kubernetes minors.
+var podRules = []Rule{
+	volumeRule("gitrepo-volume", "1.11", "", "an initContainer running git clone",
+		func(source v1.VolumeSource) bool { return source.GitRepo != nil }),
+	volumeRule("flocker-volume", "1.22", "1.25", "a CSI driver",
+		func(source v1.VolumeSource) bool { return source.Flocker != nil }),
+	volumeRule("scaleio-volume", "1.16", "1.22", "a CSI driver",
+		func(source v1.VolumeSource) bool { return source.ScaleIO != nil }),
+	volumeRule("glusterfs-volume", "1.25", "1.26", "a CSI driver or NFS",
+		func(source v1.VolumeSource) bool { return source.Glusterfs != nil }),
+	volumeRule("quobyte-volume", "1.22", "", "the Quobyte CSI driver",
+		func(source v1.VolumeSource) bool { return source.Quobyte != nil }),
+	volumeRule("storageos-volume", "1.22", "1.25", "the StorageOS CSI driver",
+		func(source v1.VolumeSource) bool { return source.StorageOS != nil }),
+	{
+		ID:           "deprecated-service-account-field",
+		DeprecatedIn: "1.1",
+		Replacement:  "spec.serviceAccountName",
+		check: func(pod *v1.Pod) string {
+			// The legacy alias is only a finding when it disagrees with
+			// the canonical field; the apiserver mirrors them otherwise.
+			if pod.Spec.DeprecatedServiceAccount != "" && pod.Spec.DeprecatedServiceAccount != pod.Spec.ServiceAccountName {
+				return "spec.serviceAccount"
+			}
+			return ""
+		},
+	},
+}
+
+// betaStorageClassAnnotation predates the storageClassName field.
+const betaStorageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+
+var betaStorageClassRule = Rule{
+	ID:           "beta-storage-class-annotation",
+	DeprecatedIn: "1.6",
+	Replacement:  "spec.storageClassName",
+}
+
+// ScanPods applies the pod rules to live pods or decoded manifests.
+func ScanPods(pods []*v1.Pod) []Finding {
+	var findings []Finding
+	for _, pod := range pods {
+		for _, rule := range podRules {
+			if detail := rule.check(pod); detail != "" {
+				findings = append(findings, Finding{
+					Rule:      rule,
+					Kind:      "Pod",
+					Namespace: pod.Namespace,
+					Name:      pod.Name,
+					Detail:    detail,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// ScanPVCs flags claims still using the beta storage-class annotation.
+func ScanPVCs(claims []*v1.PersistentVolumeClaim) []Finding {
+	var findings []Finding
+	for _, claim := range claims {
+		if _, ok := claim.Annotations[betaStorageClassAnnotation]; ok {
+			findings = append(findings, Finding{
+				Rule:      betaStorageClassRule,
+				Kind:      "PersistentVolumeClaim",
+				Namespace: claim.Namespace,
+				Name:      claim.Name,
+				Detail:    "annotation " + betaStorageClassAnnotation,
+			})
+		}
+	}
+	return findings
+}
+
+// ScanPVs flags persistent volumes backed by removed in-tree plugins.
+func ScanPVs(pvs []*v1.PersistentVolume) []Finding {
+	pvRules := []struct {
+		rule Rule
+		uses func(v1.PersistentVolumeSource) bool
+	}{
+		{
+			rule: Rule{ID: "flocker-volume", DeprecatedIn: "1.22", RemovedIn: "1.25", Replacement: "a CSI driver"},
+			uses: func(source v1.PersistentVolumeSource) bool { return source.Flocker != nil },
+		},
+		{
+			rule: Rule{ID: "scaleio-volume", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "a CSI driver"},
+			uses: func(source v1.PersistentVolumeSource) bool { return source.ScaleIO != nil },
+		},
+		{
+			rule: Rule{ID: "glusterfs-volume", DeprecatedIn: "1.25", RemovedIn: "1.26", Replacement: "a CSI driver or NFS"},
+			uses: func(source v1.PersistentVolumeSource) bool { return source.Glusterfs != nil },
+		},
+	}
+	var findings []Finding
+	for _, pv := range pvs {
+		for _, r := range pvRules {
+			if r.uses(pv.Spec.PersistentVolumeSource) {
+				findings = append(findings, Finding{
+					Rule:   r.rule,
+					Kind:   "PersistentVolume",
+					Name:   pv.Name,
+					Detail: "spec." + r.rule.ID,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// Report sorts findings for output: scheduled removals first, nearest
+// deadline first, then by object.
+func Report(findings []Finding) []Finding {
+	sorted := append([]Finding{}, findings...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if (a.Rule.RemovedIn != "") != (b.Rule.RemovedIn != "") {
+			return a.Rule.RemovedIn != ""
+		}
+		if a.Rule.RemovedIn != b.Rule.RemovedIn {
+			return a.Rule.RemovedIn < b.Rule.RemovedIn
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return sorted
+}
+
+// Guidance renders one finding's upgrade advice.
+func Guidance(finding Finding) string {
+	deadline := "removal unscheduled"
+	if finding.Rule.RemovedIn != "" {
+		deadline = "removed in " + finding.Rule.RemovedIn
+	}
+	return fmt.Sprintf("%s %s/%s uses %s (deprecated since %s, %s); migrate to %s",
+		finding.Kind, finding.Namespace, finding.Name, finding.Detail,
+		finding.Rule.DeprecatedIn, deadline, finding.Rule.Replacement)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprecations
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScanPods(t *testing.T) {
+	pods := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "legacy"},
+			Spec: v1.PodSpec{
+				Volumes: []v1.Volume{
+					{
+						Name:         "repo",
+						VolumeSource: v1.VolumeSource{GitRepo: &v1.GitRepoVolumeSource{Repository: "https://example.com/repo.git"}},
+					},
+					{
+						Name:         "gluster",
+						VolumeSource: v1.VolumeSource{Glusterfs: &v1.GlusterfsVolumeSource{EndpointsName: "ep", Path: "/vol"}},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "clean"},
+			Spec: v1.PodSpec{
+				Volumes: []v1.Volume{{
+					Name:         "data",
+					VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+				}},
+			},
+		},
+	}
+
+	findings := ScanPods(pods)
+	ids := map[string]string{}
+	for _, finding := range findings {
+		if finding.Name != "legacy" {
+			t.Errorf("clean pod flagged: %+v", finding)
+		}
+		ids[finding.Rule.ID] = finding.Detail
+	}
+	if ids["gitrepo-volume"] != "volume repo" {
+		t.Errorf("gitRepo not flagged: %v", ids)
+	}
+	if ids["glusterfs-volume"] != "volume gluster" {
+		t.Errorf("glusterfs not flagged: %v", ids)
+	}
+}
+
+func TestDeprecatedServiceAccountOnlyWhenDiverging(t *testing.T) {
+	mirrored := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mirrored"},
+		Spec:       v1.PodSpec{ServiceAccountName: "app", DeprecatedServiceAccount: "app"},
+	}
+	diverging := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "diverging"},
+		Spec:       v1.PodSpec{ServiceAccountName: "app", DeprecatedServiceAccount: "other"},
+	}
+	findings := ScanPods([]*v1.Pod{mirrored, diverging})
+	if len(findings) != 1 || findings[0].Name != "diverging" || findings[0].Rule.ID != "deprecated-service-account-field" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestScanPVCsBetaAnnotation(t *testing.T) {
+	claims := []*v1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "old",
+				Annotations: map[string]string{"volume.beta.kubernetes.io/storage-class": "fast"},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new"}},
+	}
+	findings := ScanPVCs(claims)
+	if len(findings) != 1 || findings[0].Name != "old" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestScanPVs(t *testing.T) {
+	pvs := []*v1.PersistentVolume{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-gluster"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				Glusterfs: &v1.GlusterfsPersistentVolumeSource{EndpointsName: "ep", Path: "/vol"},
+			},
+		},
+	}}
+	findings := ScanPVs(pvs)
+	if len(findings) != 1 || findings[0].Rule.ID != "glusterfs-volume" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestReportOrdersByDeadline(t *testing.T) {
+	pods := []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "legacy"},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{Name: "repo", VolumeSource: v1.VolumeSource{GitRepo: &v1.GitRepoVolumeSource{}}},
+				{Name: "gluster", VolumeSource: v1.VolumeSource{Glusterfs: &v1.GlusterfsVolumeSource{}}},
+			},
+		},
+	}}
+	sorted := Report(ScanPods(pods))
+	// glusterfs has a removal release; gitRepo does not, so it sorts last.
+	if sorted[0].Rule.ID != "glusterfs-volume" || sorted[len(sorted)-1].Rule.ID != "gitrepo-volume" {
+		t.Errorf("unexpected order: %+v", sorted)
+	}
+}
+
+func TestGuidance(t *testing.T) {
+	findings := ScanPods([]*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "legacy"},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{{Name: "gluster", VolumeSource: v1.VolumeSource{Glusterfs: &v1.GlusterfsVolumeSource{}}}},
+		},
+	}})
+	if len(findings) != 1 {
+		t.Fatalf("expected one finding, got %+v", findings)
+	}
+	text := Guidance(findings[0])
+	for _, want := range []string{"Pod default/legacy", "volume gluster", "deprecated since 1.25", "removed in 1.26", "CSI"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("guidance missing %q: %s", want, text)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dnscheck correlates the pieces cluster DNS is assembled from —
+// the kube-dns/CoreDNS Service, its EndpointSlices, the coredns ConfigMap
+// and pods' dnsPolicy settings — and reports incoherences between them.
+// Each piece looks healthy in isolation; the failures operators actually
+// hit (no ready backends behind the service VIP, DNSNone pods with no
+// nameservers to fall back to) only show up in the joins.
+package dnscheck
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+)
+
+// FindingType classifies one coherence problem.
+type FindingType string
+
+const (
+	// MissingDNSService means no DNS service was supplied at all.
+	MissingDNSService FindingType = "MissingDNSService"
+	// ServiceWithoutClusterIP means the DNS service has no VIP for pods
+	// to resolve against.
+	ServiceWithoutClusterIP FindingType = "ServiceWithoutClusterIP"
+	// NoReadyEndpoints means no EndpointSlice backing the service has a
+	// ready endpoint; lookups will black-hole at the VIP.
+	NoReadyEndpoints FindingType = "NoReadyEndpoints"
+	// MissingCorefile means the coredns ConfigMap lacks the Corefile key,
+	// so CoreDNS pods are running on defaults or failing to start.
+	MissingCorefile FindingType = "MissingCorefile"
+	// PodDNSNoneWithoutNameservers is a pod opting out of cluster DNS
+	// without supplying its own nameservers; it cannot resolve anything.
+	PodDNSNoneWithoutNameservers FindingType = "PodDNSNoneWithoutNameservers"
+)
+
+// Finding is one detected mismatch.
+type Finding struct {
+	Type FindingType
+	// Pod is the namespace/name of the affected pod, for pod findings.
+	Pod     string
+	Message string
+}
+
+// Inputs are the read results the checker correlates. Service and
+// ConfigMap may be nil when the reads returned not-found.
+type Inputs struct {
+	// Service is the kube-dns (or CoreDNS) Service.
+	Service *v1.Service
+	// EndpointSlices are the slices labeled for the DNS service.
+	EndpointSlices []*discoveryv1.EndpointSlice
+	// CoreDNSConfigMap is the coredns ConfigMap; nil for kube-dns
+	// clusters, which is not itself a finding.
+	CoreDNSConfigMap *v1.ConfigMap
+	// Pods are the pods to audit for dnsPolicy coherence.
+	Pods []*v1.Pod
+}
+
+// Check returns all detected mismatches, service-level findings first,
+// then pod findings sorted by pod.
+func Check(inputs Inputs) []Finding {
+	var findings []Finding
+
+	switch {
+	case inputs.Service == nil:
+		findings = append(findings, Finding{
+			Type:    MissingDNSService,
+			Message: "no DNS service found; pods with ClusterFirst policy cannot resolve",
+		})
+	case inputs.Service.Spec.ClusterIP == "" || inputs.Service.Spec.ClusterIP == v1.ClusterIPNone:
+		findings = append(findings, Finding{
+			Type:    ServiceWithoutClusterIP,
+			Message: fmt.Sprintf("DNS service %s/%s has no cluster IP", inputs.Service.Namespace, inputs.Service.Name),
+		})
+	default:
+		if !hasReadyEndpoint(inputs.EndpointSlices) {
+			findings = append(findings, Finding{
+				Type:    NoReadyEndpoints,
+				Message: fmt.Sprintf("DNS service %s/%s has no ready endpoints; lookups will time out", inputs.Service.Namespace, inputs.Service.Name),
+			})
+		}
+	}
+
+	if inputs.CoreDNSConfigMap != nil {
+		if _, present := inputs.CoreDNSConfigMap.Data["Corefile"]; !present {
+			findings = append(findings, Finding{
+				Type:    MissingCorefile,
+				Message: fmt.Sprintf("ConfigMap %s/%s has no Corefile key", inputs.CoreDNSConfigMap.Namespace, inputs.CoreDNSConfigMap.Name),
+			})
+		}
+	}
+
+	var podFindings []Finding
+	for _, pod := range inputs.Pods {
+		if pod.Spec.DNSPolicy != v1.DNSNone {
+			continue
+		}
+		if pod.Spec.DNSConfig == nil || len(pod.Spec.DNSConfig.Nameservers) == 0 {
+			podFindings = append(podFindings, Finding{
+				Type:    PodDNSNoneWithoutNameservers,
+				Pod:     pod.Namespace + "/" + pod.Name,
+				Message: "dnsPolicy None requires dnsConfig.nameservers; this pod cannot resolve names",
+			})
+		}
+	}
+	sort.Slice(podFindings, func(i, j int) bool { return podFindings[i].Pod < podFindings[j].Pod })
+	return append(findings, podFindings...)
+}
+
+// hasReadyEndpoint reports whether any slice carries an endpoint whose
+// Ready condition is true or unset (unset means ready per the API docs).
+func hasReadyEndpoint(slices []*discoveryv1.EndpointSlice) bool {
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnscheck
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func dnsService() *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "kube-dns"},
+		Spec:       v1.ServiceSpec{ClusterIP: "10.96.0.10"},
+	}
+}
+
+func slice(ready *bool) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "kube-dns-abc"},
+		Endpoints: []discoveryv1.Endpoint{{
+			Addresses:  []string{"10.244.0.5"},
+			Conditions: discoveryv1.EndpointConditions{Ready: ready},
+		}},
+	}
+}
+
+func findingTypes(findings []Finding) []FindingType {
+	types := make([]FindingType, 0, len(findings))
+	for _, finding := range findings {
+		types = append(types, finding.Type)
+	}
+	return types
+}
+
+func TestCheckHealthy(t *testing.T) {
+	ready := true
+	findings := Check(Inputs{
+		Service:        dnsService(),
+		EndpointSlices: []*discoveryv1.EndpointSlice{slice(&ready)},
+		CoreDNSConfigMap: &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "coredns"},
+			Data:       map[string]string{"Corefile": ".:53 {\n  forward . /etc/resolv.conf\n}\n"},
+		},
+		Pods: []*v1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec:       v1.PodSpec{DNSPolicy: v1.DNSClusterFirst},
+		}},
+	})
+	if len(findings) != 0 {
+		t.Errorf("expected no findings for a healthy cluster, got %+v", findings)
+	}
+}
+
+func TestCheckServiceProblems(t *testing.T) {
+	testCases := []struct {
+		name   string
+		inputs Inputs
+		want   FindingType
+	}{
+		{
+			name:   "missing service",
+			inputs: Inputs{},
+			want:   MissingDNSService,
+		},
+		{
+			name: "headless service",
+			inputs: Inputs{Service: &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "kube-dns"},
+				Spec:       v1.ServiceSpec{ClusterIP: v1.ClusterIPNone},
+			}},
+			want: ServiceWithoutClusterIP,
+		},
+		{
+			name:   "no slices at all",
+			inputs: Inputs{Service: dnsService()},
+			want:   NoReadyEndpoints,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := Check(tc.inputs)
+			if len(findings) != 1 || findings[0].Type != tc.want {
+				t.Errorf("expected single %s finding, got %+v", tc.want, findings)
+			}
+		})
+	}
+
+	notReady := false
+	findings := Check(Inputs{
+		Service:        dnsService(),
+		EndpointSlices: []*discoveryv1.EndpointSlice{slice(&notReady)},
+	})
+	if len(findings) != 1 || findings[0].Type != NoReadyEndpoints {
+		t.Errorf("expected NoReadyEndpoints for all-unready slices, got %+v", findings)
+	}
+
+	// An endpoint with a nil Ready condition counts as ready.
+	if findings := Check(Inputs{
+		Service:        dnsService(),
+		EndpointSlices: []*discoveryv1.EndpointSlice{slice(nil)},
+	}); len(findings) != 0 {
+		t.Errorf("nil Ready condition must count as ready, got %+v", findings)
+	}
+}
+
+func TestCheckCorefileAndPods(t *testing.T) {
+	ready := true
+	findings := Check(Inputs{
+		Service:        dnsService(),
+		EndpointSlices: []*discoveryv1.EndpointSlice{slice(&ready)},
+		CoreDNSConfigMap: &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "coredns"},
+			Data:       map[string]string{"unrelated": "x"},
+		},
+		Pods: []*v1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b-pod"},
+				Spec:       v1.PodSpec{DNSPolicy: v1.DNSNone},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a-pod"},
+				Spec:       v1.PodSpec{DNSPolicy: v1.DNSNone},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ok-pod"},
+				Spec: v1.PodSpec{
+					DNSPolicy: v1.DNSNone,
+					DNSConfig: &v1.PodDNSConfig{Nameservers: []string{"10.0.0.53"}},
+				},
+			},
+		},
+	})
+
+	types := findingTypes(findings)
+	if len(types) != 3 || types[0] != MissingCorefile {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	// Pod findings come after service-level ones, sorted by pod.
+	if findings[1].Pod != "default/a-pod" || findings[2].Pod != "default/b-pod" {
+		t.Errorf("expected sorted pod findings, got %+v", findings[1:])
+	}
+	if findings[1].Type != PodDNSNoneWithoutNameservers {
+		t.Errorf("unexpected pod finding type: %+v", findings[1])
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package downward evaluates Downward API selectors against in-memory
+// pods, mirroring what the kubelet would inject: metadata paths with
+// subscripts (metadata.labels['x']), the spec/status paths env vars may
+// use, and resource selectors with divisor rounding. Tools preview the
+// exact env value or volume file a pod would see, without scheduling
+// it. Path evaluation reuses pkg/fieldpath and the resource math in
+// pkg/api/v1/resource; this package adds the kubelet's dispatch rules.
+package downward
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+	"k8s.io/kubernetes/pkg/fieldpath"
+)
+
+// EvalFieldRef resolves an ObjectFieldSelector the way env var
+// injection does: metadata paths plus the runtime spec/status paths.
+func EvalFieldRef(pod *v1.Pod, selector *v1.ObjectFieldSelector) (string, error) {
+	if _, _, subscripted := fieldpath.SplitMaybeSubscriptedPath(selector.FieldPath); !subscripted {
+		switch selector.FieldPath {
+		case "spec.nodeName":
+			return pod.Spec.NodeName, nil
+		case "spec.serviceAccountName":
+			return pod.Spec.ServiceAccountName, nil
+		case "status.hostIP":
+			return pod.Status.HostIP, nil
+		case "status.podIP":
+			return pod.Status.PodIP, nil
+		case "status.podIPs":
+			ips := make([]string, len(pod.Status.PodIPs))
+			for i, ip := range pod.Status.PodIPs {
+				ips[i] = ip.IP
+			}
+			return strings.Join(ips, ","), nil
+		}
+	}
+	return fieldpath.ExtractFieldPathAsString(pod, selector.FieldPath)
+}
+
+// EvalVolumeFieldRef resolves an ObjectFieldSelector for a downwardAPI
+// volume file. Volumes only allow metadata paths; labels and
+// annotations render as the kubelet's multi-line key="value" format.
+func EvalVolumeFieldRef(pod *v1.Pod, selector *v1.ObjectFieldSelector) (string, error) {
+	return fieldpath.ExtractFieldPathAsString(pod, selector.FieldPath)
+}
+
+// EvalResourceFieldRef resolves a ResourceFieldSelector for the named
+// container, applying the divisor. An unset divisor divides by one, as
+// apiserver defaulting would. nodeAllocatable substitutes for missing
+// limits; nil reproduces a node whose allocatable is unknown.
+func EvalResourceFieldRef(pod *v1.Pod, containerName string, selector *v1.ResourceFieldSelector, nodeAllocatable v1.ResourceList) (string, error) {
+	normalized := *selector
+	if normalized.Divisor.IsZero() {
+		normalized.Divisor = resource.MustParse("1")
+	}
+	if normalized.ContainerName == "" {
+		normalized.ContainerName = containerName
+	}
+	if nodeAllocatable != nil {
+		return resourcehelper.ExtractResourceValueByContainerNameAndNodeAllocatable(&normalized, pod, normalized.ContainerName, nodeAllocatable)
+	}
+	return resourcehelper.ExtractResourceValueByContainerName(&normalized, pod, normalized.ContainerName)
+}
+
+// EnvValue previews one env var's Downward API value for the container.
+// Env vars sourced from ConfigMaps and Secrets are out of scope here;
+// only valueFrom.fieldRef and valueFrom.resourceFieldRef resolve.
+func EnvValue(pod *v1.Pod, containerName string, env v1.EnvVar) (string, error) {
+	if env.Value != "" || env.ValueFrom == nil {
+		return env.Value, nil
+	}
+	switch {
+	case env.ValueFrom.FieldRef != nil:
+		return EvalFieldRef(pod, env.ValueFrom.FieldRef)
+	case env.ValueFrom.ResourceFieldRef != nil:
+		return EvalResourceFieldRef(pod, containerName, env.ValueFrom.ResourceFieldRef, nil)
+	default:
+		return "", fmt.Errorf("env %s resolves from a ConfigMap or Secret, not the Downward API", env.Name)
+	}
+}
+
+// VolumeFiles previews the contents of a downwardAPI volume: file path
+// to file content, sorted iteration via the returned keys.
+func VolumeFiles(pod *v1.Pod, source *v1.DownwardAPIVolumeSource) (map[string]string, []string, error) {
+	files := map[string]string{}
+	for _, item := range source.Items {
+		var value string
+		var err error
+		switch {
+		case item.FieldRef != nil:
+			value, err = EvalVolumeFieldRef(pod, item.FieldRef)
+		case item.ResourceFieldRef != nil:
+			value, err = EvalResourceFieldRef(pod, item.ResourceFieldRef.ContainerName, item.ResourceFieldRef, nil)
+		default:
+			err = fmt.Errorf("item has neither fieldRef nor resourceFieldRef")
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving %s: %v", item.Path, err)
+		}
+		files[item.Path] = value
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return files, paths, nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downward
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "prod",
+			Name:      "web-0",
+			UID:       types.UID("abc-123"),
+			Labels: map[string]string{
+				"app":  "web",
+				"tier": "frontend",
+			},
+			Annotations: map[string]string{
+				"build/commit": "deadbeef",
+			},
+		},
+		Spec: v1.PodSpec{
+			NodeName:           "node-a",
+			ServiceAccountName: "deployer",
+			Containers: []v1.Container{{
+				Name: "app",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("250m"),
+						v1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+					Limits: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("1500m"),
+						v1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+				},
+			}},
+		},
+		Status: v1.PodStatus{
+			HostIP: "10.0.0.5",
+			PodIP:  "172.16.1.9",
+			PodIPs: []v1.PodIP{{IP: "172.16.1.9"}, {IP: "fd00::9"}},
+		},
+	}
+}
+
+func TestEvalFieldRef(t *testing.T) {
+	testCases := []struct {
+		fieldPath string
+		want      string
+		wantErr   bool
+	}{
+		{fieldPath: "metadata.name", want: "web-0"},
+		{fieldPath: "metadata.namespace", want: "prod"},
+		{fieldPath: "metadata.uid", want: "abc-123"},
+		{fieldPath: "metadata.labels['app']", want: "web"},
+		{fieldPath: "metadata.annotations['build/commit']", want: "deadbeef"},
+		{fieldPath: "metadata.labels", want: "app=\"web\"\ntier=\"frontend\""},
+		{fieldPath: "spec.nodeName", want: "node-a"},
+		{fieldPath: "spec.serviceAccountName", want: "deployer"},
+		{fieldPath: "status.hostIP", want: "10.0.0.5"},
+		{fieldPath: "status.podIP", want: "172.16.1.9"},
+		{fieldPath: "status.podIPs", want: "172.16.1.9,fd00::9"},
+		{fieldPath: "metadata.labels['missing']", want: ""},
+		{fieldPath: "spec.nodeName['x']", wantErr: true},
+		{fieldPath: "spec.hostNetwork", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.fieldPath, func(t *testing.T) {
+			got, err := EvalFieldRef(testPod(), &v1.ObjectFieldSelector{FieldPath: tc.fieldPath})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvalVolumeFieldRefRejectsRuntimePaths(t *testing.T) {
+	if _, err := EvalVolumeFieldRef(testPod(), &v1.ObjectFieldSelector{FieldPath: "status.podIP"}); err == nil {
+		t.Error("volume files must not resolve status paths")
+	}
+	got, err := EvalVolumeFieldRef(testPod(), &v1.ObjectFieldSelector{FieldPath: "metadata.labels['tier']"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "frontend" {
+		t.Errorf("got %q, want frontend", got)
+	}
+}
+
+func TestEvalResourceFieldRef(t *testing.T) {
+	testCases := []struct {
+		name      string
+		selector  v1.ResourceFieldSelector
+		container string
+		want      string
+	}{
+		{
+			name:     "cpu limit with millicore divisor",
+			selector: v1.ResourceFieldSelector{Resource: "limits.cpu", Divisor: resource.MustParse("1m")},
+			want:     "1500",
+		},
+		{
+			name:     "cpu limit rounds up with unit divisor",
+			selector: v1.ResourceFieldSelector{Resource: "limits.cpu"},
+			want:     "2",
+		},
+		{
+			name:     "memory request in Mi",
+			selector: v1.ResourceFieldSelector{Resource: "requests.memory", Divisor: resource.MustParse("1Mi")},
+			want:     "64",
+		},
+		{
+			name:      "container name from the selector wins",
+			selector:  v1.ResourceFieldSelector{ContainerName: "app", Resource: "requests.cpu", Divisor: resource.MustParse("1m")},
+			container: "other",
+			want:      "250",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			container := tc.container
+			if container == "" {
+				container = "app"
+			}
+			got, err := EvalResourceFieldRef(testPod(), container, &tc.selector, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvalResourceFieldRefMissingLimitUsesNodeAllocatable(t *testing.T) {
+	pod := testPod()
+	pod.Spec.Containers[0].Resources.Limits = nil
+	allocatable := v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}
+	got, err := EvalResourceFieldRef(pod, "app", &v1.ResourceFieldSelector{Resource: "limits.cpu", Divisor: resource.MustParse("1m")}, allocatable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "8000" {
+		t.Errorf("got %q, want 8000 from node allocatable", got)
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	pod := testPod()
+	literal, err := EnvValue(pod, "app", v1.EnvVar{Name: "MODE", Value: "serve"})
+	if err != nil || literal != "serve" {
+		t.Errorf("literal env: %q, %v", literal, err)
+	}
+	ip, err := EnvValue(pod, "app", v1.EnvVar{
+		Name:      "POD_IP",
+		ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "status.podIP"}},
+	})
+	if err != nil || ip != "172.16.1.9" {
+		t.Errorf("fieldRef env: %q, %v", ip, err)
+	}
+	if _, err := EnvValue(pod, "app", v1.EnvVar{
+		Name:      "FROM_CM",
+		ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{Key: "k"}},
+	}); err == nil {
+		t.Error("expected an error for a ConfigMap-sourced env var")
+	}
+}
+
+func TestVolumeFiles(t *testing.T) {
+	source := &v1.DownwardAPIVolumeSource{Items: []v1.DownwardAPIVolumeFile{
+		{Path: "labels", FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.labels"}},
+		{Path: "cpu_limit", ResourceFieldRef: &v1.ResourceFieldSelector{ContainerName: "app", Resource: "limits.cpu", Divisor: resource.MustParse("1m")}},
+	}}
+	files, paths, err := VolumeFiles(testPod(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != "cpu_limit" || paths[1] != "labels" {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+	if files["cpu_limit"] != "1500" {
+		t.Errorf("cpu_limit = %q", files["cpu_limit"])
+	}
+	if files["labels"] != "app=\"web\"\ntier=\"frontend\"" {
+		t.Errorf("labels = %q", files["labels"])
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package envresolve computes a container's effective environment the
+// way the kubelet assembles it: envFrom sources merged first, env
+// entries layered on top, $(VAR_NAME) references expanded with $$
+// escaping, and fieldRef/resourceFieldRef values resolved from the pod.
+// Each resolved key keeps its provenance, so "where does DB_HOST come
+// from" has an answer without reading four manifests.
+package envresolve
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/kubernetes/pkg/downward"
+	"k8s.io/kubernetes/third_party/forked/golang/expansion"
+)
+
+// Sources holds the referenced objects, keyed by name in the pod's
+// namespace.
+type Sources struct {
+	ConfigMaps map[string]*v1.ConfigMap
+	Secrets    map[string]*v1.Secret
+}
+
+// Value is one resolved variable.
+type Value struct {
+	// Value is the final string the process would see.
+	Value string
+	// Source says where it came from: "literal", "envFrom
+	// configmap/app-config", "secret/creds key password", "fieldRef
+	// status.podIP" or "resourceFieldRef limits.cpu".
+	Source string
+}
+
+// Resolve computes the effective environment of the named container.
+// Keys envFrom would reject as invalid C identifiers are skipped, as
+// the kubelet skips them; missing optional references are skipped;
+// missing required references are errors.
+func Resolve(pod *v1.Pod, containerName string, sources Sources) (map[string]Value, error) {
+	container, err := findContainer(pod, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]Value{}
+	for _, envFrom := range container.EnvFrom {
+		if err := mergeEnvFrom(envFrom, sources, env); err != nil {
+			return nil, err
+		}
+	}
+
+	values := func() map[string]string {
+		plain := make(map[string]string, len(env))
+		for key, value := range env {
+			plain[key] = value.Value
+		}
+		return plain
+	}
+	for _, envVar := range container.Env {
+		switch {
+		case envVar.ValueFrom == nil:
+			mapping := expansion.MappingFuncFor(values())
+			env[envVar.Name] = Value{Value: expansion.Expand(envVar.Value, mapping), Source: "literal"}
+		case envVar.ValueFrom.FieldRef != nil:
+			resolved, err := downward.EvalFieldRef(pod, envVar.ValueFrom.FieldRef)
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %v", envVar.Name, err)
+			}
+			env[envVar.Name] = Value{Value: resolved, Source: "fieldRef " + envVar.ValueFrom.FieldRef.FieldPath}
+		case envVar.ValueFrom.ResourceFieldRef != nil:
+			resolved, err := downward.EvalResourceFieldRef(pod, containerName, envVar.ValueFrom.ResourceFieldRef, nil)
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %v", envVar.Name, err)
+			}
+			env[envVar.Name] = Value{Value: resolved, Source: "resourceFieldRef " + envVar.ValueFrom.ResourceFieldRef.Resource}
+		case envVar.ValueFrom.ConfigMapKeyRef != nil:
+			ref := envVar.ValueFrom.ConfigMapKeyRef
+			configMap, ok := sources.ConfigMaps[ref.Name]
+			if !ok {
+				if optional(ref.Optional) {
+					continue
+				}
+				return nil, fmt.Errorf("env %s: configmap %q not found", envVar.Name, ref.Name)
+			}
+			value, ok := configMap.Data[ref.Key]
+			if !ok {
+				if optional(ref.Optional) {
+					continue
+				}
+				return nil, fmt.Errorf("env %s: key %q not found in configmap %q", envVar.Name, ref.Key, ref.Name)
+			}
+			env[envVar.Name] = Value{Value: value, Source: fmt.Sprintf("configmap/%s key %s", ref.Name, ref.Key)}
+		case envVar.ValueFrom.SecretKeyRef != nil:
+			ref := envVar.ValueFrom.SecretKeyRef
+			secret, ok := sources.Secrets[ref.Name]
+			if !ok {
+				if optional(ref.Optional) {
+					continue
+				}
+				return nil, fmt.Errorf("env %s: secret %q not found", envVar.Name, ref.Name)
+			}
+			value, ok := secret.Data[ref.Key]
+			if !ok {
+				if optional(ref.Optional) {
+					continue
+				}
+				return nil, fmt.Errorf("env %s: key %q not found in secret %q", envVar.Name, ref.Key, ref.Name)
+			}
+			env[envVar.Name] = Value{Value: string(value), Source: fmt.Sprintf("secret/%s key %s", ref.Name, ref.Key)}
+		}
+	}
+	return env, nil
+}
+
+func mergeEnvFrom(envFrom v1.EnvFromSource, sources Sources, env map[string]Value) error {
+	switch {
+	case envFrom.ConfigMapRef != nil:
+		configMap, ok := sources.ConfigMaps[envFrom.ConfigMapRef.Name]
+		if !ok {
+			if optional(envFrom.ConfigMapRef.Optional) {
+				return nil
+			}
+			return fmt.Errorf("envFrom: configmap %q not found", envFrom.ConfigMapRef.Name)
+		}
+		for key, value := range configMap.Data {
+			setEnvFromKey(env, envFrom.Prefix+key, value, "envFrom configmap/"+envFrom.ConfigMapRef.Name)
+		}
+	case envFrom.SecretRef != nil:
+		secret, ok := sources.Secrets[envFrom.SecretRef.Name]
+		if !ok {
+			if optional(envFrom.SecretRef.Optional) {
+				return nil
+			}
+			return fmt.Errorf("envFrom: secret %q not found", envFrom.SecretRef.Name)
+		}
+		for key, value := range secret.Data {
+			setEnvFromKey(env, envFrom.Prefix+key, string(value), "envFrom secret/"+envFrom.SecretRef.Name)
+		}
+	}
+	return nil
+}
+
+// setEnvFromKey applies the kubelet's rule that envFrom keys which are
+// not valid C identifiers are skipped rather than rejected.
+func setEnvFromKey(env map[string]Value, key, value, source string) {
+	if len(validation.IsCIdentifier(key)) > 0 {
+		return
+	}
+	env[key] = Value{Value: value, Source: source}
+}
+
+func findContainer(pod *v1.Pod, name string) (*v1.Container, error) {
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == name {
+			return &pod.Spec.InitContainers[i], nil
+		}
+	}
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i], nil
+		}
+	}
+	for i := range pod.Spec.EphemeralContainers {
+		if pod.Spec.EphemeralContainers[i].Name == name {
+			container := v1.Container(pod.Spec.EphemeralContainers[i].EphemeralContainerCommon)
+			return &container, nil
+		}
+	}
+	return nil, fmt.Errorf("container %q not found in pod %s/%s", name, pod.Namespace, pod.Name)
+}
+
+func optional(flag *bool) bool {
+	return flag != nil && *flag
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envresolve
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func optionalTrue() *bool { b := true; return &b }
+
+func basePod(env []v1.EnvVar, envFrom []v1.EnvFromSource) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.PodSpec{
+			NodeName: "node-1",
+			Containers: []v1.Container{{
+				Name:    "app",
+				Env:     env,
+				EnvFrom: envFrom,
+			}},
+		},
+		Status: v1.PodStatus{PodIP: "10.1.2.3"},
+	}
+}
+
+func testSources() Sources {
+	return Sources{
+		ConfigMaps: map[string]*v1.ConfigMap{
+			"app-config": {Data: map[string]string{
+				"DB_HOST":     "db.internal",
+				"DB_PORT":     "5432",
+				"invalid-key": "skipped",
+			}},
+		},
+		Secrets: map[string]*v1.Secret{
+			"creds": {Data: map[string][]byte{"PASSWORD": []byte("hunter2")}},
+		},
+	}
+}
+
+func TestResolveEnvFromAndOverride(t *testing.T) {
+	pod := basePod(
+		[]v1.EnvVar{{Name: "DB_PORT", Value: "6432"}},
+		[]v1.EnvFromSource{
+			{ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "app-config"}}},
+			{Prefix: "SECRET_", SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "creds"}}},
+		},
+	)
+	env, err := Resolve(pod, "app", testSources())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["DB_HOST"].Value != "db.internal" || env["DB_HOST"].Source != "envFrom configmap/app-config" {
+		t.Errorf("DB_HOST: %+v", env["DB_HOST"])
+	}
+	// env entries override envFrom.
+	if env["DB_PORT"].Value != "6432" || env["DB_PORT"].Source != "literal" {
+		t.Errorf("DB_PORT: %+v", env["DB_PORT"])
+	}
+	if env["SECRET_PASSWORD"].Value != "hunter2" {
+		t.Errorf("SECRET_PASSWORD: %+v", env["SECRET_PASSWORD"])
+	}
+	if _, ok := env["invalid-key"]; ok {
+		t.Error("invalid C identifier keys must be skipped")
+	}
+}
+
+func TestResolveExpansion(t *testing.T) {
+	pod := basePod([]v1.EnvVar{
+		{Name: "HOST", Value: "db.internal"},
+		{Name: "PORT", Value: "5432"},
+		{Name: "URL", Value: "postgres://$(HOST):$(PORT)/app"},
+		{Name: "LITERAL", Value: "$$(HOST) stays"},
+		{Name: "UNDEFINED", Value: "$(MISSING)"},
+	}, nil)
+	env, err := Resolve(pod, "app", Sources{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["URL"].Value != "postgres://db.internal:5432/app" {
+		t.Errorf("URL = %q", env["URL"].Value)
+	}
+	// $$ escapes to a literal $.
+	if env["LITERAL"].Value != "$(HOST) stays" {
+		t.Errorf("LITERAL = %q", env["LITERAL"].Value)
+	}
+	// Undefined references are left as-is.
+	if env["UNDEFINED"].Value != "$(MISSING)" {
+		t.Errorf("UNDEFINED = %q", env["UNDEFINED"].Value)
+	}
+}
+
+func TestResolveValueFrom(t *testing.T) {
+	pod := basePod([]v1.EnvVar{
+		{Name: "POD_IP", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "status.podIP"}}},
+		{Name: "PASSWORD", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+			LocalObjectReference: v1.LocalObjectReference{Name: "creds"}, Key: "PASSWORD",
+		}}},
+	}, nil)
+	env, err := Resolve(pod, "app", testSources())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["POD_IP"].Value != "10.1.2.3" || env["POD_IP"].Source != "fieldRef status.podIP" {
+		t.Errorf("POD_IP: %+v", env["POD_IP"])
+	}
+	if env["PASSWORD"].Value != "hunter2" || env["PASSWORD"].Source != "secret/creds key PASSWORD" {
+		t.Errorf("PASSWORD: %+v", env["PASSWORD"])
+	}
+}
+
+func TestResolveMissingReferences(t *testing.T) {
+	required := basePod([]v1.EnvVar{{Name: "X", ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: "nope"}, Key: "k",
+	}}}}, nil)
+	if _, err := Resolve(required, "app", Sources{}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("missing required configmap: %v", err)
+	}
+
+	optionalRef := basePod([]v1.EnvVar{{Name: "X", ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: "nope"}, Key: "k", Optional: optionalTrue(),
+	}}}}, nil)
+	env, err := Resolve(optionalRef, "app", Sources{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := env["X"]; ok {
+		t.Error("optional missing reference should be skipped")
+	}
+
+	if _, err := Resolve(required, "ghost", Sources{}); err == nil || !strings.Contains(err.Error(), "ghost") {
+		t.Errorf("unknown container: %v", err)
+	}
+}
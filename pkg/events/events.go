@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events correlates core/v1 Events into per-object timelines.
+// Raw event lists interleave every object in the namespace and repeat
+// the same (reason, message) pair under count or series accounting;
+// grouping by involvedObject and folding repeats into one entry with
+// first/last timestamps is what turns them into something readable.
+package events
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Entry is one deduplicated event in a timeline.
+type Entry struct {
+	// Type is v1.EventTypeNormal or v1.EventTypeWarning.
+	Type    string
+	Reason  string
+	Message string
+	// Source names the reporting component, e.g. "kubelet" or
+	// "default-scheduler".
+	Source string
+	// Count is the total number of occurrences, folding the event's
+	// own count and series into deduplicated repeats.
+	Count int32
+	// FirstSeen and LastSeen bound the occurrences.
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Timeline is the deduplicated event history of one object, ordered
+// by last occurrence.
+type Timeline struct {
+	// Object is the involved object, e.g. "Pod/default/web-0".
+	Object  v1.ObjectReference
+	Entries []Entry
+}
+
+// Warnings returns just the warning entries, in timeline order.
+func (t *Timeline) Warnings() []Entry {
+	var warnings []Entry
+	for _, entry := range t.Entries {
+		if entry.Type == v1.EventTypeWarning {
+			warnings = append(warnings, entry)
+		}
+	}
+	return warnings
+}
+
+// Correlate groups the events by involvedObject and deduplicates each
+// group. Timelines are sorted by object, their entries by LastSeen
+// then Reason.
+func Correlate(events []v1.Event) []Timeline {
+	groups := map[string][]v1.Event{}
+	refs := map[string]v1.ObjectReference{}
+	for _, event := range events {
+		key := objectKey(event.InvolvedObject)
+		groups[key] = append(groups[key], event)
+		refs[key] = event.InvolvedObject
+	}
+	timelines := make([]Timeline, 0, len(groups))
+	for key, group := range groups {
+		timelines = append(timelines, Timeline{Object: refs[key], Entries: dedup(group)})
+	}
+	sort.Slice(timelines, func(i, j int) bool {
+		return objectKey(timelines[i].Object) < objectKey(timelines[j].Object)
+	})
+	return timelines
+}
+
+// For returns the timeline of one object, identified the way the
+// involvedObject field records it.
+func For(events []v1.Event, kind, namespace, name string) Timeline {
+	ref := v1.ObjectReference{Kind: kind, Namespace: namespace, Name: name}
+	var matching []v1.Event
+	for _, event := range events {
+		if event.InvolvedObject.Kind == kind &&
+			event.InvolvedObject.Namespace == namespace &&
+			event.InvolvedObject.Name == name {
+			matching = append(matching, event)
+		}
+	}
+	return Timeline{Object: ref, Entries: dedup(matching)}
+}
+
+// dedup folds events sharing (type, reason, message, source) into one
+// entry, widening the first/last window and summing occurrences.
+func dedup(events []v1.Event) []Entry {
+	merged := map[string]*Entry{}
+	var order []string
+	for i := range events {
+		event := &events[i]
+		key := fmt.Sprintf("%s\x00%s\x00%s\x00%s", event.Type, event.Reason, event.Message, sourceOf(event))
+		first, last, count := occurrences(event)
+		entry, ok := merged[key]
+		if !ok {
+			merged[key] = &Entry{
+				Type:      event.Type,
+				Reason:    event.Reason,
+				Message:   event.Message,
+				Source:    sourceOf(event),
+				Count:     count,
+				FirstSeen: first,
+				LastSeen:  last,
+			}
+			order = append(order, key)
+			continue
+		}
+		entry.Count += count
+		if first.Before(entry.FirstSeen) {
+			entry.FirstSeen = first
+		}
+		if last.After(entry.LastSeen) {
+			entry.LastSeen = last
+		}
+	}
+	entries := make([]Entry, 0, len(order))
+	for _, key := range order {
+		entries = append(entries, *merged[key])
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastSeen.Equal(entries[j].LastSeen) {
+			return entries[i].LastSeen.Before(entries[j].LastSeen)
+		}
+		return entries[i].Reason < entries[j].Reason
+	})
+	return entries
+}
+
+// occurrences normalizes the two accounting schemes events use: the
+// legacy firstTimestamp/lastTimestamp/count fields and the newer
+// eventTime plus series {count, lastObservedTime}.
+func occurrences(event *v1.Event) (first, last time.Time, count int32) {
+	if event.Series != nil {
+		first = event.EventTime.Time
+		last = event.Series.LastObservedTime.Time
+		count = event.Series.Count
+		return first, last, count
+	}
+	first = event.FirstTimestamp.Time
+	last = event.LastTimestamp.Time
+	if first.IsZero() {
+		first = event.EventTime.Time
+	}
+	if last.IsZero() {
+		last = first
+	}
+	count = event.Count
+	if count == 0 {
+		count = 1
+	}
+	return first, last, count
+}
+
+func sourceOf(event *v1.Event) string {
+	if event.Source.Component != "" {
+		return event.Source.Component
+	}
+	return event.ReportingController
+}
+
+func objectKey(ref v1.ObjectReference) string {
+	return ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+}
+
+// Since filters events whose latest occurrence is at or after the
+// given time, preserving order.
+func Since(events []v1.Event, cutoff metav1.Time) []v1.Event {
+	var recent []v1.Event
+	for _, event := range events {
+		_, last, _ := occurrences(&event)
+		if !last.Before(cutoff.Time) {
+			recent = append(recent, event)
+		}
+	}
+	return recent
+}
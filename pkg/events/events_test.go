@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var base = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func podEvent(name, eventType, reason, message string, offset time.Duration, count int32) v1.Event {
+	at := metav1.NewTime(base.Add(offset))
+	return v1.Event{
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: name},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         v1.EventSource{Component: "kubelet"},
+		FirstTimestamp: at,
+		LastTimestamp:  at,
+		Count:          count,
+	}
+}
+
+func TestCorrelateGroupsByObject(t *testing.T) {
+	events := []v1.Event{
+		podEvent("web-0", v1.EventTypeNormal, "Pulled", "pulled image", 0, 1),
+		podEvent("web-1", v1.EventTypeNormal, "Pulled", "pulled image", time.Minute, 1),
+		{
+			InvolvedObject: v1.ObjectReference{Kind: "Node", Name: "node-1"},
+			Type:           v1.EventTypeWarning,
+			Reason:         "NodeNotReady",
+			LastTimestamp:  metav1.NewTime(base),
+		},
+	}
+	timelines := Correlate(events)
+	if len(timelines) != 3 {
+		t.Fatalf("timelines: %+v", timelines)
+	}
+	// Sorted by object key: Node//node-1 first.
+	if timelines[0].Object.Kind != "Node" || len(timelines[0].Entries) != 1 {
+		t.Errorf("first timeline: %+v", timelines[0])
+	}
+}
+
+func TestDedupMergesRepeats(t *testing.T) {
+	events := []v1.Event{
+		podEvent("web-0", v1.EventTypeWarning, "BackOff", "back-off restarting container", 0, 3),
+		podEvent("web-0", v1.EventTypeWarning, "BackOff", "back-off restarting container", 5*time.Minute, 2),
+		podEvent("web-0", v1.EventTypeNormal, "Pulled", "pulled image", time.Minute, 1),
+	}
+	timeline := For(events, "Pod", "default", "web-0")
+	if len(timeline.Entries) != 2 {
+		t.Fatalf("entries: %+v", timeline.Entries)
+	}
+	// Ordered by last occurrence: Pulled at +1m, then BackOff at +5m.
+	backoff := timeline.Entries[1]
+	if backoff.Reason != "BackOff" || backoff.Count != 5 {
+		t.Errorf("merged entry: %+v", backoff)
+	}
+	if !backoff.FirstSeen.Equal(base) || !backoff.LastSeen.Equal(base.Add(5*time.Minute)) {
+		t.Errorf("window: %v .. %v", backoff.FirstSeen, backoff.LastSeen)
+	}
+	warnings := timeline.Warnings()
+	if len(warnings) != 1 || warnings[0].Reason != "BackOff" {
+		t.Errorf("warnings: %+v", warnings)
+	}
+}
+
+func TestSeriesAccounting(t *testing.T) {
+	event := v1.Event{
+		InvolvedObject:      v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web-0"},
+		Type:                v1.EventTypeWarning,
+		Reason:              "FailedScheduling",
+		ReportingController: "default-scheduler",
+		EventTime:           metav1.NewMicroTime(base),
+		Series: &v1.EventSeries{
+			Count:            7,
+			LastObservedTime: metav1.NewMicroTime(base.Add(10 * time.Minute)),
+		},
+	}
+	timeline := For([]v1.Event{event}, "Pod", "default", "web-0")
+	if len(timeline.Entries) != 1 {
+		t.Fatalf("entries: %+v", timeline.Entries)
+	}
+	entry := timeline.Entries[0]
+	if entry.Count != 7 || entry.Source != "default-scheduler" {
+		t.Errorf("entry: %+v", entry)
+	}
+	if !entry.LastSeen.Equal(base.Add(10 * time.Minute)) {
+		t.Errorf("last seen: %v", entry.LastSeen)
+	}
+}
+
+func TestSince(t *testing.T) {
+	events := []v1.Event{
+		podEvent("web-0", v1.EventTypeNormal, "Pulled", "old", -time.Hour, 1),
+		podEvent("web-0", v1.EventTypeNormal, "Started", "new", time.Minute, 1),
+	}
+	recent := Since(events, metav1.NewTime(base))
+	if len(recent) != 1 || recent[0].Reason != "Started" {
+		t.Errorf("recent: %+v", recent)
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package exposure summarizes how workloads are reachable from outside
+// the pod network: hostNetwork pods, hostPort containers, and Services
+// of type NodePort or LoadBalancer, joined per namespace and attributed
+// to the owning workload. Security reviews ask for exactly this join;
+// computing it from cached pods and services beats re-deriving it by
+// hand each audit.
+package exposure
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Kind classifies one exposure finding.
+type Kind string
+
+const (
+	// HostNetwork marks a pod sharing the node's network namespace.
+	HostNetwork Kind = "HostNetwork"
+	// HostPort marks a container binding a port on the node.
+	HostPort Kind = "HostPort"
+	// NodePort marks a service reachable on every node's IP.
+	NodePort Kind = "NodePort"
+	// LoadBalancer marks a service with a cloud load balancer in front.
+	LoadBalancer Kind = "LoadBalancer"
+)
+
+// Finding is one way a workload is exposed.
+type Finding struct {
+	Kind Kind
+	// Workload is the controlling owner (kind/name) for pod findings,
+	// Service/<name> for service findings.
+	Workload string
+	// Detail names the specific hole: container and hostPort, service
+	// port and nodePort, or the pod for hostNetwork.
+	Detail string
+	// Port is the externally reachable port, 0 for hostNetwork.
+	Port int32
+}
+
+// NamespaceReport groups a namespace's findings.
+type NamespaceReport struct {
+	Namespace string
+	Findings  []Finding
+}
+
+// Report covers the whole cluster, namespaces sorted.
+type Report struct {
+	Namespaces []NamespaceReport
+	// Total counts findings across all namespaces, per kind.
+	Total map[Kind]int
+}
+
+func workloadOf(pod *v1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return ref.Kind + "/" + ref.Name
+		}
+	}
+	return "Pod/" + pod.Name
+}
+
+// podFindings flags hostNetwork and hostPort usage in one pod.
+func podFindings(pod *v1.Pod) []Finding {
+	workload := workloadOf(pod)
+	var findings []Finding
+	if pod.Spec.HostNetwork {
+		// The detail is workload-level, not per pod, so replicas of the
+		// same workload collapse into one finding.
+		findings = append(findings, Finding{
+			Kind:     HostNetwork,
+			Workload: workload,
+			Detail:   "pods share the node network namespace",
+		})
+	}
+	containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, port := range container.Ports {
+			if port.HostPort == 0 {
+				continue
+			}
+			// Under hostNetwork every containerPort is a host port
+			// already; reporting both would double-count.
+			if pod.Spec.HostNetwork {
+				continue
+			}
+			findings = append(findings, Finding{
+				Kind:     HostPort,
+				Workload: workload,
+				Detail:   "container " + container.Name,
+				Port:     port.HostPort,
+			})
+		}
+	}
+	return findings
+}
+
+// serviceFindings flags externally reachable service types.
+func serviceFindings(service *v1.Service) []Finding {
+	var kind Kind
+	switch service.Spec.Type {
+	case v1.ServiceTypeNodePort:
+		kind = NodePort
+	case v1.ServiceTypeLoadBalancer:
+		kind = LoadBalancer
+	default:
+		return nil
+	}
+	var findings []Finding
+	for _, port := range service.Spec.Ports {
+		findings = append(findings, Finding{
+			Kind:     kind,
+			Workload: "Service/" + service.Name,
+			Detail:   "port " + port.Name,
+			Port:     port.NodePort,
+		})
+	}
+	return findings
+}
+
+// Summarize joins pods and services into a per-namespace exposure
+// report. A pod owned by a workload appears once per distinct finding,
+// not once per replica: replicas of the same workload with the same hole
+// collapse.
+func Summarize(pods []*v1.Pod, services []*v1.Service) Report {
+	type namespaceKey struct {
+		namespace string
+		finding   Finding
+	}
+	seen := map[namespaceKey]bool{}
+	byNamespace := map[string][]Finding{}
+	add := func(namespace string, findings []Finding) {
+		for _, finding := range findings {
+			key := namespaceKey{namespace, finding}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			byNamespace[namespace] = append(byNamespace[namespace], finding)
+		}
+	}
+	for _, pod := range pods {
+		add(pod.Namespace, podFindings(pod))
+	}
+	for _, service := range services {
+		add(service.Namespace, serviceFindings(service))
+	}
+
+	report := Report{Total: map[Kind]int{}}
+	namespaces := make([]string, 0, len(byNamespace))
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		findings := byNamespace[namespace]
+		sort.Slice(findings, func(i, j int) bool {
+			a, b := findings[i], findings[j]
+			if a.Workload != b.Workload {
+				return a.Workload < b.Workload
+			}
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			return a.Port < b.Port
+		})
+		for _, finding := range findings {
+			report.Total[finding.Kind]++
+		}
+		report.Namespaces = append(report.Namespaces, NamespaceReport{
+			Namespace: namespace,
+			Findings:  findings,
+		})
+	}
+	return report
+}
+
+// SelectsPod reports whether a service's selector matches a pod's
+// labels, for correlating NodePort findings back to the workloads they
+// front.
+func SelectsPod(service *v1.Service, pod *v1.Pod) bool {
+	if service.Namespace != pod.Namespace || len(service.Spec.Selector) == 0 {
+		return false
+	}
+	return labelsMatch(service.Spec.Selector, pod.Labels)
+}
+
+func labelsMatch(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ExposedWorkloads returns, per exposed service, the workloads its
+// selector reaches — the "what is actually behind this NodePort" question
+// reviews always follow up with.
+func ExposedWorkloads(services []*v1.Service, pods []*v1.Pod) map[string][]string {
+	result := map[string][]string{}
+	for _, service := range services {
+		if service.Spec.Type != v1.ServiceTypeNodePort && service.Spec.Type != v1.ServiceTypeLoadBalancer {
+			continue
+		}
+		workloads := map[string]bool{}
+		for _, pod := range pods {
+			if SelectsPod(service, pod) {
+				workloads[workloadOf(pod)] = true
+			}
+		}
+		key := service.Namespace + "/" + service.Name
+		for workload := range workloads {
+			result[key] = append(result[key], workload)
+		}
+		sort.Strings(result[key])
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exposure
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func ownedPod(namespace, name, owner string, labels map[string]string, spec v1.PodSpec) *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind: "DaemonSet", Name: owner, Controller: &controller,
+			}},
+		},
+		Spec: spec,
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	pods := []*v1.Pod{
+		ownedPod("kube-system", "node-exporter-a", "node-exporter", nil, v1.PodSpec{HostNetwork: true}),
+		// Second replica of the same workload: same finding, counted once.
+		ownedPod("kube-system", "node-exporter-b", "node-exporter", nil, v1.PodSpec{HostNetwork: true}),
+		ownedPod("default", "ingress-x", "ingress", map[string]string{"app": "ingress"}, v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:  "proxy",
+				Ports: []v1.ContainerPort{{ContainerPort: 8080, HostPort: 80}},
+			}},
+		}),
+	}
+	services := []*v1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec: v1.ServiceSpec{
+				Type:     v1.ServiceTypeNodePort,
+				Selector: map[string]string{"app": "ingress"},
+				Ports:    []v1.ServicePort{{Name: "http", Port: 80, NodePort: 30080}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "internal"},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeClusterIP},
+		},
+	}
+
+	report := Summarize(pods, services)
+	if len(report.Namespaces) != 2 {
+		t.Fatalf("expected 2 namespaces, got %+v", report.Namespaces)
+	}
+	if report.Namespaces[0].Namespace != "default" || report.Namespaces[1].Namespace != "kube-system" {
+		t.Errorf("namespaces not sorted: %+v", report.Namespaces)
+	}
+
+	defaultFindings := report.Namespaces[0].Findings
+	if len(defaultFindings) != 2 {
+		t.Fatalf("expected hostPort and nodePort findings in default, got %+v", defaultFindings)
+	}
+	if defaultFindings[0].Kind != HostPort || defaultFindings[0].Port != 80 || defaultFindings[0].Workload != "DaemonSet/ingress" {
+		t.Errorf("unexpected hostPort finding: %+v", defaultFindings[0])
+	}
+	if defaultFindings[1].Kind != NodePort || defaultFindings[1].Port != 30080 || defaultFindings[1].Workload != "Service/web" {
+		t.Errorf("unexpected nodePort finding: %+v", defaultFindings[1])
+	}
+
+	systemFindings := report.Namespaces[1].Findings
+	if len(systemFindings) != 1 || systemFindings[0].Kind != HostNetwork {
+		t.Errorf("expected one deduplicated hostNetwork finding, got %+v", systemFindings)
+	}
+
+	want := map[Kind]int{HostNetwork: 1, HostPort: 1, NodePort: 1}
+	if !reflect.DeepEqual(report.Total, want) {
+		t.Errorf("totals = %v, want %v", report.Total, want)
+	}
+}
+
+func TestHostNetworkSuppressesHostPortDoubleCount(t *testing.T) {
+	pod := ownedPod("default", "a", "agent", nil, v1.PodSpec{
+		HostNetwork: true,
+		Containers: []v1.Container{{
+			Name:  "agent",
+			Ports: []v1.ContainerPort{{ContainerPort: 9100, HostPort: 9100}},
+		}},
+	})
+	report := Summarize([]*v1.Pod{pod}, nil)
+	if report.Total[HostPort] != 0 || report.Total[HostNetwork] != 1 {
+		t.Errorf("hostNetwork pod double-counted: %v", report.Total)
+	}
+}
+
+func TestExposedWorkloads(t *testing.T) {
+	pods := []*v1.Pod{
+		ownedPod("default", "ingress-x", "ingress", map[string]string{"app": "ingress"}, v1.PodSpec{}),
+		ownedPod("default", "other", "other", map[string]string{"app": "other"}, v1.PodSpec{}),
+		// Same labels, different namespace: not selected.
+		ownedPod("staging", "ingress-y", "ingress", map[string]string{"app": "ingress"}, v1.PodSpec{}),
+	}
+	services := []*v1.Service{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.ServiceSpec{
+			Type:     v1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{"app": "ingress"},
+			Ports:    []v1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}}
+
+	workloads := ExposedWorkloads(services, pods)
+	want := map[string][]string{"default/web": {"DaemonSet/ingress"}}
+	if !reflect.DeepEqual(workloads, want) {
+		t.Errorf("got %v, want %v", workloads, want)
+	}
+}
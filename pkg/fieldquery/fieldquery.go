@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fieldquery evaluates read-only CEL expressions against typed
+// API objects, e.g.
+//
+//	pod.spec.containers.exists(c, c.securityContext.privileged == true)
+//
+// Objects are converted to their unstructured (JSON-shaped) form, so
+// field names in expressions match the serialized API rather than Go
+// struct names. One compiled query can be evaluated against any number
+// of objects.
+package fieldquery
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Query is a compiled expression, evaluable against many objects.
+type Query struct {
+	variable string
+	program  cel.Program
+}
+
+// Compile parses and checks the expression. The variable names the
+// object in the expression, e.g. "pod" or "node"; its type is dynamic,
+// so checking catches syntax errors and misuse of literals but field
+// existence is resolved at evaluation time.
+func Compile(variable, expression string) (*Query, error) {
+	env, err := cel.NewEnv(cel.Variable(variable, cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compiling %q: %v", expression, issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{variable: variable, program: program}, nil
+}
+
+// Eval runs the query against the object and returns the result as a
+// native Go value: bool, int64, float64, string, []interface{} or
+// map[string]interface{}.
+func (q *Query) Eval(obj runtime.Object) (interface{}, error) {
+	unstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("converting %T: %v", obj, err)
+	}
+	value, _, err := q.program.Eval(map[string]interface{}{q.variable: unstructured})
+	if err != nil {
+		return nil, err
+	}
+	return native(value), nil
+}
+
+// native unwraps a CEL value to plain Go types; list and map values
+// otherwise surface their ref.Val elements.
+func native(value ref.Val) interface{} {
+	switch typed := value.(type) {
+	case traits.Lister:
+		var list []interface{}
+		for it := typed.Iterator(); it.HasNext() == types.True; {
+			list = append(list, native(it.Next()))
+		}
+		return list
+	case traits.Mapper:
+		result := map[string]interface{}{}
+		for it := typed.Iterator(); it.HasNext() == types.True; {
+			key := it.Next()
+			result[fmt.Sprintf("%v", key.Value())] = native(typed.Get(key))
+		}
+		return result
+	default:
+		return value.Value()
+	}
+}
+
+// EvalBool runs the query and requires a boolean result.
+func (q *Query) EvalBool(obj runtime.Object) (bool, error) {
+	value, err := q.Eval(obj)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %T, not bool", value)
+	}
+	return result, nil
+}
+
+// Filter returns the objects for which the query evaluates to true.
+func (q *Query) Filter(objects []runtime.Object) ([]runtime.Object, error) {
+	var matched []runtime.Object
+	for _, obj := range objects {
+		ok, err := q.EvalBool(obj)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, obj)
+		}
+	}
+	return matched, nil
+}
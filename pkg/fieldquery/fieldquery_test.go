@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldquery
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func queryPod(privileged bool) *v1.Pod {
+	var securityContext *v1.SecurityContext
+	if privileged {
+		securityContext = &v1.SecurityContext{Privileged: &privileged}
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-0"},
+		Spec: v1.PodSpec{Containers: []v1.Container{
+			{Name: "app", Image: "registry.example.com/app:v1"},
+			{Name: "sidecar", SecurityContext: securityContext},
+		}},
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	query, err := Compile("pod", `pod.spec.containers.exists(c, has(c.securityContext) && c.securityContext.privileged == true)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, privileged := range []bool{true, false} {
+		got, err := query.EvalBool(queryPod(privileged))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != privileged {
+			t.Errorf("privileged=%v: got %v", privileged, got)
+		}
+	}
+}
+
+func TestEvalTypedResults(t *testing.T) {
+	count, err := Compile("pod", `pod.spec.containers.size()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := count.Eval(queryPod(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != int64(2) {
+		t.Errorf("size() = %v (%T)", value, value)
+	}
+
+	names, err := Compile("pod", `pod.spec.containers.map(c, c.name)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err = names.Eval(queryPod(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := value.([]interface{})
+	if !ok || len(list) != 2 || list[0] != "app" {
+		t.Errorf("map() = %v (%T)", value, value)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	if _, err := Compile("pod", `pod.spec.containers.exists(`); err == nil {
+		t.Error("syntax error must fail at compile time")
+	}
+	// Missing fields surface at evaluation, since the type is dynamic.
+	query, err := Compile("pod", `pod.spec.nonexistent.field`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := query.Eval(queryPod(false)); err == nil {
+		t.Error("missing field must fail at evaluation")
+	}
+}
+
+func TestEvalBoolTypeMismatch(t *testing.T) {
+	query, err := Compile("pod", `pod.metadata.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := query.EvalBool(queryPod(false)); err == nil {
+		t.Error("non-boolean result must error")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	query, err := Compile("pod", `pod.metadata.name.startsWith("web")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := queryPod(false)
+	other.Name = "db-0"
+	matched, err := query.Filter([]runtime.Object{queryPod(false), other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matched) != 1 {
+		t.Errorf("matched: %v", matched)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fingerprint computes normalized fingerprints of PodSpecs and
+// clusters workloads that share the same spec shape. Instance-specific
+// fields (node assignment, hostname, env ordering) are normalized away so
+// that copy-pasted workloads hash identically across namespaces, and
+// near-identical workloads that differ only in resource settings or image
+// tags can be grouped to surface inconsistent configuration.
+package fingerprint
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	hashutil "k8s.io/kubernetes/pkg/util/hash"
+)
+
+// Fingerprint is a stable hash of a normalized PodSpec.
+type Fingerprint string
+
+// PodSpec returns the fingerprint of the spec with instance-specific fields
+// normalized away: node assignment, hostname, and environment variable
+// ordering do not affect the result, but images and resource settings do.
+func PodSpec(spec *v1.PodSpec) Fingerprint {
+	return hashSpec(normalize(spec, false))
+}
+
+// PodSpecShape returns the fingerprint of the spec's shape: in addition to
+// the PodSpec normalization it ignores resource requirements and image tags
+// or digests, so that near-identical copies of a workload hash identically.
+func PodSpecShape(spec *v1.PodSpec) Fingerprint {
+	return hashSpec(normalize(spec, true))
+}
+
+// Workload is a named PodSpec to be clustered.
+type Workload struct {
+	Namespace string
+	Name      string
+	Spec      *v1.PodSpec
+}
+
+// Cluster is a group of workloads sharing the same PodSpec shape.
+type Cluster struct {
+	// Shape is the shared shape fingerprint.
+	Shape Fingerprint
+	// Workloads lists the members sorted by namespace and name.
+	Workloads []Workload
+	// Consistent is true if all members also share the exact PodSpec
+	// fingerprint; false indicates copies that have drifted in resource
+	// settings or image tags.
+	Consistent bool
+}
+
+// ClusterWorkloads groups workloads by spec shape. Only clusters with more
+// than one member are returned, sorted by descending size so the heaviest
+// duplication appears first.
+func ClusterWorkloads(workloads []Workload) []Cluster {
+	byShape := map[Fingerprint][]Workload{}
+	exact := map[Fingerprint]map[Fingerprint]bool{}
+	for _, w := range workloads {
+		shape := PodSpecShape(w.Spec)
+		byShape[shape] = append(byShape[shape], w)
+		if exact[shape] == nil {
+			exact[shape] = map[Fingerprint]bool{}
+		}
+		exact[shape][PodSpec(w.Spec)] = true
+	}
+
+	var clusters []Cluster
+	for shape, members := range byShape {
+		if len(members) < 2 {
+			continue
+		}
+		sort.Slice(members, func(i, j int) bool {
+			if members[i].Namespace != members[j].Namespace {
+				return members[i].Namespace < members[j].Namespace
+			}
+			return members[i].Name < members[j].Name
+		})
+		clusters = append(clusters, Cluster{
+			Shape:      shape,
+			Workloads:  members,
+			Consistent: len(exact[shape]) == 1,
+		})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Workloads) != len(clusters[j].Workloads) {
+			return len(clusters[i].Workloads) > len(clusters[j].Workloads)
+		}
+		return clusters[i].Shape < clusters[j].Shape
+	})
+	return clusters
+}
+
+// normalize returns a deep copy of the spec with instance-specific fields
+// cleared. With shape set, fields that commonly drift between copies of the
+// same workload (resources, image tags) are cleared as well.
+func normalize(spec *v1.PodSpec, shape bool) *v1.PodSpec {
+	spec = spec.DeepCopy()
+	spec.NodeName = ""
+	spec.Hostname = ""
+	spec.Subdomain = ""
+	spec.DeprecatedServiceAccount = ""
+
+	sort.Slice(spec.Volumes, func(i, j int) bool { return spec.Volumes[i].Name < spec.Volumes[j].Name })
+	sort.Slice(spec.ImagePullSecrets, func(i, j int) bool { return spec.ImagePullSecrets[i].Name < spec.ImagePullSecrets[j].Name })
+
+	normalizeContainers := func(containers []v1.Container) {
+		for i := range containers {
+			c := &containers[i]
+			sort.Slice(c.Env, func(a, b int) bool { return c.Env[a].Name < c.Env[b].Name })
+			if shape {
+				c.Resources = v1.ResourceRequirements{}
+				c.Image = stripImageTag(c.Image)
+			}
+		}
+	}
+	normalizeContainers(spec.InitContainers)
+	normalizeContainers(spec.Containers)
+	for i := range spec.EphemeralContainers {
+		c := &spec.EphemeralContainers[i]
+		sort.Slice(c.Env, func(a, b int) bool { return c.Env[a].Name < c.Env[b].Name })
+		if shape {
+			c.Resources = v1.ResourceRequirements{}
+			c.Image = stripImageTag(c.Image)
+		}
+	}
+	if shape {
+		spec.Overhead = nil
+	}
+	return spec
+}
+
+// stripImageTag removes the tag or digest from an image reference, keeping
+// only the repository.
+func stripImageTag(image string) string {
+	if i := strings.IndexByte(image, '@'); i >= 0 {
+		image = image[:i]
+	}
+	slash := strings.LastIndexByte(image, '/')
+	if colon := strings.LastIndexByte(image, ':'); colon > slash {
+		image = image[:colon]
+	}
+	return image
+}
+
+func hashSpec(spec *v1.PodSpec) Fingerprint {
+	hasher := fnv.New64a()
+	hashutil.DeepHashObject(hasher, spec)
+	return Fingerprint(fmt.Sprintf("%016x", hasher.Sum64()))
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fingerprint
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func baseSpec() *v1.PodSpec {
+	return &v1.PodSpec{
+		Containers: []v1.Container{{
+			Name:  "app",
+			Image: "registry.example.com/app:v1.2.3",
+			Env: []v1.EnvVar{
+				{Name: "A", Value: "1"},
+				{Name: "B", Value: "2"},
+			},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("100m"),
+				},
+			},
+		}},
+	}
+}
+
+func TestPodSpecNormalization(t *testing.T) {
+	base := PodSpec(baseSpec())
+
+	testCases := []struct {
+		name   string
+		mutate func(*v1.PodSpec)
+		same   bool
+	}{
+		{
+			name:   "identical spec",
+			mutate: func(s *v1.PodSpec) {},
+			same:   true,
+		},
+		{
+			name: "env order ignored",
+			mutate: func(s *v1.PodSpec) {
+				env := s.Containers[0].Env
+				env[0], env[1] = env[1], env[0]
+			},
+			same: true,
+		},
+		{
+			name:   "node assignment ignored",
+			mutate: func(s *v1.PodSpec) { s.NodeName = "node-1" },
+			same:   true,
+		},
+		{
+			name:   "hostname ignored",
+			mutate: func(s *v1.PodSpec) { s.Hostname = "pod-abc12" },
+			same:   true,
+		},
+		{
+			name:   "image change detected",
+			mutate: func(s *v1.PodSpec) { s.Containers[0].Image = "registry.example.com/app:v2.0.0" },
+			same:   false,
+		},
+		{
+			name: "resource change detected",
+			mutate: func(s *v1.PodSpec) {
+				s.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("200m")
+			},
+			same: false,
+		},
+		{
+			name:   "env value change detected",
+			mutate: func(s *v1.PodSpec) { s.Containers[0].Env[0].Value = "changed" },
+			same:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := baseSpec()
+			tc.mutate(spec)
+			got := PodSpec(spec)
+			if (got == base) != tc.same {
+				t.Errorf("expected same=%v, base=%s got=%s", tc.same, base, got)
+			}
+		})
+	}
+}
+
+func TestPodSpecShape(t *testing.T) {
+	base := PodSpecShape(baseSpec())
+
+	// Tag and resource differences do not change the shape.
+	drifted := baseSpec()
+	drifted.Containers[0].Image = "registry.example.com/app:v9.9.9"
+	drifted.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("2")
+	if got := PodSpecShape(drifted); got != base {
+		t.Errorf("expected shape to ignore tag and resource drift, base=%s got=%s", base, got)
+	}
+
+	digest := baseSpec()
+	digest.Containers[0].Image = "registry.example.com/app@sha256:abcdef"
+	if got := PodSpecShape(digest); got != base {
+		t.Errorf("expected shape to ignore digest, base=%s got=%s", base, got)
+	}
+
+	// A different repository is a different shape.
+	other := baseSpec()
+	other.Containers[0].Image = "registry.example.com/other:v1.2.3"
+	if got := PodSpecShape(other); got == base {
+		t.Error("expected different repository to change the shape")
+	}
+}
+
+func TestClusterWorkloads(t *testing.T) {
+	exact := baseSpec()
+	drifted := baseSpec()
+	drifted.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("500m")
+	unrelated := baseSpec()
+	unrelated.Containers[0].Image = "registry.example.com/other:v1"
+
+	clusters := ClusterWorkloads([]Workload{
+		{Namespace: "team-b", Name: "app", Spec: drifted},
+		{Namespace: "team-a", Name: "app", Spec: baseSpec()},
+		{Namespace: "team-c", Name: "app", Spec: exact},
+		{Namespace: "team-d", Name: "other", Spec: unrelated},
+	})
+
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(clusters))
+	}
+	cluster := clusters[0]
+	if len(cluster.Workloads) != 3 {
+		t.Fatalf("expected 3 members, got %d", len(cluster.Workloads))
+	}
+	if cluster.Workloads[0].Namespace != "team-a" {
+		t.Errorf("expected members sorted by namespace, got %v", cluster.Workloads)
+	}
+	if cluster.Consistent {
+		t.Error("expected cluster with resource drift to be inconsistent")
+	}
+
+	// Without the drifted copy the cluster is consistent.
+	clusters = ClusterWorkloads([]Workload{
+		{Namespace: "team-a", Name: "app", Spec: baseSpec()},
+		{Namespace: "team-c", Name: "app", Spec: exact},
+	})
+	if len(clusters) != 1 || !clusters[0].Consistent {
+		t.Errorf("expected a single consistent cluster, got %+v", clusters)
+	}
+}
+
+func TestStripImageTag(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected string
+	}{
+		{"nginx", "nginx"},
+		{"nginx:1.21", "nginx"},
+		{"registry.example.com:5000/app", "registry.example.com:5000/app"},
+		{"registry.example.com:5000/app:v1", "registry.example.com:5000/app"},
+		{"app@sha256:deadbeef", "app"},
+	}
+	for _, tc := range testCases {
+		if got := stripImageTag(tc.image); got != tc.expected {
+			t.Errorf("stripImageTag(%q) = %q, expected %q", tc.image, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hubconvert
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apps "k8s.io/kubernetes/pkg/apis/apps"
+	core "k8s.io/kubernetes/pkg/apis/core"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func beta2Deployment() *appsv1beta2.Deployment {
+	return &appsv1beta2.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: appsv1beta2.DeploymentSpec{
+			Replicas: int32Ptr(3),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "app", Image: "nginx:1.25"}},
+				},
+			},
+		},
+	}
+}
+
+func TestToHub(t *testing.T) {
+	hub, err := ToHub(beta2Deployment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment, ok := hub.(*apps.Deployment)
+	if !ok {
+		t.Fatalf("expected internal Deployment, got %T", hub)
+	}
+	if deployment.Spec.Replicas != 3 || deployment.Spec.Template.Spec.Containers[0].Image != "nginx:1.25" {
+		t.Errorf("conversion lost data: %+v", deployment.Spec)
+	}
+}
+
+func TestRewriteAcrossVersions(t *testing.T) {
+	out, err := Rewrite(beta2Deployment(), appsv1.SchemeGroupVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment, ok := out.(*appsv1.Deployment)
+	if !ok {
+		t.Fatalf("expected apps/v1 Deployment, got %T", out)
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 3 {
+		t.Errorf("replicas lost in rewrite: %+v", deployment.Spec.Replicas)
+	}
+	if deployment.Spec.Selector.MatchLabels["app"] != "web" {
+		t.Errorf("selector lost in rewrite: %+v", deployment.Spec.Selector)
+	}
+}
+
+func TestNormalizeAllMixedVersions(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "nginx"}}},
+	}
+	hubs, err := NormalizeAll([]runtime.Object{beta2Deployment(), pod})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hubs[0].(*apps.Deployment); !ok {
+		t.Errorf("expected internal Deployment, got %T", hubs[0])
+	}
+	if _, ok := hubs[1].(*core.Pod); !ok {
+		t.Errorf("expected internal Pod, got %T", hubs[1])
+	}
+}
+
+func TestRecognizes(t *testing.T) {
+	if !Recognizes(appsv1beta2.SchemeGroupVersion.WithKind("Deployment")) {
+		t.Error("apps/v1beta2 Deployment should be recognized")
+	}
+	if Recognizes(schema.GroupVersionKind{Group: "nosuch.io", Version: "v1", Kind: "Widget"}) {
+		t.Error("unknown kind should not be recognized")
+	}
+}
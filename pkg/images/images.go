@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package images parses container image strings into their registry,
+// repository, tag and digest parts using the same distribution/
+// reference rules the kubelet applies, so "nginx" and
+// "docker.io/library/nginx:latest" compare equal. It also rewrites
+// references through a registry mirror map across a whole PodSpec,
+// which air-gapped clusters need for every workload they inspect.
+package images
+
+import (
+	"fmt"
+
+	dockerref "github.com/docker/distribution/reference"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Reference is a parsed, normalized image reference.
+type Reference struct {
+	// Registry is the host, with docker.io made explicit.
+	Registry string
+	// Repository is the path, with library/ made explicit for Docker
+	// Hub official images.
+	Repository string
+	// Tag defaults to latest when neither tag nor digest is present.
+	Tag string
+	// Digest is set for pinned references; a reference may carry both
+	// a tag and a digest.
+	Digest string
+}
+
+// Parse normalizes an image string. Implicit registry, library
+// namespace and :latest are filled in.
+func Parse(image string) (Reference, error) {
+	named, err := dockerref.ParseNormalizedNamed(image)
+	if err != nil {
+		return Reference{}, fmt.Errorf("parsing image reference %q: %v", image, err)
+	}
+	ref := Reference{
+		Registry:   dockerref.Domain(named),
+		Repository: dockerref.Path(named),
+	}
+	if tagged, ok := named.(dockerref.Tagged); ok {
+		ref.Tag = tagged.Tag()
+	}
+	if digested, ok := named.(dockerref.Digested); ok {
+		ref.Digest = digested.Digest().String()
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// String reassembles the canonical long form.
+func (r Reference) String() string {
+	out := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		out += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		out += "@" + r.Digest
+	}
+	return out
+}
+
+// Pinned reports whether the reference resolves to exactly one image
+// across time.
+func (r Reference) Pinned() bool {
+	return r.Digest != ""
+}
+
+// Rewrite maps the reference's registry through mirrors, returning the
+// reference unchanged when its registry has no mirror.
+func (r Reference) Rewrite(mirrors map[string]string) Reference {
+	if mirror, ok := mirrors[r.Registry]; ok {
+		r.Registry = mirror
+	}
+	return r
+}
+
+// Change records one image rewritten in a PodSpec.
+type Change struct {
+	// Container is the container's name.
+	Container string
+	// From and To are the full references before and after.
+	From string
+	To   string
+}
+
+// RewritePodSpec rewrites every container image in place — containers,
+// initContainers and ephemeralContainers — and reports what changed.
+// Images already pointing at a mirror come back normalized but
+// unlisted.
+func RewritePodSpec(spec *v1.PodSpec, mirrors map[string]string) ([]Change, error) {
+	var changes []Change
+	rewrite := func(name string, image *string) error {
+		ref, err := Parse(*image)
+		if err != nil {
+			return fmt.Errorf("container %s: %v", name, err)
+		}
+		rewritten := ref.Rewrite(mirrors)
+		if rewritten == ref {
+			return nil
+		}
+		changes = append(changes, Change{Container: name, From: ref.String(), To: rewritten.String()})
+		*image = rewritten.String()
+		return nil
+	}
+	for i := range spec.InitContainers {
+		if err := rewrite(spec.InitContainers[i].Name, &spec.InitContainers[i].Image); err != nil {
+			return nil, err
+		}
+	}
+	for i := range spec.Containers {
+		if err := rewrite(spec.Containers[i].Name, &spec.Containers[i].Image); err != nil {
+			return nil, err
+		}
+	}
+	for i := range spec.EphemeralContainers {
+		if err := rewrite(spec.EphemeralContainers[i].Name, &spec.EphemeralContainers[i].Image); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
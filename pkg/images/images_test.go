@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const digest = "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		image   string
+		want    Reference
+		wantErr bool
+	}{
+		{
+			image: "nginx",
+			want:  Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+		},
+		{
+			image: "nginx:1.25",
+			want:  Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25"},
+		},
+		{
+			image: "myco/tool",
+			want:  Reference{Registry: "docker.io", Repository: "myco/tool", Tag: "latest"},
+		},
+		{
+			image: "registry.k8s.io/pause:3.9",
+			want:  Reference{Registry: "registry.k8s.io", Repository: "pause", Tag: "3.9"},
+		},
+		{
+			image: "quay.io/org/app@" + digest,
+			want:  Reference{Registry: "quay.io", Repository: "org/app", Digest: digest},
+		},
+		{
+			image: "localhost:5000/app:dev",
+			want:  Reference{Registry: "localhost:5000", Repository: "app", Tag: "dev"},
+		},
+		{
+			image:   "UPPERCASE/bad",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.image, func(t *testing.T) {
+			got, err := Parse(tc.image)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tc.image, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	ref, err := Parse("nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.String() != "docker.io/library/nginx:latest" {
+		t.Errorf("String() = %q", ref.String())
+	}
+	again, err := Parse(ref.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != ref {
+		t.Errorf("round trip changed the reference: %+v vs %+v", ref, again)
+	}
+}
+
+func TestPinned(t *testing.T) {
+	pinned, err := Parse("quay.io/org/app@" + digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pinned.Pinned() {
+		t.Error("digest reference should be pinned")
+	}
+	floating, err := Parse("quay.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if floating.Pinned() {
+		t.Error("tag reference is not pinned")
+	}
+}
+
+func TestRewritePodSpec(t *testing.T) {
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init", Image: "busybox"}},
+		Containers: []v1.Container{
+			{Name: "app", Image: "registry.k8s.io/pause:3.9"},
+			{Name: "sidecar", Image: "mirror.example.com/library/envoy:v1.24"},
+		},
+		EphemeralContainers: []v1.EphemeralContainer{{
+			EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debug", Image: "nginx:1.25"},
+		}},
+	}
+	mirrors := map[string]string{
+		"docker.io":       "mirror.example.com",
+		"registry.k8s.io": "k8s-mirror.example.com",
+	}
+	changes, err := RewritePodSpec(spec, mirrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("changes: %+v", changes)
+	}
+	if spec.InitContainers[0].Image != "mirror.example.com/library/busybox:latest" {
+		t.Errorf("init image = %q", spec.InitContainers[0].Image)
+	}
+	if spec.Containers[0].Image != "k8s-mirror.example.com/pause:3.9" {
+		t.Errorf("app image = %q", spec.Containers[0].Image)
+	}
+	// Already mirrored: untouched and unlisted.
+	if spec.Containers[1].Image != "mirror.example.com/library/envoy:v1.24" {
+		t.Errorf("sidecar image = %q", spec.Containers[1].Image)
+	}
+	if spec.EphemeralContainers[0].Image != "mirror.example.com/library/nginx:1.25" {
+		t.Errorf("debug image = %q", spec.EphemeralContainers[0].Image)
+	}
+	if changes[0].Container != "init" || changes[0].From != "docker.io/library/busybox:latest" {
+		t.Errorf("first change: %+v", changes[0])
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package informercache maintains shared watch-backed caches so repeated
+// reads are served locally instead of hammering the API server. Any
+// resource the clientset scheme knows gets a lister on demand; label and
+// field selectors applied at construction narrow every cache the
+// instance builds, which keeps memory proportional to the slice of the
+// cluster actually being read.
+package informercache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Options narrow the caches built by one instance.
+type Options struct {
+	// Namespace restricts all caches to one namespace; empty means
+	// cluster-wide.
+	Namespace string
+	// LabelSelector and FieldSelector are applied to every list and
+	// watch the caches issue.
+	LabelSelector string
+	FieldSelector string
+	// Resync is the periodic full-resync interval; 0 disables resync,
+	// which suits read-only consumers with no reconcile loop to nudge.
+	Resync time.Duration
+}
+
+// Cache builds and owns shared informers. Listers for the same resource
+// share one watch; the first request for a resource pays the initial
+// list, later ones reuse the synced cache.
+type Cache struct {
+	factory informers.SharedInformerFactory
+	stopCh  chan struct{}
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+// New returns a cache over the client. Callers must Stop it when done
+// to release the watches.
+func New(client kubernetes.Interface, opts Options) *Cache {
+	factoryOpts := []informers.SharedInformerOption{}
+	if opts.Namespace != "" {
+		factoryOpts = append(factoryOpts, informers.WithNamespace(opts.Namespace))
+	}
+	if opts.LabelSelector != "" || opts.FieldSelector != "" {
+		factoryOpts = append(factoryOpts, informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+			listOptions.LabelSelector = opts.LabelSelector
+			listOptions.FieldSelector = opts.FieldSelector
+		}))
+	}
+	return &Cache{
+		factory: informers.NewSharedInformerFactoryWithOptions(client, opts.Resync, factoryOpts...),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// GenericLister returns a synced lister for the resource. It blocks
+// until the cache has completed its initial list or the context expires.
+func (c *Cache) GenericLister(ctx context.Context, gvr schema.GroupVersionResource) (cache.GenericLister, error) {
+	informer, err := c.factory.ForResource(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("no informer for %s: %v", gvr.String(), err)
+	}
+	if err := c.sync(ctx, informer.Informer()); err != nil {
+		return nil, fmt.Errorf("syncing %s: %v", gvr.String(), err)
+	}
+	return informer.Lister(), nil
+}
+
+// Pods returns a synced typed pod lister, the most common read in this
+// repo's tooling.
+func (c *Cache) Pods(ctx context.Context) (listerscorev1.PodLister, error) {
+	informer := c.factory.Core().V1().Pods()
+	if err := c.sync(ctx, informer.Informer()); err != nil {
+		return nil, fmt.Errorf("syncing pods: %v", err)
+	}
+	return informer.Lister(), nil
+}
+
+// Factory exposes the underlying factory for typed informers beyond the
+// shortcuts above; caches it creates share this instance's lifecycle
+// and selectors.
+func (c *Cache) Factory() informers.SharedInformerFactory {
+	return c.factory
+}
+
+// sync starts any unstarted informers and waits for this one.
+func (c *Cache) sync(ctx context.Context, informer cache.SharedIndexInformer) error {
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		return fmt.Errorf("cache is stopped")
+	}
+	// Start is idempotent; informers added since the last call are
+	// started now.
+	c.factory.Start(c.stopCh)
+	c.mu.Unlock()
+
+	syncCh := make(chan struct{})
+	go func() {
+		defer close(syncCh)
+		cache.WaitForCacheSync(c.stopCh, informer.HasSynced)
+	}()
+	select {
+	case <-syncCh:
+		if !informer.HasSynced() {
+			return fmt.Errorf("cache was stopped before syncing")
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop shuts down every informer the cache started. Listers obtained
+// earlier keep returning the last-synced state.
+func (c *Cache) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stopped {
+		c.stopped = true
+		close(c.stopCh)
+	}
+}
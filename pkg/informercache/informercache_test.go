@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informercache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testPod(namespace, name string, podLabels map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: podLabels}}
+}
+
+func TestGenericListerServesExistingObjects(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset(
+		testPod("default", "web", nil),
+		testPod("kube-system", "dns", nil),
+	)
+	c := New(client, Options{})
+	defer c.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lister, err := c.GenericLister(ctx, schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := lister.List(labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 {
+		t.Errorf("expected 2 pods, got %d", len(objs))
+	}
+	if _, err := lister.ByNamespace("default").Get("web"); err != nil {
+		t.Errorf("namespaced get failed: %v", err)
+	}
+}
+
+func TestGenericListerUnknownResource(t *testing.T) {
+	c := New(clientsetfake.NewSimpleClientset(), Options{})
+	defer c.Stop()
+
+	_, err := c.GenericLister(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "nosuchthings"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown resource")
+	}
+}
+
+func TestPodsObservesUpdates(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset(testPod("default", "web", nil))
+	c := New(client, Options{})
+	defer c.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lister, err := c.Pods(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Pods("default").Create(ctx, testPod("default", "api", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := lister.Pods("default").Get("api"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("created pod never reached the cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLabelSelectorNarrowsCache(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset(
+		testPod("default", "web", map[string]string{"app": "web"}),
+		testPod("default", "api", map[string]string{"app": "api"}),
+	)
+	c := New(client, Options{LabelSelector: "app=web"})
+	defer c.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lister, err := c.Pods(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pods, err := lister.List(labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].Name != "web" {
+		t.Errorf("selector not applied at construction: %+v", pods)
+	}
+}
+
+func TestStopIsIdempotentAndBlocksNewSyncs(t *testing.T) {
+	c := New(clientsetfake.NewSimpleClientset(), Options{})
+	c.Stop()
+	c.Stop()
+
+	_, err := c.Pods(context.Background())
+	if err == nil {
+		t.Fatal("expected an error from a stopped cache")
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package integrity validates that cross-object references in a snapshot
+// actually resolve: PVC→PV bindings (in both directions, with UID
+// agreement), pod→node, pod→serviceaccount, and pod→secret/configmap
+// volume and env references. References the API marks Optional are
+// honored and not reported. Each broken edge yields one structured
+// finding naming both ends.
+package integrity
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// EdgeType names the kind of reference that failed to resolve.
+type EdgeType string
+
+const (
+	EdgePVCToPV         EdgeType = "PVCToPV"
+	EdgePVToPVC         EdgeType = "PVToPVC"
+	EdgePVToPVCUID      EdgeType = "PVToPVCUIDMismatch"
+	EdgePodToNode       EdgeType = "PodToNode"
+	EdgePodToSA         EdgeType = "PodToServiceAccount"
+	EdgePodToSecret     EdgeType = "PodToSecret"
+	EdgePodToConfigMap  EdgeType = "PodToConfigMap"
+	EdgePodToPVC        EdgeType = "PodToPVC"
+	EdgeSAToSecretToken EdgeType = "ServiceAccountToSecret"
+)
+
+// Finding is one broken reference edge.
+type Finding struct {
+	Type EdgeType
+	// From is the object holding the reference, as kind namespace/name.
+	From string
+	// To is the referenced identity that did not resolve.
+	To      string
+	Message string
+}
+
+// Snapshot is the set of objects to cross-check. Missing slices simply
+// disable the checks that need them (a nil Nodes slice with scheduled
+// pods would report every pod, so callers should pass what they read).
+type Snapshot struct {
+	Pods            []*v1.Pod
+	Nodes           []*v1.Node
+	PVCs            []*v1.PersistentVolumeClaim
+	PVs             []*v1.PersistentVolume
+	Secrets         []*v1.Secret
+	ConfigMaps      []*v1.ConfigMap
+	ServiceAccounts []*v1.ServiceAccount
+}
+
+// Check returns all broken edges, sorted by source object then edge type.
+func Check(snapshot Snapshot) []Finding {
+	nodes := map[string]bool{}
+	for _, node := range snapshot.Nodes {
+		nodes[node.Name] = true
+	}
+	pvs := map[string]*v1.PersistentVolume{}
+	for _, pv := range snapshot.PVs {
+		pvs[pv.Name] = pv
+	}
+	pvcs := map[string]*v1.PersistentVolumeClaim{}
+	for _, pvc := range snapshot.PVCs {
+		pvcs[pvc.Namespace+"/"+pvc.Name] = pvc
+	}
+	secrets := map[string]bool{}
+	for _, secret := range snapshot.Secrets {
+		secrets[secret.Namespace+"/"+secret.Name] = true
+	}
+	configMaps := map[string]bool{}
+	for _, configMap := range snapshot.ConfigMaps {
+		configMaps[configMap.Namespace+"/"+configMap.Name] = true
+	}
+	serviceAccounts := map[string]bool{}
+	for _, sa := range snapshot.ServiceAccounts {
+		serviceAccounts[sa.Namespace+"/"+sa.Name] = true
+	}
+
+	var findings []Finding
+
+	for _, pvc := range snapshot.PVCs {
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		if _, bound := pvs[pvc.Spec.VolumeName]; !bound {
+			findings = append(findings, Finding{
+				Type:    EdgePVCToPV,
+				From:    "PersistentVolumeClaim " + pvc.Namespace + "/" + pvc.Name,
+				To:      "PersistentVolume " + pvc.Spec.VolumeName,
+				Message: "claim is bound to a volume that does not exist",
+			})
+		}
+	}
+
+	for _, pv := range snapshot.PVs {
+		ref := pv.Spec.ClaimRef
+		if ref == nil {
+			continue
+		}
+		pvc, present := pvcs[ref.Namespace+"/"+ref.Name]
+		if !present {
+			findings = append(findings, Finding{
+				Type:    EdgePVToPVC,
+				From:    "PersistentVolume " + pv.Name,
+				To:      "PersistentVolumeClaim " + ref.Namespace + "/" + ref.Name,
+				Message: "volume claimRef points at a claim that does not exist",
+			})
+			continue
+		}
+		if ref.UID != "" && ref.UID != pvc.UID {
+			findings = append(findings, Finding{
+				Type:    EdgePVToPVCUID,
+				From:    "PersistentVolume " + pv.Name,
+				To:      "PersistentVolumeClaim " + ref.Namespace + "/" + ref.Name,
+				Message: fmt.Sprintf("claimRef UID %s does not match claim UID %s; the claim was recreated", ref.UID, pvc.UID),
+			})
+		}
+	}
+
+	for _, pod := range snapshot.Pods {
+		from := "Pod " + pod.Namespace + "/" + pod.Name
+
+		if snapshot.Nodes != nil && pod.Spec.NodeName != "" && !nodes[pod.Spec.NodeName] {
+			findings = append(findings, Finding{
+				Type:    EdgePodToNode,
+				From:    from,
+				To:      "Node " + pod.Spec.NodeName,
+				Message: "pod is scheduled to a node that does not exist",
+			})
+		}
+		if snapshot.ServiceAccounts != nil && pod.Spec.ServiceAccountName != "" && pod.Spec.ServiceAccountName != "default" {
+			if !serviceAccounts[pod.Namespace+"/"+pod.Spec.ServiceAccountName] {
+				findings = append(findings, Finding{
+					Type:    EdgePodToSA,
+					From:    from,
+					To:      "ServiceAccount " + pod.Namespace + "/" + pod.Spec.ServiceAccountName,
+					Message: "pod references a service account that does not exist",
+				})
+			}
+		}
+
+		for i := range pod.Spec.Volumes {
+			volume := &pod.Spec.Volumes[i]
+			switch {
+			case volume.Secret != nil:
+				if snapshot.Secrets == nil || boolValue(volume.Secret.Optional) {
+					continue
+				}
+				if !secrets[pod.Namespace+"/"+volume.Secret.SecretName] {
+					findings = append(findings, Finding{
+						Type:    EdgePodToSecret,
+						From:    from,
+						To:      "Secret " + pod.Namespace + "/" + volume.Secret.SecretName,
+						Message: fmt.Sprintf("volume %q references a missing secret", volume.Name),
+					})
+				}
+			case volume.ConfigMap != nil:
+				if snapshot.ConfigMaps == nil || boolValue(volume.ConfigMap.Optional) {
+					continue
+				}
+				if !configMaps[pod.Namespace+"/"+volume.ConfigMap.Name] {
+					findings = append(findings, Finding{
+						Type:    EdgePodToConfigMap,
+						From:    from,
+						To:      "ConfigMap " + pod.Namespace + "/" + volume.ConfigMap.Name,
+						Message: fmt.Sprintf("volume %q references a missing configmap", volume.Name),
+					})
+				}
+			case volume.PersistentVolumeClaim != nil:
+				if snapshot.PVCs == nil {
+					continue
+				}
+				if _, present := pvcs[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName]; !present {
+					findings = append(findings, Finding{
+						Type:    EdgePodToPVC,
+						From:    from,
+						To:      "PersistentVolumeClaim " + pod.Namespace + "/" + volume.PersistentVolumeClaim.ClaimName,
+						Message: fmt.Sprintf("volume %q references a missing claim", volume.Name),
+					})
+				}
+			}
+		}
+
+		findings = append(findings, checkEnvRefs(pod, from, secrets, configMaps, snapshot)...)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].From != findings[j].From {
+			return findings[i].From < findings[j].From
+		}
+		if findings[i].Type != findings[j].Type {
+			return findings[i].Type < findings[j].Type
+		}
+		return findings[i].To < findings[j].To
+	})
+	return findings
+}
+
+// checkEnvRefs covers env and envFrom secret/configmap references across
+// all container types.
+func checkEnvRefs(pod *v1.Pod, from string, secrets, configMaps map[string]bool, snapshot Snapshot) []Finding {
+	var findings []Finding
+	containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+
+	for i := range containers {
+		container := &containers[i]
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil && snapshot.Secrets != nil && !boolValue(ref.Optional) {
+				if !secrets[pod.Namespace+"/"+ref.Name] {
+					findings = append(findings, Finding{
+						Type:    EdgePodToSecret,
+						From:    from,
+						To:      "Secret " + pod.Namespace + "/" + ref.Name,
+						Message: fmt.Sprintf("container %q env %q references a missing secret", container.Name, env.Name),
+					})
+				}
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil && snapshot.ConfigMaps != nil && !boolValue(ref.Optional) {
+				if !configMaps[pod.Namespace+"/"+ref.Name] {
+					findings = append(findings, Finding{
+						Type:    EdgePodToConfigMap,
+						From:    from,
+						To:      "ConfigMap " + pod.Namespace + "/" + ref.Name,
+						Message: fmt.Sprintf("container %q env %q references a missing configmap", container.Name, env.Name),
+					})
+				}
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if ref := envFrom.SecretRef; ref != nil && snapshot.Secrets != nil && !boolValue(ref.Optional) {
+				if !secrets[pod.Namespace+"/"+ref.Name] {
+					findings = append(findings, Finding{
+						Type:    EdgePodToSecret,
+						From:    from,
+						To:      "Secret " + pod.Namespace + "/" + ref.Name,
+						Message: fmt.Sprintf("container %q envFrom references a missing secret", container.Name),
+					})
+				}
+			}
+			if ref := envFrom.ConfigMapRef; ref != nil && snapshot.ConfigMaps != nil && !boolValue(ref.Optional) {
+				if !configMaps[pod.Namespace+"/"+ref.Name] {
+					findings = append(findings, Finding{
+						Type:    EdgePodToConfigMap,
+						From:    from,
+						To:      "ConfigMap " + pod.Namespace + "/" + ref.Name,
+						Message: fmt.Sprintf("container %q envFrom references a missing configmap", container.Name),
+					})
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func boolValue(b *bool) bool {
+	return b != nil && *b
+}
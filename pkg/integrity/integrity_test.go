@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integrity
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestCheckVolumeBindings(t *testing.T) {
+	snapshot := Snapshot{
+		PVCs: []*v1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bound", UID: types.UID("uid-pvc")},
+				Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dangling"},
+				Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-gone"},
+			},
+		},
+		PVs: []*v1.PersistentVolume{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+				Spec: v1.PersistentVolumeSpec{ClaimRef: &v1.ObjectReference{
+					Namespace: "default", Name: "bound", UID: types.UID("uid-stale"),
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-2"},
+				Spec: v1.PersistentVolumeSpec{ClaimRef: &v1.ObjectReference{
+					Namespace: "default", Name: "missing",
+				}},
+			},
+		},
+	}
+
+	findings := Check(snapshot)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+	byType := map[EdgeType]Finding{}
+	for _, finding := range findings {
+		byType[finding.Type] = finding
+	}
+	if _, present := byType[EdgePVCToPV]; !present {
+		t.Errorf("expected a PVCToPV finding: %+v", findings)
+	}
+	if _, present := byType[EdgePVToPVC]; !present {
+		t.Errorf("expected a PVToPVC finding: %+v", findings)
+	}
+	if finding, present := byType[EdgePVToPVCUID]; !present || finding.From != "PersistentVolume pv-1" {
+		t.Errorf("expected a UID mismatch on pv-1: %+v", findings)
+	}
+}
+
+func TestCheckPodReferences(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.PodSpec{
+			NodeName:           "node-gone",
+			ServiceAccountName: "runner",
+			Volumes: []v1.Volume{
+				{
+					Name:         "secret",
+					VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "creds"}},
+				},
+				{
+					Name: "optional-config",
+					VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+						LocalObjectReference: v1.LocalObjectReference{Name: "missing-but-optional"},
+						Optional:             boolPtr(true),
+					}},
+				},
+				{
+					Name:         "data",
+					VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "gone"}},
+				},
+			},
+			Containers: []v1.Container{{
+				Name: "main",
+				Env: []v1.EnvVar{{
+					Name: "TOKEN",
+					ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: "env-secret"},
+						Key:                  "token",
+					}},
+				}},
+				EnvFrom: []v1.EnvFromSource{{
+					ConfigMapRef: &v1.ConfigMapEnvSource{
+						LocalObjectReference: v1.LocalObjectReference{Name: "env-config"},
+					},
+				}},
+			}},
+		},
+	}
+
+	snapshot := Snapshot{
+		Pods:            []*v1.Pod{pod},
+		Nodes:           []*v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}},
+		PVCs:            []*v1.PersistentVolumeClaim{},
+		Secrets:         []*v1.Secret{},
+		ConfigMaps:      []*v1.ConfigMap{},
+		ServiceAccounts: []*v1.ServiceAccount{},
+	}
+
+	findings := Check(snapshot)
+	counts := map[EdgeType]int{}
+	for _, finding := range findings {
+		counts[finding.Type]++
+		if finding.From != "Pod default/web" {
+			t.Errorf("unexpected source: %+v", finding)
+		}
+	}
+	want := map[EdgeType]int{
+		EdgePodToNode:      1,
+		EdgePodToSA:        1,
+		EdgePodToSecret:    2, // volume + env
+		EdgePodToConfigMap: 1, // envFrom; the optional volume is skipped
+		EdgePodToPVC:       1,
+	}
+	for edgeType, count := range want {
+		if counts[edgeType] != count {
+			t.Errorf("expected %d %s findings, got %d: %+v", count, edgeType, counts[edgeType], findings)
+		}
+	}
+	if len(findings) != 6 {
+		t.Errorf("expected 6 findings total, got %d", len(findings))
+	}
+}
+
+func TestCheckHealthySnapshot(t *testing.T) {
+	snapshot := Snapshot{
+		Pods: []*v1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec: v1.PodSpec{
+				NodeName:           "node-1",
+				ServiceAccountName: "default", // implicit SA is never a finding
+			},
+		}},
+		Nodes: []*v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}},
+	}
+	if findings := Check(snapshot); len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
+	}
+}
+
+func TestCheckSkipsUnreadKinds(t *testing.T) {
+	// With no Nodes slice read, scheduled pods must not be reported.
+	snapshot := Snapshot{
+		Pods: []*v1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec:       v1.PodSpec{NodeName: "node-unknown"},
+		}},
+	}
+	if findings := Check(snapshot); len(findings) != 0 {
+		t.Errorf("expected node check disabled without node data, got %+v", findings)
+	}
+}
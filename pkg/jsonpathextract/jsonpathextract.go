@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jsonpathextract evaluates kubectl's jsonpath dialect —
+// including range and filter expressions — directly against Go API
+// structs, and renders custom-columns style tables from them. It
+// accepts the same relaxed path spellings kubectl does, so
+// "metadata.name", ".metadata.name" and "{.metadata.name}" are all
+// the same column.
+package jsonpathextract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Extract evaluates the template against the object and returns the
+// rendered text. Missing keys are errors; use ExtractAll for paths
+// that may match nothing.
+func Extract(obj interface{}, template string) (string, error) {
+	parser, err := compile(template, false)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := parser.Execute(&buf, obj); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ExtractAll evaluates the template and returns each matched value
+// separately, tolerating paths that match nothing.
+func ExtractAll(obj interface{}, template string) ([]interface{}, error) {
+	parser, err := compile(template, true)
+	if err != nil {
+		return nil, err
+	}
+	results, err := parser.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for _, group := range results {
+		for _, value := range group {
+			values = append(values, value.Interface())
+		}
+	}
+	return values, nil
+}
+
+func compile(template string, allowMissing bool) (*jsonpath.JSONPath, error) {
+	relaxed, err := RelaxedJSONPathExpression(template)
+	if err != nil {
+		return nil, err
+	}
+	parser := jsonpath.New("extract").AllowMissingKeys(allowMissing)
+	if err := parser.Parse(relaxed); err != nil {
+		return nil, fmt.Errorf("parsing jsonpath %q: %v", template, err)
+	}
+	return parser, nil
+}
+
+// RelaxedJSONPathExpression normalizes the spellings kubectl's
+// custom-columns accepts: a bare "metadata.name", a leading-dot
+// ".metadata.name" and a braced "{.metadata.name}" all become the
+// canonical "{.metadata.name}".
+func RelaxedJSONPathExpression(pathExpression string) (string, error) {
+	if len(pathExpression) == 0 {
+		return pathExpression, nil
+	}
+	if !strings.HasPrefix(pathExpression, "{") {
+		if !strings.HasPrefix(pathExpression, ".") {
+			pathExpression = "." + pathExpression
+		}
+		pathExpression = "{" + pathExpression + "}"
+	}
+	if !strings.HasSuffix(pathExpression, "}") {
+		return "", fmt.Errorf("unclosed jsonpath expression %q", pathExpression)
+	}
+	return pathExpression, nil
+}
+
+// Column is one custom-columns style column.
+type Column struct {
+	Header string
+	Path   string
+}
+
+// ParseColumns parses a "NAME:.metadata.name,STATUS:.status.phase"
+// spec, the format kubectl's custom-columns flag takes.
+func ParseColumns(spec string) ([]Column, error) {
+	var columns []Column
+	for _, part := range strings.Split(spec, ",") {
+		header, path, found := strings.Cut(part, ":")
+		if !found || header == "" || path == "" {
+			return nil, fmt.Errorf("column %q must be HEADER:jsonpath", part)
+		}
+		columns = append(columns, Column{Header: header, Path: path})
+	}
+	return columns, nil
+}
+
+// Table renders one row per object under a header row. Cells that
+// match nothing render as "<none>", as kubectl prints them.
+func Table(objects []interface{}, columns []Column) ([][]string, error) {
+	header := make([]string, len(columns))
+	parsers := make([]*jsonpath.JSONPath, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+		parser, err := compile(column.Path, true)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", column.Header, err)
+		}
+		parsers[i] = parser
+	}
+	rows := [][]string{header}
+	for _, obj := range objects {
+		row := make([]string, len(columns))
+		for i, parser := range parsers {
+			var buf bytes.Buffer
+			if err := parser.Execute(&buf, obj); err != nil {
+				return nil, fmt.Errorf("column %s: %v", columns[i].Header, err)
+			}
+			if buf.Len() == 0 {
+				row[i] = "<none>"
+			} else {
+				row[i] = buf.String()
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
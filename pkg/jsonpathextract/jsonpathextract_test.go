@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonpathextract
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func extractPod(name, phase string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: v1.PodSpec{Containers: []v1.Container{
+			{Name: "app", Image: "app:v1"},
+			{Name: "sidecar", Image: "sidecar:v2"},
+		}},
+		Status: v1.PodStatus{Phase: v1.PodPhase(phase)},
+	}
+}
+
+func TestExtractRelaxedSpellings(t *testing.T) {
+	pod := extractPod("web-0", "Running")
+	for _, template := range []string{"metadata.name", ".metadata.name", "{.metadata.name}"} {
+		got, err := Extract(pod, template)
+		if err != nil {
+			t.Fatalf("%q: %v", template, err)
+		}
+		if got != "web-0" {
+			t.Errorf("%q = %q", template, got)
+		}
+	}
+}
+
+func TestExtractRangeAndFilter(t *testing.T) {
+	pod := extractPod("web-0", "Running")
+	got, err := Extract(pod, `{range .spec.containers[*]}{.name} {end}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "app sidecar " {
+		t.Errorf("range = %q", got)
+	}
+
+	got, err = Extract(pod, `{.spec.containers[?(@.name=="sidecar")].image}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "sidecar:v2" {
+		t.Errorf("filter = %q", got)
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	values, err := ExtractAll(extractPod("web-0", "Running"), ".spec.containers[*].image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0] != "app:v1" {
+		t.Errorf("values: %v", values)
+	}
+
+	// A path matching nothing is not an error here.
+	values, err = ExtractAll(extractPod("web-0", "Running"), ".spec.nodeSelector.zone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 0 {
+		t.Errorf("values: %v", values)
+	}
+}
+
+func TestExtractMissingKey(t *testing.T) {
+	if _, err := Extract(extractPod("web-0", "Running"), ".spec.nodeSelector.zone"); err == nil {
+		t.Error("missing key must error in Extract")
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("NAME:.metadata.name,STATUS:.status.phase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 2 || columns[1].Header != "STATUS" {
+		t.Errorf("columns: %+v", columns)
+	}
+	if _, err := ParseColumns("NAME"); err == nil {
+		t.Error("missing path must error")
+	}
+}
+
+func TestTable(t *testing.T) {
+	columns, err := ParseColumns("NAME:.metadata.name,STATUS:.status.phase,ZONE:.spec.nodeSelector.zone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := Table([]interface{}{
+		extractPod("web-0", "Running"),
+		extractPod("web-1", "Pending"),
+	}, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"NAME", "STATUS", "ZONE"},
+		{"web-0", "Running", "<none>"},
+		{"web-1", "Pending", "<none>"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows: %v", rows)
+	}
+}
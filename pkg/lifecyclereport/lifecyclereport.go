@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lifecyclereport inventories the lifecycle hooks of a Pod's
+// containers and analyzes PreStop hooks against the Pod's termination grace
+// period. Containers whose PreStop work cannot finish before the kubelet's
+// SIGKILL are a common source of ungraceful shutdowns, and the signal is
+// invisible without comparing the hook against the grace budget.
+package lifecyclereport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// HookEvent distinguishes the two lifecycle hook points.
+type HookEvent string
+
+const (
+	// PostStart hooks run immediately after container start.
+	PostStart HookEvent = "PostStart"
+	// PreStop hooks run before the container receives SIGTERM.
+	PreStop HookEvent = "PreStop"
+)
+
+// HookType is the handler mechanism of a hook.
+type HookType string
+
+const (
+	// HookExec is a command executed inside the container.
+	HookExec HookType = "Exec"
+	// HookHTTPGet is an HTTP GET against the container.
+	HookHTTPGet HookType = "HTTPGet"
+	// HookTCPSocket is the deprecated TCP handler; it is accepted by the
+	// API but not executed by the kubelet.
+	HookTCPSocket HookType = "TCPSocket"
+)
+
+// Hook is one lifecycle hook on one container.
+type Hook struct {
+	// Container is the container's name.
+	Container string
+	// Event is the hook point.
+	Event HookEvent
+	// Type is the handler mechanism.
+	Type HookType
+	// Detail is a short rendering of the handler: the exec command line or
+	// the HTTP/TCP target.
+	Detail string
+}
+
+// Hooks lists every lifecycle hook declared by the Pod's init and regular
+// containers, in spec order. Ephemeral containers cannot declare hooks.
+func Hooks(pod *v1.Pod) []Hook {
+	var hooks []Hook
+	collect := func(containers []v1.Container) {
+		for i := range containers {
+			c := &containers[i]
+			if c.Lifecycle == nil {
+				continue
+			}
+			if c.Lifecycle.PostStart != nil {
+				hooks = append(hooks, newHook(c.Name, PostStart, c.Lifecycle.PostStart))
+			}
+			if c.Lifecycle.PreStop != nil {
+				hooks = append(hooks, newHook(c.Name, PreStop, c.Lifecycle.PreStop))
+			}
+		}
+	}
+	collect(pod.Spec.InitContainers)
+	collect(pod.Spec.Containers)
+	return hooks
+}
+
+func newHook(container string, event HookEvent, handler *v1.LifecycleHandler) Hook {
+	hook := Hook{Container: container, Event: event}
+	switch {
+	case handler.Exec != nil:
+		hook.Type = HookExec
+		hook.Detail = strings.Join(handler.Exec.Command, " ")
+	case handler.HTTPGet != nil:
+		hook.Type = HookHTTPGet
+		hook.Detail = fmt.Sprintf("%s:%s%s", handler.HTTPGet.Host, handler.HTTPGet.Port.String(), handler.HTTPGet.Path)
+	case handler.TCPSocket != nil:
+		hook.Type = HookTCPSocket
+		hook.Detail = fmt.Sprintf("%s:%s", handler.TCPSocket.Host, handler.TCPSocket.Port.String())
+	}
+	return hook
+}
+
+// defaultGracePeriodSeconds mirrors the API default for
+// terminationGracePeriodSeconds.
+const defaultGracePeriodSeconds = int64(30)
+
+// shortGraceThresholdSeconds is the budget below which a PreStop hook of
+// unknown duration is worth flagging on its own.
+const shortGraceThresholdSeconds = int64(10)
+
+// BudgetFinding flags one container whose PreStop hook is at risk of being
+// cut short by the grace period.
+type BudgetFinding struct {
+	// Container is the container's name.
+	Container string
+	// EstimatedSeconds is the hook's estimated duration, or -1 if it could
+	// not be estimated.
+	EstimatedSeconds int64
+	// GracePeriodSeconds is the Pod's effective termination grace period.
+	GracePeriodSeconds int64
+	// Reason explains the finding.
+	Reason string
+}
+
+// AnalyzePreStopBudget compares each container's PreStop hook against the
+// Pod's termination grace period. Hooks whose estimated duration meets or
+// exceeds the budget are flagged, as are hooks of unknown duration when the
+// budget is very short. Hook durations are estimated conservatively: only
+// plain "sleep N" exec commands yield an estimate.
+func AnalyzePreStopBudget(pod *v1.Pod) []BudgetFinding {
+	grace := defaultGracePeriodSeconds
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		grace = *pod.Spec.TerminationGracePeriodSeconds
+	}
+
+	var findings []BudgetFinding
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		if c.Lifecycle == nil || c.Lifecycle.PreStop == nil {
+			continue
+		}
+		estimated, known := estimateSeconds(c.Lifecycle.PreStop)
+		switch {
+		case known && estimated >= grace:
+			findings = append(findings, BudgetFinding{
+				Container:          c.Name,
+				EstimatedSeconds:   estimated,
+				GracePeriodSeconds: grace,
+				Reason:             fmt.Sprintf("PreStop hook needs ~%ds but terminationGracePeriodSeconds is %d; the container will be SIGKILLed mid-hook", estimated, grace),
+			})
+		case !known && grace < shortGraceThresholdSeconds:
+			findings = append(findings, BudgetFinding{
+				Container:          c.Name,
+				EstimatedSeconds:   -1,
+				GracePeriodSeconds: grace,
+				Reason:             fmt.Sprintf("PreStop hook of unknown duration with only %ds grace period", grace),
+			})
+		}
+	}
+	return findings
+}
+
+// estimateSeconds returns the hook's duration when it is statically known.
+// Only exec commands of the form "sleep N" (with an optional path prefix on
+// the binary) can be estimated.
+func estimateSeconds(handler *v1.LifecycleHandler) (int64, bool) {
+	if handler.Exec == nil || len(handler.Exec.Command) != 2 {
+		return 0, false
+	}
+	cmd := handler.Exec.Command[0]
+	if cmd != "sleep" && !strings.HasSuffix(cmd, "/sleep") {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(handler.Exec.Command[1], 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return seconds, true
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecyclereport
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func int64Ptr(i int64) *int64 { return &i }
+
+func TestHooks(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{
+				Name: "init",
+				Lifecycle: &v1.Lifecycle{
+					PostStart: &v1.LifecycleHandler{
+						Exec: &v1.ExecAction{Command: []string{"sh", "-c", "warm-up"}},
+					},
+				},
+			}},
+			Containers: []v1.Container{
+				{Name: "plain"},
+				{
+					Name: "app",
+					Lifecycle: &v1.Lifecycle{
+						PreStop: &v1.LifecycleHandler{
+							HTTPGet: &v1.HTTPGetAction{Host: "localhost", Port: intstr.FromInt(8080), Path: "/drain"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := []Hook{
+		{Container: "init", Event: PostStart, Type: HookExec, Detail: "sh -c warm-up"},
+		{Container: "app", Event: PreStop, Type: HookHTTPGet, Detail: "localhost:8080/drain"},
+	}
+	if got := Hooks(pod); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func preStopSleepPod(seconds string, grace *int64) *v1.Pod {
+	return &v1.Pod{
+		Spec: v1.PodSpec{
+			TerminationGracePeriodSeconds: grace,
+			Containers: []v1.Container{{
+				Name: "app",
+				Lifecycle: &v1.Lifecycle{
+					PreStop: &v1.LifecycleHandler{
+						Exec: &v1.ExecAction{Command: []string{"sleep", seconds}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestAnalyzePreStopBudget(t *testing.T) {
+	testCases := []struct {
+		name         string
+		pod          *v1.Pod
+		wantFindings int
+	}{
+		{
+			name:         "sleep exceeding grace period",
+			pod:          preStopSleepPod("60", int64Ptr(30)),
+			wantFindings: 1,
+		},
+		{
+			name:         "sleep equal to grace period",
+			pod:          preStopSleepPod("30", int64Ptr(30)),
+			wantFindings: 1,
+		},
+		{
+			name:         "sleep within grace period",
+			pod:          preStopSleepPod("5", int64Ptr(30)),
+			wantFindings: 0,
+		},
+		{
+			name:         "sleep exceeding default grace period",
+			pod:          preStopSleepPod("45", nil),
+			wantFindings: 1,
+		},
+		{
+			name: "unknown duration with short grace period",
+			pod: &v1.Pod{
+				Spec: v1.PodSpec{
+					TerminationGracePeriodSeconds: int64Ptr(2),
+					Containers: []v1.Container{{
+						Name: "app",
+						Lifecycle: &v1.Lifecycle{
+							PreStop: &v1.LifecycleHandler{
+								HTTPGet: &v1.HTTPGetAction{Port: intstr.FromInt(80)},
+							},
+						},
+					}},
+				},
+			},
+			wantFindings: 1,
+		},
+		{
+			name: "unknown duration with ample grace period",
+			pod: &v1.Pod{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{
+						Name: "app",
+						Lifecycle: &v1.Lifecycle{
+							PreStop: &v1.LifecycleHandler{
+								HTTPGet: &v1.HTTPGetAction{Port: intstr.FromInt(80)},
+							},
+						},
+					}},
+				},
+			},
+			wantFindings: 0,
+		},
+		{
+			name:         "no hooks",
+			pod:          &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}}},
+			wantFindings: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := AnalyzePreStopBudget(tc.pod)
+			if len(findings) != tc.wantFindings {
+				t.Errorf("expected %d findings, got %+v", tc.wantFindings, findings)
+			}
+			for _, f := range findings {
+				if f.Reason == "" || f.GracePeriodSeconds == 0 {
+					t.Errorf("incomplete finding: %+v", f)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateSeconds(t *testing.T) {
+	testCases := []struct {
+		command  []string
+		expected int64
+		known    bool
+	}{
+		{[]string{"sleep", "30"}, 30, true},
+		{[]string{"/bin/sleep", "5"}, 5, true},
+		{[]string{"sleep", "abc"}, 0, false},
+		{[]string{"sh", "-c", "sleep 30"}, 0, false},
+		{[]string{"drain.sh"}, 0, false},
+	}
+	for _, tc := range testCases {
+		handler := &v1.LifecycleHandler{Exec: &v1.ExecAction{Command: tc.command}}
+		got, known := estimateSeconds(handler)
+		if got != tc.expected || known != tc.known {
+			t.Errorf("estimateSeconds(%v) = (%d, %v), expected (%d, %v)", tc.command, got, known, tc.expected, tc.known)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package listerindex layers secondary indexes over the generated
+// core/v1 listers, so a read-heavy consumer answers "all pods on node
+// X" or "all pods mounting claim Y" from the index bucket instead of
+// scanning every cached pod. The index functions plug into any
+// cache.SharedIndexInformer; the typed wrappers hide the string keys.
+package listerindex
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Index names, usable directly against a cache.Indexer.
+const (
+	// PodNodeIndex buckets pods by spec.nodeName.
+	PodNodeIndex = "byNode"
+	// PodPVCIndex buckets pods by each "namespace/claimName" their
+	// volumes reference.
+	PodPVCIndex = "byPVC"
+	// OwnerUIDIndex buckets any object by each ownerReference UID.
+	OwnerUIDIndex = "byOwnerUID"
+)
+
+// PodIndexers returns the indexers an informer needs for PodIndex.
+func PodIndexers() cache.Indexers {
+	return cache.Indexers{
+		PodNodeIndex:  PodNodeIndexFunc,
+		PodPVCIndex:   PodPVCIndexFunc,
+		OwnerUIDIndex: OwnerUIDIndexFunc,
+	}
+}
+
+// PodNodeIndexFunc indexes a pod under its assigned node; unscheduled
+// pods are not indexed.
+func PodNodeIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected *v1.Pod, got %T", obj)
+	}
+	if pod.Spec.NodeName == "" {
+		return nil, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+// PodPVCIndexFunc indexes a pod under every persistentVolumeClaim its
+// volumes and ephemeral volumes reference, keyed "namespace/claim".
+func PodPVCIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected *v1.Pod, got %T", obj)
+	}
+	var keys []string
+	for _, volume := range pod.Spec.Volumes {
+		claimName := ""
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			claimName = volume.PersistentVolumeClaim.ClaimName
+		case volume.Ephemeral != nil:
+			// Ephemeral volumes own a claim named "<pod>-<volume>".
+			claimName = pod.Name + "-" + volume.Name
+		}
+		if claimName != "" {
+			keys = append(keys, pod.Namespace+"/"+claimName)
+		}
+	}
+	return keys, nil
+}
+
+// OwnerUIDIndexFunc indexes any object under each of its owner UIDs.
+func OwnerUIDIndexFunc(obj interface{}) ([]string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	owners := accessor.GetOwnerReferences()
+	keys := make([]string, 0, len(owners))
+	for _, owner := range owners {
+		keys = append(keys, string(owner.UID))
+	}
+	return keys, nil
+}
+
+// PodIndex answers pod queries from index buckets.
+type PodIndex struct {
+	indexer cache.Indexer
+}
+
+// NewPodIndex wraps the informer's indexer, adding any of the pod
+// indexes it does not yet carry. Adding indexers fails once the
+// informer has started, so build the index before Run.
+func NewPodIndex(indexer cache.Indexer) (*PodIndex, error) {
+	missing := cache.Indexers{}
+	existing := indexer.GetIndexers()
+	for name, fn := range PodIndexers() {
+		if _, ok := existing[name]; !ok {
+			missing[name] = fn
+		}
+	}
+	if len(missing) > 0 {
+		if err := indexer.AddIndexers(missing); err != nil {
+			return nil, fmt.Errorf("adding pod indexers: %v", err)
+		}
+	}
+	return &PodIndex{indexer: indexer}, nil
+}
+
+// OnNode lists the pods assigned to the node.
+func (p *PodIndex) OnNode(nodeName string) ([]*v1.Pod, error) {
+	return p.byIndex(PodNodeIndex, nodeName)
+}
+
+// MountingClaim lists the pods whose volumes reference the claim.
+func (p *PodIndex) MountingClaim(namespace, claimName string) ([]*v1.Pod, error) {
+	return p.byIndex(PodPVCIndex, namespace+"/"+claimName)
+}
+
+// OwnedBy lists the pods carrying an ownerReference with the UID.
+func (p *PodIndex) OwnedBy(uid types.UID) ([]*v1.Pod, error) {
+	return p.byIndex(OwnerUIDIndex, string(uid))
+}
+
+func (p *PodIndex) byIndex(indexName, key string) ([]*v1.Pod, error) {
+	items, err := p.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*v1.Pod, 0, len(items))
+	for _, item := range items {
+		pod, ok := item.(*v1.Pod)
+		if !ok {
+			return nil, fmt.Errorf("expected *v1.Pod in index %s, got %T", indexName, item)
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package listerindex
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+func indexedStore(t *testing.T, pods ...*v1.Pod) *PodIndex {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	index, err := NewPodIndex(indexer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pod := range pods {
+		if err := indexer.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return index
+}
+
+func TestOnNode(t *testing.T) {
+	index := indexedStore(t,
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "p1"}, Spec: v1.PodSpec{NodeName: "node-1"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "p2"}, Spec: v1.PodSpec{NodeName: "node-1"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "p3"}, Spec: v1.PodSpec{NodeName: "node-2"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "pending"}},
+	)
+	pods, err := index.OnNode("node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 2 {
+		t.Errorf("node-1 has %d pods, want 2", len(pods))
+	}
+	pods, err = index.OnNode("node-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 0 {
+		t.Errorf("empty node returned %d pods", len(pods))
+	}
+}
+
+func TestMountingClaim(t *testing.T) {
+	index := indexedStore(t,
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "db"},
+			Spec: v1.PodSpec{Volumes: []v1.Volume{{
+				Name:         "data",
+				VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "db-data"}},
+			}}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "scratch"},
+			Spec: v1.PodSpec{Volumes: []v1.Volume{{
+				Name:         "tmp",
+				VolumeSource: v1.VolumeSource{Ephemeral: &v1.EphemeralVolumeSource{}},
+			}}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "db"},
+			Spec: v1.PodSpec{Volumes: []v1.Volume{{
+				Name:         "data",
+				VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "db-data"}},
+			}}},
+		},
+	)
+	pods, err := index.MountingClaim("prod", "db-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].Name != "db" {
+		t.Errorf("prod/db-data: %+v", pods)
+	}
+	// The ephemeral volume's generated claim name is pod-volume.
+	pods, err = index.MountingClaim("prod", "scratch-tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].Name != "scratch" {
+		t.Errorf("ephemeral claim: %+v", pods)
+	}
+}
+
+func TestOwnedBy(t *testing.T) {
+	uid := types.UID("rs-uid-1")
+	index := indexedStore(t,
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "a", Name: "owned",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", UID: uid}},
+		}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "orphan"}},
+	)
+	pods, err := index.OwnedBy(uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].Name != "owned" {
+		t.Errorf("OwnedBy: %+v", pods)
+	}
+}
+
+func TestNewPodIndexKeepsExistingIndexers(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		PodNodeIndex: PodNodeIndexFunc,
+	})
+	if _, err := NewPodIndex(indexer); err != nil {
+		t.Fatalf("NewPodIndex over a partially indexed store: %v", err)
+	}
+	indexers := indexer.GetIndexers()
+	for _, name := range []string{PodNodeIndex, PodPVCIndex, OwnerUIDIndex} {
+		if _, ok := indexers[name]; !ok {
+			t.Errorf("index %s missing", name)
+		}
+	}
+}
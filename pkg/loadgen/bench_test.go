@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadgen
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kubernetes/pkg/fingerprint"
+	"k8s.io/kubernetes/pkg/rwopcheck"
+)
+
+// benchConfig approximates a mid-size cluster; keep it fixed so numbers
+// stay comparable across releases.
+var benchConfig = Config{Seed: 7, Pods: 2000, Nodes: 50, PVCs: 200}
+
+func benchCodec(b *testing.B) runtime.Codec {
+	b.Helper()
+	info, ok := runtime.SerializerInfoForMediaType(clientsetscheme.Codecs.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	if !ok {
+		b.Fatal("no JSON serializer")
+	}
+	return clientsetscheme.Codecs.CodecForVersions(info.Serializer, info.Serializer, v1.SchemeGroupVersion, v1.SchemeGroupVersion)
+}
+
+func BenchmarkGeneratePods(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		New(benchConfig).Pods()
+	}
+}
+
+func BenchmarkDecodePods(b *testing.B) {
+	codec := benchCodec(b)
+	pods := New(benchConfig).Pods()
+	encoded := make([][]byte, len(pods))
+	for i, pod := range pods {
+		data, err := runtime.Encode(codec, pod)
+		if err != nil {
+			b.Fatal(err)
+		}
+		encoded[i] = data
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := runtime.Decode(codec, encoded[i%len(encoded)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFingerprintPodSpec(b *testing.B) {
+	pods := New(benchConfig).Pods()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fingerprint.PodSpec(&pods[i%len(pods)].Spec)
+	}
+}
+
+func BenchmarkRWOPCheck(b *testing.B) {
+	g := New(benchConfig)
+	pods := g.Pods()
+	claims := g.PVCs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rwopcheck.Check(pods, claims, nil)
+	}
+}
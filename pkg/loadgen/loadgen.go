@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package loadgen synthesizes realistic Pod, Node and PVC populations at
+// configurable scale for benchmarking read paths. Generation is seeded and
+// fully deterministic, so two runs with the same config produce identical
+// objects and performance numbers are comparable across machines and
+// releases. Field distributions (container counts, label cardinality,
+// node spread) are tunable to approximate a target cluster's shape.
+package loadgen
+
+import (
+	"fmt"
+	"math/rand"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Config shapes the synthesized population.
+type Config struct {
+	// Seed makes generation deterministic; the zero seed is valid.
+	Seed int64
+	// Pods, Nodes and PVCs are the object counts to generate.
+	Pods  int
+	Nodes int
+	PVCs  int
+	// Namespaces is the number of distinct namespaces pods spread over;
+	// zero means 10.
+	Namespaces int
+	// AppLabelCardinality is the number of distinct `app` label values;
+	// zero means 50.
+	AppLabelCardinality int
+	// ExtraLabels is the number of additional low-cardinality labels per
+	// pod; zero means 3.
+	ExtraLabels int
+	// MaxContainers caps containers per pod; zero means 3.
+	MaxContainers int
+	// PVCFraction is the fraction of pods (0..1) referencing a PVC;
+	// zero means 0.2.
+	PVCFraction float64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Namespaces == 0 {
+		c.Namespaces = 10
+	}
+	if c.AppLabelCardinality == 0 {
+		c.AppLabelCardinality = 50
+	}
+	if c.ExtraLabels == 0 {
+		c.ExtraLabels = 3
+	}
+	if c.MaxContainers == 0 {
+		c.MaxContainers = 3
+	}
+	if c.PVCFraction == 0 {
+		c.PVCFraction = 0.2
+	}
+	return c
+}
+
+// Generator produces the population for one config.
+type Generator struct {
+	config Config
+	rand   *rand.Rand
+}
+
+// New returns a generator for the config.
+func New(config Config) *Generator {
+	config = config.withDefaults()
+	return &Generator{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)),
+	}
+}
+
+// Nodes synthesizes the node population.
+func (g *Generator) Nodes() []*v1.Node {
+	nodes := make([]*v1.Node, 0, g.config.Nodes)
+	for i := 0; i < g.config.Nodes; i++ {
+		cpu := 4 << uint(g.rand.Intn(3)) // 4, 8 or 16 cores
+		nodes = append(nodes, &v1.Node{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("node-%04d", i),
+				UID:  types.UID(fmt.Sprintf("node-uid-%04d", i)),
+				Labels: map[string]string{
+					"topology.kubernetes.io/zone": fmt.Sprintf("zone-%d", i%3),
+					"kubernetes.io/hostname":      fmt.Sprintf("node-%04d", i),
+				},
+			},
+			Status: v1.NodeStatus{
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%d", cpu)),
+					v1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dGi", cpu*4)),
+					v1.ResourcePods:   resource.MustParse("110"),
+				},
+				Conditions: []v1.NodeCondition{
+					{Type: v1.NodeReady, Status: v1.ConditionTrue},
+				},
+			},
+		})
+	}
+	return nodes
+}
+
+// Pods synthesizes the pod population. Pods reference generated nodes and
+// PVCs when those counts are non-zero.
+func (g *Generator) Pods() []*v1.Pod {
+	pods := make([]*v1.Pod, 0, g.config.Pods)
+	for i := 0; i < g.config.Pods; i++ {
+		namespace := fmt.Sprintf("ns-%03d", g.rand.Intn(g.config.Namespaces))
+		app := fmt.Sprintf("app-%03d", g.rand.Intn(g.config.AppLabelCardinality))
+
+		labels := map[string]string{"app": app}
+		for l := 0; l < g.config.ExtraLabels; l++ {
+			labels[fmt.Sprintf("label-%d", l)] = fmt.Sprintf("value-%d", g.rand.Intn(5))
+		}
+
+		containerCount := 1 + g.rand.Intn(g.config.MaxContainers)
+		containers := make([]v1.Container, 0, containerCount)
+		for c := 0; c < containerCount; c++ {
+			containers = append(containers, v1.Container{
+				Name:  fmt.Sprintf("c%d", c),
+				Image: fmt.Sprintf("registry.example.com/%s:v1.%d", app, g.rand.Intn(20)),
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%dm", 100*(1+g.rand.Intn(5)))),
+						v1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dMi", 64<<uint(g.rand.Intn(4)))),
+					},
+				},
+			})
+		}
+
+		pod := &v1.Pod{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       namespace,
+				Name:            fmt.Sprintf("%s-%06d", app, i),
+				UID:             types.UID(fmt.Sprintf("pod-uid-%06d", i)),
+				ResourceVersion: fmt.Sprintf("%d", 1000+i),
+				Labels:          labels,
+			},
+			Spec: v1.PodSpec{Containers: containers},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+			},
+		}
+		if g.config.Nodes > 0 {
+			pod.Spec.NodeName = fmt.Sprintf("node-%04d", g.rand.Intn(g.config.Nodes))
+		}
+		if g.config.PVCs > 0 && g.rand.Float64() < g.config.PVCFraction {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+						ClaimName: fmt.Sprintf("pvc-%05d", g.rand.Intn(g.config.PVCs)),
+					},
+				},
+			})
+		}
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// PVCs synthesizes the claim population. Claim names match the references
+// Pods generates.
+func (g *Generator) PVCs() []*v1.PersistentVolumeClaim {
+	claims := make([]*v1.PersistentVolumeClaim, 0, g.config.PVCs)
+	modes := []v1.PersistentVolumeAccessMode{
+		v1.ReadWriteOnce, v1.ReadWriteOnce, v1.ReadWriteOnce, // RWO dominates in practice
+		v1.ReadWriteMany,
+		v1.ReadWriteOncePod,
+	}
+	for i := 0; i < g.config.PVCs; i++ {
+		claims = append(claims, &v1.PersistentVolumeClaim{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: fmt.Sprintf("ns-%03d", g.rand.Intn(g.config.Namespaces)),
+				Name:      fmt.Sprintf("pvc-%05d", i),
+				UID:       types.UID(fmt.Sprintf("pvc-uid-%05d", i)),
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				AccessModes: []v1.PersistentVolumeAccessMode{modes[g.rand.Intn(len(modes))]},
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceStorage: resource.MustParse(fmt.Sprintf("%dGi", 1<<uint(g.rand.Intn(6)))),
+					},
+				},
+			},
+		})
+	}
+	return claims
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadgen
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+func TestDeterminism(t *testing.T) {
+	config := Config{Seed: 42, Pods: 50, Nodes: 5, PVCs: 10}
+
+	a, b := New(config), New(config)
+	if !equality.Semantic.DeepEqual(a.Pods(), b.Pods()) {
+		t.Error("same seed must produce identical pods")
+	}
+	if !equality.Semantic.DeepEqual(a.Nodes(), b.Nodes()) {
+		t.Error("same seed must produce identical nodes")
+	}
+	if !equality.Semantic.DeepEqual(a.PVCs(), b.PVCs()) {
+		t.Error("same seed must produce identical claims")
+	}
+
+	other := New(Config{Seed: 43, Pods: 50, Nodes: 5, PVCs: 10})
+	if equality.Semantic.DeepEqual(New(config).Pods(), other.Pods()) {
+		t.Error("different seeds should produce different pods")
+	}
+}
+
+func TestPopulationShape(t *testing.T) {
+	config := Config{Seed: 1, Pods: 200, Nodes: 10, PVCs: 20, Namespaces: 4, AppLabelCardinality: 8}
+	g := New(config)
+
+	pods := g.Pods()
+	if len(pods) != 200 {
+		t.Fatalf("expected 200 pods, got %d", len(pods))
+	}
+
+	namespaces := map[string]bool{}
+	apps := map[string]bool{}
+	withPVC := 0
+	for _, pod := range pods {
+		namespaces[pod.Namespace] = true
+		apps[pod.Labels["app"]] = true
+		if len(pod.Spec.Containers) < 1 || len(pod.Spec.Containers) > 3 {
+			t.Fatalf("pod %s has %d containers", pod.Name, len(pod.Spec.Containers))
+		}
+		if pod.Spec.NodeName == "" {
+			t.Fatalf("pod %s is unscheduled despite nodes being generated", pod.Name)
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				withPVC++
+			}
+		}
+	}
+	if len(namespaces) > 4 {
+		t.Errorf("expected at most 4 namespaces, got %d", len(namespaces))
+	}
+	if len(apps) > 8 {
+		t.Errorf("expected at most 8 app values, got %d", len(apps))
+	}
+	// Default PVCFraction is 0.2; allow a generous band.
+	if withPVC < 10 || withPVC > 90 {
+		t.Errorf("expected roughly 20%% of pods to reference a PVC, got %d/200", withPVC)
+	}
+
+	if nodes := g.Nodes(); len(nodes) != 10 {
+		t.Errorf("expected 10 nodes, got %d", len(nodes))
+	}
+	if claims := g.PVCs(); len(claims) != 20 {
+		t.Errorf("expected 20 claims, got %d", len(claims))
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logtargets maps a Pod's status to the log requests that can
+// be served for it. Which containers have logs at all, and whether a
+// previous instance's logs survive, is spread across three container
+// status lists and the restart counters; enumerating it in one place
+// saves every log reader from re-deriving the rules.
+package logtargets
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ContainerKind distinguishes the three container lists of a pod.
+type ContainerKind string
+
+const (
+	InitContainer      ContainerKind = "init"
+	RegularContainer   ContainerKind = "regular"
+	EphemeralContainer ContainerKind = "ephemeral"
+)
+
+// Target is one readable log stream of a pod.
+type Target struct {
+	// Container is the container name to put in the log request.
+	Container string
+	Kind      ContainerKind
+	// State summarizes the current instance: "running", "terminated"
+	// or "waiting".
+	State string
+	// Restarts is the container's restart count.
+	Restarts int32
+	// Previous is true when logs of the prior instance can be
+	// requested (the container restarted at least once, or is
+	// terminated with an earlier run recorded).
+	Previous bool
+	// Reason explains a waiting container with no logs yet, e.g.
+	// "ContainerCreating" or "ImagePullBackOff".
+	Reason string
+}
+
+// Readable reports whether the current instance has logs to serve; a
+// container that never started does not.
+func (t Target) Readable() bool {
+	return t.State != "waiting"
+}
+
+// Targets enumerates the pod's log targets: init containers first,
+// then regular, then ephemeral, each list in spec order. Containers
+// with no status yet appear as waiting targets so callers can report
+// why no logs exist.
+func Targets(pod *v1.Pod) []Target {
+	var targets []Target
+	statuses := map[string]v1.ContainerStatus{}
+	for _, list := range [][]v1.ContainerStatus{
+		pod.Status.InitContainerStatuses,
+		pod.Status.ContainerStatuses,
+		pod.Status.EphemeralContainerStatuses,
+	} {
+		for _, status := range list {
+			statuses[status.Name] = status
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		targets = append(targets, targetFor(container.Name, InitContainer, statuses))
+	}
+	for _, container := range pod.Spec.Containers {
+		targets = append(targets, targetFor(container.Name, RegularContainer, statuses))
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		targets = append(targets, targetFor(container.Name, EphemeralContainer, statuses))
+	}
+	return targets
+}
+
+// Lookup returns the target for one container by name.
+func Lookup(pod *v1.Pod, container string) (Target, error) {
+	for _, target := range Targets(pod) {
+		if target.Container == container {
+			return target, nil
+		}
+	}
+	names := make([]string, 0)
+	for _, target := range Targets(pod) {
+		names = append(names, target.Container)
+	}
+	sort.Strings(names)
+	return Target{}, fmt.Errorf("container %q not found in pod %s, choose one of %v", container, pod.Name, names)
+}
+
+func targetFor(name string, kind ContainerKind, statuses map[string]v1.ContainerStatus) Target {
+	target := Target{Container: name, Kind: kind, State: "waiting"}
+	status, ok := statuses[name]
+	if !ok {
+		return target
+	}
+	target.Restarts = status.RestartCount
+	switch {
+	case status.State.Running != nil:
+		target.State = "running"
+	case status.State.Terminated != nil:
+		target.State = "terminated"
+		target.Reason = status.State.Terminated.Reason
+	case status.State.Waiting != nil:
+		target.Reason = status.State.Waiting.Reason
+	}
+	// The kubelet keeps the previous instance's logs across one
+	// restart; LastTerminationState records that instance.
+	target.Previous = status.RestartCount > 0 && status.LastTerminationState.Terminated != nil
+	return target
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logtargets
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func restartingPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-0"},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "migrate"}},
+			Containers:     []v1.Container{{Name: "app"}, {Name: "sidecar"}},
+			EphemeralContainers: []v1.EphemeralContainer{{
+				EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debugger"},
+			}},
+		},
+		Status: v1.PodStatus{
+			InitContainerStatuses: []v1.ContainerStatus{{
+				Name:  "migrate",
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}},
+			}},
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:         "app",
+					RestartCount: 3,
+					State:        v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					LastTerminationState: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled"},
+					},
+				},
+				{
+					Name:  "sidecar",
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				},
+			},
+			EphemeralContainerStatuses: []v1.ContainerStatus{{
+				Name:  "debugger",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+}
+
+func TestTargets(t *testing.T) {
+	targets := Targets(restartingPod())
+	if len(targets) != 4 {
+		t.Fatalf("targets: %+v", targets)
+	}
+	// Spec order: init first, ephemeral last.
+	if targets[0].Container != "migrate" || targets[0].Kind != InitContainer || targets[0].State != "terminated" {
+		t.Errorf("migrate: %+v", targets[0])
+	}
+	if targets[3].Container != "debugger" || targets[3].Kind != EphemeralContainer {
+		t.Errorf("debugger: %+v", targets[3])
+	}
+}
+
+func TestPreviousAvailability(t *testing.T) {
+	app, err := Lookup(restartingPod(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !app.Previous || app.Restarts != 3 || !app.Readable() {
+		t.Errorf("app: %+v", app)
+	}
+
+	migrate, err := Lookup(restartingPod(), "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Terminated once, never restarted: current logs only.
+	if migrate.Previous || !migrate.Readable() {
+		t.Errorf("migrate: %+v", migrate)
+	}
+}
+
+func TestWaitingContainer(t *testing.T) {
+	sidecar, err := Lookup(restartingPod(), "sidecar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sidecar.Readable() || sidecar.Reason != "ImagePullBackOff" {
+		t.Errorf("sidecar: %+v", sidecar)
+	}
+
+	// A container with no status at all is waiting with no reason.
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}}}
+	target, err := Lookup(pod, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Readable() || target.Reason != "" {
+		t.Errorf("statusless: %+v", target)
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	_, err := Lookup(restartingPod(), "nope")
+	if err == nil || !strings.Contains(err.Error(), "app") {
+		t.Errorf("error should list choices: %v", err)
+	}
+}
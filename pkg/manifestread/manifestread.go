@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manifestread decodes multi-document YAML and JSON List
+// manifests into the typed structs registered in the clientset scheme.
+// In strict mode a typo'd field or a key defined twice is an error
+// naming the offending field and the line the document starts on,
+// instead of a value that silently vanishes on decode.
+package manifestread
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// Options controls decoding.
+type Options struct {
+	// Strict rejects unknown fields and duplicate keys instead of
+	// dropping them.
+	Strict bool
+	// KeepLists returns v1.List objects as-is instead of flattening
+	// their items into the result.
+	KeepLists bool
+}
+
+var (
+	lenientCodecs = serializer.NewCodecFactory(clientsetscheme.Scheme)
+	strictCodecs  = serializer.NewCodecFactory(clientsetscheme.Scheme, serializer.EnableStrict)
+)
+
+// Decode reads every document in the stream. YAML streams may hold any
+// number of documents; a JSON stream is one document, which may be a
+// List. Empty documents and comment-only documents are skipped.
+func Decode(r io.Reader, opts Options) ([]runtime.Object, error) {
+	decoder := lenientCodecs.UniversalDeserializer()
+	if opts.Strict {
+		decoder = strictCodecs.UniversalDeserializer()
+	}
+
+	var objects []runtime.Object
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	line := 1
+	for index := 0; ; index++ {
+		if index > 0 {
+			// The reader consumed a "---" separator line before this
+			// document.
+			line++
+		}
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading document %d: %v", index+1, err)
+		}
+		startLine := line
+		line += bytes.Count(doc, []byte("\n"))
+		if len(bytes.TrimSpace(stripComments(doc))) == 0 {
+			continue
+		}
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("document %d (starting at line %d): %v", index+1, startLine, err)
+		}
+		if list, ok := obj.(*v1.List); ok && !opts.KeepLists {
+			expanded, err := expandList(decoder, list)
+			if err != nil {
+				return nil, fmt.Errorf("document %d (starting at line %d): %v", index+1, startLine, err)
+			}
+			objects = append(objects, expanded...)
+			continue
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// DecodeString is Decode over a literal manifest.
+func DecodeString(manifest string, opts Options) ([]runtime.Object, error) {
+	return Decode(strings.NewReader(manifest), opts)
+}
+
+func expandList(decoder runtime.Decoder, list *v1.List) ([]runtime.Object, error) {
+	items := make([]runtime.Object, 0, len(list.Items))
+	for i, item := range list.Items {
+		if item.Object != nil {
+			items = append(items, item.Object)
+			continue
+		}
+		obj, _, err := decoder.Decode(item.Raw, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list item %d: %v", i, err)
+		}
+		items = append(items, obj)
+	}
+	return items, nil
+}
+
+// stripComments removes full-line YAML comments so a documents block
+// holding only comments does not decode as an empty object.
+func stripComments(doc []byte) []byte {
+	lines := bytes.Split(doc, []byte("\n"))
+	kept := make([][]byte, 0, len(lines))
+	for _, l := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(l), []byte("#")) {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return bytes.Join(kept, []byte("\n"))
+}
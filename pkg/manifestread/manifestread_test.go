@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifestread
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const multiDoc = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: default
+---
+# a comment-only document is skipped
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`
+
+func TestDecodeMultiDocument(t *testing.T) {
+	objects, err := DecodeString(multiDoc, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("decoded %d objects, want 2", len(objects))
+	}
+	pod, ok := objects[0].(*v1.Pod)
+	if !ok || pod.Name != "web" {
+		t.Errorf("first object: %T %+v", objects[0], objects[0])
+	}
+	service, ok := objects[1].(*v1.Service)
+	if !ok || service.Spec.Ports[0].Port != 80 {
+		t.Errorf("second object: %T", objects[1])
+	}
+}
+
+func TestDecodeJSONList(t *testing.T) {
+	manifest := `{"apiVersion":"v1","kind":"List","items":[
+		{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}},
+		{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b"}}
+	]}`
+	objects, err := DecodeString(manifest, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("decoded %d objects, want 2 flattened items", len(objects))
+	}
+	for i, want := range []string{"a", "b"} {
+		cm, ok := objects[i].(*v1.ConfigMap)
+		if !ok || cm.Name != want {
+			t.Errorf("item %d: %T %+v", i, objects[i], objects[i])
+		}
+	}
+
+	kept, err := DecodeString(manifest, Options{KeepLists: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 {
+		t.Fatalf("KeepLists decoded %d objects, want 1", len(kept))
+	}
+	if _, ok := kept[0].(*v1.List); !ok {
+		t.Errorf("KeepLists object: %T", kept[0])
+	}
+}
+
+func TestStrictRejectsUnknownField(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containres:
+  - name: app
+`
+	if _, err := DecodeString(manifest, Options{}); err != nil {
+		t.Fatalf("lenient decode should drop the typo, got %v", err)
+	}
+	_, err := DecodeString(manifest, Options{Strict: true})
+	if err == nil {
+		t.Fatal("strict decode accepted a typo'd field")
+	}
+	if !strings.Contains(err.Error(), "containres") {
+		t.Errorf("error does not name the unknown field: %v", err)
+	}
+}
+
+func TestStrictRejectsDuplicateKeys(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  key: one
+  key: two
+`
+	_, err := DecodeString(manifest, Options{Strict: true})
+	if err == nil {
+		t.Fatal("strict decode accepted a duplicate key")
+	}
+	if !strings.Contains(err.Error(), "key") {
+		t.Errorf("error does not name the duplicate key: %v", err)
+	}
+}
+
+func TestErrorReportsDocumentStartLine(t *testing.T) {
+	manifest := multiDoc + `---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: bad
+spec:
+  nodename: typo
+`
+	_, err := DecodeString(manifest, Options{Strict: true})
+	if err == nil {
+		t.Fatal("expected a strict decode error")
+	}
+	if !strings.Contains(err.Error(), "document 4") || !strings.Contains(err.Error(), "line 17") {
+		t.Errorf("error does not locate the document: %v", err)
+	}
+}
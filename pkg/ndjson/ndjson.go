@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ndjson writes objects and watch events as newline-delimited JSON,
+// one envelope per line, for piping into jq and log pipelines. The envelope
+// carries kind/namespace/name (and the event type for watch streams) ahead
+// of the full object so shell tooling can filter without parsing payloads.
+package ndjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Envelope is the per-line wrapper around an object or event.
+type Envelope struct {
+	// Event is the watch event type; empty for plain object export.
+	Event string `json:"event,omitempty"`
+	// APIVersion and Kind identify the object when its TypeMeta is
+	// populated.
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Object is the full JSON encoding of the object. Patch-feed lines
+	// carry Patch instead.
+	Object json.RawMessage `json:"object,omitempty"`
+	// Patch is an RFC 6902 JSON Patch against the object state from the
+	// previous line for the same object; set only by PatchWriter.
+	Patch json.RawMessage `json:"patch,omitempty"`
+}
+
+// Writer emits NDJSON lines. It is safe for concurrent use; each line is
+// written atomically.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter returns a writer emitting to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteObject writes one object line.
+func (w *Writer) WriteObject(obj runtime.Object) error {
+	return w.write("", obj)
+}
+
+// WriteEvent writes one watch event line. Error events are encoded with
+// the raw status payload in the object position.
+func (w *Writer) WriteEvent(event watch.Event) error {
+	return w.write(string(event.Type), event.Object)
+}
+
+func (w *Writer) write(eventType string, obj runtime.Object) error {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("encoding object: %v", err)
+	}
+	envelope := Envelope{
+		Event:  eventType,
+		Object: raw,
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	envelope.APIVersion, envelope.Kind = gvk.ToAPIVersionAndKind()
+	if accessor, err := meta.Accessor(obj); err == nil {
+		envelope.Namespace = accessor.GetNamespace()
+		envelope.Name = accessor.GetName()
+	}
+	return w.writeEnvelope(envelope)
+}
+
+func (w *Writer) writeEnvelope(envelope Envelope) error {
+	line, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("encoding envelope: %v", err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.w.Write(line)
+	return err
+}
+
+// Copy drains a watch result channel into the writer until the channel
+// closes, returning the first write error.
+func Copy(w *Writer, events <-chan watch.Event) error {
+	for event := range events {
+		if err := w.WriteEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
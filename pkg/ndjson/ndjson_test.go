@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestWriteObject(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	pod := &v1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+	}
+	if err := w.WriteObject(pod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.WriteObject(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal([]byte(lines[0]), &envelope); err != nil {
+		t.Fatalf("line is not valid JSON: %v", err)
+	}
+	if envelope.Kind != "Pod" || envelope.APIVersion != "v1" {
+		t.Errorf("unexpected type info: %+v", envelope)
+	}
+	if envelope.Namespace != "default" || envelope.Name != "web" || envelope.Event != "" {
+		t.Errorf("unexpected envelope: %+v", envelope)
+	}
+	var pod2 v1.Pod
+	if err := json.Unmarshal(envelope.Object, &pod2); err != nil {
+		t.Fatalf("embedded object is not valid JSON: %v", err)
+	}
+	if pod2.Name != "web" {
+		t.Errorf("embedded object lost data: %+v", pod2)
+	}
+
+	// The node line has no namespace and no type meta set.
+	envelope = Envelope{}
+	if err := json.Unmarshal([]byte(lines[1]), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Namespace != "" || envelope.Name != "node-1" {
+		t.Errorf("unexpected envelope: %+v", envelope)
+	}
+}
+
+func TestWriteEventAndCopy(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	events := make(chan watch.Event, 2)
+	events <- watch.Event{Type: watch.Added, Object: &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+	}}
+	events <- watch.Event{Type: watch.Deleted, Object: &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+	}}
+	close(events)
+
+	if err := Copy(w, events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var first, second Envelope
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.Event != "ADDED" || second.Event != "DELETED" {
+		t.Errorf("unexpected event types: %q %q", first.Event, second.Event)
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ndjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// PatchOptions configures the delta feed.
+type PatchOptions struct {
+	// CheckpointEvery re-emits the full object after this many patch
+	// lines for the same object, bounding how much history a consumer
+	// must replay to reconstruct state. 0 means every 64 patches.
+	CheckpointEvery int
+	// MaxTracked caps how many objects keep a base copy in memory for
+	// diffing. Once the cap is reached, events for new objects fall
+	// back to full-object lines; already-tracked objects keep their
+	// delta stream. 0 means unlimited.
+	MaxTracked int
+}
+
+const defaultCheckpointEvery = 64
+
+// PatchWriter emits a change-data-capture feed: full-object lines for
+// ADDED, DELETED and periodic checkpoints, RFC 6902 JSON Patch lines for
+// the MODIFIED events in between. Lines are written in event order under
+// a single lock, and each patch applies to the state produced by the
+// previous line for the same object — a consumer that applies lines in
+// order is always consistent, and one that starts mid-stream waits for
+// the next full-object line. Deleted objects release their tracked base,
+// so steady-state memory is proportional to the live set.
+type PatchWriter struct {
+	opts PatchOptions
+
+	mu sync.Mutex
+	w  *Writer
+	// bases maps object keys to the JSON of the last emitted state and
+	// the number of patch lines since the last full-object line.
+	bases map[string]*patchBase
+}
+
+type patchBase struct {
+	raw             json.RawMessage
+	sinceCheckpoint int
+}
+
+// NewPatchWriter returns a delta-feed writer emitting to w.
+func NewPatchWriter(w io.Writer, opts PatchOptions) *PatchWriter {
+	if opts.CheckpointEvery <= 0 {
+		opts.CheckpointEvery = defaultCheckpointEvery
+	}
+	return &PatchWriter{
+		opts:  opts,
+		w:     NewWriter(w),
+		bases: map[string]*patchBase{},
+	}
+}
+
+// WriteEvent writes one line for the event: a patch when the object is
+// tracked and no checkpoint is due, the full object otherwise.
+func (p *PatchWriter) WriteEvent(event watch.Event) error {
+	accessor, err := meta.Accessor(event.Object)
+	if err != nil {
+		// Error events and other unkeyable payloads pass through whole.
+		return p.w.WriteEvent(event)
+	}
+	gvk := event.Object.GetObjectKind().GroupVersionKind()
+	key := gvk.Kind + "/" + accessor.GetNamespace() + "/" + accessor.GetName()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event.Type {
+	case watch.Deleted:
+		delete(p.bases, key)
+		return p.w.WriteEvent(event)
+	case watch.Modified:
+		base, tracked := p.bases[key]
+		if !tracked || base.sinceCheckpoint >= p.opts.CheckpointEvery {
+			return p.writeFull(event, key)
+		}
+		raw, err := json.Marshal(event.Object)
+		if err != nil {
+			return fmt.Errorf("encoding object: %v", err)
+		}
+		patch, err := computePatch(base.raw, raw)
+		if err != nil {
+			return fmt.Errorf("diffing %s: %v", key, err)
+		}
+		base.raw = raw
+		base.sinceCheckpoint++
+
+		envelope := Envelope{
+			Event:     string(event.Type),
+			Namespace: accessor.GetNamespace(),
+			Name:      accessor.GetName(),
+			Patch:     patch,
+		}
+		envelope.APIVersion, envelope.Kind = gvk.ToAPIVersionAndKind()
+		return p.w.writeEnvelope(envelope)
+	default:
+		return p.writeFull(event, key)
+	}
+}
+
+// writeFull emits the full object and resets the checkpoint counter,
+// tracking the object unless the cap forbids it. Callers hold p.mu.
+func (p *PatchWriter) writeFull(event watch.Event, key string) error {
+	raw, err := json.Marshal(event.Object)
+	if err != nil {
+		return fmt.Errorf("encoding object: %v", err)
+	}
+	if _, tracked := p.bases[key]; tracked || p.opts.MaxTracked == 0 || len(p.bases) < p.opts.MaxTracked {
+		p.bases[key] = &patchBase{raw: raw}
+	}
+	return p.w.WriteEvent(event)
+}
+
+// CopyPatches drains a watch result channel into the patch writer until
+// the channel closes, returning the first write error.
+func CopyPatches(w *PatchWriter, events <-chan watch.Event) error {
+	for event := range events {
+		if err := w.WriteEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// computePatch builds an RFC 6902 patch turning old into new. Objects
+// are diffed member by member; arrays of equal length are diffed per
+// index and replaced wholesale otherwise, trading patch minimality for
+// predictable output.
+func computePatch(old, new json.RawMessage) (json.RawMessage, error) {
+	var oldVal, newVal interface{}
+	if err := json.Unmarshal(old, &oldVal); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(new, &newVal); err != nil {
+		return nil, err
+	}
+	ops := diffValues(oldVal, newVal, "")
+	if ops == nil {
+		// An event that changed nothing still gets a line, so every
+		// upstream event maps to exactly one feed line in order.
+		ops = []patchOp{}
+	}
+	return json.Marshal(ops)
+}
+
+// patchOp is one RFC 6902 operation. Value is a pointer so remove ops
+// omit it while add/replace can still carry false, 0 and null.
+type patchOp struct {
+	Op    string       `json:"op"`
+	Path  string       `json:"path"`
+	Value *interface{} `json:"value,omitempty"`
+}
+
+func opValue(v interface{}) *interface{} { return &v }
+
+func diffValues(old, new interface{}, path string) []patchOp {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		return diffObjects(oldMap, newMap, path)
+	}
+	oldSlice, oldIsSlice := old.([]interface{})
+	newSlice, newIsSlice := new.([]interface{})
+	if oldIsSlice && newIsSlice && len(oldSlice) == len(newSlice) {
+		var ops []patchOp
+		for i := range oldSlice {
+			ops = append(ops, diffValues(oldSlice[i], newSlice[i], path+"/"+strconv.Itoa(i))...)
+		}
+		return ops
+	}
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	return []patchOp{{Op: "replace", Path: path, Value: opValue(new)}}
+}
+
+// diffObjects walks members in sorted key order so identical changes
+// always produce byte-identical patches.
+func diffObjects(old, new map[string]interface{}, path string) []patchOp {
+	var ops []patchOp
+	for _, key := range sortedKeys(old) {
+		newVal, ok := new[key]
+		if !ok {
+			ops = append(ops, patchOp{Op: "remove", Path: path + "/" + escapePointer(key)})
+			continue
+		}
+		ops = append(ops, diffValues(old[key], newVal, path+"/"+escapePointer(key))...)
+	}
+	for _, key := range sortedKeys(new) {
+		if _, ok := old[key]; !ok {
+			ops = append(ops, patchOp{Op: "add", Path: path + "/" + escapePointer(key), Value: opValue(new[key])})
+		}
+	}
+	return ops
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointer applies the RFC 6901 token escapes.
+func escapePointer(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func feedLines(t *testing.T, buf *bytes.Buffer) []Envelope {
+	t.Helper()
+	var envelopes []Envelope
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var envelope Envelope
+		if err := json.Unmarshal([]byte(line), &envelope); err != nil {
+			t.Fatalf("line is not valid JSON: %v", err)
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes
+}
+
+// replayFeed reconstructs the final state of one object by applying the
+// feed in order, the way a CDC consumer would.
+func replayFeed(t *testing.T, envelopes []Envelope) json.RawMessage {
+	t.Helper()
+	var state json.RawMessage
+	for _, envelope := range envelopes {
+		if envelope.Object != nil {
+			state = envelope.Object
+			continue
+		}
+		patch, err := jsonpatch.DecodePatch(envelope.Patch)
+		if err != nil {
+			t.Fatalf("invalid patch %s: %v", envelope.Patch, err)
+		}
+		state, err = patch.Apply(state)
+		if err != nil {
+			t.Fatalf("applying %s: %v", envelope.Patch, err)
+		}
+	}
+	return state
+}
+
+func podAt(labels map[string]string, rv string) *v1.Pod {
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "web",
+			Labels:          labels,
+			ResourceVersion: rv,
+		},
+	}
+}
+
+func TestPatchWriterEmitsDeltas(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPatchWriter(&buf, PatchOptions{})
+
+	events := []watch.Event{
+		{Type: watch.Added, Object: podAt(map[string]string{"app": "web"}, "1")},
+		{Type: watch.Modified, Object: podAt(map[string]string{"app": "web", "tier": "fe"}, "2")},
+		{Type: watch.Modified, Object: podAt(map[string]string{"app": "api"}, "3")},
+	}
+	for _, event := range events {
+		if err := w.WriteEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	envelopes := feedLines(t, &buf)
+	if len(envelopes) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(envelopes))
+	}
+	if envelopes[0].Object == nil || envelopes[0].Patch != nil {
+		t.Errorf("ADDED must carry the full object: %+v", envelopes[0])
+	}
+	for _, envelope := range envelopes[1:] {
+		if envelope.Patch == nil || envelope.Object != nil {
+			t.Errorf("MODIFIED must carry a patch: %+v", envelope)
+		}
+		if envelope.Kind != "Pod" || envelope.Name != "web" {
+			t.Errorf("patch line lost addressing: %+v", envelope)
+		}
+	}
+
+	var replayed v1.Pod
+	if err := json.Unmarshal(replayFeed(t, envelopes), &replayed); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.ResourceVersion != "3" || replayed.Labels["app"] != "api" || replayed.Labels["tier"] != "" {
+		t.Errorf("replayed state diverged: %+v", replayed.ObjectMeta)
+	}
+}
+
+func TestPatchWriterCheckpoints(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPatchWriter(&buf, PatchOptions{CheckpointEvery: 2})
+
+	if err := w.WriteEvent(watch.Event{Type: watch.Added, Object: podAt(nil, "1")}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i <= 6; i++ {
+		event := watch.Event{Type: watch.Modified, Object: podAt(nil, strings.Repeat("x", i))}
+		if err := w.WriteEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var shapes []string
+	for _, envelope := range feedLines(t, &buf) {
+		if envelope.Object != nil {
+			shapes = append(shapes, "full")
+		} else {
+			shapes = append(shapes, "patch")
+		}
+	}
+	// Full on ADDED, then a checkpoint after every second patch.
+	want := []string{"full", "patch", "patch", "full", "patch", "patch"}
+	if strings.Join(shapes, ",") != strings.Join(want, ",") {
+		t.Errorf("feed shape = %v, want %v", shapes, want)
+	}
+}
+
+func TestPatchWriterDeleteReleasesBase(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPatchWriter(&buf, PatchOptions{})
+
+	for _, event := range []watch.Event{
+		{Type: watch.Added, Object: podAt(nil, "1")},
+		{Type: watch.Deleted, Object: podAt(nil, "2")},
+		{Type: watch.Added, Object: podAt(nil, "3")},
+	} {
+		if err := w.WriteEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(w.bases) != 1 {
+		t.Errorf("expected one tracked base after delete and re-add, got %d", len(w.bases))
+	}
+	for _, envelope := range feedLines(t, &buf) {
+		if envelope.Object == nil {
+			t.Errorf("delete/re-add must stay full-object: %+v", envelope)
+		}
+	}
+}
+
+func TestPatchWriterMaxTracked(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPatchWriter(&buf, PatchOptions{MaxTracked: 1})
+
+	other := &v1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api", ResourceVersion: "1"},
+	}
+	for _, event := range []watch.Event{
+		{Type: watch.Added, Object: podAt(nil, "1")},
+		{Type: watch.Added, Object: other},
+		{Type: watch.Modified, Object: other},
+	} {
+		if err := w.WriteEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	envelopes := feedLines(t, &buf)
+	// The second pod is over the cap: its MODIFIED stays a full object.
+	if envelopes[2].Object == nil {
+		t.Errorf("untracked object must fall back to full lines: %+v", envelopes[2])
+	}
+}
+
+func TestComputePatchShapes(t *testing.T) {
+	testCases := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "scalar replace",
+			old:  `{"a":1}`,
+			new:  `{"a":2}`,
+			want: `[{"op":"replace","path":"/a","value":2}]`,
+		},
+		{
+			name: "add and remove",
+			old:  `{"a":1}`,
+			new:  `{"b":true}`,
+			want: `[{"op":"remove","path":"/a"},{"op":"add","path":"/b","value":true}]`,
+		},
+		{
+			name: "nested map",
+			old:  `{"m":{"x":"1","y":"2"}}`,
+			new:  `{"m":{"x":"1","y":"3"}}`,
+			want: `[{"op":"replace","path":"/m/y","value":"3"}]`,
+		},
+		{
+			name: "equal length array diffs per index",
+			old:  `{"a":[1,2,3]}`,
+			new:  `{"a":[1,9,3]}`,
+			want: `[{"op":"replace","path":"/a/1","value":9}]`,
+		},
+		{
+			name: "resized array replaced whole",
+			old:  `{"a":[1,2]}`,
+			new:  `{"a":[1]}`,
+			want: `[{"op":"replace","path":"/a","value":[1]}]`,
+		},
+		{
+			name: "pointer tokens escaped",
+			old:  `{"a/b":1}`,
+			new:  `{"a/b":2}`,
+			want: `[{"op":"replace","path":"/a~1b","value":2}]`,
+		},
+		{
+			name: "no change emits empty patch",
+			old:  `{"a":1}`,
+			new:  `{"a":1}`,
+			want: `[]`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := computePatch(json.RawMessage(tc.old), json.RawMessage(tc.new))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patch) != tc.want {
+				t.Errorf("patch = %s, want %s", patch, tc.want)
+			}
+			decoded, err := jsonpatch.DecodePatch(patch)
+			if err != nil {
+				t.Fatalf("patch does not decode: %v", err)
+			}
+			applied, err := decoded.Apply([]byte(tc.old))
+			if err != nil {
+				t.Fatalf("patch does not apply: %v", err)
+			}
+			if !jsonpatch.Equal(applied, []byte(tc.new)) {
+				t.Errorf("applied = %s, want %s", applied, tc.new)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nodetrends tracks Node conditions and allocatable capacity over
+// time from watch events. A single NodeStatus is a point-in-time reading;
+// this package keeps a bounded window of readings per node and derives the
+// two signals a snapshot cannot show: conditions that flap between states,
+// and allocatable capacity that erodes slowly across updates.
+package nodetrends
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// trackedConditions are the condition types worth trending; other
+// conditions (from node problem detectors etc.) are ignored.
+var trackedConditions = []v1.NodeConditionType{
+	v1.NodeReady,
+	v1.NodeMemoryPressure,
+	v1.NodeDiskPressure,
+	v1.NodePIDPressure,
+}
+
+// trackedResources are the allocatable resources sampled for erosion.
+var trackedResources = []v1.ResourceName{
+	v1.ResourceCPU,
+	v1.ResourceMemory,
+	v1.ResourcePods,
+	v1.ResourceEphemeralStorage,
+}
+
+const (
+	// defaultMaxSamples bounds memory per node series.
+	defaultMaxSamples = 256
+	// flapThreshold is the number of status transitions inside the window
+	// at which a condition counts as flapping.
+	flapThreshold = 3
+)
+
+// ConditionSample is one observed condition status.
+type ConditionSample struct {
+	Time   time.Time
+	Status v1.ConditionStatus
+}
+
+// AllocatableSample is one observed allocatable quantity.
+type AllocatableSample struct {
+	Time     time.Time
+	Quantity resource.Quantity
+}
+
+// Flap reports a condition changing status repeatedly inside the window.
+type Flap struct {
+	Node        string
+	Condition   v1.NodeConditionType
+	Transitions int
+	// Last is the most recently observed status.
+	Last v1.ConditionStatus
+}
+
+// Erosion reports allocatable capacity that shrank across the window.
+type Erosion struct {
+	Node     string
+	Resource v1.ResourceName
+	First    resource.Quantity
+	Last     resource.Quantity
+}
+
+type nodeSeries struct {
+	conditions  map[v1.NodeConditionType][]ConditionSample
+	allocatable map[v1.ResourceName][]AllocatableSample
+}
+
+// Tracker accumulates node samples inside a sliding time window. It is
+// safe for concurrent use.
+type Tracker struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxSamples int
+	nodes      map[string]*nodeSeries
+}
+
+// New returns a tracker keeping samples for the given window.
+func New(window time.Duration) *Tracker {
+	return &Tracker{
+		window:     window,
+		maxSamples: defaultMaxSamples,
+		nodes:      map[string]*nodeSeries{},
+	}
+}
+
+// Observe records one node status reading taken at the given time. Feed it
+// from Added and Modified watch events.
+func (t *Tracker) Observe(node *v1.Node, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	series := t.nodes[node.Name]
+	if series == nil {
+		series = &nodeSeries{
+			conditions:  map[v1.NodeConditionType][]ConditionSample{},
+			allocatable: map[v1.ResourceName][]AllocatableSample{},
+		}
+		t.nodes[node.Name] = series
+	}
+
+	for _, conditionType := range trackedConditions {
+		status := v1.ConditionUnknown
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == conditionType {
+				status = condition.Status
+				break
+			}
+		}
+		samples := append(series.conditions[conditionType], ConditionSample{Time: at, Status: status})
+		series.conditions[conditionType] = t.prune(samples, at)
+	}
+
+	for _, resourceName := range trackedResources {
+		quantity, present := node.Status.Allocatable[resourceName]
+		if !present {
+			continue
+		}
+		samples := append(series.allocatable[resourceName], AllocatableSample{Time: at, Quantity: quantity.DeepCopy()})
+		series.allocatable[resourceName] = t.pruneAllocatable(samples, at)
+	}
+}
+
+// Forget drops all series for a deleted node.
+func (t *Tracker) Forget(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.nodes, name)
+}
+
+// Flapping returns conditions that changed status at least flapThreshold
+// times inside the window ending at now, sorted by node then condition.
+func (t *Tracker) Flapping(now time.Time) []Flap {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var flaps []Flap
+	for name, series := range t.nodes {
+		for _, conditionType := range trackedConditions {
+			samples := inWindow(series.conditions[conditionType], now.Add(-t.window))
+			transitions := 0
+			for i := 1; i < len(samples); i++ {
+				if samples[i].Status != samples[i-1].Status {
+					transitions++
+				}
+			}
+			if transitions >= flapThreshold {
+				flaps = append(flaps, Flap{
+					Node:        name,
+					Condition:   conditionType,
+					Transitions: transitions,
+					Last:        samples[len(samples)-1].Status,
+				})
+			}
+		}
+	}
+	sort.Slice(flaps, func(i, j int) bool {
+		if flaps[i].Node != flaps[j].Node {
+			return flaps[i].Node < flaps[j].Node
+		}
+		return flaps[i].Condition < flaps[j].Condition
+	})
+	return flaps
+}
+
+// Erosions returns resources whose allocatable shrank between the first
+// and last sample in the window ending at now.
+func (t *Tracker) Erosions(now time.Time) []Erosion {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var erosions []Erosion
+	for name, series := range t.nodes {
+		for _, resourceName := range trackedResources {
+			samples := allocatableInWindow(series.allocatable[resourceName], now.Add(-t.window))
+			if len(samples) < 2 {
+				continue
+			}
+			first, last := samples[0].Quantity, samples[len(samples)-1].Quantity
+			if last.Cmp(first) < 0 {
+				erosions = append(erosions, Erosion{
+					Node:     name,
+					Resource: resourceName,
+					First:    first,
+					Last:     last,
+				})
+			}
+		}
+	}
+	sort.Slice(erosions, func(i, j int) bool {
+		if erosions[i].Node != erosions[j].Node {
+			return erosions[i].Node < erosions[j].Node
+		}
+		return erosions[i].Resource < erosions[j].Resource
+	})
+	return erosions
+}
+
+// ConditionSeries returns a copy of the samples for one node condition,
+// oldest first, for export.
+func (t *Tracker) ConditionSeries(node string, condition v1.NodeConditionType) []ConditionSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	series := t.nodes[node]
+	if series == nil {
+		return nil
+	}
+	return append([]ConditionSample{}, series.conditions[condition]...)
+}
+
+// AllocatableSeries returns a copy of the samples for one node resource,
+// oldest first, for export.
+func (t *Tracker) AllocatableSeries(node string, name v1.ResourceName) []AllocatableSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	series := t.nodes[node]
+	if series == nil {
+		return nil
+	}
+	return append([]AllocatableSample{}, series.allocatable[name]...)
+}
+
+// prune drops samples outside the window and enforces the sample cap.
+func (t *Tracker) prune(samples []ConditionSample, now time.Time) []ConditionSample {
+	samples = inWindow(samples, now.Add(-t.window))
+	if len(samples) > t.maxSamples {
+		samples = samples[len(samples)-t.maxSamples:]
+	}
+	return samples
+}
+
+func (t *Tracker) pruneAllocatable(samples []AllocatableSample, now time.Time) []AllocatableSample {
+	samples = allocatableInWindow(samples, now.Add(-t.window))
+	if len(samples) > t.maxSamples {
+		samples = samples[len(samples)-t.maxSamples:]
+	}
+	return samples
+}
+
+func inWindow(samples []ConditionSample, cutoff time.Time) []ConditionSample {
+	for i, sample := range samples {
+		if !sample.Time.Before(cutoff) {
+			return samples[i:]
+		}
+	}
+	return nil
+}
+
+func allocatableInWindow(samples []AllocatableSample, cutoff time.Time) []AllocatableSample {
+	for i, sample := range samples {
+		if !sample.Time.Before(cutoff) {
+			return samples[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodetrends
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func nodeWith(name string, ready v1.ConditionStatus, memory string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: ready},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse},
+			},
+			Allocatable: v1.ResourceList{
+				v1.ResourceMemory: resource.MustParse(memory),
+				v1.ResourceCPU:    resource.MustParse("4"),
+			},
+		},
+	}
+}
+
+func TestFlapping(t *testing.T) {
+	tracker := New(10 * time.Minute)
+	base := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	statuses := []v1.ConditionStatus{
+		v1.ConditionTrue, v1.ConditionFalse, v1.ConditionTrue, v1.ConditionFalse,
+	}
+	for i, status := range statuses {
+		tracker.Observe(nodeWith("node-1", status, "8Gi"), base.Add(time.Duration(i)*time.Minute))
+	}
+	// A stable node must not be reported.
+	for i := 0; i < 4; i++ {
+		tracker.Observe(nodeWith("node-2", v1.ConditionTrue, "8Gi"), base.Add(time.Duration(i)*time.Minute))
+	}
+
+	flaps := tracker.Flapping(base.Add(4 * time.Minute))
+	if len(flaps) != 1 {
+		t.Fatalf("expected 1 flap, got %+v", flaps)
+	}
+	flap := flaps[0]
+	if flap.Node != "node-1" || flap.Condition != v1.NodeReady || flap.Transitions != 3 || flap.Last != v1.ConditionFalse {
+		t.Errorf("unexpected flap: %+v", flap)
+	}
+}
+
+func TestFlappingOutsideWindowIgnored(t *testing.T) {
+	tracker := New(2 * time.Minute)
+	base := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// All transitions happen early, then the condition is stable; by the
+	// time we ask, the flapping samples have aged out.
+	statuses := []v1.ConditionStatus{
+		v1.ConditionTrue, v1.ConditionFalse, v1.ConditionTrue, v1.ConditionFalse,
+	}
+	for i, status := range statuses {
+		tracker.Observe(nodeWith("node-1", status, "8Gi"), base.Add(time.Duration(i)*time.Second))
+	}
+	tracker.Observe(nodeWith("node-1", v1.ConditionFalse, "8Gi"), base.Add(5*time.Minute))
+
+	if flaps := tracker.Flapping(base.Add(5 * time.Minute)); len(flaps) != 0 {
+		t.Errorf("expected no flaps after window aged out, got %+v", flaps)
+	}
+}
+
+func TestErosions(t *testing.T) {
+	tracker := New(time.Hour)
+	base := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.Observe(nodeWith("node-1", v1.ConditionTrue, "8Gi"), base)
+	tracker.Observe(nodeWith("node-1", v1.ConditionTrue, "7500Mi"), base.Add(10*time.Minute))
+	tracker.Observe(nodeWith("node-1", v1.ConditionTrue, "7Gi"), base.Add(20*time.Minute))
+	// Stable allocatable elsewhere.
+	tracker.Observe(nodeWith("node-2", v1.ConditionTrue, "8Gi"), base)
+	tracker.Observe(nodeWith("node-2", v1.ConditionTrue, "8Gi"), base.Add(20*time.Minute))
+
+	erosions := tracker.Erosions(base.Add(20 * time.Minute))
+	if len(erosions) != 1 {
+		t.Fatalf("expected 1 erosion, got %+v", erosions)
+	}
+	e := erosions[0]
+	if e.Node != "node-1" || e.Resource != v1.ResourceMemory {
+		t.Errorf("unexpected erosion: %+v", e)
+	}
+	if e.First.String() != "8Gi" || e.Last.String() != "7Gi" {
+		t.Errorf("unexpected quantities: first=%s last=%s", e.First.String(), e.Last.String())
+	}
+}
+
+func TestSeriesExportAndForget(t *testing.T) {
+	tracker := New(time.Hour)
+	base := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.Observe(nodeWith("node-1", v1.ConditionTrue, "8Gi"), base)
+	tracker.Observe(nodeWith("node-1", v1.ConditionFalse, "8Gi"), base.Add(time.Minute))
+
+	series := tracker.ConditionSeries("node-1", v1.NodeReady)
+	if len(series) != 2 || series[0].Status != v1.ConditionTrue || series[1].Status != v1.ConditionFalse {
+		t.Fatalf("unexpected condition series: %+v", series)
+	}
+	if allocs := tracker.AllocatableSeries("node-1", v1.ResourceCPU); len(allocs) != 2 {
+		t.Fatalf("unexpected allocatable series: %+v", allocs)
+	}
+
+	tracker.Forget("node-1")
+	if series := tracker.ConditionSeries("node-1", v1.NodeReady); series != nil {
+		t.Errorf("expected no series after Forget, got %+v", series)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package normalize ships reusable normalization profiles that clear flaky
+// fields before diff or hash operations: server-managed metadata, status,
+// autoscaler-managed replica counts, kubectl bookkeeping annotations, and
+// so on. Profiles live in a registry and support per-kind overrides, so
+// diff consumers share one set of ignore lists instead of each maintaining
+// their own.
+package normalize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Override adds kind-specific rules on top of a profile's base rules.
+type Override struct {
+	// RemovePaths lists dot-separated field paths to delete.
+	RemovePaths []string
+	// RemoveAnnotationPrefixes lists annotation key prefixes to delete.
+	RemoveAnnotationPrefixes []string
+}
+
+// Profile is a named set of normalization rules.
+type Profile struct {
+	// Name identifies the profile in the registry.
+	Name string
+	// RemovePaths lists dot-separated field paths to delete from every
+	// kind, e.g. "status" or "metadata.managedFields".
+	RemovePaths []string
+	// RemoveAnnotationPrefixes lists annotation key prefixes to delete
+	// from every kind.
+	RemoveAnnotationPrefixes []string
+	// KindOverrides adds rules for specific kinds.
+	KindOverrides map[schema.GroupKind]Override
+}
+
+// Apply returns a normalized deep copy of the object's unstructured form.
+// The group/kind selects any per-kind overrides; it must be provided by the
+// caller because typed objects often carry no TypeMeta.
+func (p *Profile) Apply(obj map[string]interface{}, gk schema.GroupKind) map[string]interface{} {
+	u := &unstructured.Unstructured{Object: obj}
+	normalized := u.DeepCopy().Object
+
+	removePaths := p.RemovePaths
+	annotationPrefixes := p.RemoveAnnotationPrefixes
+	if override, ok := p.KindOverrides[gk]; ok {
+		removePaths = append(append([]string{}, removePaths...), override.RemovePaths...)
+		annotationPrefixes = append(append([]string{}, annotationPrefixes...), override.RemoveAnnotationPrefixes...)
+	}
+
+	for _, path := range removePaths {
+		unstructured.RemoveNestedField(normalized, strings.Split(path, ".")...)
+	}
+	if len(annotationPrefixes) > 0 {
+		annotations, found, _ := unstructured.NestedStringMap(normalized, "metadata", "annotations")
+		if found {
+			for key := range annotations {
+				for _, prefix := range annotationPrefixes {
+					if strings.HasPrefix(key, prefix) {
+						delete(annotations, key)
+						break
+					}
+				}
+			}
+			if len(annotations) == 0 {
+				unstructured.RemoveNestedField(normalized, "metadata", "annotations")
+			} else {
+				unstructured.SetNestedStringMap(normalized, annotations, "metadata", "annotations")
+			}
+		}
+	}
+	return normalized
+}
+
+// ToMap converts a typed or unstructured object to the map form Apply
+// consumes.
+func ToMap(obj runtime.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy().Object, nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+// Registry holds named profiles. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	profiles map[string]*Profile
+}
+
+// NewRegistry returns a registry pre-populated with the built-in profiles.
+func NewRegistry() *Registry {
+	r := &Registry{profiles: map[string]*Profile{}}
+	for _, p := range builtinProfiles() {
+		// Built-ins are constructed here; registration cannot collide.
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds a profile. Registering a name twice is an error so that
+// profile behavior cannot change silently at a distance.
+func (r *Registry) Register(p *Profile) error {
+	if p.Name == "" {
+		return fmt.Errorf("profile name must not be empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.profiles[p.Name]; exists {
+		return fmt.Errorf("profile %q already registered", p.Name)
+	}
+	r.profiles[p.Name] = p
+	return nil
+}
+
+// Get returns the named profile.
+func (r *Registry) Get(name string) (*Profile, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.profiles[name]
+	return p, ok
+}
+
+// Names returns the registered profile names, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Built-in profile names.
+const (
+	// ProfileServerManaged clears fields the server rewrites on every
+	// round trip; nearly every diff wants this as a baseline.
+	ProfileServerManaged = "server-managed"
+	// ProfileIgnoreStatus additionally clears the whole status subtree.
+	ProfileIgnoreStatus = "ignore-status"
+	// ProfileIgnoreAutoscaled additionally clears replica fields managed
+	// by autoscalers on scalable workload kinds.
+	ProfileIgnoreAutoscaled = "ignore-autoscaled"
+	// ProfileIgnoreKubectl clears kubectl bookkeeping annotations.
+	ProfileIgnoreKubectl = "ignore-kubectl"
+)
+
+func builtinProfiles() []*Profile {
+	serverManagedPaths := []string{
+		"metadata.managedFields",
+		"metadata.resourceVersion",
+		"metadata.generation",
+		"metadata.creationTimestamp",
+		"metadata.uid",
+	}
+	replicaOverrides := map[schema.GroupKind]Override{
+		{Group: "apps", Kind: "Deployment"}:  {RemovePaths: []string{"spec.replicas"}},
+		{Group: "apps", Kind: "ReplicaSet"}:  {RemovePaths: []string{"spec.replicas"}},
+		{Group: "apps", Kind: "StatefulSet"}: {RemovePaths: []string{"spec.replicas"}},
+	}
+	return []*Profile{
+		{
+			Name:        ProfileServerManaged,
+			RemovePaths: serverManagedPaths,
+		},
+		{
+			Name:        ProfileIgnoreStatus,
+			RemovePaths: append([]string{"status"}, serverManagedPaths...),
+		},
+		{
+			Name:          ProfileIgnoreAutoscaled,
+			RemovePaths:   serverManagedPaths,
+			KindOverrides: replicaOverrides,
+		},
+		{
+			Name:        ProfileIgnoreKubectl,
+			RemovePaths: serverManagedPaths,
+			RemoveAnnotationPrefixes: []string{
+				"kubectl.kubernetes.io/",
+				"deployment.kubernetes.io/revision",
+			},
+		},
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package normalize
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func deploymentMap(t *testing.T) map[string]interface{} {
+	t.Helper()
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "web",
+			ResourceVersion: "123",
+			Generation:      7,
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				"team": "payments",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(5)},
+		Status: appsv1.DeploymentStatus{
+			ReadyReplicas: 5,
+		},
+	}
+	m, err := ToMap(deployment)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+var deploymentGK = schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+func nestedExists(t *testing.T, obj map[string]interface{}, fields ...string) bool {
+	t.Helper()
+	_, found, err := unstructured.NestedFieldNoCopy(obj, fields...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return found
+}
+
+func TestBuiltinProfiles(t *testing.T) {
+	registry := NewRegistry()
+
+	t.Run(ProfileServerManaged, func(t *testing.T) {
+		profile, _ := registry.Get(ProfileServerManaged)
+		got := profile.Apply(deploymentMap(t), deploymentGK)
+		if nestedExists(t, got, "metadata", "resourceVersion") {
+			t.Error("expected resourceVersion removed")
+		}
+		if !nestedExists(t, got, "status") || !nestedExists(t, got, "spec", "replicas") {
+			t.Error("expected status and replicas kept")
+		}
+	})
+
+	t.Run(ProfileIgnoreStatus, func(t *testing.T) {
+		profile, _ := registry.Get(ProfileIgnoreStatus)
+		got := profile.Apply(deploymentMap(t), deploymentGK)
+		if nestedExists(t, got, "status") {
+			t.Error("expected status removed")
+		}
+	})
+
+	t.Run(ProfileIgnoreAutoscaled, func(t *testing.T) {
+		profile, _ := registry.Get(ProfileIgnoreAutoscaled)
+		got := profile.Apply(deploymentMap(t), deploymentGK)
+		if nestedExists(t, got, "spec", "replicas") {
+			t.Error("expected spec.replicas removed for Deployment")
+		}
+
+		// The override is per kind: a Pod keeps its spec untouched.
+		pod, err := ToMap(&v1.Pod{Spec: v1.PodSpec{NodeName: "n"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = profile.Apply(pod, schema.GroupKind{Kind: "Pod"})
+		if !nestedExists(t, got, "spec", "nodeName") {
+			t.Error("expected pod spec untouched")
+		}
+	})
+
+	t.Run(ProfileIgnoreKubectl, func(t *testing.T) {
+		profile, _ := registry.Get(ProfileIgnoreKubectl)
+		got := profile.Apply(deploymentMap(t), deploymentGK)
+		annotations, _, _ := unstructured.NestedStringMap(got, "metadata", "annotations")
+		if _, exists := annotations["kubectl.kubernetes.io/last-applied-configuration"]; exists {
+			t.Error("expected kubectl annotation removed")
+		}
+		if annotations["team"] != "payments" {
+			t.Errorf("expected unrelated annotation kept, got %v", annotations)
+		}
+	})
+}
+
+func TestApplyDoesNotMutateInput(t *testing.T) {
+	registry := NewRegistry()
+	profile, _ := registry.Get(ProfileIgnoreStatus)
+	input := deploymentMap(t)
+	profile.Apply(input, deploymentGK)
+	if !nestedExists(t, input, "status") {
+		t.Error("Apply must not mutate its input")
+	}
+}
+
+func TestRegistryRegistration(t *testing.T) {
+	registry := NewRegistry()
+	custom := &Profile{Name: "custom", RemovePaths: []string{"spec"}}
+	if err := registry.Register(custom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(custom); err == nil {
+		t.Error("expected duplicate registration to fail")
+	}
+	if err := registry.Register(&Profile{}); err == nil {
+		t.Error("expected unnamed profile to be rejected")
+	}
+	if _, ok := registry.Get("custom"); !ok {
+		t.Error("expected custom profile retrievable")
+	}
+	names := registry.Names()
+	if len(names) != 5 {
+		t.Errorf("expected 4 builtins plus custom, got %v", names)
+	}
+}
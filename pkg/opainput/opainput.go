@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package opainput converts API objects into stable input documents for
+// Open Policy Agent evaluation. The document shape is deliberately flat and
+// lowerCamel-cased so Rego policies can address fields without knowing Go
+// naming, and it denormalizes the lookups policies otherwise have to do
+// themselves: the controlling owner and the namespace's labels. The OPA
+// engine itself stays behind the Evaluator interface; this package only
+// standardizes what goes into it.
+package opainput
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Owner is the denormalized controlling owner of an object.
+type Owner struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+}
+
+// Document is the OPA input for one object. Field names are part of the
+// policy contract; do not rename them.
+type Document struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Name       string            `json:"name"`
+	UID        string            `json:"uid,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	// Owner is the controlling owner reference, if any.
+	Owner *Owner `json:"owner,omitempty"`
+	// NamespaceLabels are the labels of the object's namespace, when the
+	// converter has a namespace source.
+	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty"`
+	// Object is the full object in unstructured (JSON) form.
+	Object map[string]interface{} `json:"object"`
+}
+
+// NamespaceGetter supplies namespaces for label denormalization, typically
+// backed by a lister. It may be nil.
+type NamespaceGetter func(name string) (*v1.Namespace, error)
+
+// Converter builds documents from objects.
+type Converter struct {
+	// Namespaces, when set, is used to attach namespaceLabels.
+	Namespaces NamespaceGetter
+}
+
+// Convert produces the input document for one object. The object's
+// TypeMeta must be populated (cached objects from the dynamic client and
+// decoded objects both qualify).
+func (c *Converter) Convert(obj runtime.Object) (Document, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		return Document{}, fmt.Errorf("object has no type information; populate TypeMeta before converting")
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return Document{}, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return Document{}, fmt.Errorf("converting %s %s: %v", gvk.Kind, accessor.GetName(), err)
+	}
+
+	doc := Document{
+		Namespace: accessor.GetNamespace(),
+		Name:      accessor.GetName(),
+		UID:       string(accessor.GetUID()),
+		Labels:    accessor.GetLabels(),
+		Object:    content,
+	}
+	doc.APIVersion, doc.Kind = gvk.ToAPIVersionAndKind()
+
+	for _, ref := range accessor.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			doc.Owner = &Owner{
+				APIVersion: ref.APIVersion,
+				Kind:       ref.Kind,
+				Name:       ref.Name,
+				UID:        string(ref.UID),
+			}
+			break
+		}
+	}
+
+	if c.Namespaces != nil && doc.Namespace != "" {
+		namespace, err := c.Namespaces(doc.Namespace)
+		if err != nil {
+			return Document{}, fmt.Errorf("resolving namespace %s: %v", doc.Namespace, err)
+		}
+		doc.NamespaceLabels = namespace.Labels
+	}
+	return doc, nil
+}
+
+// Decision is one policy verdict.
+type Decision struct {
+	Allow bool
+	// Reasons explain a deny; empty on allow.
+	Reasons []string
+}
+
+// Evaluator evaluates one input document. Implementations wrap an OPA SDK
+// instance or a remote OPA's data API.
+type Evaluator interface {
+	Eval(ctx context.Context, input Document) (Decision, error)
+}
+
+// Result pairs a document with its decision.
+type Result struct {
+	Document Document
+	Decision Decision
+}
+
+// Runner feeds cached objects through conversion and evaluation.
+type Runner struct {
+	Converter *Converter
+	Evaluator Evaluator
+}
+
+// EvaluateAll converts and evaluates every object, stopping at the first
+// conversion or evaluation error.
+func (r *Runner) EvaluateAll(ctx context.Context, objs []runtime.Object) ([]Result, error) {
+	results := make([]Result, 0, len(objs))
+	for _, obj := range objs {
+		doc, err := r.Converter.Convert(obj)
+		if err != nil {
+			return results, err
+		}
+		decision, err := r.Evaluator.Eval(ctx, doc)
+		if err != nil {
+			return results, fmt.Errorf("evaluating %s %s/%s: %v", doc.Kind, doc.Namespace, doc.Name, err)
+		}
+		results = append(results, Result{Document: doc, Decision: decision})
+	}
+	return results, nil
+}
+
+// Denials filters results down to denied documents.
+func Denials(results []Result) []Result {
+	var denied []Result
+	for _, result := range results {
+		if !result.Decision.Allow {
+			denied = append(denied, result)
+		}
+	}
+	return denied
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opainput
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testPod() *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "prod",
+			Name:      "web-abc123-x",
+			UID:       types.UID("uid-1"),
+			Labels:    map[string]string{"app": "web"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "web-abc123",
+				UID:        types.UID("uid-rs"),
+				Controller: &controller,
+			}},
+		},
+		Spec: v1.PodSpec{NodeName: "node-1"},
+	}
+}
+
+func TestConvert(t *testing.T) {
+	converter := &Converter{
+		Namespaces: func(name string) (*v1.Namespace, error) {
+			return &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{"env": "production"},
+				},
+			}, nil
+		},
+	}
+
+	doc, err := converter.Convert(testPod())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Kind != "Pod" || doc.APIVersion != "v1" || doc.Namespace != "prod" || doc.Name != "web-abc123-x" {
+		t.Errorf("unexpected identity: %+v", doc)
+	}
+	if doc.Owner == nil || doc.Owner.Kind != "ReplicaSet" || doc.Owner.Name != "web-abc123" {
+		t.Errorf("unexpected owner: %+v", doc.Owner)
+	}
+	if doc.NamespaceLabels["env"] != "production" {
+		t.Errorf("expected namespace labels, got %+v", doc.NamespaceLabels)
+	}
+
+	// The document must serialize with the stable lowerCamel contract.
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"apiVersion", "kind", "namespace", "name", "uid", "labels", "owner", "namespaceLabels", "object"} {
+		if _, present := decoded[key]; !present {
+			t.Errorf("expected key %q in serialized document", key)
+		}
+	}
+	spec, ok := decoded["object"].(map[string]interface{})["spec"].(map[string]interface{})
+	if !ok || spec["nodeName"] != "node-1" {
+		t.Errorf("object payload not in JSON form: %+v", decoded["object"])
+	}
+}
+
+func TestConvertRequiresTypeMeta(t *testing.T) {
+	converter := &Converter{}
+	pod := testPod()
+	pod.TypeMeta = metav1.TypeMeta{}
+	if _, err := converter.Convert(pod); err == nil {
+		t.Fatal("expected error for object without TypeMeta")
+	}
+}
+
+// denyNodePolicy denies pods bound to a specific node, as a stand-in for a
+// real OPA evaluator.
+type denyNodePolicy struct {
+	node string
+}
+
+func (p denyNodePolicy) Eval(_ context.Context, input Document) (Decision, error) {
+	spec, _ := input.Object["spec"].(map[string]interface{})
+	if spec != nil && spec["nodeName"] == p.node {
+		return Decision{Allow: false, Reasons: []string{"node is cordoned by policy"}}, nil
+	}
+	return Decision{Allow: true}, nil
+}
+
+func TestRunnerEvaluateAll(t *testing.T) {
+	runner := &Runner{
+		Converter: &Converter{},
+		Evaluator: denyNodePolicy{node: "node-1"},
+	}
+
+	allowed := testPod()
+	allowed.Name = "other"
+	allowed.Spec.NodeName = "node-2"
+
+	results, err := runner.EvaluateAll(context.Background(), []runtime.Object{testPod(), allowed})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	denied := Denials(results)
+	if len(denied) != 1 || denied[0].Document.Name != "web-abc123-x" {
+		t.Errorf("unexpected denials: %+v", denied)
+	}
+	if len(denied[0].Decision.Reasons) != 1 {
+		t.Errorf("expected a deny reason, got %+v", denied[0].Decision)
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ownership builds the ownerReference graph of a set of
+// objects and predicts what the garbage collector would do: which
+// dependents fall when an owner is deleted, in what order under
+// foreground propagation, and which blockOwnerDeletion references
+// hold a foreground deletion open. UIDs drive the matching, as they
+// do in the garbage collector itself — a stale reference whose UID no
+// longer exists dangles rather than matching a recreated object.
+package ownership
+
+import (
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Object is the slice of an API object the analyzer needs.
+type Object struct {
+	Kind      string
+	Namespace string
+	Name      string
+	UID       types.UID
+	// OwnerReferences are the object's metadata.ownerReferences.
+	OwnerReferences []metav1.OwnerReference
+}
+
+func (o Object) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// FromMeta builds an Object from any object's metadata.
+func FromMeta(kind string, meta metav1.Object) Object {
+	return Object{
+		Kind:            kind,
+		Namespace:       meta.GetNamespace(),
+		Name:            meta.GetName(),
+		UID:             meta.GetUID(),
+		OwnerReferences: meta.GetOwnerReferences(),
+	}
+}
+
+// Graph is the ownership graph of a fixed set of objects.
+type Graph struct {
+	byUID      map[types.UID]Object
+	dependents map[types.UID][]types.UID
+}
+
+// NewGraph indexes the objects by UID and by owner.
+func NewGraph(objects []Object) *Graph {
+	graph := &Graph{
+		byUID:      map[types.UID]Object{},
+		dependents: map[types.UID][]types.UID{},
+	}
+	for _, obj := range objects {
+		graph.byUID[obj.UID] = obj
+	}
+	for _, obj := range objects {
+		for _, owner := range obj.OwnerReferences {
+			graph.dependents[owner.UID] = append(graph.dependents[owner.UID], obj.UID)
+		}
+	}
+	return graph
+}
+
+// Dependents returns the direct dependents of the object, sorted.
+func (g *Graph) Dependents(uid types.UID) []Object {
+	return g.sorted(g.dependents[uid])
+}
+
+// Owners returns the owners of the object that exist in the graph;
+// references to absent UIDs are returned as the second value, since a
+// dangling owner means the garbage collector will delete the object.
+func (g *Graph) Owners(uid types.UID) (present []Object, dangling []metav1.OwnerReference) {
+	obj, ok := g.byUID[uid]
+	if !ok {
+		return nil, nil
+	}
+	for _, ref := range obj.OwnerReferences {
+		if owner, ok := g.byUID[ref.UID]; ok {
+			present = append(present, owner)
+		} else {
+			dangling = append(dangling, ref)
+		}
+	}
+	return present, dangling
+}
+
+// Orphans returns every object with at least one dangling owner
+// reference — candidates the garbage collector would remove on its
+// next sync.
+func (g *Graph) Orphans() []Object {
+	var orphans []Object
+	for uid := range g.byUID {
+		if _, dangling := g.Owners(uid); len(dangling) > 0 {
+			orphans = append(orphans, g.byUID[uid])
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].String() < orphans[j].String() })
+	return orphans
+}
+
+// Propagation mirrors metav1.DeletionPropagation for prediction.
+type Propagation string
+
+const (
+	// Background deletes the owner first; dependents follow
+	// asynchronously.
+	Background Propagation = "Background"
+	// Foreground deletes dependents with blockOwnerDeletion first
+	// and removes the owner last.
+	Foreground Propagation = "Foreground"
+	// Orphan removes the owner and strips its references from
+	// dependents, leaving them alive.
+	Orphan Propagation = "Orphan"
+)
+
+// DeletionPlan predicts a cascading deletion.
+type DeletionPlan struct {
+	// Deleted lists every object that would be removed. Under
+	// Foreground the order is dependents-first; under Background the
+	// owner comes first.
+	Deleted []Object
+	// Orphaned lists dependents that survive with the owner's
+	// reference removed (Orphan propagation only).
+	Orphaned []Object
+	// Blocking lists the dependents whose blockOwnerDeletion
+	// references hold the owner's foreground deletion open.
+	Blocking []Object
+}
+
+// PlanDeletion predicts what deleting the object with the given
+// propagation removes. Dependents are followed transitively; a
+// dependent with another surviving owner is still deleted, matching
+// the garbage collector, which deletes once any owner is gone unless
+// the remaining references are intact — here the deleted owner's
+// reference going away makes the dependent collectable.
+func (g *Graph) PlanDeletion(uid types.UID, propagation Propagation) (DeletionPlan, error) {
+	root, ok := g.byUID[uid]
+	if !ok {
+		return DeletionPlan{}, fmt.Errorf("no object with UID %s in the graph", uid)
+	}
+	plan := DeletionPlan{}
+	if propagation == Orphan {
+		plan.Deleted = []Object{root}
+		plan.Orphaned = g.Dependents(uid)
+		return plan, nil
+	}
+
+	visited := map[types.UID]bool{uid: true}
+	var cascade []Object
+	var walk func(owner types.UID)
+	walk = func(owner types.UID) {
+		for _, dependent := range g.Dependents(owner) {
+			if visited[dependent.UID] {
+				continue
+			}
+			visited[dependent.UID] = true
+			walk(dependent.UID)
+			cascade = append(cascade, dependent)
+			if propagation == Foreground && blocksOwner(dependent, owner) {
+				plan.Blocking = append(plan.Blocking, dependent)
+			}
+		}
+	}
+	walk(uid)
+
+	if propagation == Foreground {
+		// Dependents-first: the walk already appended leaves before
+		// their owners.
+		plan.Deleted = append(cascade, root)
+	} else {
+		plan.Deleted = append([]Object{root}, reverse(cascade)...)
+	}
+	return plan, nil
+}
+
+func blocksOwner(dependent Object, owner types.UID) bool {
+	for _, ref := range dependent.OwnerReferences {
+		if ref.UID == owner && ref.BlockOwnerDeletion != nil && *ref.BlockOwnerDeletion {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Graph) sorted(uids []types.UID) []Object {
+	objects := make([]Object, 0, len(uids))
+	for _, uid := range uids {
+		objects = append(objects, g.byUID[uid])
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].String() < objects[j].String() })
+	return objects
+}
+
+func reverse(objects []Object) []Object {
+	reversed := make([]Object, len(objects))
+	for i, obj := range objects {
+		reversed[len(objects)-1-i] = obj
+	}
+	return reversed
+}
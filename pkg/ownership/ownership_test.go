@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ownership
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func ref(kind, name string, uid types.UID, block bool) metav1.OwnerReference {
+	return metav1.OwnerReference{Kind: kind, Name: name, UID: uid, BlockOwnerDeletion: &block}
+}
+
+// deployGraph builds Deployment -> ReplicaSet -> 2 Pods, plus a pod
+// whose ReplicaSet owner no longer exists.
+func deployGraph() *Graph {
+	return NewGraph([]Object{
+		{Kind: "Deployment", Namespace: "default", Name: "web", UID: "deploy"},
+		{Kind: "ReplicaSet", Namespace: "default", Name: "web-abc", UID: "rs",
+			OwnerReferences: []metav1.OwnerReference{ref("Deployment", "web", "deploy", true)}},
+		{Kind: "Pod", Namespace: "default", Name: "web-abc-1", UID: "pod1",
+			OwnerReferences: []metav1.OwnerReference{ref("ReplicaSet", "web-abc", "rs", true)}},
+		{Kind: "Pod", Namespace: "default", Name: "web-abc-2", UID: "pod2",
+			OwnerReferences: []metav1.OwnerReference{ref("ReplicaSet", "web-abc", "rs", false)}},
+		{Kind: "Pod", Namespace: "default", Name: "stale-1", UID: "stale",
+			OwnerReferences: []metav1.OwnerReference{ref("ReplicaSet", "gone", "vanished", true)}},
+	})
+}
+
+func TestDependentsAndOwners(t *testing.T) {
+	graph := deployGraph()
+	pods := graph.Dependents("rs")
+	if len(pods) != 2 || pods[0].Name != "web-abc-1" {
+		t.Errorf("dependents: %+v", pods)
+	}
+	owners, dangling := graph.Owners("pod1")
+	if len(owners) != 1 || owners[0].UID != "rs" || len(dangling) != 0 {
+		t.Errorf("owners: %+v, dangling: %+v", owners, dangling)
+	}
+	_, dangling = graph.Owners("stale")
+	if len(dangling) != 1 || dangling[0].UID != "vanished" {
+		t.Errorf("dangling: %+v", dangling)
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	orphans := deployGraph().Orphans()
+	if len(orphans) != 1 || orphans[0].UID != "stale" {
+		t.Errorf("orphans: %+v", orphans)
+	}
+}
+
+func TestPlanForeground(t *testing.T) {
+	plan, err := deployGraph().PlanDeletion("deploy", Foreground)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Deleted) != 4 {
+		t.Fatalf("deleted: %+v", plan.Deleted)
+	}
+	// Dependents first, the deployment last.
+	if plan.Deleted[len(plan.Deleted)-1].UID != "deploy" {
+		t.Errorf("owner should go last: %+v", plan.Deleted)
+	}
+	position := map[types.UID]int{}
+	for i, obj := range plan.Deleted {
+		position[obj.UID] = i
+	}
+	if position["pod1"] > position["rs"] || position["pod2"] > position["rs"] {
+		t.Errorf("pods must fall before their ReplicaSet: %+v", plan.Deleted)
+	}
+	// pod1 and the ReplicaSet block; pod2 set blockOwnerDeletion=false.
+	blocking := map[types.UID]bool{}
+	for _, obj := range plan.Blocking {
+		blocking[obj.UID] = true
+	}
+	if !blocking["pod1"] || !blocking["rs"] || blocking["pod2"] {
+		t.Errorf("blocking: %+v", plan.Blocking)
+	}
+}
+
+func TestPlanBackground(t *testing.T) {
+	plan, err := deployGraph().PlanDeletion("deploy", Background)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Deleted[0].UID != "deploy" || len(plan.Deleted) != 4 {
+		t.Errorf("owner should go first: %+v", plan.Deleted)
+	}
+	if len(plan.Blocking) != 0 {
+		t.Errorf("background deletion is not blocked: %+v", plan.Blocking)
+	}
+}
+
+func TestPlanOrphan(t *testing.T) {
+	plan, err := deployGraph().PlanDeletion("rs", Orphan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Deleted) != 1 || plan.Deleted[0].UID != "rs" {
+		t.Errorf("deleted: %+v", plan.Deleted)
+	}
+	if len(plan.Orphaned) != 2 {
+		t.Errorf("orphaned: %+v", plan.Orphaned)
+	}
+
+	if _, err := deployGraph().PlanDeletion("unknown", Background); err == nil {
+		t.Error("unknown UID must error")
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podstatus
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSummarize(t *testing.T) {
+	now := metav1.Now()
+	testCases := []struct {
+		name string
+		pod  v1.Pod
+		want string
+	}{
+		{
+			name: "pending with no statuses",
+			pod:  v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending}},
+			want: "Pending",
+		},
+		{
+			name: "running and ready",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+				Status: v1.PodStatus{
+					Phase:      v1.PodRunning,
+					Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					}},
+				},
+			},
+			want: "Running",
+		},
+		{
+			name: "crash loop wins over phase",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodRunning,
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name:  "app",
+						State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					}},
+				},
+			},
+			want: "CrashLoopBackOff",
+		},
+		{
+			name: "image pull back-off",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name:  "app",
+						State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+					}},
+				},
+			},
+			want: "ImagePullBackOff",
+		},
+		{
+			name: "init progress counter",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{{Name: "a"}, {Name: "b"}},
+					Containers:     []v1.Container{{Name: "app"}},
+				},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					InitContainerStatuses: []v1.ContainerStatus{
+						{Name: "a", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+						{Name: "b", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+					},
+				},
+			},
+			want: "Init:1/2",
+		},
+		{
+			name: "failed init container exit code",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{InitContainers: []v1.Container{{Name: "a"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					InitContainerStatuses: []v1.ContainerStatus{
+						{Name: "a", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 2}}},
+					},
+				},
+			},
+			want: "Init:ExitCode:2",
+		},
+		{
+			name: "init crash loop",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{InitContainers: []v1.Container{{Name: "a"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					InitContainerStatuses: []v1.ContainerStatus{
+						{Name: "a", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+					},
+				},
+			},
+			want: "Init:CrashLoopBackOff",
+		},
+		{
+			name: "terminating overrides everything",
+			pod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+				Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodRunning,
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					}},
+				},
+			},
+			want: "Terminating",
+		},
+		{
+			name: "node lost shows unknown",
+			pod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+				Status:     v1.PodStatus{Phase: v1.PodRunning, Reason: "NodeLost"},
+			},
+			want: "Unknown",
+		},
+		{
+			name: "scheduling gated",
+			pod: v1.Pod{
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					Conditions: []v1.PodCondition{{
+						Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: v1.PodReasonSchedulingGated,
+					}},
+				},
+			},
+			want: "SchedulingGated",
+		},
+		{
+			name: "completed sidecar with running main container",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}, {Name: "once"}}},
+				Status: v1.PodStatus{
+					Phase:      v1.PodRunning,
+					Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+					ContainerStatuses: []v1.ContainerStatus{
+						{Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+						{Name: "once", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}},
+					},
+				},
+			},
+			want: "Running",
+		},
+		{
+			name: "succeeded pod shows Completed",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodSucceeded,
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name:  "app",
+						State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}},
+					}},
+				},
+			},
+			want: "Completed",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Summarize(&tc.pod); got != tc.want {
+				t.Errorf("Summarize() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestComputeCounts(t *testing.T) {
+	pod := v1.Pod{
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}, {Name: "sidecar"}}},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+			ContainerStatuses: []v1.ContainerStatus{
+				{Name: "app", Ready: true, RestartCount: 3, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+				{Name: "sidecar", RestartCount: 1, State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+			},
+		},
+	}
+	summary := Compute(&pod)
+	if summary.ReadyContainers != 1 || summary.TotalContainers != 2 {
+		t.Errorf("ready = %d/%d, want 1/2", summary.ReadyContainers, summary.TotalContainers)
+	}
+	if summary.Restarts != 4 {
+		t.Errorf("restarts = %d, want 4", summary.Restarts)
+	}
+	if summary.Status != "CrashLoopBackOff" {
+		t.Errorf("status = %q", summary.Status)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ports resolves intstr.IntOrString port references — probe ports,
+// service target ports — against container named ports, following the same
+// IANA_SVC_NAME rules the kubelet and endpoints controller apply. Results
+// are concrete port numbers or structured errors that carry enough context
+// for analysis tooling, replacing ad hoc (and subtly divergent) resolution
+// in downstream code.
+package ports
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NotFoundError reports a port reference that did not resolve.
+type NotFoundError struct {
+	// Port is the unresolved reference.
+	Port intstr.IntOrString
+	// Container is the container searched, or empty when the whole pod
+	// was searched.
+	Container string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Container != "" {
+		return fmt.Sprintf("port %s not found in container %q", e.Port.String(), e.Container)
+	}
+	return fmt.Sprintf("port %s not found in pod", e.Port.String())
+}
+
+// InvalidPortError reports a reference that can never resolve: an
+// out-of-range number or a name violating IANA_SVC_NAME.
+type InvalidPortError struct {
+	Port   intstr.IntOrString
+	Detail string
+}
+
+func (e *InvalidPortError) Error() string {
+	return fmt.Sprintf("invalid port %s: %s", e.Port.String(), e.Detail)
+}
+
+// ResolveContainerPort resolves a port reference against one container's
+// declared ports. Numeric references resolve to themselves after range
+// validation; named references must match a declared port name.
+func ResolveContainerPort(port intstr.IntOrString, container *v1.Container) (int32, error) {
+	switch port.Type {
+	case intstr.Int:
+		number := port.IntValue()
+		if number < 1 || number > 65535 {
+			return 0, &InvalidPortError{Port: port, Detail: "port number out of range"}
+		}
+		return int32(number), nil
+	case intstr.String:
+		name := port.StrVal
+		if errs := validation.IsValidPortName(name); len(errs) > 0 {
+			return 0, &InvalidPortError{Port: port, Detail: errs[0]}
+		}
+		for _, declared := range container.Ports {
+			if declared.Name == name {
+				return declared.ContainerPort, nil
+			}
+		}
+		return 0, &NotFoundError{Port: port, Container: container.Name}
+	}
+	return 0, &InvalidPortError{Port: port, Detail: "unknown reference type"}
+}
+
+// ResolveProbePort resolves the port a probe targets. GRPC probes carry a
+// plain int32 and resolve directly.
+func ResolveProbePort(probe *v1.Probe, container *v1.Container) (int32, error) {
+	switch {
+	case probe == nil:
+		return 0, fmt.Errorf("no probe")
+	case probe.HTTPGet != nil:
+		return ResolveContainerPort(probe.HTTPGet.Port, container)
+	case probe.TCPSocket != nil:
+		return ResolveContainerPort(probe.TCPSocket.Port, container)
+	case probe.GRPC != nil:
+		return probe.GRPC.Port, nil
+	}
+	return 0, fmt.Errorf("probe has no handler")
+}
+
+// ResolveServicePort resolves a service's target port against the pod,
+// searching every container the way the endpoints controller does. A
+// numeric target resolves directly; a named target must match some
+// container's port name. An unset target defaults to the service port.
+func ResolveServicePort(pod *v1.Pod, svcPort *v1.ServicePort) (int32, error) {
+	target := svcPort.TargetPort
+	if target.Type == intstr.String && target.StrVal == "" {
+		return svcPort.Port, nil
+	}
+	if target.Type == intstr.Int {
+		if target.IntValue() == 0 {
+			return svcPort.Port, nil
+		}
+		return ResolveContainerPort(target, &v1.Container{})
+	}
+	if errs := validation.IsValidPortName(target.StrVal); len(errs) > 0 {
+		return 0, &InvalidPortError{Port: target, Detail: errs[0]}
+	}
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		for _, declared := range container.Ports {
+			if declared.Name == target.StrVal && declared.Protocol == svcPort.Protocol {
+				return declared.ContainerPort, nil
+			}
+		}
+	}
+	return 0, &NotFoundError{Port: target}
+}
+
+// DuplicatePortName reports one port name declared by multiple containers
+// (or twice in one container) within a pod.
+type DuplicatePortName struct {
+	// Name is the duplicated port name.
+	Name string
+	// Containers lists the declaring containers, in spec order, one entry
+	// per declaration.
+	Containers []string
+}
+
+// FindDuplicatePortNames returns the port names declared more than once
+// within the pod's spec. Named ports must be unique within a pod for
+// service target port resolution to be well defined.
+func FindDuplicatePortNames(pod *v1.Pod) []DuplicatePortName {
+	declarations := map[string][]string{}
+	var order []string
+	record := func(containers []v1.Container) {
+		for i := range containers {
+			c := &containers[i]
+			for _, port := range c.Ports {
+				if port.Name == "" {
+					continue
+				}
+				if _, seen := declarations[port.Name]; !seen {
+					order = append(order, port.Name)
+				}
+				declarations[port.Name] = append(declarations[port.Name], c.Name)
+			}
+		}
+	}
+	record(pod.Spec.InitContainers)
+	record(pod.Spec.Containers)
+
+	var duplicates []DuplicatePortName
+	for _, name := range order {
+		if containers := declarations[name]; len(containers) > 1 {
+			duplicates = append(duplicates, DuplicatePortName{Name: name, Containers: containers})
+		}
+	}
+	return duplicates
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ports
+
+import (
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func portedContainer() *v1.Container {
+	return &v1.Container{
+		Name: "app",
+		Ports: []v1.ContainerPort{
+			{Name: "http", ContainerPort: 8080, Protocol: v1.ProtocolTCP},
+			{Name: "metrics", ContainerPort: 9090, Protocol: v1.ProtocolTCP},
+		},
+	}
+}
+
+func TestResolveContainerPort(t *testing.T) {
+	container := portedContainer()
+	testCases := []struct {
+		name     string
+		port     intstr.IntOrString
+		expected int32
+		wantErr  error
+	}{
+		{name: "numeric", port: intstr.FromInt(8443), expected: 8443},
+		{name: "named", port: intstr.FromString("http"), expected: 8080},
+		{name: "unknown name", port: intstr.FromString("grpc"), wantErr: &NotFoundError{}},
+		{name: "invalid name", port: intstr.FromString("NOT_A_NAME"), wantErr: &InvalidPortError{}},
+		{name: "zero", port: intstr.FromInt(0), wantErr: &InvalidPortError{}},
+		{name: "out of range", port: intstr.FromInt(70000), wantErr: &InvalidPortError{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResolveContainerPort(tc.port, container)
+			if tc.wantErr != nil {
+				switch tc.wantErr.(type) {
+				case *NotFoundError:
+					var notFound *NotFoundError
+					if !errors.As(err, &notFound) {
+						t.Fatalf("expected NotFoundError, got %v", err)
+					}
+					if notFound.Container != "app" {
+						t.Errorf("expected container context in error, got %+v", notFound)
+					}
+				case *InvalidPortError:
+					var invalid *InvalidPortError
+					if !errors.As(err, &invalid) {
+						t.Fatalf("expected InvalidPortError, got %v", err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveProbePort(t *testing.T) {
+	container := portedContainer()
+	httpProbe := &v1.Probe{ProbeHandler: v1.ProbeHandler{
+		HTTPGet: &v1.HTTPGetAction{Port: intstr.FromString("http")},
+	}}
+	if got, err := ResolveProbePort(httpProbe, container); err != nil || got != 8080 {
+		t.Errorf("expected 8080, got %d err=%v", got, err)
+	}
+
+	tcpProbe := &v1.Probe{ProbeHandler: v1.ProbeHandler{
+		TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(3306)},
+	}}
+	if got, err := ResolveProbePort(tcpProbe, container); err != nil || got != 3306 {
+		t.Errorf("expected 3306, got %d err=%v", got, err)
+	}
+
+	grpcProbe := &v1.Probe{ProbeHandler: v1.ProbeHandler{
+		GRPC: &v1.GRPCAction{Port: 50051},
+	}}
+	if got, err := ResolveProbePort(grpcProbe, container); err != nil || got != 50051 {
+		t.Errorf("expected 50051, got %d err=%v", got, err)
+	}
+
+	if _, err := ResolveProbePort(&v1.Probe{}, container); err == nil {
+		t.Error("expected error for probe without handler")
+	}
+}
+
+func TestResolveServicePort(t *testing.T) {
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{
+		{Name: "side", Ports: []v1.ContainerPort{{Name: "admin", ContainerPort: 7000, Protocol: v1.ProtocolTCP}}},
+		*portedContainer(),
+	}}}
+
+	testCases := []struct {
+		name     string
+		svcPort  v1.ServicePort
+		expected int32
+		wantErr  bool
+	}{
+		{
+			name:     "named target in second container",
+			svcPort:  v1.ServicePort{Port: 80, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromString("metrics")},
+			expected: 9090,
+		},
+		{
+			name:     "numeric target",
+			svcPort:  v1.ServicePort{Port: 80, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromInt(8443)},
+			expected: 8443,
+		},
+		{
+			name:     "unset target defaults to service port",
+			svcPort:  v1.ServicePort{Port: 80, Protocol: v1.ProtocolTCP},
+			expected: 80,
+		},
+		{
+			name:    "protocol mismatch",
+			svcPort: v1.ServicePort{Port: 80, Protocol: v1.ProtocolUDP, TargetPort: intstr.FromString("metrics")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown name",
+			svcPort: v1.ServicePort{Port: 80, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromString("ghost")},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResolveServicePort(pod, &tc.svcPort)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindDuplicatePortNames(t *testing.T) {
+	pod := &v1.Pod{Spec: v1.PodSpec{
+		InitContainers: []v1.Container{
+			{Name: "init", Ports: []v1.ContainerPort{{Name: "http", ContainerPort: 80}}},
+		},
+		Containers: []v1.Container{
+			{Name: "app", Ports: []v1.ContainerPort{
+				{Name: "http", ContainerPort: 8080},
+				{Name: "metrics", ContainerPort: 9090},
+			}},
+			{Name: "side", Ports: []v1.ContainerPort{{ContainerPort: 7000}}},
+		},
+	}}
+
+	duplicates := FindDuplicatePortNames(pod)
+	if len(duplicates) != 1 {
+		t.Fatalf("expected 1 duplicate, got %+v", duplicates)
+	}
+	if duplicates[0].Name != "http" || len(duplicates[0].Containers) != 2 {
+		t.Errorf("unexpected duplicate: %+v", duplicates[0])
+	}
+
+	clean := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{*portedContainer()}}}
+	if duplicates := FindDuplicatePortNames(clean); len(duplicates) != 0 {
+		t.Errorf("expected no duplicates, got %+v", duplicates)
+	}
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package projectedresolve resolves the file tree that a Pod's projected
+// volumes would materialize, combining Secret, ConfigMap, DownwardAPI and
+// ServiceAccountToken projections. It reports the final path layout, the
+// per-file modes, the object keys each file comes from, path collisions
+// between sources, and optional sources that are missing from the cluster.
+package projectedresolve
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// SourceType identifies the kind of projection a file originates from.
+type SourceType string
+
+const (
+	// SourceSecret is a file projected from a Secret key.
+	SourceSecret SourceType = "Secret"
+	// SourceConfigMap is a file projected from a ConfigMap key.
+	SourceConfigMap SourceType = "ConfigMap"
+	// SourceDownwardAPI is a file projected from the downward API.
+	SourceDownwardAPI SourceType = "DownwardAPI"
+	// SourceServiceAccountToken is a projected service account token.
+	SourceServiceAccountToken SourceType = "ServiceAccountToken"
+)
+
+// Source describes where a projected file's content comes from.
+type Source struct {
+	// Type is the projection kind.
+	Type SourceType
+	// Name is the name of the referenced Secret or ConfigMap. For
+	// ServiceAccountToken sources it is the requested audience, which may
+	// be empty for the API server's default audience.
+	Name string
+	// Key is the data key within the referenced object. For DownwardAPI
+	// sources it is the fieldRef or resourceFieldRef path instead.
+	Key string
+}
+
+// String returns a short human-readable description of the source.
+func (s Source) String() string {
+	if s.Key == "" {
+		return fmt.Sprintf("%s %q", s.Type, s.Name)
+	}
+	return fmt.Sprintf("%s %q key %q", s.Type, s.Name, s.Key)
+}
+
+// File is a single resolved file in the projected volume's tree.
+type File struct {
+	// Path is the file's path relative to the volume mount point.
+	Path string
+	// Mode is the file's permission bits after applying the volume's
+	// defaultMode and any per-item mode override.
+	Mode int32
+	// Source describes where the file's content comes from.
+	Source Source
+}
+
+// Collision records a path that more than one projection source writes to.
+// The kubelet refuses to set up such a volume, so collisions always indicate
+// a misconfigured Pod.
+type Collision struct {
+	// Path is the colliding relative path.
+	Path string
+	// Sources lists every source that produces the path, in spec order.
+	Sources []Source
+}
+
+// VolumeResult is the resolved content of a single projected volume.
+type VolumeResult struct {
+	// Volume is the volume's name in the Pod spec.
+	Volume string
+	// Files lists the resolved files sorted by path. Colliding paths
+	// appear once, attributed to the first source in spec order.
+	Files []File
+	// Collisions lists paths written by more than one source.
+	Collisions []Collision
+	// MissingOptional lists optional Secret or ConfigMap sources that do
+	// not exist; their files are absent from Files.
+	MissingOptional []Source
+}
+
+// Getter provides access to the Secrets and ConfigMaps referenced by
+// projections so that key enumeration and optional-source handling can be
+// resolved against actual cluster state.
+type Getter interface {
+	GetSecret(namespace, name string) (*v1.Secret, error)
+	GetConfigMap(namespace, name string) (*v1.ConfigMap, error)
+}
+
+const defaultMode = int32(0644)
+
+// Resolve resolves every projected volume in the Pod's spec. Volumes of
+// other types are ignored. An error is returned if a non-optional source
+// object cannot be retrieved or a projection is structurally invalid.
+func Resolve(pod *v1.Pod, getter Getter) ([]VolumeResult, error) {
+	var results []VolumeResult
+	for i := range pod.Spec.Volumes {
+		vol := &pod.Spec.Volumes[i]
+		if vol.Projected == nil {
+			continue
+		}
+		result, err := resolveVolume(pod.Namespace, vol.Name, vol.Projected, getter)
+		if err != nil {
+			return nil, fmt.Errorf("volume %q: %v", vol.Name, err)
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+func resolveVolume(namespace, volName string, projected *v1.ProjectedVolumeSource, getter Getter) (*VolumeResult, error) {
+	mode := defaultMode
+	if projected.DefaultMode != nil {
+		mode = *projected.DefaultMode
+	}
+
+	result := &VolumeResult{Volume: volName}
+	// byPath tracks every source per cleaned path so collisions can be
+	// reported with full attribution.
+	byPath := map[string][]File{}
+	addFile := func(f File) error {
+		cleaned, err := cleanPath(f.Path)
+		if err != nil {
+			return fmt.Errorf("%v: %v", f.Source, err)
+		}
+		f.Path = cleaned
+		byPath[cleaned] = append(byPath[cleaned], f)
+		return nil
+	}
+
+	for i := range projected.Sources {
+		source := &projected.Sources[i]
+		var err error
+		switch {
+		case source.Secret != nil:
+			err = resolveSecret(namespace, source.Secret, getter, mode, result, addFile)
+		case source.ConfigMap != nil:
+			err = resolveConfigMap(namespace, source.ConfigMap, getter, mode, result, addFile)
+		case source.DownwardAPI != nil:
+			err = resolveDownwardAPI(source.DownwardAPI, mode, addFile)
+		case source.ServiceAccountToken != nil:
+			err = addFile(File{
+				Path: source.ServiceAccountToken.Path,
+				Mode: mode,
+				Source: Source{
+					Type: SourceServiceAccountToken,
+					Name: source.ServiceAccountToken.Audience,
+				},
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	paths := make([]string, 0, len(byPath))
+	for p := range byPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		files := byPath[p]
+		result.Files = append(result.Files, files[0])
+		if len(files) > 1 {
+			sources := make([]Source, 0, len(files))
+			for _, f := range files {
+				sources = append(sources, f.Source)
+			}
+			result.Collisions = append(result.Collisions, Collision{Path: p, Sources: sources})
+		}
+	}
+	return result, nil
+}
+
+func resolveSecret(namespace string, proj *v1.SecretProjection, getter Getter, mode int32, result *VolumeResult, addFile func(File) error) error {
+	secret, err := getter.GetSecret(namespace, proj.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) && proj.Optional != nil && *proj.Optional {
+			result.MissingOptional = append(result.MissingOptional, Source{Type: SourceSecret, Name: proj.Name})
+			return nil
+		}
+		return fmt.Errorf("secret %q: %v", proj.Name, err)
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	return projectKeys(SourceSecret, proj.Name, keys, proj.Items, proj.Optional, mode, addFile)
+}
+
+func resolveConfigMap(namespace string, proj *v1.ConfigMapProjection, getter Getter, mode int32, result *VolumeResult, addFile func(File) error) error {
+	configMap, err := getter.GetConfigMap(namespace, proj.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) && proj.Optional != nil && *proj.Optional {
+			result.MissingOptional = append(result.MissingOptional, Source{Type: SourceConfigMap, Name: proj.Name})
+			return nil
+		}
+		return fmt.Errorf("configmap %q: %v", proj.Name, err)
+	}
+	keys := make([]string, 0, len(configMap.Data)+len(configMap.BinaryData))
+	for k := range configMap.Data {
+		keys = append(keys, k)
+	}
+	for k := range configMap.BinaryData {
+		keys = append(keys, k)
+	}
+	return projectKeys(SourceConfigMap, proj.Name, keys, proj.Items, proj.Optional, mode, addFile)
+}
+
+// projectKeys maps object data keys to file paths. With no items every key
+// becomes a file named after itself; with items only the listed keys are
+// projected, under the configured paths.
+func projectKeys(sourceType SourceType, name string, keys []string, items []v1.KeyToPath, optional *bool, mode int32, addFile func(File) error) error {
+	if len(items) == 0 {
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := addFile(File{Path: key, Mode: mode, Source: Source{Type: sourceType, Name: name, Key: key}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	present := map[string]bool{}
+	for _, key := range keys {
+		present[key] = true
+	}
+	for _, item := range items {
+		if !present[item.Key] {
+			if optional != nil && *optional {
+				continue
+			}
+			return fmt.Errorf("%s %q: key %q not found", strings.ToLower(string(sourceType)), name, item.Key)
+		}
+		fileMode := mode
+		if item.Mode != nil {
+			fileMode = *item.Mode
+		}
+		if err := addFile(File{Path: item.Path, Mode: fileMode, Source: Source{Type: sourceType, Name: name, Key: item.Key}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveDownwardAPI(proj *v1.DownwardAPIProjection, mode int32, addFile func(File) error) error {
+	for _, item := range proj.Items {
+		fileMode := mode
+		if item.Mode != nil {
+			fileMode = *item.Mode
+		}
+		source := Source{Type: SourceDownwardAPI}
+		switch {
+		case item.FieldRef != nil:
+			source.Key = item.FieldRef.FieldPath
+		case item.ResourceFieldRef != nil:
+			source.Name = item.ResourceFieldRef.ContainerName
+			source.Key = item.ResourceFieldRef.Resource
+		}
+		if err := addFile(File{Path: item.Path, Mode: fileMode, Source: source}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanPath validates and normalizes a projected item path the same way the
+// kubelet does: paths must be relative and must not escape the volume root.
+func cleanPath(p string) (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("path must not be empty")
+	}
+	if path.IsAbs(p) {
+		return "", fmt.Errorf("path %q must be relative", p)
+	}
+	cleaned := path.Clean(p)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("path %q must not escape the volume root", p)
+	}
+	return cleaned, nil
+}
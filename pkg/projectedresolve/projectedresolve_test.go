@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projectedresolve
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeGetter struct {
+	secrets    map[string]*v1.Secret
+	configMaps map[string]*v1.ConfigMap
+}
+
+func (f *fakeGetter) GetSecret(namespace, name string) (*v1.Secret, error) {
+	if s, ok := f.secrets[namespace+"/"+name]; ok {
+		return s, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+}
+
+func (f *fakeGetter) GetConfigMap(namespace, name string) (*v1.ConfigMap, error) {
+	if cm, ok := f.configMaps[namespace+"/"+name]; ok {
+		return cm, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+}
+
+func projectedPod(sources ...v1.VolumeProjection) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{{
+				Name: "proj",
+				VolumeSource: v1.VolumeSource{
+					Projected: &v1.ProjectedVolumeSource{Sources: sources},
+				},
+			}},
+		},
+	}
+}
+
+func int32Ptr(i int32) *int32 { return &i }
+func boolPtr(b bool) *bool    { return &b }
+
+func TestResolve(t *testing.T) {
+	getter := &fakeGetter{
+		secrets: map[string]*v1.Secret{
+			"ns/creds": {Data: map[string][]byte{"user": nil, "pass": nil}},
+		},
+		configMaps: map[string]*v1.ConfigMap{
+			"ns/conf": {
+				Data:       map[string]string{"app.yaml": ""},
+				BinaryData: map[string][]byte{"user": nil},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		sources  []v1.VolumeProjection
+		expected *VolumeResult
+		wantErr  bool
+	}{
+		{
+			name: "secret whole object",
+			sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "creds"}}},
+			},
+			expected: &VolumeResult{
+				Volume: "proj",
+				Files: []File{
+					{Path: "pass", Mode: 0644, Source: Source{Type: SourceSecret, Name: "creds", Key: "pass"}},
+					{Path: "user", Mode: 0644, Source: Source{Type: SourceSecret, Name: "creds", Key: "user"}},
+				},
+			},
+		},
+		{
+			name: "items with mode override",
+			sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{
+					LocalObjectReference: v1.LocalObjectReference{Name: "creds"},
+					Items:                []v1.KeyToPath{{Key: "pass", Path: "secret/pass.txt", Mode: int32Ptr(0400)}},
+				}},
+			},
+			expected: &VolumeResult{
+				Volume: "proj",
+				Files: []File{
+					{Path: "secret/pass.txt", Mode: 0400, Source: Source{Type: SourceSecret, Name: "creds", Key: "pass"}},
+				},
+			},
+		},
+		{
+			name: "collision between secret and configmap",
+			sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "creds"}}},
+				{ConfigMap: &v1.ConfigMapProjection{LocalObjectReference: v1.LocalObjectReference{Name: "conf"}}},
+			},
+			expected: &VolumeResult{
+				Volume: "proj",
+				Files: []File{
+					{Path: "app.yaml", Mode: 0644, Source: Source{Type: SourceConfigMap, Name: "conf", Key: "app.yaml"}},
+					{Path: "pass", Mode: 0644, Source: Source{Type: SourceSecret, Name: "creds", Key: "pass"}},
+					{Path: "user", Mode: 0644, Source: Source{Type: SourceSecret, Name: "creds", Key: "user"}},
+				},
+				Collisions: []Collision{{
+					Path: "user",
+					Sources: []Source{
+						{Type: SourceSecret, Name: "creds", Key: "user"},
+						{Type: SourceConfigMap, Name: "conf", Key: "user"},
+					},
+				}},
+			},
+		},
+		{
+			name: "missing optional secret",
+			sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{
+					LocalObjectReference: v1.LocalObjectReference{Name: "absent"},
+					Optional:             boolPtr(true),
+				}},
+			},
+			expected: &VolumeResult{
+				Volume:          "proj",
+				MissingOptional: []Source{{Type: SourceSecret, Name: "absent"}},
+			},
+		},
+		{
+			name: "missing required secret",
+			sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "absent"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing key in optional source is skipped",
+			sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{
+					LocalObjectReference: v1.LocalObjectReference{Name: "creds"},
+					Items:                []v1.KeyToPath{{Key: "absent", Path: "f"}},
+					Optional:             boolPtr(true),
+				}},
+			},
+			expected: &VolumeResult{Volume: "proj"},
+		},
+		{
+			name: "downward api and token",
+			sources: []v1.VolumeProjection{
+				{DownwardAPI: &v1.DownwardAPIProjection{
+					Items: []v1.DownwardAPIVolumeFile{{
+						Path:     "labels",
+						FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.labels"},
+					}},
+				}},
+				{ServiceAccountToken: &v1.ServiceAccountTokenProjection{Path: "token", Audience: "api"}},
+			},
+			expected: &VolumeResult{
+				Volume: "proj",
+				Files: []File{
+					{Path: "labels", Mode: 0644, Source: Source{Type: SourceDownwardAPI, Key: "metadata.labels"}},
+					{Path: "token", Mode: 0644, Source: Source{Type: SourceServiceAccountToken, Name: "api"}},
+				},
+			},
+		},
+		{
+			name: "path escaping volume root",
+			sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{
+					LocalObjectReference: v1.LocalObjectReference{Name: "creds"},
+					Items:                []v1.KeyToPath{{Key: "user", Path: "../escape"}},
+				}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := Resolve(projectedPod(tc.sources...), getter)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			if !reflect.DeepEqual(&results[0], tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, &results[0])
+			}
+		})
+	}
+}
+
+func TestResolveDefaultMode(t *testing.T) {
+	pod := projectedPod(v1.VolumeProjection{
+		Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "creds"}},
+	})
+	pod.Spec.Volumes[0].Projected.DefaultMode = int32Ptr(0600)
+	getter := &fakeGetter{secrets: map[string]*v1.Secret{
+		"ns/creds": {Data: map[string][]byte{"user": nil}},
+	}}
+	results, err := Resolve(pod, getter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Files[0].Mode != 0600 {
+		t.Errorf("expected mode 0600, got %o", results[0].Files[0].Mode)
+	}
+}
+
+func TestResolveIgnoresNonProjectedVolumes(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{{
+				Name:         "empty",
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			}},
+		},
+	}
+	results, err := Resolve(pod, &fakeGetter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no results, got %d", len(results))
+	}
+}
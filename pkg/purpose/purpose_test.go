@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purpose
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+type captureRoundTripper struct {
+	request *http.Request
+}
+
+func (c *captureRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.request = req
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func stampedRequest(t *testing.T, ctx context.Context, impersonate bool, userAgent string) *http.Request {
+	t.Helper()
+	capture := &captureRoundTripper{}
+	rt := WrapTransport(capture, impersonate)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://cluster/api/v1/pods", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	return capture.request
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("expected no purpose on fresh context")
+	}
+	ctx := WithPurpose(context.Background(), "nightly-export")
+	if purpose, ok := FromContext(ctx); !ok || purpose != "nightly-export" {
+		t.Errorf("expected nightly-export, got %q ok=%v", purpose, ok)
+	}
+}
+
+func TestRoundTripStampsUserAgent(t *testing.T) {
+	ctx := WithPurpose(context.Background(), "nightly-export")
+
+	got := stampedRequest(t, ctx, false, "kread/v1.0 (linux/amd64)")
+	if ua := got.Header.Get("User-Agent"); ua != "kread/v1.0 (linux/amd64) purpose/nightly-export" {
+		t.Errorf("unexpected user agent %q", ua)
+	}
+	if got.Header.Get("Impersonate-Extra-purpose") != "" {
+		t.Error("extra header must not be set without opt-in")
+	}
+
+	// Without a prior user agent the suffix stands alone.
+	got = stampedRequest(t, ctx, false, "")
+	if ua := got.Header.Get("User-Agent"); ua != "purpose/nightly-export" {
+		t.Errorf("unexpected user agent %q", ua)
+	}
+}
+
+func TestRoundTripImpersonationExtra(t *testing.T) {
+	ctx := WithPurpose(context.Background(), "nightly-export")
+	got := stampedRequest(t, ctx, true, "")
+	if header := got.Header.Get("Impersonate-Extra-purpose"); header != "nightly-export" {
+		t.Errorf("expected impersonation extra, got %q", header)
+	}
+}
+
+func TestRoundTripWithoutPurposeIsUntouched(t *testing.T) {
+	got := stampedRequest(t, context.Background(), true, "kread/v1.0")
+	if ua := got.Header.Get("User-Agent"); ua != "kread/v1.0" {
+		t.Errorf("expected untouched user agent, got %q", ua)
+	}
+	if got.Header.Get("Impersonate-Extra-purpose") != "" {
+		t.Error("expected no extra header without purpose")
+	}
+}
+
+func TestRoundTripSanitizesPurpose(t *testing.T) {
+	ctx := WithPurpose(context.Background(), "bad\r\nheader: x")
+	got := stampedRequest(t, ctx, false, "")
+	if ua := got.Header.Get("User-Agent"); ua != "purpose/badheaderx" {
+		t.Errorf("expected sanitized tag, got %q", ua)
+	}
+
+	// A tag that sanitizes to nothing is dropped entirely.
+	ctx = WithPurpose(context.Background(), "///")
+	got = stampedRequest(t, ctx, false, "kread/v1.0")
+	if ua := got.Header.Get("User-Agent"); ua != "kread/v1.0" {
+		t.Errorf("expected untouched user agent, got %q", ua)
+	}
+}
+
+func TestAddToConfig(t *testing.T) {
+	config := &restclient.Config{}
+	AddToConfig(config, false)
+	capture := &captureRoundTripper{}
+	rt := config.WrapTransport(capture)
+
+	ctx := WithPurpose(context.Background(), "backfill")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://cluster/api/v1/pods", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if ua := capture.request.Header.Get("User-Agent"); ua != "purpose/backfill" {
+		t.Errorf("expected config-installed transport to stamp, got %q", ua)
+	}
+}
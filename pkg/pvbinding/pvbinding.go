@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pvbinding simulates the PV controller's volume matching for a
+// claim: same phase, capacity, class, volumeMode, selector, access mode
+// and node affinity rules, but every rejected volume keeps the reason it
+// was rejected. The controller's own matcher returns only the winner,
+// which is useless when the question is why a claim sits Pending.
+package pvbinding
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	storagehelpers "k8s.io/component-helpers/storage/volume"
+)
+
+// Rejection records why one volume cannot bind the claim.
+type Rejection struct {
+	// Volume is the PV's name.
+	Volume string
+	// Reason is a single human-readable sentence.
+	Reason string
+}
+
+// Result is the outcome of a binding simulation.
+type Result struct {
+	// Match is the volume the controller would bind, nil when none
+	// fits.
+	Match *v1.PersistentVolume
+	// Rejections explains every volume that did not match. When Match
+	// is non-nil the remaining candidates are still explained.
+	Rejections []Rejection
+	// ProvisionLikely notes that the claim names a storage class or a
+	// data source, so dynamic provisioning may satisfy it even with no
+	// static match.
+	ProvisionLikely bool
+}
+
+// MatchPVToPVC simulates binding against the volumes. A nil node
+// reproduces the PV controller; a node applies the scheduler's
+// additional node affinity check.
+func MatchPVToPVC(claim *v1.PersistentVolumeClaim, volumes []*v1.PersistentVolume, node *v1.Node) (Result, error) {
+	result := Result{
+		ProvisionLikely: storagehelpers.GetPersistentVolumeClaimClass(claim) != "" ||
+			claim.Spec.DataSource != nil || claim.Spec.DataSourceRef != nil,
+	}
+
+	var selector labels.Selector
+	if claim.Spec.Selector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(claim.Spec.Selector)
+		if err != nil {
+			return result, fmt.Errorf("invalid claim selector: %v", err)
+		}
+	}
+	requestedQty := claim.Spec.Resources.Requests[v1.ResourceStorage]
+	requestedClass := storagehelpers.GetPersistentVolumeClaimClass(claim)
+
+	var smallest *v1.PersistentVolume
+	var smallestQty resource.Quantity
+	for _, volume := range volumes {
+		if reason := rejectVolume(claim, volume, node, selector, requestedQty, requestedClass); reason != "" {
+			result.Rejections = append(result.Rejections, Rejection{Volume: volume.Name, Reason: reason})
+			continue
+		}
+		if storagehelpers.IsVolumeBoundToClaim(volume, claim) {
+			// A pre-bound volume wins outright.
+			result.Match = volume
+			return result, nil
+		}
+		volumeQty := volume.Spec.Capacity[v1.ResourceStorage]
+		if smallest == nil || smallestQty.Cmp(volumeQty) > 0 {
+			smallest = volume
+			smallestQty = volumeQty
+		}
+	}
+	result.Match = smallest
+	if smallest != nil {
+		// The winner was the smallest fit; note why the others lost.
+		for _, volume := range volumes {
+			if volume.Name == smallest.Name {
+				continue
+			}
+			already := false
+			for _, rejection := range result.Rejections {
+				if rejection.Volume == volume.Name {
+					already = true
+					break
+				}
+			}
+			if !already {
+				result.Rejections = append(result.Rejections, Rejection{
+					Volume: volume.Name,
+					Reason: fmt.Sprintf("matches, but %s is a smaller fit", smallest.Name),
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+func rejectVolume(claim *v1.PersistentVolumeClaim, volume *v1.PersistentVolume, node *v1.Node, selector labels.Selector, requestedQty resource.Quantity, requestedClass string) string {
+	if volume.DeletionTimestamp != nil {
+		return "volume is being deleted"
+	}
+	if volume.Spec.ClaimRef != nil && !storagehelpers.IsVolumeBoundToClaim(volume, claim) {
+		return fmt.Sprintf("already bound to claim %s/%s", volume.Spec.ClaimRef.Namespace, volume.Spec.ClaimRef.Name)
+	}
+	volumeQty := volume.Spec.Capacity[v1.ResourceStorage]
+	if volumeQty.Cmp(requestedQty) < 0 {
+		return fmt.Sprintf("capacity %s is below the requested %s", volumeQty.String(), requestedQty.String())
+	}
+	if storagehelpers.CheckVolumeModeMismatches(&claim.Spec, &volume.Spec) {
+		return "volumeMode does not match"
+	}
+	if node != nil {
+		if err := storagehelpers.CheckNodeAffinity(volume, node.Labels); err != nil {
+			return fmt.Sprintf("node affinity does not admit node %s: %v", node.Name, err)
+		}
+	}
+	if storagehelpers.IsVolumeBoundToClaim(volume, claim) {
+		// Pre-bound volumes skip the phase, selector, class and access
+		// mode filters, exactly as the controller does.
+		return ""
+	}
+	if volume.Status.Phase != v1.VolumeAvailable {
+		return fmt.Sprintf("volume is %s, not Available", volume.Status.Phase)
+	}
+	if selector != nil && !selector.Matches(labels.Set(volume.Labels)) {
+		return "labels do not match the claim's selector"
+	}
+	if storagehelpers.GetPersistentVolumeClass(volume) != requestedClass {
+		return fmt.Sprintf("storage class %q does not match requested %q", storagehelpers.GetPersistentVolumeClass(volume), requestedClass)
+	}
+	if !storagehelpers.CheckAccessModes(claim, volume) {
+		return fmt.Sprintf("access modes %v do not contain requested %v", volume.Spec.AccessModes, claim.Spec.AccessModes)
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvbinding
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func makePV(name, capacity string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity:    v1.ResourceList{v1.ResourceStorage: resource.MustParse(capacity)},
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+		},
+		Status: v1.PersistentVolumeStatus{Phase: v1.VolumeAvailable},
+	}
+}
+
+func makeClaim(request string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(request)},
+			},
+		},
+	}
+}
+
+func TestMatchPicksSmallestFit(t *testing.T) {
+	volumes := []*v1.PersistentVolume{makePV("big", "100Gi"), makePV("small", "10Gi"), makePV("tiny", "1Gi")}
+	result, err := MatchPVToPVC(makeClaim("5Gi"), volumes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Match == nil || result.Match.Name != "small" {
+		t.Fatalf("match = %+v, want small", result.Match)
+	}
+	reasons := map[string]string{}
+	for _, rejection := range result.Rejections {
+		reasons[rejection.Volume] = rejection.Reason
+	}
+	if !strings.Contains(reasons["tiny"], "below the requested") {
+		t.Errorf("tiny: %q", reasons["tiny"])
+	}
+	if !strings.Contains(reasons["big"], "smaller fit") {
+		t.Errorf("big: %q", reasons["big"])
+	}
+}
+
+func TestMatchFilters(t *testing.T) {
+	blockMode := v1.PersistentVolumeBlock
+	boundToOther := makePV("taken", "10Gi")
+	boundToOther.Spec.ClaimRef = &v1.ObjectReference{Namespace: "other", Name: "theirs"}
+	wrongClass := makePV("fast", "10Gi")
+	wrongClass.Spec.StorageClassName = "fast-ssd"
+	wrongClass.Labels = map[string]string{"tier": "gold"}
+	released := makePV("released", "10Gi")
+	released.Status.Phase = v1.VolumeReleased
+	block := makePV("block", "10Gi")
+	block.Spec.VolumeMode = &blockMode
+	readOnly := makePV("rox", "10Gi")
+	readOnly.Spec.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadOnlyMany}
+	readOnly.Labels = map[string]string{"tier": "gold"}
+	unlabeled := makePV("unlabeled", "10Gi")
+
+	claim := makeClaim("5Gi")
+	claim.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}}
+
+	result, err := MatchPVToPVC(claim, []*v1.PersistentVolume{boundToOther, wrongClass, released, block, readOnly, unlabeled}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Match != nil {
+		t.Fatalf("unexpected match %s", result.Match.Name)
+	}
+	wantReasons := map[string]string{
+		"taken":     "already bound",
+		"fast":      "storage class",
+		"released":  "not Available",
+		"block":     "volumeMode",
+		"rox":       "access modes",
+		"unlabeled": "selector",
+	}
+	if len(result.Rejections) != len(wantReasons) {
+		t.Fatalf("rejections: %+v", result.Rejections)
+	}
+	for _, rejection := range result.Rejections {
+		if !strings.Contains(rejection.Reason, wantReasons[rejection.Volume]) {
+			t.Errorf("%s: %q does not mention %q", rejection.Volume, rejection.Reason, wantReasons[rejection.Volume])
+		}
+	}
+}
+
+func TestMatchPreBoundVolumeWins(t *testing.T) {
+	claim := makeClaim("5Gi")
+	preBound := makePV("mine", "100Gi")
+	preBound.Spec.ClaimRef = &v1.ObjectReference{Namespace: claim.Namespace, Name: claim.Name}
+	// Pre-bound volumes skip the class and phase filters.
+	preBound.Status.Phase = v1.VolumeBound
+	smaller := makePV("small", "10Gi")
+
+	result, err := MatchPVToPVC(claim, []*v1.PersistentVolume{smaller, preBound}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Match == nil || result.Match.Name != "mine" {
+		t.Fatalf("match = %+v, want the pre-bound volume", result.Match)
+	}
+}
+
+func TestMatchNodeAffinity(t *testing.T) {
+	local := makePV("local", "10Gi")
+	local.Spec.NodeAffinity = &v1.VolumeNodeAffinity{Required: &v1.NodeSelector{
+		NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{{
+			Key: "kubernetes.io/hostname", Operator: v1.NodeSelectorOpIn, Values: []string{"node-1"},
+		}}}},
+	}}
+	otherNode := &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name: "node-2", Labels: map[string]string{"kubernetes.io/hostname": "node-2"},
+	}}
+
+	result, err := MatchPVToPVC(makeClaim("5Gi"), []*v1.PersistentVolume{local}, otherNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Match != nil {
+		t.Fatal("local volume must not match a different node")
+	}
+	if len(result.Rejections) != 1 || !strings.Contains(result.Rejections[0].Reason, "node affinity") {
+		t.Errorf("rejections: %+v", result.Rejections)
+	}
+}
+
+func TestProvisionLikely(t *testing.T) {
+	claim := makeClaim("5Gi")
+	class := "standard"
+	claim.Spec.StorageClassName = &class
+	result, err := MatchPVToPVC(claim, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.ProvisionLikely {
+		t.Error("a classed claim should be flagged as provisionable")
+	}
+	if result.Match != nil {
+		t.Error("no volumes, no match")
+	}
+}
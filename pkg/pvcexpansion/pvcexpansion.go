@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pvcexpansion reduces a claim's expansion state to one enum.
+// The raw signals are spread over three places with different
+// lifetimes — the Resizing/FileSystemResizePending conditions, the
+// alpha resizeStatus and allocatedResources fields, and the gap
+// between spec and status capacity — and which of them is populated
+// depends on feature gates and CSI driver behavior, so each is used
+// here as a fallback for the next.
+package pvcexpansion
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// State is the summarized expansion state of a claim.
+type State string
+
+const (
+	// ExpansionIdle means no expansion is requested or one has
+	// completed.
+	ExpansionIdle State = "Idle"
+	// ControllerExpanding means the external resize controller is
+	// growing the backing volume.
+	ControllerExpanding State = "ControllerExpanding"
+	// NodeExpansionPending means the volume grew but the node must
+	// still resize the filesystem, which happens when a pod using
+	// the claim starts or restarts.
+	NodeExpansionPending State = "NodeExpansionPending"
+	// Failed means the controller or kubelet hit a terminal error;
+	// transient errors do not set this.
+	Failed State = "Failed"
+)
+
+// Status is the interpreted expansion state.
+type Status struct {
+	State State
+	// Explanation says what the state was derived from and what, if
+	// anything, is being waited on.
+	Explanation string
+	// Requested is the spec's storage request; Actual the status
+	// capacity (the bound PV's capacity when the claim reports none).
+	Requested resource.Quantity
+	Actual    resource.Quantity
+}
+
+// Interpret reduces the claim's status to a single state. The bound
+// PV may be nil; it only backfills capacity for claims whose status
+// capacity is not yet recorded.
+func Interpret(claim *v1.PersistentVolumeClaim, pv *v1.PersistentVolume) Status {
+	status := Status{
+		Requested: claim.Spec.Resources.Requests[v1.ResourceStorage],
+		Actual:    claim.Status.Capacity[v1.ResourceStorage],
+	}
+	if status.Actual.IsZero() && pv != nil {
+		status.Actual = pv.Spec.Capacity[v1.ResourceStorage]
+	}
+
+	// The resizeStatus field, when set, is authoritative: the resize
+	// controller and kubelet drive it through the whole state
+	// machine and clear it on completion.
+	if claim.Status.ResizeStatus != nil {
+		switch *claim.Status.ResizeStatus {
+		case v1.PersistentVolumeClaimControllerExpansionInProgress:
+			return withState(status, ControllerExpanding, "resizeStatus reports the controller is expanding the volume")
+		case v1.PersistentVolumeClaimNodeExpansionPending:
+			return withState(status, NodeExpansionPending, "resizeStatus reports the volume grew; the node resizes the filesystem when a pod mounts it")
+		case v1.PersistentVolumeClaimNodeExpansionInProgress:
+			return withState(status, NodeExpansionPending, "resizeStatus reports the kubelet is resizing the filesystem")
+		case v1.PersistentVolumeClaimControllerExpansionFailed:
+			return withState(status, Failed, "the controller hit a terminal error expanding the volume; shrink the request or delete and restore")
+		case v1.PersistentVolumeClaimNodeExpansionFailed:
+			return withState(status, Failed, "the kubelet hit a terminal error resizing the filesystem")
+		case v1.PersistentVolumeClaimNoExpansionInProgress:
+			// Explicitly cleared; fall through to the capacity check.
+		}
+	}
+
+	// Conditions cover drivers and versions that never set
+	// resizeStatus.
+	for _, condition := range claim.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case v1.PersistentVolumeClaimFileSystemResizePending:
+			return withState(status, NodeExpansionPending, "the FileSystemResizePending condition is set: "+condition.Message)
+		case v1.PersistentVolumeClaimResizing:
+			return withState(status, ControllerExpanding, "the Resizing condition is set: "+condition.Message)
+		}
+	}
+
+	// No in-flight markers: compare what was asked for with what the
+	// claim has. allocatedResources records the controller's target
+	// once expansion starts, so a larger spec request alone means the
+	// controller has not picked the change up yet.
+	if !status.Requested.IsZero() && !status.Actual.IsZero() && status.Requested.Cmp(status.Actual) > 0 {
+		allocated, tracked := claim.Status.AllocatedResources[v1.ResourceStorage]
+		if tracked && allocated.Cmp(status.Actual) > 0 {
+			return withState(status, ControllerExpanding, fmt.Sprintf(
+				"allocatedResources (%s) exceeds capacity (%s); expansion is underway", allocated.String(), status.Actual.String()))
+		}
+		return withState(status, ControllerExpanding, fmt.Sprintf(
+			"spec requests %s but the claim has %s; awaiting the resize controller", status.Requested.String(), status.Actual.String()))
+	}
+	return withState(status, ExpansionIdle, "no expansion in progress")
+}
+
+func withState(status Status, state State, explanation string) Status {
+	status.State = state
+	status.Explanation = explanation
+	return status
+}
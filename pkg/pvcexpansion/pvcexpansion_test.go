@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvcexpansion
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func claimOf(requested, actual string) *v1.PersistentVolumeClaim {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+				v1.ResourceStorage: resource.MustParse(requested),
+			}},
+		},
+	}
+	if actual != "" {
+		claim.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse(actual)}
+	}
+	return claim
+}
+
+func resizeStatus(s v1.PersistentVolumeClaimResizeStatus) *v1.PersistentVolumeClaimResizeStatus {
+	return &s
+}
+
+func TestInterpretResizeStatus(t *testing.T) {
+	testCases := []struct {
+		status v1.PersistentVolumeClaimResizeStatus
+		want   State
+	}{
+		{v1.PersistentVolumeClaimControllerExpansionInProgress, ControllerExpanding},
+		{v1.PersistentVolumeClaimNodeExpansionPending, NodeExpansionPending},
+		{v1.PersistentVolumeClaimNodeExpansionInProgress, NodeExpansionPending},
+		{v1.PersistentVolumeClaimControllerExpansionFailed, Failed},
+		{v1.PersistentVolumeClaimNodeExpansionFailed, Failed},
+	}
+	for _, tc := range testCases {
+		claim := claimOf("20Gi", "10Gi")
+		claim.Status.ResizeStatus = resizeStatus(tc.status)
+		got := Interpret(claim, nil)
+		if got.State != tc.want {
+			t.Errorf("resizeStatus %q: state %q, want %q", tc.status, got.State, tc.want)
+		}
+		if got.Explanation == "" {
+			t.Errorf("resizeStatus %q: missing explanation", tc.status)
+		}
+	}
+}
+
+func TestInterpretConditions(t *testing.T) {
+	claim := claimOf("20Gi", "10Gi")
+	claim.Status.Conditions = []v1.PersistentVolumeClaimCondition{{
+		Type:    v1.PersistentVolumeClaimFileSystemResizePending,
+		Status:  v1.ConditionTrue,
+		Message: "Waiting for user to (re-)start a pod",
+	}}
+	got := Interpret(claim, nil)
+	if got.State != NodeExpansionPending || !strings.Contains(got.Explanation, "re-)start a pod") {
+		t.Errorf("got %+v", got)
+	}
+
+	claim.Status.Conditions[0].Type = v1.PersistentVolumeClaimResizing
+	if got := Interpret(claim, nil); got.State != ControllerExpanding {
+		t.Errorf("Resizing condition: %+v", got)
+	}
+}
+
+func TestInterpretCapacityGap(t *testing.T) {
+	// Request enlarged, nothing picked it up yet.
+	got := Interpret(claimOf("20Gi", "10Gi"), nil)
+	if got.State != ControllerExpanding || !strings.Contains(got.Explanation, "awaiting the resize controller") {
+		t.Errorf("got %+v", got)
+	}
+
+	// allocatedResources tracks an in-flight target.
+	claim := claimOf("20Gi", "10Gi")
+	claim.Status.AllocatedResources = v1.ResourceList{v1.ResourceStorage: resource.MustParse("20Gi")}
+	got = Interpret(claim, nil)
+	if got.State != ControllerExpanding || !strings.Contains(got.Explanation, "allocatedResources") {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestInterpretIdle(t *testing.T) {
+	got := Interpret(claimOf("10Gi", "10Gi"), nil)
+	if got.State != ExpansionIdle {
+		t.Errorf("got %+v", got)
+	}
+
+	// A cleared resizeStatus means a completed expansion.
+	claim := claimOf("20Gi", "20Gi")
+	claim.Status.ResizeStatus = resizeStatus(v1.PersistentVolumeClaimNoExpansionInProgress)
+	if got := Interpret(claim, nil); got.State != ExpansionIdle {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestInterpretPVCapacityFallback(t *testing.T) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+		},
+	}
+	got := Interpret(claimOf("20Gi", ""), pv)
+	if got.Actual.String() != "10Gi" || got.State != ControllerExpanding {
+		t.Errorf("got %+v", got)
+	}
+}
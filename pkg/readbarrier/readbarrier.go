@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package readbarrier provides a consistency barrier between a live
+// apiserver view and a locally maintained cache. Barrier issues a live
+// read to learn the current resourceVersion for each kind, then waits
+// until the cache has observed at least that version, giving multi-step
+// workflows read-your-writes semantics over the cache: any mutation that
+// completed before the barrier started is visible after it returns.
+//
+// ResourceVersions are opaque per the API contract, but a barrier needs
+// ordering; this package relies on the etcd-backed integer versions every
+// supported apiserver produces and fails loudly on anything else.
+package readbarrier
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// LiveReader returns the server's current resourceVersion for a kind,
+// typically from the ListMeta of a live `List` with Limit=1.
+type LiveReader func(ctx context.Context, kind string) (string, error)
+
+// ObservedReader returns the newest resourceVersion the local cache has
+// observed for a kind. It is polled, so it must be cheap.
+type ObservedReader func(kind string) (string, error)
+
+// defaultPollInterval balances barrier latency against polling cost; the
+// informer machinery delivers within a few milliseconds of this anyway.
+const defaultPollInterval = 25 * time.Millisecond
+
+// Barrier waits for a cache to catch up with live reads.
+type Barrier struct {
+	live     LiveReader
+	observed ObservedReader
+	// PollInterval overrides how often the cache is re-checked; zero
+	// means the package default.
+	PollInterval time.Duration
+}
+
+// New returns a barrier comparing the given live and cached views.
+func New(live LiveReader, observed ObservedReader) *Barrier {
+	return &Barrier{live: live, observed: observed}
+}
+
+// Wait blocks until, for every given kind, the cache has observed a
+// resourceVersion at least as new as a live read issued when Wait was
+// called. It returns the context's error if it expires first.
+func (b *Barrier) Wait(ctx context.Context, kinds ...string) error {
+	// Capture all live targets up front so the barrier point is a single
+	// moment, not one per kind.
+	targets := make(map[string]uint64, len(kinds))
+	for _, kind := range kinds {
+		rv, err := b.live(ctx, kind)
+		if err != nil {
+			return fmt.Errorf("live read for %s: %v", kind, err)
+		}
+		target, err := parseResourceVersion(rv)
+		if err != nil {
+			return fmt.Errorf("live read for %s: %v", kind, err)
+		}
+		targets[kind] = target
+	}
+
+	interval := b.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	for _, kind := range kinds {
+		kind := kind
+		target := targets[kind]
+		err := wait.PollImmediateUntilWithContext(ctx, interval, func(ctx context.Context) (bool, error) {
+			rv, err := b.observed(kind)
+			if err != nil {
+				return false, fmt.Errorf("cache read for %s: %v", kind, err)
+			}
+			if rv == "" {
+				// Cache has not listed yet.
+				return false, nil
+			}
+			observed, err := parseResourceVersion(rv)
+			if err != nil {
+				return false, fmt.Errorf("cache read for %s: %v", kind, err)
+			}
+			return observed >= target, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseResourceVersion(rv string) (uint64, error) {
+	parsed, err := strconv.ParseUint(rv, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("resourceVersion %q is not orderable: %v", rv, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readbarrier
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCache is an ObservedReader whose per-kind versions advance under test
+// control.
+type fakeCache struct {
+	mu       sync.Mutex
+	observed map[string]string
+}
+
+func (c *fakeCache) set(kind, rv string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.observed == nil {
+		c.observed = map[string]string{}
+	}
+	c.observed[kind] = rv
+}
+
+func (c *fakeCache) read(kind string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.observed[kind], nil
+}
+
+func liveAt(rvs map[string]string) LiveReader {
+	return func(_ context.Context, kind string) (string, error) {
+		return rvs[kind], nil
+	}
+}
+
+func TestWaitCatchesUp(t *testing.T) {
+	cache := &fakeCache{}
+	cache.set("pods", "90")
+	cache.set("nodes", "200")
+
+	barrier := New(liveAt(map[string]string{"pods": "100", "nodes": "200"}), cache.read)
+	barrier.PollInterval = time.Millisecond
+
+	// Advance the pods cache past the live target shortly after the
+	// barrier starts polling.
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cache.set("pods", "105")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := barrier.Wait(ctx, "pods", "nodes"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWaitTimesOut(t *testing.T) {
+	cache := &fakeCache{}
+	cache.set("pods", "90")
+
+	barrier := New(liveAt(map[string]string{"pods": "100"}), cache.read)
+	barrier.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := barrier.Wait(ctx, "pods"); err == nil {
+		t.Fatal("expected timeout error for a cache that never catches up")
+	}
+}
+
+func TestWaitUnparsableResourceVersion(t *testing.T) {
+	cache := &fakeCache{}
+	barrier := New(liveAt(map[string]string{"pods": "not-a-number"}), cache.read)
+
+	if err := barrier.Wait(context.Background(), "pods"); err == nil {
+		t.Fatal("expected error for unorderable resourceVersion")
+	}
+}
+
+func TestWaitEmptyCacheNotReady(t *testing.T) {
+	// An empty observed version means the cache has not listed; the
+	// barrier must wait rather than treat it as version zero satisfied.
+	cache := &fakeCache{}
+	barrier := New(liveAt(map[string]string{"pods": "0"}), cache.read)
+	barrier.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := barrier.Wait(ctx, "pods"); err == nil {
+		t.Fatal("expected barrier to wait for a cache that has not listed")
+	}
+}
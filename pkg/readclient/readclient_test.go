@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var podsGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/namespaces/default/pods/web", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"web"}}`))
+	})
+	mux.HandleFunc("/api/v1/namespaces/default/pods", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			w.Write([]byte(`{"type":"ADDED","object":{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"api"}}}` + "\n"))
+			return
+		}
+		if selector := r.URL.Query().Get("labelSelector"); selector != "" && selector != "app=web" {
+			t.Errorf("unexpected selector %q", selector)
+		}
+		w.Write([]byte(`{"apiVersion":"v1","kind":"PodList","items":[{"metadata":{"namespace":"default","name":"web"}}]}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func clientFor(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+	return New(&rest.Config{Host: server.URL})
+}
+
+func TestGetDecodesTyped(t *testing.T) {
+	client := clientFor(t, testServer(t))
+	obj, err := client.Get(context.Background(), podsGVR, "default", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		t.Fatalf("expected *v1.Pod, got %T", obj)
+	}
+	if pod.Name != "web" {
+		t.Errorf("unexpected pod: %+v", pod)
+	}
+}
+
+func TestListPassesSelectors(t *testing.T) {
+	client := clientFor(t, testServer(t))
+	obj, err := client.List(context.Background(), podsGVR, "default", metav1.ListOptions{LabelSelector: "app=web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := obj.(*v1.PodList)
+	if !ok {
+		t.Fatalf("expected *v1.PodList, got %T", obj)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "web" {
+		t.Errorf("unexpected list: %+v", list.Items)
+	}
+}
+
+func TestWatchDeliversTypedEvents(t *testing.T) {
+	client := clientFor(t, testServer(t))
+	watcher, err := client.Watch(context.Background(), podsGVR, "default", metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	event, ok := <-watcher.ResultChan()
+	if !ok {
+		t.Fatal("watch closed without events")
+	}
+	if event.Type != watch.Added {
+		t.Errorf("event type = %s, want ADDED", event.Type)
+	}
+	pod, ok := event.Object.(*v1.Pod)
+	if !ok || pod.Name != "api" {
+		t.Errorf("unexpected event object: %T %+v", event.Object, event.Object)
+	}
+}
+
+const kubeconfigTemplate = `apiVersion: v1
+kind: Config
+current-context: main
+clusters:
+- name: main
+  cluster:
+    server: https://example.invalid:6443
+contexts:
+- name: main
+  context:
+    cluster: main
+    user: main
+users:
+- name: main
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws-iam-authenticator
+      args: ["token", "-i", "cluster"]
+`
+
+func TestRESTConfigFromKubeconfigWithExec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(kubeconfigTemplate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := RESTConfig(Config{Kubeconfig: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "https://example.invalid:6443" {
+		t.Errorf("host = %q", config.Host)
+	}
+	if config.ExecProvider == nil || config.ExecProvider.Command != "aws-iam-authenticator" {
+		t.Errorf("exec plugin not carried through: %+v", config.ExecProvider)
+	}
+	if config.UserAgent != "k8s-read" {
+		t.Errorf("user agent = %q", config.UserAgent)
+	}
+}
+
+func TestRESTConfigMissingEverything(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "nope"))
+	t.Setenv("HOME", t.TempDir())
+	if _, err := RESTConfig(Config{}); err == nil {
+		t.Error("expected an error with no kubeconfig and no cluster environment")
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package readproxy serves cached objects over HTTP with proper caching
+// semantics. The ETag is derived from the object's resourceVersion — the
+// server-side change token — so a conditional GET from a polling client
+// costs a 304 with no body whenever the object has not changed.
+// Last-Modified is taken from the newest managedFields timestamp, the
+// closest thing an object carries to a modification time.
+package readproxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ETagFor returns the entity tag for an object: its resourceVersion as a
+// quoted strong validator. Empty when the object has no resourceVersion.
+func ETagFor(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil || accessor.GetResourceVersion() == "" {
+		return ""
+	}
+	return `"` + accessor.GetResourceVersion() + `"`
+}
+
+// LastModified returns the newest managedFields operation time, which
+// tracks the last write through the apiserver. The zero time means the
+// object carries no managedFields.
+func LastModified(obj runtime.Object) time.Time {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return time.Time{}
+	}
+	var newest time.Time
+	for _, entry := range accessor.GetManagedFields() {
+		if entry.Time != nil && entry.Time.Time.After(newest) {
+			newest = entry.Time.Time
+		}
+	}
+	return newest
+}
+
+// etagMatches implements If-None-Match comparison: "*" matches anything,
+// otherwise any listed tag must equal the current one. Weak-prefix tags
+// compare by their opaque part, per RFC 9110's weak comparison.
+func etagMatches(header, etag string) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	current := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.TrimPrefix(candidate, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeObject writes one object with conditional-GET handling. The codec
+// determines the body encoding; its media type is sent as Content-Type.
+func ServeObject(w http.ResponseWriter, r *http.Request, obj runtime.Object, codec runtime.Codec, mediaType string) {
+	etag := ETagFor(obj)
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	lastModified := LastModified(obj)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since (RFC 9110).
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if etagMatches(match, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	} else if since := r.Header.Get("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		if at, err := http.ParseTime(since); err == nil && !lastModified.Truncate(time.Second).After(at) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	data, err := runtime.Encode(codec, obj)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encoding object: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// Lookup resolves a request to an object. A nil object with a nil error
+// means not found.
+type Lookup func(r *http.Request) (runtime.Object, error)
+
+// NewHandler serves GET requests through the lookup with caching
+// semantics applied.
+func NewHandler(lookup Lookup, codec runtime.Codec, mediaType string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		obj, err := lookup(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if obj == nil {
+			http.NotFound(w, r)
+			return
+		}
+		ServeObject(w, r, obj, codec, mediaType)
+	})
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func testCodec(t *testing.T) runtime.Codec {
+	t.Helper()
+	info, ok := runtime.SerializerInfoForMediaType(clientsetscheme.Codecs.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	if !ok {
+		t.Fatal("no JSON serializer")
+	}
+	return clientsetscheme.Codecs.CodecForVersions(info.Serializer, info.Serializer, v1.SchemeGroupVersion, v1.SchemeGroupVersion)
+}
+
+func servedPod(rv string, modified time.Time) *v1.Pod {
+	pod := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "web",
+			ResourceVersion: rv,
+		},
+	}
+	if !modified.IsZero() {
+		at := metav1.NewTime(modified)
+		pod.ManagedFields = []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl", Time: &at},
+		}
+	}
+	return pod
+}
+
+func newTestHandler(t *testing.T, obj runtime.Object) http.Handler {
+	t.Helper()
+	return NewHandler(func(*http.Request) (runtime.Object, error) {
+		return obj, nil
+	}, testCodec(t), "application/json")
+}
+
+func TestServeSetsValidators(t *testing.T) {
+	modified := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	handler := newTestHandler(t, servedPod("12345", modified))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pods/default/web", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", recorder.Code)
+	}
+	if etag := recorder.Header().Get("ETag"); etag != `"12345"` {
+		t.Errorf("unexpected ETag %q", etag)
+	}
+	if lastModified := recorder.Header().Get("Last-Modified"); lastModified != "Thu, 01 Jun 2023 12:00:00 GMT" {
+		t.Errorf("unexpected Last-Modified %q", lastModified)
+	}
+	if recorder.Body.Len() == 0 {
+		t.Error("expected a body on unconditional GET")
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	modified := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	handler := newTestHandler(t, servedPod("12345", modified))
+
+	testCases := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{
+			name:       "matching etag",
+			headers:    map[string]string{"If-None-Match": `"12345"`},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "weak etag matches",
+			headers:    map[string]string{"If-None-Match": `W/"12345"`},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "etag in list matches",
+			headers:    map[string]string{"If-None-Match": `"999", "12345"`},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "stale etag",
+			headers:    map[string]string{"If-None-Match": `"11111"`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "not modified since",
+			headers:    map[string]string{"If-Modified-Since": "Thu, 01 Jun 2023 12:00:00 GMT"},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "modified since",
+			headers:    map[string]string{"If-Modified-Since": "Thu, 01 Jun 2023 11:00:00 GMT"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "etag precedence over date",
+			headers: map[string]string{
+				"If-None-Match":     `"11111"`,
+				"If-Modified-Since": "Thu, 01 Jun 2023 12:00:00 GMT",
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/pods/default/web", nil)
+			for key, value := range tc.headers {
+				request.Header.Set(key, value)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", recorder.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusNotModified && recorder.Body.Len() != 0 {
+				t.Error("304 must not carry a body")
+			}
+		})
+	}
+}
+
+func TestHandlerEdges(t *testing.T) {
+	handler := NewHandler(func(*http.Request) (runtime.Object, error) {
+		return nil, nil
+	}, testCodec(t), "application/json")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pods/default/ghost", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing object, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/pods/default/web", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", recorder.Code)
+	}
+}
+
+func TestNoManagedFieldsOmitsLastModified(t *testing.T) {
+	handler := newTestHandler(t, servedPod("12345", time.Time{}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pods/default/web", nil))
+	if lastModified := recorder.Header().Get("Last-Modified"); lastModified != "" {
+		t.Errorf("expected no Last-Modified, got %q", lastModified)
+	}
+}
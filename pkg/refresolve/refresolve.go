@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package refresolve resolves the various reference shapes used by core
+// APIs — ObjectReference, TypedLocalObjectReference and TypedObjectReference
+// (including its cross-namespace alpha semantics) — to the referenced
+// object. Lookups that fail because the object is gone or access is denied
+// are reported as structured outcomes rather than bare errors, so callers
+// do not have to re-implement the same error classification everywhere.
+package refresolve
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Reference is the normalized form of the reference types supported by
+// Resolve.
+type Reference struct {
+	// Group is the API group; empty for the core group.
+	Group string
+	// Version is the preferred API version; empty means any version the
+	// RESTMapper knows for the group/kind.
+	Version string
+	// Kind is the referenced kind.
+	Kind string
+	// Namespace is the namespace to look in; ignored for cluster-scoped
+	// kinds.
+	Namespace string
+	// Name is the referenced object's name.
+	Name string
+}
+
+// FromObjectReference normalizes a v1.ObjectReference.
+func FromObjectReference(ref *v1.ObjectReference) Reference {
+	gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+	return Reference{
+		Group:     gv.Group,
+		Version:   gv.Version,
+		Kind:      ref.Kind,
+		Namespace: ref.Namespace,
+		Name:      ref.Name,
+	}
+}
+
+// FromTypedLocalObjectReference normalizes a v1.TypedLocalObjectReference,
+// which always refers into the given namespace.
+func FromTypedLocalObjectReference(ref *v1.TypedLocalObjectReference, namespace string) Reference {
+	r := Reference{Kind: ref.Kind, Namespace: namespace, Name: ref.Name}
+	if ref.APIGroup != nil {
+		r.Group = *ref.APIGroup
+	}
+	return r
+}
+
+// FromTypedObjectReference normalizes a v1.TypedObjectReference. Following
+// the CrossNamespaceVolumeDataSource alpha semantics, the reference's own
+// namespace wins when set; otherwise the given namespace is used.
+func FromTypedObjectReference(ref *v1.TypedObjectReference, namespace string) Reference {
+	r := Reference{Kind: ref.Kind, Namespace: namespace, Name: ref.Name}
+	if ref.APIGroup != nil {
+		r.Group = *ref.APIGroup
+	}
+	if ref.Namespace != nil && *ref.Namespace != "" {
+		r.Namespace = *ref.Namespace
+	}
+	return r
+}
+
+// Outcome classifies how a resolution ended.
+type Outcome string
+
+const (
+	// Found means the referenced object was retrieved.
+	Found Outcome = "Found"
+	// NotFound means the referenced object does not exist.
+	NotFound Outcome = "NotFound"
+	// Denied means access to the referenced object was forbidden or
+	// unauthorized for the resolving client.
+	Denied Outcome = "Denied"
+)
+
+// Result is the structured outcome of a resolution.
+type Result struct {
+	// Reference is the normalized reference that was resolved.
+	Reference Reference
+	// Outcome classifies the result.
+	Outcome Outcome
+	// Object is the referenced object; set only for Found.
+	Object *unstructured.Unstructured
+	// Message carries the server's error message for NotFound and Denied.
+	Message string
+}
+
+// Resolver resolves references using a dynamic client and a RESTMapper.
+type Resolver struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+}
+
+// NewResolver returns a resolver backed by the given client and mapper.
+func NewResolver(client dynamic.Interface, mapper meta.RESTMapper) *Resolver {
+	return &Resolver{client: client, mapper: mapper}
+}
+
+// Resolve fetches the referenced object. NotFound and Denied lookups are
+// reported in the Result; an error is returned only for malformed
+// references, unmappable kinds, or transport failures.
+func (r *Resolver) Resolve(ctx context.Context, ref Reference) (*Result, error) {
+	if ref.Kind == "" || ref.Name == "" {
+		return nil, fmt.Errorf("reference must carry kind and name, got %+v", ref)
+	}
+	var versions []string
+	if ref.Version != "" {
+		versions = []string{ref.Version}
+	}
+	mapping, err := r.mapper.RESTMapping(schema.GroupKind{Group: ref.Group, Kind: ref.Kind}, versions...)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s.%s: %v", ref.Kind, ref.Group, err)
+	}
+
+	var client dynamic.ResourceInterface = r.client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		client = r.client.Resource(mapping.Resource).Namespace(ref.Namespace)
+	}
+	obj, err := client.Get(ctx, ref.Name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		return &Result{Reference: ref, Outcome: Found, Object: obj}, nil
+	case apierrors.IsNotFound(err):
+		return &Result{Reference: ref, Outcome: NotFound, Message: err.Error()}, nil
+	case apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err):
+		return &Result{Reference: ref, Outcome: Denied, Message: err.Error()}, nil
+	default:
+		return nil, err
+	}
+}
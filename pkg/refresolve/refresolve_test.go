@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package refresolve
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func newTestMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{
+		{Version: "v1"},
+		{Group: "snapshot.storage.k8s.io", Version: "v1"},
+	})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func newTestResolver(t *testing.T, objects ...runtime.Object) *Resolver {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshotList"}, &runtime.Unknown{})
+	client := dynamicfake.NewSimpleDynamicClient(scheme, objects...)
+	return NewResolver(client, newTestMapper())
+}
+
+func TestResolveObjectReference(t *testing.T) {
+	resolver := newTestResolver(t,
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "creds"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+	)
+
+	testCases := []struct {
+		name     string
+		ref      Reference
+		expected Outcome
+	}{
+		{
+			name:     "namespaced found",
+			ref:      FromObjectReference(&v1.ObjectReference{APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "creds"}),
+			expected: Found,
+		},
+		{
+			name:     "cluster scoped found",
+			ref:      FromObjectReference(&v1.ObjectReference{APIVersion: "v1", Kind: "Node", Name: "node-1"}),
+			expected: Found,
+		},
+		{
+			name:     "not found",
+			ref:      FromObjectReference(&v1.ObjectReference{APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "absent"}),
+			expected: NotFound,
+		},
+		{
+			name:     "wrong namespace not found",
+			ref:      FromObjectReference(&v1.ObjectReference{APIVersion: "v1", Kind: "Secret", Namespace: "other", Name: "creds"}),
+			expected: NotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := resolver.Resolve(context.Background(), tc.ref)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Outcome != tc.expected {
+				t.Errorf("expected outcome %s, got %s (%s)", tc.expected, result.Outcome, result.Message)
+			}
+			if tc.expected == Found && result.Object == nil {
+				t.Error("expected object for Found outcome")
+			}
+		})
+	}
+}
+
+func TestResolveDenied(t *testing.T) {
+	resolver := newTestResolver(t)
+	fakeClient := resolver.client.(*dynamicfake.FakeDynamicClient)
+	fakeClient.PrependReactor("get", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "creds", nil)
+	})
+
+	result, err := resolver.Resolve(context.Background(), Reference{Kind: "Secret", Namespace: "default", Name: "creds"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Outcome != Denied {
+		t.Errorf("expected Denied, got %s", result.Outcome)
+	}
+	if result.Message == "" {
+		t.Error("expected server message for Denied outcome")
+	}
+}
+
+func TestResolveUnmappableKind(t *testing.T) {
+	resolver := newTestResolver(t)
+	_, err := resolver.Resolve(context.Background(), Reference{Kind: "Widget", Name: "w"})
+	if err == nil {
+		t.Fatal("expected error for unmappable kind")
+	}
+	_, err = resolver.Resolve(context.Background(), Reference{Kind: "Secret"})
+	if err == nil {
+		t.Fatal("expected error for reference without name")
+	}
+}
+
+func TestFromTypedReferences(t *testing.T) {
+	group := "snapshot.storage.k8s.io"
+	local := FromTypedLocalObjectReference(&v1.TypedLocalObjectReference{
+		APIGroup: &group, Kind: "VolumeSnapshot", Name: "snap",
+	}, "default")
+	if local.Group != group || local.Namespace != "default" || local.Name != "snap" {
+		t.Errorf("unexpected normalized local reference: %+v", local)
+	}
+
+	// Without an explicit namespace the caller's namespace applies.
+	typed := FromTypedObjectReference(&v1.TypedObjectReference{
+		APIGroup: &group, Kind: "VolumeSnapshot", Name: "snap",
+	}, "default")
+	if typed.Namespace != "default" {
+		t.Errorf("expected caller namespace, got %q", typed.Namespace)
+	}
+
+	// CrossNamespace alpha semantics: the reference's namespace wins.
+	other := "other"
+	cross := FromTypedObjectReference(&v1.TypedObjectReference{
+		APIGroup: &group, Kind: "VolumeSnapshot", Namespace: &other, Name: "snap",
+	}, "default")
+	if cross.Namespace != "other" {
+		t.Errorf("expected cross-namespace reference to win, got %q", cross.Namespace)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package refs extracts the outgoing references scattered through
+// core/v1 objects — imagePullSecrets, env and volume sources, claimRef,
+// nodeName, ownerReferences — into one edge list, and indexes edges
+// both ways so "which Secrets does this Pod read" and "which Pods
+// reference PVC X" are the same lookup.
+package refs
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Node identifies an object in the graph.
+type Node struct {
+	// Kind is the referenced kind, e.g. "Secret" or "Node".
+	Kind string
+	// Namespace is empty for cluster-scoped kinds.
+	Namespace string
+	Name      string
+}
+
+func (n Node) String() string {
+	if n.Namespace == "" {
+		return n.Kind + "/" + n.Name
+	}
+	return n.Kind + "/" + n.Namespace + "/" + n.Name
+}
+
+// Edge is one reference from one object to another.
+type Edge struct {
+	From Node
+	To   Node
+	// FieldPath locates the reference in the source object.
+	FieldPath string
+}
+
+// FromObject extracts every outgoing reference of one object.
+// Unsupported types yield only their ownerReferences.
+func FromObject(obj runtime.Object) ([]Edge, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		kind = fmt.Sprintf("%T", obj)
+	}
+	var from Node
+	var edges []Edge
+
+	add := func(toKind, toNamespace, toName, fieldPath string) {
+		if toName == "" {
+			return
+		}
+		edges = append(edges, Edge{From: from, To: Node{Kind: toKind, Namespace: toNamespace, Name: toName}, FieldPath: fieldPath})
+	}
+
+	switch typed := obj.(type) {
+	case *v1.Pod:
+		from = Node{Kind: "Pod", Namespace: typed.Namespace, Name: typed.Name}
+		podEdges(typed, add)
+	case *v1.PersistentVolume:
+		from = Node{Kind: "PersistentVolume", Name: typed.Name}
+		if typed.Spec.ClaimRef != nil {
+			add("PersistentVolumeClaim", typed.Spec.ClaimRef.Namespace, typed.Spec.ClaimRef.Name, "spec.claimRef")
+		}
+		if csi := typed.Spec.CSI; csi != nil {
+			for field, ref := range map[string]*v1.SecretReference{
+				"spec.csi.nodePublishSecretRef":       csi.NodePublishSecretRef,
+				"spec.csi.controllerPublishSecretRef": csi.ControllerPublishSecretRef,
+				"spec.csi.nodeStageSecretRef":         csi.NodeStageSecretRef,
+				"spec.csi.controllerExpandSecretRef":  csi.ControllerExpandSecretRef,
+			} {
+				if ref != nil {
+					add("Secret", ref.Namespace, ref.Name, field)
+				}
+			}
+		}
+	case *v1.PersistentVolumeClaim:
+		from = Node{Kind: "PersistentVolumeClaim", Namespace: typed.Namespace, Name: typed.Name}
+		add("PersistentVolume", "", typed.Spec.VolumeName, "spec.volumeName")
+		if ds := typed.Spec.DataSource; ds != nil {
+			add(ds.Kind, typed.Namespace, ds.Name, "spec.dataSource")
+		}
+	case *v1.ServiceAccount:
+		from = Node{Kind: "ServiceAccount", Namespace: typed.Namespace, Name: typed.Name}
+		for i, secret := range typed.Secrets {
+			add("Secret", typed.Namespace, secret.Name, fmt.Sprintf("secrets[%d]", i))
+		}
+		for i, secret := range typed.ImagePullSecrets {
+			add("Secret", typed.Namespace, secret.Name, fmt.Sprintf("imagePullSecrets[%d]", i))
+		}
+	case *v1.Endpoints:
+		from = Node{Kind: "Endpoints", Namespace: typed.Namespace, Name: typed.Name}
+		for si, subset := range typed.Subsets {
+			for ai, address := range subset.Addresses {
+				if address.TargetRef != nil {
+					add(address.TargetRef.Kind, address.TargetRef.Namespace, address.TargetRef.Name,
+						fmt.Sprintf("subsets[%d].addresses[%d].targetRef", si, ai))
+				}
+			}
+		}
+	default:
+		from = Node{Kind: kind, Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	}
+
+	for i, owner := range accessor.GetOwnerReferences() {
+		add(owner.Kind, accessor.GetNamespace(), owner.Name, fmt.Sprintf("metadata.ownerReferences[%d]", i))
+	}
+	return edges, nil
+}
+
+func podEdges(pod *v1.Pod, add func(kind, namespace, name, fieldPath string)) {
+	ns := pod.Namespace
+	add("Node", "", pod.Spec.NodeName, "spec.nodeName")
+	add("ServiceAccount", ns, pod.Spec.ServiceAccountName, "spec.serviceAccountName")
+	for i, secret := range pod.Spec.ImagePullSecrets {
+		add("Secret", ns, secret.Name, fmt.Sprintf("spec.imagePullSecrets[%d]", i))
+	}
+	for i, volume := range pod.Spec.Volumes {
+		prefix := fmt.Sprintf("spec.volumes[%d]", i)
+		switch {
+		case volume.Secret != nil:
+			add("Secret", ns, volume.Secret.SecretName, prefix+".secret")
+		case volume.ConfigMap != nil:
+			add("ConfigMap", ns, volume.ConfigMap.Name, prefix+".configMap")
+		case volume.PersistentVolumeClaim != nil:
+			add("PersistentVolumeClaim", ns, volume.PersistentVolumeClaim.ClaimName, prefix+".persistentVolumeClaim")
+		case volume.Projected != nil:
+			for j, source := range volume.Projected.Sources {
+				sourcePrefix := fmt.Sprintf("%s.projected.sources[%d]", prefix, j)
+				if source.Secret != nil {
+					add("Secret", ns, source.Secret.Name, sourcePrefix+".secret")
+				}
+				if source.ConfigMap != nil {
+					add("ConfigMap", ns, source.ConfigMap.Name, sourcePrefix+".configMap")
+				}
+			}
+		}
+	}
+	containers := make([]v1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	containers = append(containers, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	for _, container := range containers {
+		prefix := fmt.Sprintf("container %s", container.Name)
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				add("ConfigMap", ns, envFrom.ConfigMapRef.Name, prefix+" envFrom")
+			}
+			if envFrom.SecretRef != nil {
+				add("Secret", ns, envFrom.SecretRef.Name, prefix+" envFrom")
+			}
+		}
+		for _, envVar := range container.Env {
+			if envVar.ValueFrom == nil {
+				continue
+			}
+			if envVar.ValueFrom.ConfigMapKeyRef != nil {
+				add("ConfigMap", ns, envVar.ValueFrom.ConfigMapKeyRef.Name, fmt.Sprintf("%s env %s", prefix, envVar.Name))
+			}
+			if envVar.ValueFrom.SecretKeyRef != nil {
+				add("Secret", ns, envVar.ValueFrom.SecretKeyRef.Name, fmt.Sprintf("%s env %s", prefix, envVar.Name))
+			}
+		}
+	}
+}
+
+// Graph indexes edges in both directions.
+type Graph struct {
+	out map[Node][]Edge
+	in  map[Node][]Edge
+}
+
+// Build extracts and indexes the references of every object.
+func Build(objects []runtime.Object) (*Graph, error) {
+	graph := &Graph{out: map[Node][]Edge{}, in: map[Node][]Edge{}}
+	for _, obj := range objects {
+		edges, err := FromObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		for _, edge := range edges {
+			graph.out[edge.From] = append(graph.out[edge.From], edge)
+			graph.in[edge.To] = append(graph.in[edge.To], edge)
+		}
+	}
+	return graph, nil
+}
+
+// Dependencies returns the edges leaving the node, sorted by target.
+func (g *Graph) Dependencies(node Node) []Edge {
+	return sortedEdges(g.out[node], func(e Edge) string { return e.To.String() + " " + e.FieldPath })
+}
+
+// Dependents returns the edges pointing at the node, sorted by source.
+func (g *Graph) Dependents(node Node) []Edge {
+	return sortedEdges(g.in[node], func(e Edge) string { return e.From.String() + " " + e.FieldPath })
+}
+
+func sortedEdges(edges []Edge, key func(Edge) string) []Edge {
+	sorted := make([]Edge, len(edges))
+	copy(sorted, edges)
+	sort.Slice(sorted, func(i, j int) bool { return key(sorted[i]) < key(sorted[j]) })
+	return sorted
+}
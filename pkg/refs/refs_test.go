@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package refs
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func referencingPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "prod", Name: "web-0",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "web"}},
+		},
+		Spec: v1.PodSpec{
+			NodeName:           "node-1",
+			ServiceAccountName: "web-sa",
+			ImagePullSecrets:   []v1.LocalObjectReference{{Name: "registry-creds"}},
+			Volumes: []v1.Volume{
+				{Name: "data", VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "web-data"},
+				}},
+				{Name: "cfg", VolumeSource: v1.VolumeSource{
+					ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: "web-config"}},
+				}},
+			},
+			Containers: []v1.Container{{
+				Name: "app",
+				Env: []v1.EnvVar{{
+					Name: "PASSWORD",
+					ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: "db-creds"}, Key: "password",
+					}},
+				}},
+			}},
+		},
+	}
+}
+
+func TestFromObjectPod(t *testing.T) {
+	edges, err := FromObject(referencingPod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := map[string]string{}
+	for _, edge := range edges {
+		targets[edge.To.String()] = edge.FieldPath
+	}
+	want := map[string]string{
+		"Node/node-1":                         "spec.nodeName",
+		"ServiceAccount/prod/web-sa":          "spec.serviceAccountName",
+		"Secret/prod/registry-creds":          "spec.imagePullSecrets[0]",
+		"PersistentVolumeClaim/prod/web-data": "spec.volumes[0].persistentVolumeClaim",
+		"ConfigMap/prod/web-config":           "spec.volumes[1].configMap",
+		"Secret/prod/db-creds":                "container app env PASSWORD",
+		"StatefulSet/prod/web":                "metadata.ownerReferences[0]",
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("edges: %v", targets)
+	}
+	for target, fieldPath := range want {
+		if targets[target] != fieldPath {
+			t.Errorf("%s: fieldPath %q, want %q", target, targets[target], fieldPath)
+		}
+	}
+}
+
+func TestFromObjectPV(t *testing.T) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: v1.PersistentVolumeSpec{
+			ClaimRef: &v1.ObjectReference{Namespace: "prod", Name: "web-data"},
+			PersistentVolumeSource: v1.PersistentVolumeSource{CSI: &v1.CSIPersistentVolumeSource{
+				Driver:               "ebs.csi.aws.com",
+				VolumeHandle:         "vol-1",
+				NodePublishSecretRef: &v1.SecretReference{Namespace: "kube-system", Name: "csi-creds"},
+			}},
+		},
+	}
+	edges, err := FromObject(pv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 2 {
+		t.Fatalf("edges: %+v", edges)
+	}
+}
+
+func TestGraphQueries(t *testing.T) {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web-data"},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+	}
+	graph, err := Build([]runtime.Object{referencingPod(), claim})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod := Node{Kind: "Pod", Namespace: "prod", Name: "web-0"}
+	dependencies := graph.Dependencies(pod)
+	if len(dependencies) != 7 {
+		t.Errorf("pod dependencies: %+v", dependencies)
+	}
+
+	pvc := Node{Kind: "PersistentVolumeClaim", Namespace: "prod", Name: "web-data"}
+	dependents := graph.Dependents(pvc)
+	if len(dependents) != 1 || dependents[0].From != pod {
+		t.Errorf("PVC dependents: %+v", dependents)
+	}
+	if deps := graph.Dependencies(pvc); len(deps) != 1 || deps[0].To.String() != "PersistentVolume/pv-1" {
+		t.Errorf("PVC dependencies: %+v", deps)
+	}
+
+	if unknown := graph.Dependents(Node{Kind: "Secret", Namespace: "prod", Name: "unused"}); len(unknown) != 0 {
+		t.Errorf("unexpected dependents: %+v", unknown)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rerrors is the error taxonomy for read operations. Every error
+// crossing a layer boundary (client, cache, decode) is classified into one
+// of a small set of typed wrappers that carry the original error, work
+// with errors.As/errors.Is, and answer the one question callers keep
+// re-deriving by string matching: is this retryable, and after how long.
+package rerrors
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Class names a taxonomy bucket.
+type Class string
+
+const (
+	ClassNotFound   Class = "NotFound"
+	ClassForbidden  Class = "Forbidden"
+	ClassExpired    Class = "Expired"
+	ClassThrottled  Class = "Throttled"
+	ClassDecode     Class = "Decode"
+	ClassStaleCache Class = "StaleCache"
+	// ClassUnknown is everything not yet classified; treated retryable.
+	ClassUnknown Class = "Unknown"
+)
+
+// Error is the common shape of classified errors.
+type Error struct {
+	// Class is the taxonomy bucket.
+	Class Class
+	// Resource and Name identify what was being read, when known.
+	Resource string
+	Name     string
+	// RetryAfter is a retry hint for retryable classes; zero means
+	// "immediately" or "not retryable" depending on Retryable.
+	RetryAfter time.Duration
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	target := e.Resource
+	if e.Name != "" {
+		target = e.Resource + " " + e.Name
+	}
+	if target != "" {
+		return fmt.Sprintf("%s reading %s: %v", e.Class, target, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Class, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Retryable reports whether retrying the operation can succeed without
+// operator intervention. NotFound and Forbidden are terminal; Expired
+// requires a re-list rather than a plain retry but is recoverable, so it
+// reports true.
+func (e *Error) Retryable() bool {
+	switch e.Class {
+	case ClassNotFound, ClassForbidden, ClassDecode:
+		return false
+	default:
+		return true
+	}
+}
+
+// NotFound wraps a missing-object error.
+func NotFound(resource, name string, err error) *Error {
+	return &Error{Class: ClassNotFound, Resource: resource, Name: name, Err: err}
+}
+
+// Forbidden wraps an authorization failure.
+func Forbidden(resource, name string, err error) *Error {
+	return &Error{Class: ClassForbidden, Resource: resource, Name: name, Err: err}
+}
+
+// Expired wraps a resourceVersion-too-old failure; the caller must
+// re-list before retrying the watch.
+func Expired(resource string, err error) *Error {
+	return &Error{Class: ClassExpired, Resource: resource, Err: err}
+}
+
+// Throttled wraps a rate-limit rejection with the server's retry hint.
+func Throttled(resource string, retryAfter time.Duration, err error) *Error {
+	return &Error{Class: ClassThrottled, Resource: resource, RetryAfter: retryAfter, Err: err}
+}
+
+// Decode wraps a deserialization failure; retrying the same bytes cannot
+// help.
+func Decode(resource, name string, err error) *Error {
+	return &Error{Class: ClassDecode, Resource: resource, Name: name, Err: err}
+}
+
+// StaleCache reports a cache that has not caught up to a required
+// resourceVersion; retry after it syncs.
+func StaleCache(resource string, err error) *Error {
+	return &Error{Class: ClassStaleCache, Resource: resource, Err: err}
+}
+
+// Classify maps an arbitrary error from the client or decode layers into
+// the taxonomy. Already-classified errors pass through unchanged; nil
+// stays nil.
+func Classify(err error, resource, name string) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	switch {
+	case apierrors.IsNotFound(err) || apierrors.IsGone(err) && !apierrors.IsResourceExpired(err):
+		return NotFound(resource, name, err)
+	case apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err):
+		return Forbidden(resource, name, err)
+	case apierrors.IsResourceExpired(err):
+		return Expired(resource, err)
+	case apierrors.IsTooManyRequests(err):
+		retryAfter := time.Duration(0)
+		if seconds, present := apierrors.SuggestsClientDelay(err); present {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return Throttled(resource, retryAfter, err)
+	case runtime.IsStrictDecodingError(err) || runtime.IsMissingKind(err) || runtime.IsMissingVersion(err) || runtime.IsNotRegisteredError(err):
+		return Decode(resource, name, err)
+	default:
+		return &Error{Class: ClassUnknown, Resource: resource, Name: name, Err: err}
+	}
+}
+
+// IsClass reports whether err (or anything it wraps) is classified into
+// the given bucket.
+func IsClass(err error, class Class) bool {
+	var classified *Error
+	return errors.As(err, &classified) && classified.Class == class
+}
+
+// IsRetryable reports whether err is worth retrying. Unclassified errors
+// default to retryable, matching client-go's posture for transient
+// failures.
+func IsRetryable(err error) bool {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Retryable()
+	}
+	return true
+}
+
+// RetryAfter returns the retry hint carried by err, or zero.
+func RetryAfter(err error) time.Duration {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassify(t *testing.T) {
+	podsResource := schema.GroupResource{Resource: "pods"}
+	testCases := []struct {
+		name      string
+		err       error
+		wantClass Class
+		retryable bool
+	}{
+		{
+			name:      "not found",
+			err:       apierrors.NewNotFound(podsResource, "web"),
+			wantClass: ClassNotFound,
+			retryable: false,
+		},
+		{
+			name:      "forbidden",
+			err:       apierrors.NewForbidden(podsResource, "web", errors.New("denied")),
+			wantClass: ClassForbidden,
+			retryable: false,
+		},
+		{
+			name:      "expired",
+			err:       apierrors.NewResourceExpired("too old resource version"),
+			wantClass: ClassExpired,
+			retryable: true,
+		},
+		{
+			name:      "throttled",
+			err:       apierrors.NewTooManyRequests("slow down", 7),
+			wantClass: ClassThrottled,
+			retryable: true,
+		},
+		{
+			name:      "unknown",
+			err:       errors.New("connection refused"),
+			wantClass: ClassUnknown,
+			retryable: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			classified := Classify(tc.err, "pods", "web")
+			if !IsClass(classified, tc.wantClass) {
+				t.Fatalf("expected class %s, got %v", tc.wantClass, classified)
+			}
+			if IsRetryable(classified) != tc.retryable {
+				t.Errorf("expected retryable=%v for %v", tc.retryable, classified)
+			}
+			// The original error stays reachable for apierrors checks.
+			if !errors.Is(classified, tc.err) {
+				t.Errorf("expected wrapped error to match errors.Is")
+			}
+		})
+	}
+}
+
+func TestClassifyNilAndPassthrough(t *testing.T) {
+	if Classify(nil, "pods", "web") != nil {
+		t.Error("nil must stay nil")
+	}
+	original := Throttled("pods", 3*time.Second, errors.New("429"))
+	reclassified := Classify(fmt.Errorf("listing: %w", original), "pods", "")
+	if !IsClass(reclassified, ClassThrottled) {
+		t.Errorf("already-classified error must pass through, got %v", reclassified)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := Classify(apierrors.NewTooManyRequests("slow down", 7), "pods", "")
+	if got := RetryAfter(err); got != 7*time.Second {
+		t.Errorf("expected 7s retry hint, got %v", got)
+	}
+	if got := RetryAfter(errors.New("plain")); got != 0 {
+		t.Errorf("expected zero hint for unclassified error, got %v", got)
+	}
+}
+
+func TestErrorsAs(t *testing.T) {
+	wrapped := fmt.Errorf("outer context: %w", StaleCache("pods", errors.New("cache at rv 90, need 100")))
+	var classified *Error
+	if !errors.As(wrapped, &classified) {
+		t.Fatal("errors.As must find the classified error through wrapping")
+	}
+	if classified.Class != ClassStaleCache || !classified.Retryable() {
+		t.Errorf("unexpected classification: %+v", classified)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := NotFound("pods", "web", errors.New(`pods "web" not found`))
+	want := `NotFound reading pods web: pods "web" not found`
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resourcehelpers aggregates pod resource requirements the way
+// the scheduler does — containers summed, init containers folded in as
+// a per-resource maximum, Overhead added on top — so report and
+// capacity tooling in this repo stops reimplementing the rule and
+// getting init containers wrong. The arithmetic lives in
+// pkg/api/v1/resource; this package gives it the shapes reads want.
+package resourcehelpers
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+)
+
+// PodRequests returns the pod's effective resource requests, including
+// Overhead. This is the quantity the scheduler reserves on a node.
+func PodRequests(pod *v1.Pod) v1.ResourceList {
+	requests, _ := resourcehelper.PodRequestsAndLimits(pod)
+	return requests
+}
+
+// PodLimits returns the pod's effective resource limits. Overhead is
+// added only to resources that already have a non-zero limit, matching
+// the kubelet's cgroup math.
+func PodLimits(pod *v1.Pod) v1.ResourceList {
+	_, limits := resourcehelper.PodRequestsAndLimits(pod)
+	return limits
+}
+
+// MaxContainerResources returns, per resource, the largest single
+// request among all containers and init containers — the floor any node
+// must clear for the pod's biggest container, useful when judging
+// whether splitting a workload would help it schedule.
+func MaxContainerResources(pod *v1.Pod) v1.ResourceList {
+	max := v1.ResourceList{}
+	for _, container := range append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		for name, quantity := range container.Resources.Requests {
+			if value, ok := max[name]; !ok || quantity.Cmp(value) > 0 {
+				max[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return max
+}
+
+// RequestsForResource returns the effective request for one resource as
+// a scalar, zero when unset.
+func RequestsForResource(pod *v1.Pod, name v1.ResourceName) resource.Quantity {
+	if quantity, ok := PodRequests(pod)[name]; ok {
+		return quantity
+	}
+	return resource.Quantity{}
+}
+
+// TotalRequests sums the effective requests of many pods, e.g. all pods
+// on one node or in one namespace.
+func TotalRequests(pods []*v1.Pod) v1.ResourceList {
+	total := v1.ResourceList{}
+	for _, pod := range pods {
+		for name, quantity := range PodRequests(pod) {
+			if value, ok := total[name]; ok {
+				value.Add(quantity)
+				total[name] = value
+			} else {
+				total[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return total
+}
+
+// Fits reports whether the pod's effective requests fit within the
+// available list, and the resources that do not fit.
+func Fits(pod *v1.Pod, available v1.ResourceList) (bool, []v1.ResourceName) {
+	var exceeded []v1.ResourceName
+	for name, quantity := range PodRequests(pod) {
+		if quantity.IsZero() {
+			continue
+		}
+		capacity, ok := available[name]
+		if !ok || quantity.Cmp(capacity) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+	return len(exceeded) == 0, exceeded
+}
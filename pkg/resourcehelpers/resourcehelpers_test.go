@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcehelpers
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func requests(cpu, memory string) v1.ResourceRequirements {
+	list := v1.ResourceList{}
+	if cpu != "" {
+		list[v1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		list[v1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return v1.ResourceRequirements{Requests: list}
+}
+
+func testPod() *v1.Pod {
+	return &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				{Name: "migrate", Resources: requests("500m", "1Gi")},
+			},
+			Containers: []v1.Container{
+				{Name: "app", Resources: requests("200m", "256Mi")},
+				{Name: "sidecar", Resources: requests("100m", "128Mi")},
+			},
+		},
+	}
+}
+
+func TestPodRequestsInitContainerMax(t *testing.T) {
+	pod := testPod()
+	reqs := PodRequests(pod)
+	// Containers sum to 300m/384Mi; the init container's 500m/1Gi
+	// dominates both.
+	if cpu := reqs[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("500m")) != 0 {
+		t.Errorf("cpu = %s, want 500m", cpu.String())
+	}
+	if mem := reqs[v1.ResourceMemory]; mem.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("memory = %s, want 1Gi", mem.String())
+	}
+
+	// With a bigger main container sum, the sum wins.
+	pod.Spec.Containers[0].Resources = requests("600m", "2Gi")
+	reqs = PodRequests(pod)
+	if cpu := reqs[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("700m")) != 0 {
+		t.Errorf("cpu = %s, want 700m", cpu.String())
+	}
+}
+
+func TestPodRequestsOverhead(t *testing.T) {
+	pod := testPod()
+	pod.Spec.Overhead = v1.ResourceList{v1.ResourceCPU: resource.MustParse("50m")}
+	reqs := PodRequests(pod)
+	if cpu := reqs[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("550m")) != 0 {
+		t.Errorf("cpu = %s, want 550m", cpu.String())
+	}
+}
+
+func TestPodLimitsOverheadOnlyOnLimited(t *testing.T) {
+	pod := testPod()
+	pod.Spec.Containers[0].Resources.Limits = v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pod.Spec.Overhead = v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("50m"),
+		v1.ResourceMemory: resource.MustParse("64Mi"),
+	}
+	limits := PodLimits(pod)
+	if cpu := limits[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("1050m")) != 0 {
+		t.Errorf("cpu limit = %s, want 1050m", cpu.String())
+	}
+	// Memory has no limit, so overhead must not invent one.
+	if mem, ok := limits[v1.ResourceMemory]; ok && !mem.IsZero() {
+		t.Errorf("memory limit invented from overhead: %s", mem.String())
+	}
+}
+
+func TestMaxContainerResources(t *testing.T) {
+	max := MaxContainerResources(testPod())
+	if cpu := max[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("500m")) != 0 {
+		t.Errorf("max cpu = %s, want 500m", cpu.String())
+	}
+	if mem := max[v1.ResourceMemory]; mem.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("max memory = %s, want 1Gi", mem.String())
+	}
+}
+
+func TestTotalRequests(t *testing.T) {
+	total := TotalRequests([]*v1.Pod{testPod(), testPod()})
+	if cpu := total[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("1")) != 0 {
+		t.Errorf("total cpu = %s, want 1", cpu.String())
+	}
+}
+
+func TestFits(t *testing.T) {
+	pod := testPod()
+	ok, exceeded := Fits(pod, v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("1"),
+		v1.ResourceMemory: resource.MustParse("2Gi"),
+	})
+	if !ok || len(exceeded) != 0 {
+		t.Errorf("pod should fit, exceeded %v", exceeded)
+	}
+	ok, exceeded = Fits(pod, v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("250m"),
+		v1.ResourceMemory: resource.MustParse("2Gi"),
+	})
+	if ok || len(exceeded) != 1 || exceeded[0] != v1.ResourceCPU {
+		t.Errorf("expected cpu to exceed, got ok=%v exceeded=%v", ok, exceeded)
+	}
+}
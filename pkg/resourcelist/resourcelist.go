@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resourcelist provides arithmetic over v1.ResourceList values
+// — add, subtract, per-resource max and comparison — so capacity math
+// like "allocatable minus the requests of every pod on the node" is a
+// single call instead of a hand-rolled quantity loop repeated in each
+// report. All operations return new lists; inputs are never mutated.
+package resourcelist
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+)
+
+// Add returns a + b, resource by resource. Resources present in only
+// one list are carried through unchanged.
+func Add(a, b v1.ResourceList) v1.ResourceList {
+	result := clone(a)
+	for name, quantity := range b {
+		sum := result[name]
+		sum.Add(quantity)
+		result[name] = sum
+	}
+	return result
+}
+
+// Sub returns a - b, resource by resource. A resource present only in
+// b appears in the result with a negative value; use NonNegative to
+// clamp or detect deficits.
+func Sub(a, b v1.ResourceList) v1.ResourceList {
+	result := clone(a)
+	for name, quantity := range b {
+		diff := result[name]
+		diff.Sub(quantity)
+		result[name] = diff
+	}
+	return result
+}
+
+// Max returns the per-resource maximum of a and b. This is the merge
+// rule for init containers, whose requests apply sequentially rather
+// than additively.
+func Max(a, b v1.ResourceList) v1.ResourceList {
+	result := clone(a)
+	for name, quantity := range b {
+		if current, ok := result[name]; !ok || quantity.Cmp(current) > 0 {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+	return result
+}
+
+// Cmp compares a and b for the named resource, treating a missing
+// entry as zero. It returns -1, 0 or 1 as a is less than, equal to or
+// greater than b.
+func Cmp(a, b v1.ResourceList, name v1.ResourceName) int {
+	left := a[name]
+	right := b[name]
+	return left.Cmp(right)
+}
+
+// NonNegative reports whether every quantity in the list is zero or
+// positive, and returns the names of resources in deficit, sorted.
+func NonNegative(list v1.ResourceList) (bool, []v1.ResourceName) {
+	var negative []v1.ResourceName
+	for name, quantity := range list {
+		if quantity.Sign() < 0 {
+			negative = append(negative, name)
+		}
+	}
+	sort.Slice(negative, func(i, j int) bool { return negative[i] < negative[j] })
+	return len(negative) == 0, negative
+}
+
+// NodeFree returns the node's allocatable minus the summed effective
+// requests (including overhead) of the given pods. Resources the pods
+// request but the node does not advertise — a hugepages size or
+// ephemeral-storage on a node without the capacity — show up negative.
+func NodeFree(node *v1.Node, pods []*v1.Pod) v1.ResourceList {
+	free := clone(node.Status.Allocatable)
+	for _, pod := range pods {
+		requests, _ := resourcehelper.PodRequestsAndLimits(pod)
+		free = Sub(free, requests)
+	}
+	return free
+}
+
+// HugePages returns just the hugepages-<size> entries of the list.
+func HugePages(list v1.ResourceList) v1.ResourceList {
+	result := v1.ResourceList{}
+	for name, quantity := range list {
+		if resourcehelper.IsHugePageResourceName(name) {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+	return result
+}
+
+// IsZero reports whether every quantity in the list is zero; an empty
+// list is zero.
+func IsZero(list v1.ResourceList) bool {
+	for _, quantity := range list {
+		if !quantity.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func clone(list v1.ResourceList) v1.ResourceList {
+	result := make(v1.ResourceList, len(list))
+	for name, quantity := range list {
+		result[name] = quantity.DeepCopy()
+	}
+	return result
+}
+
+// Equal reports whether a and b hold the same resources with equal
+// values, ignoring zero entries on either side.
+func Equal(a, b v1.ResourceList) bool {
+	names := map[v1.ResourceName]bool{}
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+	for name := range names {
+		left, right := a[name], b[name]
+		if left.Cmp(right) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelist
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func list(pairs ...string) v1.ResourceList {
+	result := v1.ResourceList{}
+	for i := 0; i < len(pairs); i += 2 {
+		result[v1.ResourceName(pairs[i])] = resource.MustParse(pairs[i+1])
+	}
+	return result
+}
+
+func TestAddSub(t *testing.T) {
+	a := list("cpu", "500m", "memory", "1Gi")
+	b := list("cpu", "250m", "ephemeral-storage", "2Gi")
+
+	sum := Add(a, b)
+	if !Equal(sum, list("cpu", "750m", "memory", "1Gi", "ephemeral-storage", "2Gi")) {
+		t.Errorf("Add = %v", sum)
+	}
+	// Inputs are untouched.
+	if !Equal(a, list("cpu", "500m", "memory", "1Gi")) {
+		t.Errorf("Add mutated its input: %v", a)
+	}
+
+	diff := Sub(a, b)
+	if Cmp(diff, nil, "ephemeral-storage") >= 0 {
+		t.Errorf("Sub should go negative: %v", diff)
+	}
+	ok, deficits := NonNegative(diff)
+	if ok || len(deficits) != 1 || deficits[0] != "ephemeral-storage" {
+		t.Errorf("NonNegative = %v, %v", ok, deficits)
+	}
+}
+
+func TestMaxAndCmp(t *testing.T) {
+	merged := Max(list("cpu", "1", "memory", "512Mi"), list("cpu", "500m", "memory", "2Gi"))
+	if !Equal(merged, list("cpu", "1", "memory", "2Gi")) {
+		t.Errorf("Max = %v", merged)
+	}
+	if Cmp(list("cpu", "1"), list("cpu", "1000m"), "cpu") != 0 {
+		t.Error("1 CPU and 1000m must compare equal")
+	}
+	if Cmp(nil, list("memory", "1Gi"), "memory") != -1 {
+		t.Error("missing entries compare as zero")
+	}
+}
+
+func TestNodeFree(t *testing.T) {
+	node := &v1.Node{Status: v1.NodeStatus{Allocatable: list(
+		"cpu", "4", "memory", "8Gi", "hugepages-2Mi", "1Gi", "pods", "110",
+	)}}
+	pod := func(cpu, memory string) *v1.Pod {
+		return &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{
+			Resources: v1.ResourceRequirements{Requests: list("cpu", cpu, "memory", memory)},
+		}}}}
+	}
+	hugePod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{
+		Resources: v1.ResourceRequirements{Requests: list("hugepages-2Mi", "512Mi")},
+	}}}}
+
+	free := NodeFree(node, []*v1.Pod{pod("1", "2Gi"), pod("500m", "1Gi"), hugePod})
+	if Cmp(free, list("cpu", "2500m"), "cpu") != 0 {
+		t.Errorf("free cpu: %v", free[v1.ResourceCPU])
+	}
+	if Cmp(free, list("memory", "5Gi"), "memory") != 0 {
+		t.Errorf("free memory: %v", free[v1.ResourceMemory])
+	}
+	huge := HugePages(free)
+	if len(huge) != 1 || Cmp(huge, list("hugepages-2Mi", "512Mi"), "hugepages-2Mi") != 0 {
+		t.Errorf("free hugepages: %v", huge)
+	}
+	if ok, _ := NonNegative(free); !ok {
+		t.Errorf("node should not be overcommitted: %v", free)
+	}
+}
+
+func TestIsZeroAndEqual(t *testing.T) {
+	if !IsZero(nil) || !IsZero(list("cpu", "0")) {
+		t.Error("nil and all-zero lists are zero")
+	}
+	if IsZero(list("cpu", "1m")) {
+		t.Error("non-zero quantity")
+	}
+	// Zero entries are ignored by Equal.
+	if !Equal(list("cpu", "1", "memory", "0"), list("cpu", "1")) {
+		t.Error("zero entries should not break equality")
+	}
+	if Equal(list("cpu", "1"), list("cpu", "2")) {
+		t.Error("different values must not be equal")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runtimeclass resolves a pod's runtimeClassName to its
+// RuntimeClass and answers the two questions capacity math and runtime
+// audits need: which handler actually runs the pod, and what overhead the
+// pod charges the node. The Overhead defaulting rules live in PodSpec
+// comments (admission copies the class overhead onto pods; a pod with no
+// class has none); this makes them executable for objects that may or may
+// not have passed through that admission.
+package runtimeclass
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+)
+
+// DefaultHandler is the implicit handler for pods without a runtime
+// class: the container runtime's configured default (runc on typical
+// clusters).
+const DefaultHandler = ""
+
+// Getter supplies RuntimeClasses by name, typically a lister.
+type Getter func(name string) (*nodev1.RuntimeClass, error)
+
+// Resolution is the resolved runtime facts for one pod.
+type Resolution struct {
+	// ClassName is the pod's runtimeClassName; empty for the default
+	// runtime.
+	ClassName string
+	// Class is the resolved RuntimeClass; nil for the default runtime.
+	Class *nodev1.RuntimeClass
+	// Handler is the CRI handler that runs the pod; DefaultHandler for
+	// pods without a class.
+	Handler string
+	// Overhead is the effective pod overhead: the pod's own Overhead
+	// when admission already set it, otherwise the class's. Nil when
+	// neither defines one.
+	Overhead v1.ResourceList
+}
+
+// Resolve resolves the pod's runtime class via the getter. A pod naming a
+// class that does not resolve is an error — such pods are unschedulable,
+// and capacity math silently treating them as default-runtime would be
+// wrong.
+func Resolve(pod *v1.Pod, get Getter) (*Resolution, error) {
+	if pod.Spec.RuntimeClassName == nil || *pod.Spec.RuntimeClassName == "" {
+		return &Resolution{
+			Handler:  DefaultHandler,
+			Overhead: overheadFromPod(pod),
+		}, nil
+	}
+
+	name := *pod.Spec.RuntimeClassName
+	class, err := get(name)
+	if err != nil {
+		return nil, fmt.Errorf("resolving runtime class %q for pod %s/%s: %v", name, pod.Namespace, pod.Name, err)
+	}
+
+	resolution := &Resolution{
+		ClassName: name,
+		Class:     class,
+		Handler:   class.Handler,
+		Overhead:  overheadFromPod(pod),
+	}
+	// Admission copies class overhead onto the pod at create time; for
+	// objects that predate the class's overhead or were built by hand,
+	// fall back to the class.
+	if resolution.Overhead == nil && class.Overhead != nil {
+		resolution.Overhead = class.Overhead.PodFixed.DeepCopy()
+	}
+	return resolution, nil
+}
+
+// overheadFromPod returns a copy of the pod's own overhead, or nil.
+func overheadFromPod(pod *v1.Pod) v1.ResourceList {
+	if pod.Spec.Overhead == nil {
+		return nil
+	}
+	return pod.Spec.Overhead.DeepCopy()
+}
+
+// TotalRequests returns the pod's scheduling footprint: the maximum of
+// container requests (accounting for init containers running serially)
+// plus the resolved overhead, the same math the scheduler applies.
+func TotalRequests(pod *v1.Pod, resolution *Resolution) v1.ResourceList {
+	total := v1.ResourceList{}
+
+	// Sum of regular container requests.
+	for i := range pod.Spec.Containers {
+		for name, quantity := range pod.Spec.Containers[i].Resources.Requests {
+			sum := total[name]
+			sum.Add(quantity)
+			total[name] = sum
+		}
+	}
+	// Init containers run one at a time; the pod needs the max of any
+	// single init container and the running-state sum.
+	for i := range pod.Spec.InitContainers {
+		for name, quantity := range pod.Spec.InitContainers[i].Resources.Requests {
+			if current, present := total[name]; !present || quantity.Cmp(current) > 0 {
+				total[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	if resolution != nil {
+		for name, quantity := range resolution.Overhead {
+			sum := total[name]
+			sum.Add(quantity)
+			total[name] = sum
+		}
+	}
+	return total
+}
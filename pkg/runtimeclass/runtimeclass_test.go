@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtimeclass
+
+import (
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func gvisorClass() *nodev1.RuntimeClass {
+	return &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gvisor"},
+		Handler:    "runsc",
+		Overhead: &nodev1.Overhead{
+			PodFixed: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("250m"),
+				v1.ResourceMemory: resource.MustParse("120Mi"),
+			},
+		},
+	}
+}
+
+func classGetter(classes ...*nodev1.RuntimeClass) Getter {
+	return func(name string) (*nodev1.RuntimeClass, error) {
+		for _, class := range classes {
+			if class.Name == name {
+				return class, nil
+			}
+		}
+		return nil, errors.New("not found")
+	}
+}
+
+func stringPtr(s string) *string { return &s }
+
+func TestResolveDefaultRuntime(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}}
+	resolution, err := Resolve(pod, classGetter())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolution.Handler != DefaultHandler || resolution.Class != nil || resolution.Overhead != nil {
+		t.Errorf("unexpected resolution for classless pod: %+v", resolution)
+	}
+}
+
+func TestResolveClassOverheadFallback(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sandboxed"},
+		Spec:       v1.PodSpec{RuntimeClassName: stringPtr("gvisor")},
+	}
+	resolution, err := Resolve(pod, classGetter(gvisorClass()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolution.Handler != "runsc" || resolution.ClassName != "gvisor" {
+		t.Errorf("unexpected resolution: %+v", resolution)
+	}
+	if cpu := resolution.Overhead[v1.ResourceCPU]; cpu.String() != "250m" {
+		t.Errorf("expected class overhead applied, got %+v", resolution.Overhead)
+	}
+}
+
+func TestResolvePodOverheadWins(t *testing.T) {
+	// Admission already stamped a (different) overhead on the pod; the
+	// pod's value is authoritative.
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sandboxed"},
+		Spec: v1.PodSpec{
+			RuntimeClassName: stringPtr("gvisor"),
+			Overhead: v1.ResourceList{
+				v1.ResourceCPU: resource.MustParse("100m"),
+			},
+		},
+	}
+	resolution, err := Resolve(pod, classGetter(gvisorClass()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu := resolution.Overhead[v1.ResourceCPU]; cpu.String() != "100m" {
+		t.Errorf("expected pod overhead to win, got %+v", resolution.Overhead)
+	}
+}
+
+func TestResolveMissingClassFails(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sandboxed"},
+		Spec:       v1.PodSpec{RuntimeClassName: stringPtr("kata")},
+	}
+	if _, err := Resolve(pod, classGetter(gvisorClass())); err == nil {
+		t.Fatal("expected error for unresolvable class")
+	}
+}
+
+func TestTotalRequests(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			RuntimeClassName: stringPtr("gvisor"),
+			InitContainers: []v1.Container{{
+				Name: "init",
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("1"),
+				}},
+			}},
+			Containers: []v1.Container{
+				{
+					Name: "a",
+					Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("200m"),
+						v1.ResourceMemory: resource.MustParse("256Mi"),
+					}},
+				},
+				{
+					Name: "b",
+					Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+						v1.ResourceCPU: resource.MustParse("300m"),
+					}},
+				},
+			},
+		},
+	}
+	resolution, err := Resolve(pod, classGetter(gvisorClass()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := TotalRequests(pod, resolution)
+	// Init container (1) dominates the container sum (500m); overhead
+	// (250m) is added on top.
+	if cpu := total[v1.ResourceCPU]; cpu.String() != "1250m" {
+		t.Errorf("unexpected cpu total %s", cpu.String())
+	}
+	// Memory: containers 256Mi + overhead 120Mi.
+	if memory := total[v1.ResourceMemory]; memory.String() != "376Mi" {
+		t.Errorf("unexpected memory total %s", memory.String())
+	}
+}
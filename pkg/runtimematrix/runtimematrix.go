@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runtimematrix flattens the runtime hardening knobs of every
+// container — pod sysctls, seccomp, capabilities, privileged, procMount,
+// readOnlyRootFilesystem, runAsUser/NonRoot — into one row per
+// container, with the pod-vs-container precedence already resolved.
+// Fleet hardening reviews consume the matrix as CSV or JSON instead of
+// re-deriving precedence per spreadsheet. Ulimits are set by the
+// container runtime, not the pod spec, so they are out of scope here;
+// sysctls are the spec-visible half of that story.
+package runtimematrix
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Row is the effective runtime posture of one container.
+type Row struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// ContainerType is "init", "container" or "ephemeral".
+	ContainerType string `json:"containerType"`
+	Privileged    bool   `json:"privileged"`
+	// CapAdd and CapDrop are sorted, comma-joined capability lists.
+	CapAdd  string `json:"capAdd,omitempty"`
+	CapDrop string `json:"capDrop,omitempty"`
+	// Seccomp is the effective profile type (container overrides pod),
+	// with the localhost profile path appended when set.
+	Seccomp   string `json:"seccomp,omitempty"`
+	ProcMount string `json:"procMount,omitempty"`
+	// ReadOnlyRootFilesystem is "true", "false" or "" (unset).
+	ReadOnlyRootFilesystem string `json:"readOnlyRootFilesystem,omitempty"`
+	// RunAsUser is the effective UID, container overriding pod; ""
+	// means runtime default.
+	RunAsUser    string `json:"runAsUser,omitempty"`
+	RunAsNonRoot string `json:"runAsNonRoot,omitempty"`
+	// Sysctls are pod-scoped and repeated on each row so every row is
+	// self-contained; sorted "name=value" pairs, comma-joined.
+	Sysctls string `json:"sysctls,omitempty"`
+	// AllowPrivilegeEscalation is "true", "false" or "" (unset, which
+	// the runtime treats as true unless otherwise constrained).
+	AllowPrivilegeEscalation string `json:"allowPrivilegeEscalation,omitempty"`
+}
+
+// effectiveSeccomp resolves container-over-pod seccomp precedence.
+func effectiveSeccomp(pod *v1.PodSecurityContext, container *v1.SecurityContext) string {
+	var profile *v1.SeccompProfile
+	if container != nil && container.SeccompProfile != nil {
+		profile = container.SeccompProfile
+	} else if pod != nil && pod.SeccompProfile != nil {
+		profile = pod.SeccompProfile
+	}
+	if profile == nil {
+		return ""
+	}
+	if profile.Type == v1.SeccompProfileTypeLocalhost && profile.LocalhostProfile != nil {
+		return string(profile.Type) + ":" + *profile.LocalhostProfile
+	}
+	return string(profile.Type)
+}
+
+func capabilityList(capabilities []v1.Capability) string {
+	if len(capabilities) == 0 {
+		return ""
+	}
+	names := make([]string, len(capabilities))
+	for i, capability := range capabilities {
+		names[i] = string(capability)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func boolString(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+// effectiveRunAs resolves the container-over-pod precedence for runAs
+// fields.
+func effectiveRunAs(pod *v1.PodSecurityContext, container *v1.SecurityContext) (user, nonRoot string) {
+	if pod != nil {
+		if pod.RunAsUser != nil {
+			user = strconv.FormatInt(*pod.RunAsUser, 10)
+		}
+		nonRoot = boolString(pod.RunAsNonRoot)
+	}
+	if container != nil {
+		if container.RunAsUser != nil {
+			user = strconv.FormatInt(*container.RunAsUser, 10)
+		}
+		if container.RunAsNonRoot != nil {
+			nonRoot = boolString(container.RunAsNonRoot)
+		}
+	}
+	return user, nonRoot
+}
+
+func rowFor(pod *v1.Pod, container v1.Container, containerType, sysctls string) Row {
+	podSC := pod.Spec.SecurityContext
+	sc := container.SecurityContext
+	row := Row{
+		Namespace:     pod.Namespace,
+		Pod:           pod.Name,
+		Container:     container.Name,
+		ContainerType: containerType,
+		Seccomp:       effectiveSeccomp(podSC, sc),
+		Sysctls:       sysctls,
+	}
+	row.RunAsUser, row.RunAsNonRoot = effectiveRunAs(podSC, sc)
+	if sc != nil {
+		row.Privileged = sc.Privileged != nil && *sc.Privileged
+		if sc.Capabilities != nil {
+			row.CapAdd = capabilityList(sc.Capabilities.Add)
+			row.CapDrop = capabilityList(sc.Capabilities.Drop)
+		}
+		if sc.ProcMount != nil {
+			row.ProcMount = string(*sc.ProcMount)
+		}
+		row.ReadOnlyRootFilesystem = boolString(sc.ReadOnlyRootFilesystem)
+		row.AllowPrivilegeEscalation = boolString(sc.AllowPrivilegeEscalation)
+	}
+	return row
+}
+
+// Rows flattens pods into the per-container matrix, sorted by
+// namespace/pod/container.
+func Rows(pods []*v1.Pod) []Row {
+	var rows []Row
+	for _, pod := range pods {
+		sysctls := ""
+		if pod.Spec.SecurityContext != nil && len(pod.Spec.SecurityContext.Sysctls) > 0 {
+			pairs := make([]string, len(pod.Spec.SecurityContext.Sysctls))
+			for i, sysctl := range pod.Spec.SecurityContext.Sysctls {
+				pairs[i] = sysctl.Name + "=" + sysctl.Value
+			}
+			sort.Strings(pairs)
+			sysctls = strings.Join(pairs, ",")
+		}
+		for _, container := range pod.Spec.InitContainers {
+			rows = append(rows, rowFor(pod, container, "init", sysctls))
+		}
+		for _, container := range pod.Spec.Containers {
+			rows = append(rows, rowFor(pod, container, "container", sysctls))
+		}
+		for _, ephemeral := range pod.Spec.EphemeralContainers {
+			rows = append(rows, rowFor(pod, v1.Container(ephemeral.EphemeralContainerCommon), "ephemeral", sysctls))
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		return a.Container < b.Container
+	})
+	return rows
+}
+
+// csvHeader matches the Row field order.
+var csvHeader = []string{
+	"namespace", "pod", "container", "containerType", "privileged",
+	"capAdd", "capDrop", "seccomp", "procMount", "readOnlyRootFilesystem",
+	"runAsUser", "runAsNonRoot", "sysctls", "allowPrivilegeEscalation",
+}
+
+// WriteCSV exports the matrix with a header row.
+func WriteCSV(w io.Writer, rows []Row) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.Namespace, row.Pod, row.Container, row.ContainerType,
+			strconv.FormatBool(row.Privileged),
+			row.CapAdd, row.CapDrop, row.Seccomp, row.ProcMount,
+			row.ReadOnlyRootFilesystem, row.RunAsUser, row.RunAsNonRoot,
+			row.Sysctls, row.AllowPrivilegeEscalation,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON exports the matrix as a JSON array.
+func WriteJSON(w io.Writer, rows []Row) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(rows); err != nil {
+		return fmt.Errorf("encoding matrix: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtimematrix
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func boolPtr(b bool) *bool    { return &b }
+func int64Ptr(i int64) *int64 { return &i }
+
+func TestRowsPrecedence(t *testing.T) {
+	localhost := "audit.json"
+	procMount := v1.UnmaskedProcMount
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web"},
+		Spec: v1.PodSpec{
+			SecurityContext: &v1.PodSecurityContext{
+				RunAsUser:      int64Ptr(1000),
+				RunAsNonRoot:   boolPtr(true),
+				SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+				Sysctls: []v1.Sysctl{
+					{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+					{Name: "kernel.shm_rmid_forced", Value: "0"},
+				},
+			},
+			InitContainers: []v1.Container{{Name: "setup"}},
+			Containers: []v1.Container{
+				{
+					Name: "app",
+					SecurityContext: &v1.SecurityContext{
+						RunAsUser:      int64Ptr(2000),
+						SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost, LocalhostProfile: &localhost},
+						Capabilities: &v1.Capabilities{
+							Add:  []v1.Capability{"NET_BIND_SERVICE"},
+							Drop: []v1.Capability{"NET_RAW", "ALL"},
+						},
+						ReadOnlyRootFilesystem:   boolPtr(true),
+						AllowPrivilegeEscalation: boolPtr(false),
+					},
+				},
+				{
+					Name: "debug",
+					SecurityContext: &v1.SecurityContext{
+						Privileged: boolPtr(true),
+						ProcMount:  &procMount,
+					},
+				},
+			},
+		},
+	}
+
+	rows := Rows([]*v1.Pod{pod})
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d: %+v", len(rows), rows)
+	}
+	byName := map[string]Row{}
+	for _, row := range rows {
+		byName[row.Container] = row
+	}
+
+	app := byName["app"]
+	if app.Seccomp != "Localhost:audit.json" {
+		t.Errorf("container seccomp must override pod: %q", app.Seccomp)
+	}
+	if app.RunAsUser != "2000" {
+		t.Errorf("container runAsUser must override pod: %q", app.RunAsUser)
+	}
+	if app.RunAsNonRoot != "true" {
+		t.Errorf("pod runAsNonRoot must be inherited: %q", app.RunAsNonRoot)
+	}
+	if app.CapAdd != "NET_BIND_SERVICE" || app.CapDrop != "ALL,NET_RAW" {
+		t.Errorf("capabilities wrong: add=%q drop=%q", app.CapAdd, app.CapDrop)
+	}
+	if app.ReadOnlyRootFilesystem != "true" || app.AllowPrivilegeEscalation != "false" {
+		t.Errorf("bool fields wrong: %+v", app)
+	}
+
+	debug := byName["debug"]
+	if !debug.Privileged || debug.ProcMount != "Unmasked" {
+		t.Errorf("privileged/procMount wrong: %+v", debug)
+	}
+	if debug.Seccomp != "RuntimeDefault" {
+		t.Errorf("pod seccomp must be inherited when container is silent: %q", debug.Seccomp)
+	}
+
+	setup := byName["setup"]
+	if setup.ContainerType != "init" {
+		t.Errorf("init container not typed: %+v", setup)
+	}
+	if setup.RunAsUser != "1000" || setup.ReadOnlyRootFilesystem != "" {
+		t.Errorf("nil container securityContext must inherit pod fields only: %+v", setup)
+	}
+
+	// Sysctls are pod-scoped: identical, sorted, on every row.
+	want := "kernel.shm_rmid_forced=0,net.ipv4.tcp_syncookies=1"
+	for _, row := range rows {
+		if row.Sysctls != want {
+			t.Errorf("sysctls on %s = %q, want %q", row.Container, row.Sysctls, want)
+		}
+	}
+}
+
+func TestRowsSorted(t *testing.T) {
+	pods := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "zeta", Name: "a"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "c"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "alpha", Name: "b"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "z"}, {Name: "a"}}},
+		},
+	}
+	rows := Rows(pods)
+	got := make([]string, len(rows))
+	for i, row := range rows {
+		got[i] = row.Namespace + "/" + row.Pod + "/" + row.Container
+	}
+	want := []string{"alpha/b/a", "alpha/b/z", "zeta/a/c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	rows := Rows([]*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "p"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name:            "c",
+			SecurityContext: &v1.SecurityContext{Privileged: boolPtr(true)},
+		}}},
+	}})
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header + 1 row, got %d", len(records))
+	}
+	if records[0][0] != "namespace" || records[0][len(records[0])-1] != "allowPrivilegeEscalation" {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+	row := records[1]
+	if row[0] != "default" || row[1] != "p" || row[2] != "c" || row[4] != "true" {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	rows := Rows([]*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "p"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "c"}}},
+	}})
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"pod": "p"`) || !strings.Contains(out, `"containerType": "container"`) {
+		t.Errorf("unexpected JSON: %s", out)
+	}
+	// Unset optional fields stay out of the export entirely.
+	if strings.Contains(out, "seccomp") {
+		t.Errorf("empty seccomp must be omitted: %s", out)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rwopcheck detects access-mode conflicts around ReadWriteOncePod
+// and ReadWriteOnce volumes: RWOP claims referenced by more than one pod,
+// RWO claims attached from multiple nodes, and PersistentVolumes whose
+// access modes cannot support how their bound claim is actually used. The
+// API validates the access-mode enum but nothing checks real usage until
+// pods wedge at attach time.
+package rwopcheck
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ConflictType classifies a finding.
+type ConflictType string
+
+const (
+	// RWOPMultiplePods is a ReadWriteOncePod claim referenced by more
+	// than one pod; only one of them can ever run.
+	RWOPMultiplePods ConflictType = "ReadWriteOncePodMultiplePods"
+	// RWOMultipleNodes is a ReadWriteOnce claim whose referencing pods
+	// are spread across nodes; attach will fail for all but one node.
+	RWOMultipleNodes ConflictType = "ReadWriteOnceMultipleNodes"
+	// PVModeMismatch is a PersistentVolume that does not offer the access
+	// mode its bound claim requests.
+	PVModeMismatch ConflictType = "PersistentVolumeModeMismatch"
+)
+
+// PodRef identifies a pod using a claim and where it runs.
+type PodRef struct {
+	Namespace string
+	Name      string
+	// Node is empty for pods not yet scheduled.
+	Node string
+}
+
+// Conflict is one detected access-mode problem.
+type Conflict struct {
+	// Type classifies the conflict.
+	Type ConflictType
+	// Claim is the namespace/name of the PVC involved.
+	Claim string
+	// Volume is the PersistentVolume name, for PVModeMismatch.
+	Volume string
+	// Pods lists the referencing pods, sorted, for pod-driven conflicts.
+	Pods []PodRef
+	// Message explains the conflict.
+	Message string
+}
+
+// Check inspects the given pods, claims and volumes and returns all
+// detected conflicts, sorted by claim.
+func Check(pods []*v1.Pod, claims []*v1.PersistentVolumeClaim, volumes []*v1.PersistentVolume) []Conflict {
+	claimsByKey := map[string]*v1.PersistentVolumeClaim{}
+	for _, claim := range claims {
+		claimsByKey[claim.Namespace+"/"+claim.Name] = claim
+	}
+
+	// podsByClaim collects every pod referencing each claim.
+	podsByClaim := map[string][]PodRef{}
+	for _, pod := range pods {
+		for i := range pod.Spec.Volumes {
+			source := pod.Spec.Volumes[i].PersistentVolumeClaim
+			if source == nil {
+				continue
+			}
+			key := pod.Namespace + "/" + source.ClaimName
+			podsByClaim[key] = append(podsByClaim[key], PodRef{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				Node:      pod.Spec.NodeName,
+			})
+		}
+	}
+
+	var conflicts []Conflict
+	for key, refs := range podsByClaim {
+		claim, known := claimsByKey[key]
+		if !known {
+			continue
+		}
+		sort.Slice(refs, func(i, j int) bool {
+			if refs[i].Namespace != refs[j].Namespace {
+				return refs[i].Namespace < refs[j].Namespace
+			}
+			return refs[i].Name < refs[j].Name
+		})
+		switch {
+		case hasMode(claim.Spec.AccessModes, v1.ReadWriteOncePod) && len(refs) > 1:
+			conflicts = append(conflicts, Conflict{
+				Type:    RWOPMultiplePods,
+				Claim:   key,
+				Pods:    refs,
+				Message: fmt.Sprintf("ReadWriteOncePod claim is referenced by %d pods; only one can run", len(refs)),
+			})
+		case hasMode(claim.Spec.AccessModes, v1.ReadWriteOnce) && !hasMode(claim.Spec.AccessModes, v1.ReadWriteMany):
+			if nodes := distinctNodes(refs); len(nodes) > 1 {
+				conflicts = append(conflicts, Conflict{
+					Type:    RWOMultipleNodes,
+					Claim:   key,
+					Pods:    refs,
+					Message: fmt.Sprintf("ReadWriteOnce claim is used from %d nodes (%v); attach will fail for all but one", len(nodes), nodes),
+				})
+			}
+		}
+	}
+
+	for _, volume := range volumes {
+		claimRef := volume.Spec.ClaimRef
+		if claimRef == nil {
+			continue
+		}
+		claim, known := claimsByKey[claimRef.Namespace+"/"+claimRef.Name]
+		if !known {
+			continue
+		}
+		for _, requested := range claim.Spec.AccessModes {
+			if !hasMode(volume.Spec.AccessModes, requested) {
+				conflicts = append(conflicts, Conflict{
+					Type:    PVModeMismatch,
+					Claim:   claimRef.Namespace + "/" + claimRef.Name,
+					Volume:  volume.Name,
+					Message: fmt.Sprintf("bound claim requests %s but volume only offers %v", requested, volume.Spec.AccessModes),
+				})
+			}
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Claim != conflicts[j].Claim {
+			return conflicts[i].Claim < conflicts[j].Claim
+		}
+		return conflicts[i].Type < conflicts[j].Type
+	})
+	return conflicts
+}
+
+func hasMode(modes []v1.PersistentVolumeAccessMode, mode v1.PersistentVolumeAccessMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// distinctNodes returns the sorted set of nodes the refs are scheduled to,
+// ignoring unscheduled pods.
+func distinctNodes(refs []PodRef) []string {
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if ref.Node != "" {
+			seen[ref.Node] = true
+		}
+	}
+	nodes := make([]string, 0, len(seen))
+	for node := range seen {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rwopcheck
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func claim(name string, modes ...v1.PersistentVolumeAccessMode) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       v1.PersistentVolumeClaimSpec{AccessModes: modes},
+	}
+}
+
+func podUsingClaim(name, node, claimName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: v1.PodSpec{
+			NodeName: node,
+			Volumes: []v1.Volume{{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+				},
+			}},
+		},
+	}
+}
+
+func TestCheckRWOPMultiplePods(t *testing.T) {
+	conflicts := Check(
+		[]*v1.Pod{
+			podUsingClaim("pod-b", "node-1", "data"),
+			podUsingClaim("pod-a", "node-1", "data"),
+		},
+		[]*v1.PersistentVolumeClaim{claim("data", v1.ReadWriteOncePod)},
+		nil,
+	)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %+v", conflicts)
+	}
+	c := conflicts[0]
+	if c.Type != RWOPMultiplePods || c.Claim != "default/data" {
+		t.Errorf("unexpected conflict: %+v", c)
+	}
+	if len(c.Pods) != 2 || c.Pods[0].Name != "pod-a" {
+		t.Errorf("expected sorted pod refs, got %+v", c.Pods)
+	}
+}
+
+func TestCheckRWOMultipleNodes(t *testing.T) {
+	pods := []*v1.Pod{
+		podUsingClaim("pod-a", "node-1", "data"),
+		podUsingClaim("pod-b", "node-2", "data"),
+		podUsingClaim("pod-c", "", "data"), // unscheduled, ignored for node spread
+	}
+	conflicts := Check(pods, []*v1.PersistentVolumeClaim{claim("data", v1.ReadWriteOnce)}, nil)
+	if len(conflicts) != 1 || conflicts[0].Type != RWOMultipleNodes {
+		t.Fatalf("expected RWO multi-node conflict, got %+v", conflicts)
+	}
+
+	// Same node is fine for RWO.
+	sameNode := []*v1.Pod{
+		podUsingClaim("pod-a", "node-1", "data"),
+		podUsingClaim("pod-b", "node-1", "data"),
+	}
+	if conflicts := Check(sameNode, []*v1.PersistentVolumeClaim{claim("data", v1.ReadWriteOnce)}, nil); len(conflicts) != 0 {
+		t.Errorf("expected no conflict for co-located RWO pods, got %+v", conflicts)
+	}
+
+	// RWX claims are exempt even across nodes.
+	rwx := claim("data", v1.ReadWriteOnce, v1.ReadWriteMany)
+	if conflicts := Check(pods, []*v1.PersistentVolumeClaim{rwx}, nil); len(conflicts) != 0 {
+		t.Errorf("expected no conflict for RWX-capable claim, got %+v", conflicts)
+	}
+}
+
+func TestCheckPVModeMismatch(t *testing.T) {
+	volume := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: v1.PersistentVolumeSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			ClaimRef:    &v1.ObjectReference{Namespace: "default", Name: "data"},
+		},
+	}
+	conflicts := Check(nil, []*v1.PersistentVolumeClaim{claim("data", v1.ReadWriteMany)}, []*v1.PersistentVolume{volume})
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %+v", conflicts)
+	}
+	if conflicts[0].Type != PVModeMismatch || conflicts[0].Volume != "pv-1" {
+		t.Errorf("unexpected conflict: %+v", conflicts[0])
+	}
+
+	// Matching modes produce no conflict.
+	ok := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-2"},
+		Spec: v1.PersistentVolumeSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce, v1.ReadWriteMany},
+			ClaimRef:    &v1.ObjectReference{Namespace: "default", Name: "data"},
+		},
+	}
+	if conflicts := Check(nil, []*v1.PersistentVolumeClaim{claim("data", v1.ReadWriteMany)}, []*v1.PersistentVolume{ok}); len(conflicts) != 0 {
+		t.Errorf("expected no conflict, got %+v", conflicts)
+	}
+}
+
+func TestCheckSinglePodRWOPClean(t *testing.T) {
+	conflicts := Check(
+		[]*v1.Pod{podUsingClaim("pod-a", "node-1", "data")},
+		[]*v1.PersistentVolumeClaim{claim("data", v1.ReadWriteOncePod)},
+		nil,
+	)
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", conflicts)
+	}
+}
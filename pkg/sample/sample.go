@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sample draws statistically representative samples from kinds
+// too large to scan in full. Selection hashes object UIDs, so a given
+// fraction admits a stable pseudo-random subset without coordinating
+// state, and samples can be stratified (by namespace, node, or any key
+// function) so small strata are not drowned out by the big ones.
+// Reservoir sampling over paginated lists bounds memory at the sample
+// size regardless of how large the kind is.
+package sample
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Admit reports whether an object falls inside the sampled fraction.
+// Selection hashes the object's UID, so it is deterministic per object,
+// uniform across objects, and needs no state: two readers sampling the
+// same fraction see the same subset.
+func Admit(obj runtime.Object, fraction float64) bool {
+	if fraction >= 1 {
+		return true
+	}
+	if fraction <= 0 {
+		return false
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(accessor.GetUID()))
+	// Map the hash onto [0, 1) and admit the low end.
+	return float64(h.Sum64()%1e9)/1e9 < fraction
+}
+
+// Filter returns the objects admitted at the fraction, preserving order.
+func Filter(objs []runtime.Object, fraction float64) []runtime.Object {
+	var admitted []runtime.Object
+	for _, obj := range objs {
+		if Admit(obj, fraction) {
+			admitted = append(admitted, obj)
+		}
+	}
+	return admitted
+}
+
+// KeyFunc assigns an object to a stratum, e.g. its namespace or node.
+type KeyFunc func(obj runtime.Object) string
+
+// ByNamespace stratifies by object namespace.
+func ByNamespace(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetNamespace()
+}
+
+// Stratified draws up to perStratum objects from each stratum using
+// reservoir sampling, so every stratum is represented even when one
+// dwarfs the rest. The seed makes runs reproducible.
+func Stratified(objs []runtime.Object, key KeyFunc, perStratum int, seed int64) map[string][]runtime.Object {
+	rng := rand.New(rand.NewSource(seed))
+	reservoirs := map[string][]runtime.Object{}
+	counts := map[string]int{}
+	for _, obj := range objs {
+		stratum := key(obj)
+		counts[stratum]++
+		reservoir := reservoirs[stratum]
+		if len(reservoir) < perStratum {
+			reservoirs[stratum] = append(reservoir, obj)
+			continue
+		}
+		// Classic reservoir step: the nth element replaces a random slot
+		// with probability perStratum/n.
+		if slot := rng.Intn(counts[stratum]); slot < perStratum {
+			reservoir[slot] = obj
+		}
+	}
+	return reservoirs
+}
+
+// ListPageFunc fetches one page of a paginated list and returns the
+// items with the continue token for the next page; an empty token ends
+// the listing.
+type ListPageFunc func(ctx context.Context, opts metav1.ListOptions) (items []runtime.Object, continueToken string, err error)
+
+// Reservoir draws a uniform sample of size k over a paginated list
+// without holding more than k items and one page in memory. Total is the
+// number of objects seen, for scaling estimates back up.
+func Reservoir(ctx context.Context, list ListPageFunc, k int, pageSize int64, seed int64) (sample []runtime.Object, total int, err error) {
+	if k <= 0 {
+		return nil, 0, fmt.Errorf("sample size must be positive, got %d", k)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		items, continueToken, err := list(ctx, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, obj := range items {
+			total++
+			if len(sample) < k {
+				sample = append(sample, obj)
+				continue
+			}
+			if slot := rng.Intn(total); slot < k {
+				sample[slot] = obj
+			}
+		}
+		if continueToken == "" {
+			return sample, total, nil
+		}
+		opts.Continue = continueToken
+	}
+}
+
+// Estimate scales a count observed in a sample back to the population:
+// matched of sampleSize hits over a population of total.
+func Estimate(matched, sampleSize, total int) float64 {
+	if sampleSize == 0 {
+		return 0
+	}
+	return float64(matched) / float64(sampleSize) * float64(total)
+}
+
+// SortedStrata returns the stratum keys in sorted order, for stable
+// iteration over a Stratified result.
+func SortedStrata(reservoirs map[string][]runtime.Object) []string {
+	strata := make([]string, 0, len(reservoirs))
+	for stratum := range reservoirs {
+		strata = append(strata, stratum)
+	}
+	sort.Strings(strata)
+	return strata
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sample
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func somePod(namespace string, i int) runtime.Object {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      fmt.Sprintf("pod-%d", i),
+		UID:       types.UID(fmt.Sprintf("%s-uid-%d", namespace, i)),
+	}}
+}
+
+func TestAdmitFractionAndDeterminism(t *testing.T) {
+	const n = 10000
+	pods := make([]runtime.Object, n)
+	for i := range pods {
+		pods[i] = somePod("default", i)
+	}
+
+	admitted := Filter(pods, 0.1)
+	// The hash is uniform; 10% of 10k should land well within 8-12%.
+	if len(admitted) < n/100*8 || len(admitted) > n/100*12 {
+		t.Errorf("admitted %d of %d at fraction 0.1", len(admitted), n)
+	}
+	// Same fraction, same subset.
+	again := Filter(pods, 0.1)
+	if len(again) != len(admitted) {
+		t.Errorf("admission is not deterministic: %d vs %d", len(again), len(admitted))
+	}
+	// A larger fraction is a superset of a smaller one.
+	larger := Filter(pods, 0.5)
+	inLarger := map[string]bool{}
+	for _, obj := range larger {
+		inLarger[string(obj.(*v1.Pod).UID)] = true
+	}
+	for _, obj := range admitted {
+		if !inLarger[string(obj.(*v1.Pod).UID)] {
+			t.Fatal("fraction 0.1 admitted an object fraction 0.5 rejected")
+		}
+	}
+
+	if Admit(pods[0], 1) != true || Admit(pods[0], 0) != false {
+		t.Error("boundary fractions mishandled")
+	}
+}
+
+func TestStratifiedRepresentsSmallStrata(t *testing.T) {
+	var pods []runtime.Object
+	for i := 0; i < 5000; i++ {
+		pods = append(pods, somePod("huge", i))
+	}
+	for i := 0; i < 3; i++ {
+		pods = append(pods, somePod("tiny", i))
+	}
+
+	reservoirs := Stratified(pods, ByNamespace, 10, 42)
+	if len(reservoirs["huge"]) != 10 {
+		t.Errorf("huge stratum sampled %d, want 10", len(reservoirs["huge"]))
+	}
+	// A stratum smaller than the reservoir is taken whole.
+	if len(reservoirs["tiny"]) != 3 {
+		t.Errorf("tiny stratum sampled %d, want all 3", len(reservoirs["tiny"]))
+	}
+	if got := SortedStrata(reservoirs); len(got) != 2 || got[0] != "huge" || got[1] != "tiny" {
+		t.Errorf("unexpected strata %v", got)
+	}
+}
+
+func TestReservoirOverPages(t *testing.T) {
+	const total = 1000
+	pages := func(ctx context.Context, opts metav1.ListOptions) ([]runtime.Object, string, error) {
+		start := 0
+		if opts.Continue != "" {
+			start, _ = strconv.Atoi(opts.Continue)
+		}
+		end := start + int(opts.Limit)
+		if end > total {
+			end = total
+		}
+		var items []runtime.Object
+		for i := start; i < end; i++ {
+			items = append(items, somePod("default", i))
+		}
+		if end == total {
+			return items, "", nil
+		}
+		return items, strconv.Itoa(end), nil
+	}
+
+	sample, seen, err := Reservoir(context.Background(), pages, 50, 100, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != total {
+		t.Errorf("saw %d objects, want %d", seen, total)
+	}
+	if len(sample) != 50 {
+		t.Errorf("sample size %d, want 50", len(sample))
+	}
+	// No duplicates.
+	names := map[string]bool{}
+	for _, obj := range sample {
+		name := obj.(*v1.Pod).Name
+		if names[name] {
+			t.Errorf("duplicate %s in sample", name)
+		}
+		names[name] = true
+	}
+
+	if _, _, err := Reservoir(context.Background(), pages, 0, 100, 1); err == nil {
+		t.Error("expected error for non-positive sample size")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	if got := Estimate(5, 50, 1000); math.Abs(got-100) > 1e-9 {
+		t.Errorf("Estimate = %v, want 100", got)
+	}
+	if got := Estimate(0, 0, 1000); got != 0 {
+		t.Errorf("Estimate with empty sample = %v, want 0", got)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedscore scores feasible nodes for a Pod the way the scheduler's
+// preferredDuringScheduling plugins would: PreferredSchedulingTerm weights
+// from node affinity and WeightedPodAffinityTerm weights from pod affinity
+// and anti-affinity. Placement preview tools use it to rank the nodes that
+// filtering left over, rather than just listing them.
+package schedscore
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+)
+
+// NodeScore is the accumulated preference score of one node, broken down by
+// source so "why this node" output can show the contributing terms.
+type NodeScore struct {
+	// Node is the node's name.
+	Node string
+	// Score is the total: NodeAffinity + PodAffinity - PodAntiAffinity.
+	Score int64
+	// NodeAffinity is the weight sum of matching PreferredSchedulingTerms.
+	NodeAffinity int64
+	// PodAffinity is the weight sum of matching preferred pod affinity terms.
+	PodAffinity int64
+	// PodAntiAffinity is the weight sum of matching preferred pod
+	// anti-affinity terms; it is subtracted from the total.
+	PodAntiAffinity int64
+}
+
+// ScoreNodes scores the given nodes for the pod, considering the pod's
+// preferred node affinity and preferred pod (anti-)affinity against the
+// existing pods. Results are sorted by descending score, ties broken by
+// node name. Terms using a namespaceSelector are rejected, since namespace
+// labels are not available here.
+func ScoreNodes(pod *v1.Pod, nodes []*v1.Node, existingPods []*v1.Pod) ([]NodeScore, error) {
+	var preferredTerms *nodeaffinity.PreferredSchedulingTerms
+	affinity := pod.Spec.Affinity
+	if affinity != nil && affinity.NodeAffinity != nil && len(affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0 {
+		var err error
+		preferredTerms, err = nodeaffinity.NewPreferredSchedulingTerms(affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferred node affinity: %v", err)
+		}
+	}
+
+	podsByNode := map[string][]*v1.Pod{}
+	for _, p := range existingPods {
+		if p.Spec.NodeName != "" {
+			podsByNode[p.Spec.NodeName] = append(podsByNode[p.Spec.NodeName], p)
+		}
+	}
+
+	var affinityTerms, antiAffinityTerms []v1.WeightedPodAffinityTerm
+	if affinity != nil && affinity.PodAffinity != nil {
+		affinityTerms = affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	}
+	if affinity != nil && affinity.PodAntiAffinity != nil {
+		antiAffinityTerms = affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	}
+
+	scores := make([]NodeScore, 0, len(nodes))
+	for _, node := range nodes {
+		score := NodeScore{Node: node.Name}
+		if preferredTerms != nil {
+			score.NodeAffinity = preferredTerms.Score(node)
+		}
+		var err error
+		score.PodAffinity, err = scoreWeightedTerms(pod, node, nodes, podsByNode, affinityTerms)
+		if err != nil {
+			return nil, err
+		}
+		score.PodAntiAffinity, err = scoreWeightedTerms(pod, node, nodes, podsByNode, antiAffinityTerms)
+		if err != nil {
+			return nil, err
+		}
+		score.Score = score.NodeAffinity + score.PodAffinity - score.PodAntiAffinity
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Node < scores[j].Node
+	})
+	return scores, nil
+}
+
+// scoreWeightedTerms sums the weights of the terms that the candidate node
+// satisfies: a term matches if some existing pod selected by it runs in the
+// candidate's topology domain, i.e. on a node sharing the candidate's value
+// for the term's topology key.
+func scoreWeightedTerms(pod *v1.Pod, candidate *v1.Node, nodes []*v1.Node, podsByNode map[string][]*v1.Pod, terms []v1.WeightedPodAffinityTerm) (int64, error) {
+	if len(terms) == 0 {
+		return 0, nil
+	}
+	nodeByName := map[string]*v1.Node{}
+	for _, n := range nodes {
+		nodeByName[n.Name] = n
+	}
+	var total int64
+	for i := range terms {
+		term := &terms[i]
+		if term.PodAffinityTerm.NamespaceSelector != nil {
+			return 0, fmt.Errorf("namespaceSelector is not supported in placement scoring")
+		}
+		selector, err := metav1.LabelSelectorAsSelector(term.PodAffinityTerm.LabelSelector)
+		if err != nil {
+			return 0, fmt.Errorf("invalid label selector in weighted term: %v", err)
+		}
+		topologyValue, ok := candidate.Labels[term.PodAffinityTerm.TopologyKey]
+		if !ok {
+			continue
+		}
+		namespaces := term.PodAffinityTerm.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{pod.Namespace}
+		}
+		if matchInDomain(selector, namespaces, term.PodAffinityTerm.TopologyKey, topologyValue, nodeByName, podsByNode) {
+			total += int64(term.Weight)
+		}
+	}
+	return total, nil
+}
+
+func matchInDomain(selector labels.Selector, namespaces []string, topologyKey, topologyValue string, nodeByName map[string]*v1.Node, podsByNode map[string][]*v1.Pod) bool {
+	allowed := map[string]bool{}
+	for _, ns := range namespaces {
+		allowed[ns] = true
+	}
+	for nodeName, pods := range podsByNode {
+		node, ok := nodeByName[nodeName]
+		if !ok || node.Labels[topologyKey] != topologyValue {
+			continue
+		}
+		for _, p := range pods {
+			if allowed[p.Namespace] && selector.Matches(labels.Set(p.Labels)) {
+				return true
+			}
+		}
+	}
+	return false
+}
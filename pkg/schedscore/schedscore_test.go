@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedscore
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func node(name string, nodeLabels map[string]string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels}}
+}
+
+func runningPod(namespace, name, nodeName string, podLabels map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: podLabels},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func TestScoreNodesNodeAffinity(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+		Spec: v1.PodSpec{
+			Affinity: &v1.Affinity{
+				NodeAffinity: &v1.NodeAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
+						{
+							Weight: 10,
+							Preference: v1.NodeSelectorTerm{
+								MatchExpressions: []v1.NodeSelectorRequirement{{
+									Key: "disktype", Operator: v1.NodeSelectorOpIn, Values: []string{"ssd"},
+								}},
+							},
+						},
+						{
+							Weight: 5,
+							Preference: v1.NodeSelectorTerm{
+								MatchExpressions: []v1.NodeSelectorRequirement{{
+									Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"},
+								}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	nodes := []*v1.Node{
+		node("plain", nil),
+		node("ssd-a", map[string]string{"disktype": "ssd", "zone": "a"}),
+		node("ssd-b", map[string]string{"disktype": "ssd", "zone": "b"}),
+	}
+
+	scores, err := ScoreNodes(pod, nodes, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if scores[0].Node != "ssd-a" || scores[0].Score != 15 {
+		t.Errorf("expected ssd-a with score 15 first, got %+v", scores[0])
+	}
+	if scores[1].Node != "ssd-b" || scores[1].Score != 10 {
+		t.Errorf("expected ssd-b with score 10 second, got %+v", scores[1])
+	}
+	if scores[2].Node != "plain" || scores[2].Score != 0 {
+		t.Errorf("expected plain with score 0 last, got %+v", scores[2])
+	}
+}
+
+func TestScoreNodesPodAffinity(t *testing.T) {
+	zoneNodes := []*v1.Node{
+		node("a-1", map[string]string{"topology.kubernetes.io/zone": "a"}),
+		node("a-2", map[string]string{"topology.kubernetes.io/zone": "a"}),
+		node("b-1", map[string]string{"topology.kubernetes.io/zone": "b"}),
+	}
+	existing := []*v1.Pod{
+		runningPod("default", "cache-1", "a-1", map[string]string{"app": "cache"}),
+		runningPod("default", "web-1", "b-1", map[string]string{"app": "web"}),
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+		Spec: v1.PodSpec{
+			Affinity: &v1.Affinity{
+				PodAffinity: &v1.PodAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+						Weight: 20,
+						PodAffinityTerm: v1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+							TopologyKey:   "topology.kubernetes.io/zone",
+						},
+					}},
+				},
+				PodAntiAffinity: &v1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+						Weight: 5,
+						PodAffinityTerm: v1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+							TopologyKey:   "topology.kubernetes.io/zone",
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	scores, err := ScoreNodes(pod, zoneNodes, existing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byNode := map[string]NodeScore{}
+	for _, s := range scores {
+		byNode[s.Node] = s
+	}
+
+	// Both nodes in zone a gain the affinity weight: the cache pod runs in
+	// that topology domain.
+	for _, name := range []string{"a-1", "a-2"} {
+		if s := byNode[name]; s.PodAffinity != 20 || s.Score != 20 {
+			t.Errorf("expected %s to score 20 from pod affinity, got %+v", name, s)
+		}
+	}
+	// The zone b node is penalized by anti-affinity against the web pod.
+	if s := byNode["b-1"]; s.PodAntiAffinity != 5 || s.Score != -5 {
+		t.Errorf("expected b-1 to score -5 from anti-affinity, got %+v", s)
+	}
+	if scores[2].Node != "b-1" {
+		t.Errorf("expected b-1 ranked last, got %v", scores)
+	}
+}
+
+func TestScoreNodesOtherNamespaceIgnored(t *testing.T) {
+	nodes := []*v1.Node{node("a-1", map[string]string{"zone": "a"})}
+	existing := []*v1.Pod{
+		runningPod("other", "cache-1", "a-1", map[string]string{"app": "cache"}),
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+		Spec: v1.PodSpec{
+			Affinity: &v1.Affinity{
+				PodAffinity: &v1.PodAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+						Weight: 20,
+						PodAffinityTerm: v1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+							TopologyKey:   "zone",
+						},
+					}},
+				},
+			},
+		},
+	}
+	scores, err := ScoreNodes(pod, nodes, existing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if scores[0].Score != 0 {
+		t.Errorf("expected pods in other namespaces to be ignored, got %+v", scores[0])
+	}
+}
+
+func TestScoreNodesNamespaceSelectorRejected(t *testing.T) {
+	nodes := []*v1.Node{node("a-1", map[string]string{"zone": "a"})}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+		Spec: v1.PodSpec{
+			Affinity: &v1.Affinity{
+				PodAffinity: &v1.PodAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+						Weight: 1,
+						PodAffinityTerm: v1.PodAffinityTerm{
+							LabelSelector:     &metav1.LabelSelector{},
+							NamespaceSelector: &metav1.LabelSelector{},
+							TopologyKey:       "zone",
+						},
+					}},
+				},
+			},
+		},
+	}
+	if _, err := ScoreNodes(pod, nodes, nil); err == nil {
+		t.Fatal("expected error for namespaceSelector term")
+	}
+}
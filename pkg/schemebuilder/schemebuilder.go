@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schemebuilder composes a runtime.Scheme holding only the kinds a
+// consumer actually needs, instead of registering entire API groups. The
+// selected kinds are copied out of a full source scheme, so the generated
+// deepcopy machinery attached to the Go types comes along for free. This
+// keeps init time and the set of reachable types small for embedded and
+// edge consumers that decode a handful of kinds.
+package schemebuilder
+
+import (
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// Builder accumulates kind selections and produces a reduced scheme.
+type Builder struct {
+	source *runtime.Scheme
+	gvks   []schema.GroupVersionKind
+}
+
+// New returns a builder drawing kinds from the full client-go scheme.
+func New() *Builder {
+	return &Builder{source: clientsetscheme.Scheme}
+}
+
+// WithSource replaces the scheme kinds are copied from; useful for trees
+// with CRD or aggregated types registered elsewhere.
+func (b *Builder) WithSource(source *runtime.Scheme) *Builder {
+	b.source = source
+	return b
+}
+
+// Add selects kinds for the reduced scheme. The corresponding List kind,
+// when the source scheme has one, is included automatically.
+func (b *Builder) Add(gvks ...schema.GroupVersionKind) *Builder {
+	b.gvks = append(b.gvks, gvks...)
+	return b
+}
+
+// AddAPIVersionKind selects a kind by its wire identity, e.g.
+// ("v1", "Pod") or ("apps/v1", "Deployment").
+func (b *Builder) AddAPIVersionKind(apiVersion, kind string) *Builder {
+	return b.Add(schema.FromAPIVersionAndKind(apiVersion, kind))
+}
+
+// Build registers the selected kinds into a fresh scheme. It fails if any
+// selected kind is not known to the source scheme.
+func (b *Builder) Build() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+
+	groupVersions := map[schema.GroupVersion]bool{}
+	for _, gvk := range b.gvks {
+		if err := b.copyKind(scheme, gvk); err != nil {
+			return nil, err
+		}
+		groupVersions[gvk.GroupVersion()] = true
+
+		// Most kinds pair with a generated List type; carry it over so
+		// list/watch decoding works without a second selection.
+		listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+		if b.source.Recognizes(listGVK) && !scheme.Recognizes(listGVK) {
+			if err := b.copyKind(scheme, listGVK); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Register the meta types (Status, ListOptions, WatchEvent, ...) for
+	// each selected group version, as group install packages do.
+	byGroup := map[string][]schema.GroupVersion{}
+	for gv := range groupVersions {
+		metav1.AddToGroupVersion(scheme, gv)
+		byGroup[gv.Group] = append(byGroup[gv.Group], gv)
+	}
+	// Version priority is declared per group.
+	for _, gvs := range byGroup {
+		sort.Slice(gvs, func(i, j int) bool { return gvs[i].Version < gvs[j].Version })
+		utilruntime.Must(scheme.SetVersionPriority(gvs...))
+	}
+	return scheme, nil
+}
+
+func (b *Builder) copyKind(scheme *runtime.Scheme, gvk schema.GroupVersionKind) error {
+	obj, err := b.source.New(gvk)
+	if err != nil {
+		return fmt.Errorf("kind %s is not registered in the source scheme: %v", gvk, err)
+	}
+	scheme.AddKnownTypeWithName(gvk, obj)
+	return nil
+}
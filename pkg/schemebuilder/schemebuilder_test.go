@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemebuilder
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+func TestBuildReducedScheme(t *testing.T) {
+	scheme, err := New().
+		AddAPIVersionKind("v1", "Pod").
+		AddAPIVersionKind("apps/v1", "Deployment").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, gvk := range []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Pod"},
+		{Version: "v1", Kind: "PodList"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+		{Group: "apps", Version: "v1", Kind: "DeploymentList"},
+	} {
+		if !scheme.Recognizes(gvk) {
+			t.Errorf("expected scheme to recognize %s", gvk)
+		}
+	}
+	for _, gvk := range []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Service"},
+		{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+		{Group: "batch", Version: "v1", Kind: "Job"},
+	} {
+		if scheme.Recognizes(gvk) {
+			t.Errorf("expected reduced scheme to not recognize %s", gvk)
+		}
+	}
+}
+
+func TestBuildRoundTrip(t *testing.T) {
+	scheme, err := New().Add(v1.SchemeGroupVersion.WithKind("Pod")).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+	info, ok := runtime.SerializerInfoForMediaType(codecs.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	if !ok {
+		t.Fatal("no JSON serializer")
+	}
+	codec := codecs.CodecForVersions(info.Serializer, info.Serializer, v1.SchemeGroupVersion, v1.SchemeGroupVersion)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec:       v1.PodSpec{NodeName: "node-1"},
+	}
+	data, err := runtime.Encode(codec, pod)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	decoded, err := runtime.Decode(codec, data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	decodedPod, ok := decoded.(*v1.Pod)
+	if !ok {
+		t.Fatalf("decoded to unexpected type %T", decoded)
+	}
+	if decodedPod.Name != "web" || decodedPod.Spec.NodeName != "node-1" {
+		t.Errorf("round trip lost data: %+v", decodedPod)
+	}
+}
+
+func TestBuildUnknownKind(t *testing.T) {
+	if _, err := New().AddAPIVersionKind("example.com/v1", "Widget").Build(); err == nil {
+		t.Fatal("expected error for unregistered kind")
+	}
+}
+
+func TestBuildWithSource(t *testing.T) {
+	source := runtime.NewScheme()
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+	source.AddKnownTypeWithName(gvk, &appsv1.ReplicaSet{})
+
+	scheme, err := New().WithSource(source).Add(gvk).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !scheme.Recognizes(gvk) {
+		t.Errorf("expected scheme to recognize %s", gvk)
+	}
+	// The source had no list kind, so none is copied.
+	if scheme.Recognizes(gvk.GroupVersion().WithKind("ReplicaSetList")) {
+		t.Error("did not expect ReplicaSetList to be registered")
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scopedclient separates cluster-scoped from namespaced reads in
+// the type system. A ClusterClient has no namespace parameter anywhere;
+// a NamespacedClient yields nothing until a namespace is supplied. That
+// removes at compile time the two recurring bugs of the generic client
+// surface: passing a namespace to PersistentVolume lists (silently
+// ignored) and forgetting one for pods (silently listing nothing or
+// everything, depending on the caller).
+//
+// Wrappers for the core kinds this package reads are defined below;
+// adding a kind is one constructor following the same shape.
+package scopedclient
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ClusterClient reads a cluster-scoped kind. There is deliberately no
+// namespace anywhere in its surface.
+type ClusterClient[T runtime.Object] struct {
+	get  func(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	list func(ctx context.Context, opts metav1.ListOptions) ([]T, error)
+}
+
+// Get fetches one object by name.
+func (c ClusterClient[T]) Get(ctx context.Context, name string) (T, error) {
+	return c.get(ctx, name, metav1.GetOptions{})
+}
+
+// List lists the kind.
+func (c ClusterClient[T]) List(ctx context.Context, opts metav1.ListOptions) ([]T, error) {
+	return c.list(ctx, opts)
+}
+
+// NamespacedClient reads a namespaced kind. It exposes no reads itself:
+// callers must pick a namespace (or All) first, so the scope decision is
+// always explicit in the code.
+type NamespacedClient[T runtime.Object] struct {
+	get  func(ctx context.Context, namespace, name string, opts metav1.GetOptions) (T, error)
+	list func(ctx context.Context, namespace string, opts metav1.ListOptions) ([]T, error)
+}
+
+// Namespace scopes the client to one namespace.
+func (c NamespacedClient[T]) Namespace(namespace string) ScopedReader[T] {
+	return ScopedReader[T]{client: c, namespace: namespace}
+}
+
+// All scopes the client to every namespace.
+func (c NamespacedClient[T]) All() ScopedReader[T] {
+	return ScopedReader[T]{client: c, namespace: metav1.NamespaceAll}
+}
+
+// ScopedReader is a NamespacedClient bound to a namespace.
+type ScopedReader[T runtime.Object] struct {
+	client    NamespacedClient[T]
+	namespace string
+}
+
+// Get fetches one object by name within the bound namespace.
+func (s ScopedReader[T]) Get(ctx context.Context, name string) (T, error) {
+	return s.client.get(ctx, s.namespace, name, metav1.GetOptions{})
+}
+
+// List lists the bound namespace.
+func (s ScopedReader[T]) List(ctx context.Context, opts metav1.ListOptions) ([]T, error) {
+	return s.client.list(ctx, s.namespace, opts)
+}
+
+// Pods returns the namespaced pod client.
+func Pods(client kubernetes.Interface) NamespacedClient[*v1.Pod] {
+	return NamespacedClient[*v1.Pod]{
+		get: func(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*v1.Pod, error) {
+			return client.CoreV1().Pods(namespace).Get(ctx, name, opts)
+		},
+		list: func(ctx context.Context, namespace string, opts metav1.ListOptions) ([]*v1.Pod, error) {
+			list, err := client.CoreV1().Pods(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return pointers(list.Items), nil
+		},
+	}
+}
+
+// ConfigMaps returns the namespaced ConfigMap client.
+func ConfigMaps(client kubernetes.Interface) NamespacedClient[*v1.ConfigMap] {
+	return NamespacedClient[*v1.ConfigMap]{
+		get: func(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*v1.ConfigMap, error) {
+			return client.CoreV1().ConfigMaps(namespace).Get(ctx, name, opts)
+		},
+		list: func(ctx context.Context, namespace string, opts metav1.ListOptions) ([]*v1.ConfigMap, error) {
+			list, err := client.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return pointers(list.Items), nil
+		},
+	}
+}
+
+// PersistentVolumeClaims returns the namespaced PVC client.
+func PersistentVolumeClaims(client kubernetes.Interface) NamespacedClient[*v1.PersistentVolumeClaim] {
+	return NamespacedClient[*v1.PersistentVolumeClaim]{
+		get: func(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*v1.PersistentVolumeClaim, error) {
+			return client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, opts)
+		},
+		list: func(ctx context.Context, namespace string, opts metav1.ListOptions) ([]*v1.PersistentVolumeClaim, error) {
+			list, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return pointers(list.Items), nil
+		},
+	}
+}
+
+// Nodes returns the cluster-scoped node client.
+func Nodes(client kubernetes.Interface) ClusterClient[*v1.Node] {
+	return ClusterClient[*v1.Node]{
+		get: func(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Node, error) {
+			return client.CoreV1().Nodes().Get(ctx, name, opts)
+		},
+		list: func(ctx context.Context, opts metav1.ListOptions) ([]*v1.Node, error) {
+			list, err := client.CoreV1().Nodes().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return pointers(list.Items), nil
+		},
+	}
+}
+
+// PersistentVolumes returns the cluster-scoped PV client.
+func PersistentVolumes(client kubernetes.Interface) ClusterClient[*v1.PersistentVolume] {
+	return ClusterClient[*v1.PersistentVolume]{
+		get: func(ctx context.Context, name string, opts metav1.GetOptions) (*v1.PersistentVolume, error) {
+			return client.CoreV1().PersistentVolumes().Get(ctx, name, opts)
+		},
+		list: func(ctx context.Context, opts metav1.ListOptions) ([]*v1.PersistentVolume, error) {
+			list, err := client.CoreV1().PersistentVolumes().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return pointers(list.Items), nil
+		},
+	}
+}
+
+// Namespaces returns the cluster-scoped namespace client.
+func Namespaces(client kubernetes.Interface) ClusterClient[*v1.Namespace] {
+	return ClusterClient[*v1.Namespace]{
+		get: func(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Namespace, error) {
+			return client.CoreV1().Namespaces().Get(ctx, name, opts)
+		},
+		list: func(ctx context.Context, opts metav1.ListOptions) ([]*v1.Namespace, error) {
+			list, err := client.CoreV1().Namespaces().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return pointers(list.Items), nil
+		},
+	}
+}
+
+// pointers converts a list's value items to the pointer elements the
+// clients return.
+func pointers[T any](items []T) []*T {
+	out := make([]*T, len(items))
+	for i := range items {
+		out[i] = &items[i]
+	}
+	return out
+}
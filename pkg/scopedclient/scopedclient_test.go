@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scopedclient
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func fakeObjects() *clientsetfake.Clientset {
+	return clientsetfake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv-1"}},
+	)
+}
+
+func TestNamespacedClient(t *testing.T) {
+	ctx := context.Background()
+	pods := Pods(fakeObjects())
+
+	pod, err := pods.Namespace("default").Get(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Name != "web" {
+		t.Errorf("unexpected pod %+v", pod)
+	}
+
+	// The same name in another namespace is a different object.
+	if _, err := pods.Namespace("prod").Get(ctx, "web"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected NotFound across namespaces, got %v", err)
+	}
+
+	scoped, err := pods.Namespace("default").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scoped) != 1 || scoped[0].Name != "web" {
+		t.Errorf("namespace list leaked across namespaces: %+v", scoped)
+	}
+
+	all, err := pods.All().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("all-namespaces list = %d pods, want 2", len(all))
+	}
+}
+
+func TestClusterClient(t *testing.T) {
+	ctx := context.Background()
+	client := fakeObjects()
+
+	node, err := Nodes(client).Get(ctx, "node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Name != "node-1" {
+		t.Errorf("unexpected node %+v", node)
+	}
+
+	pvs, err := PersistentVolumes(client).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pvs) != 1 || pvs[0].Name != "pv-1" {
+		t.Errorf("unexpected PV list %+v", pvs)
+	}
+}
+
+func TestListReturnsStablePointers(t *testing.T) {
+	ctx := context.Background()
+	pods, err := Pods(fakeObjects()).All().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each element must point at its own item, not a shared loop
+	// variable.
+	names := map[string]bool{}
+	for _, pod := range pods {
+		names[pod.Name] = true
+	}
+	if !names["web"] || !names["api"] {
+		t.Errorf("pointer conversion aliased items: %v", names)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package securityaudit grades a pod against the Pod Security Standards
+// using the vendored pod-security-admission checks, and annotates each
+// violation with the spec fields that caused it. The admission plugin
+// reports only reason strings; an auditor fixing manifests needs to
+// know which field to change.
+package securityaudit
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	psapi "k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// Violation is one failed check at a level.
+type Violation struct {
+	// Check is the pod-security-admission check ID.
+	Check string
+	// Level is the standard the check belongs to: baseline checks fail
+	// both baseline and restricted, restricted checks only restricted.
+	Level psapi.Level
+	// Reason and Detail are the check's own explanation.
+	Reason string
+	Detail string
+	// Fields are the spec paths the check inspects.
+	Fields []string
+}
+
+// Report grades one pod.
+type Report struct {
+	// Level is the strictest standard the pod satisfies: restricted,
+	// baseline, or privileged when even baseline fails.
+	Level psapi.Level
+	// Violations holds every failed check, baseline first.
+	Violations []Violation
+}
+
+// checkFields maps check IDs to the spec paths they read. Paths use
+// the container wildcard spelling of kubectl explain.
+var checkFields = map[policy.CheckID][]string{
+	"allowPrivilegeEscalation": {"spec.containers[*].securityContext.allowPrivilegeEscalation"},
+	"appArmorProfile":          {"metadata.annotations[container.apparmor.security.beta.kubernetes.io/*]"},
+	"capabilities_baseline":    {"spec.containers[*].securityContext.capabilities.add"},
+	"capabilities_restricted":  {"spec.containers[*].securityContext.capabilities"},
+	"hostNamespaces":           {"spec.hostNetwork", "spec.hostPID", "spec.hostIPC"},
+	"hostPathVolumes":          {"spec.volumes[*].hostPath"},
+	"hostPorts":                {"spec.containers[*].ports[*].hostPort"},
+	"privileged":               {"spec.containers[*].securityContext.privileged"},
+	"procMount":                {"spec.containers[*].securityContext.procMount"},
+	"restrictedVolumes":        {"spec.volumes[*]"},
+	"runAsNonRoot":             {"spec.securityContext.runAsNonRoot", "spec.containers[*].securityContext.runAsNonRoot"},
+	"runAsUser":                {"spec.securityContext.runAsUser", "spec.containers[*].securityContext.runAsUser"},
+	"seLinuxOptions":           {"spec.securityContext.seLinuxOptions", "spec.containers[*].securityContext.seLinuxOptions"},
+	"seccompProfile_baseline":  {"spec.securityContext.seccompProfile", "spec.containers[*].securityContext.seccompProfile"},
+	"seccompProfile_restricted": {
+		"spec.securityContext.seccompProfile", "spec.containers[*].securityContext.seccompProfile"},
+	"sysctls":            {"spec.securityContext.sysctls"},
+	"windowsHostProcess": {"spec.securityContext.windowsOptions.hostProcess", "spec.containers[*].securityContext.windowsOptions.hostProcess"},
+}
+
+// Audit grades the pod against the latest policy version.
+func Audit(pod *v1.Pod) (Report, error) {
+	return AuditAt(pod, psapi.LatestVersion())
+}
+
+// AuditAt grades the pod against the standards at a policy version, so
+// reports stay comparable across cluster upgrades.
+func AuditAt(pod *v1.Pod, version psapi.Version) (Report, error) {
+	report := Report{Level: psapi.LevelRestricted}
+	for _, check := range policy.DefaultChecks() {
+		versioned, ok := applicableVersion(check, version)
+		if !ok {
+			continue
+		}
+		result := versioned.CheckPod(&pod.ObjectMeta, &pod.Spec)
+		if result.Allowed {
+			continue
+		}
+		report.Violations = append(report.Violations, Violation{
+			Check:  string(check.ID),
+			Level:  check.Level,
+			Reason: result.ForbiddenReason,
+			Detail: result.ForbiddenDetail,
+			Fields: checkFields[check.ID],
+		})
+		if check.Level == psapi.LevelBaseline {
+			report.Level = psapi.LevelPrivileged
+		} else if report.Level == psapi.LevelRestricted {
+			report.Level = psapi.LevelBaseline
+		}
+	}
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		if report.Violations[i].Level != report.Violations[j].Level {
+			return report.Violations[i].Level == psapi.LevelBaseline
+		}
+		return report.Violations[i].Check < report.Violations[j].Check
+	})
+	return report, nil
+}
+
+// Meets reports whether the pod satisfies the level at the latest
+// policy version.
+func Meets(pod *v1.Pod, level psapi.Level) (bool, error) {
+	report, err := Audit(pod)
+	if err != nil {
+		return false, err
+	}
+	switch level {
+	case psapi.LevelPrivileged:
+		return true, nil
+	case psapi.LevelBaseline:
+		return report.Level == psapi.LevelBaseline || report.Level == psapi.LevelRestricted, nil
+	case psapi.LevelRestricted:
+		return report.Level == psapi.LevelRestricted, nil
+	default:
+		return false, fmt.Errorf("unknown level %q", level)
+	}
+}
+
+// applicableVersion picks the newest revision of the check that is not
+// newer than the requested policy version.
+func applicableVersion(check policy.Check, version psapi.Version) (policy.VersionedCheck, bool) {
+	var picked policy.VersionedCheck
+	found := false
+	for _, versioned := range check.Versions {
+		if versioned.MinimumVersion == (psapi.Version{}) || versioned.MinimumVersion.Older(version) || versioned.MinimumVersion == version {
+			picked = versioned
+			found = true
+		}
+	}
+	return picked, found
+}
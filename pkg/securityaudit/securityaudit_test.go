@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package securityaudit
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	psapi "k8s.io/pod-security-admission/api"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+// restrictedPod satisfies the restricted profile.
+func restrictedPod() *v1.Pod {
+	runtimeDefault := v1.SeccompProfileTypeRuntimeDefault
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tight"},
+		Spec: v1.PodSpec{
+			SecurityContext: &v1.PodSecurityContext{
+				RunAsNonRoot:   boolPtr(true),
+				SeccompProfile: &v1.SeccompProfile{Type: runtimeDefault},
+			},
+			Containers: []v1.Container{{
+				Name:  "app",
+				Image: "app:1",
+				SecurityContext: &v1.SecurityContext{
+					AllowPrivilegeEscalation: boolPtr(false),
+					Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+				},
+			}},
+		},
+	}
+}
+
+func TestAuditRestrictedPod(t *testing.T) {
+	report, err := Audit(restrictedPod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Level != psapi.LevelRestricted {
+		t.Errorf("level = %s, violations: %+v", report.Level, report.Violations)
+	}
+}
+
+func TestAuditPlainPodIsBaseline(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "plain"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "app:1"}}},
+	}
+	report, err := Audit(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Level != psapi.LevelBaseline {
+		t.Errorf("level = %s, want baseline; violations: %+v", report.Level, report.Violations)
+	}
+	// Every violation must be a restricted-level one.
+	for _, violation := range report.Violations {
+		if violation.Level != psapi.LevelRestricted {
+			t.Errorf("unexpected baseline violation: %+v", violation)
+		}
+	}
+}
+
+func TestAuditPrivilegedPod(t *testing.T) {
+	pod := restrictedPod()
+	pod.Spec.HostNetwork = true
+	pod.Spec.Containers[0].SecurityContext.Privileged = boolPtr(true)
+	pod.Spec.Containers[0].SecurityContext.AllowPrivilegeEscalation = boolPtr(true)
+
+	report, err := Audit(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Level != psapi.LevelPrivileged {
+		t.Errorf("level = %s, want privileged", report.Level)
+	}
+	byCheck := map[string]Violation{}
+	for _, violation := range report.Violations {
+		byCheck[violation.Check] = violation
+	}
+	host, ok := byCheck["hostNamespaces"]
+	if !ok || len(host.Fields) == 0 || host.Fields[0] != "spec.hostNetwork" {
+		t.Errorf("hostNamespaces violation: %+v", host)
+	}
+	if _, ok := byCheck["privileged"]; !ok {
+		t.Errorf("privileged check missing: %+v", report.Violations)
+	}
+	// Baseline violations sort before restricted ones.
+	if report.Violations[0].Level != psapi.LevelBaseline {
+		t.Errorf("first violation is %s", report.Violations[0].Level)
+	}
+}
+
+func TestMeets(t *testing.T) {
+	pod := restrictedPod()
+	for _, level := range []psapi.Level{psapi.LevelPrivileged, psapi.LevelBaseline, psapi.LevelRestricted} {
+		ok, err := Meets(pod, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("restricted pod should meet %s", level)
+		}
+	}
+	pod.Spec.HostPID = true
+	ok, err := Meets(pod, psapi.LevelBaseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("hostPID must fail baseline")
+	}
+}
+
+func TestAuditAtOlderVersion(t *testing.T) {
+	// The restricted seccomp requirement arrived in v1.19; a pod
+	// without a seccomp profile passes restricted at v1.18.
+	pod := restrictedPod()
+	pod.Spec.SecurityContext.SeccompProfile = nil
+	report, err := AuditAt(pod, psapi.MajorMinorVersion(1, 18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Level != psapi.LevelRestricted {
+		t.Errorf("level at v1.18 = %s; violations: %+v", report.Level, report.Violations)
+	}
+	report, err = Audit(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Level == psapi.LevelRestricted {
+		t.Error("missing seccomp profile should fail restricted at latest")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package selectorcache compiles metav1.LabelSelector values into
+// labels.Selector matchers through an LRU, so scans that evaluate the
+// same selectors against thousands of objects — PVC selectors,
+// affinity terms, topology spread constraints — pay the parse cost
+// once per distinct selector instead of once per object.
+package selectorcache
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/lru"
+)
+
+// DefaultSize is the cache capacity used by NewCompiler(0). Most
+// clusters have far fewer distinct selectors than objects, so a small
+// cache captures nearly all repeats.
+const DefaultSize = 256
+
+// Compiler caches compiled selectors. It is safe for concurrent use.
+type Compiler struct {
+	cache  *lru.Cache
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCompiler returns a Compiler holding at most size compiled
+// selectors; size <= 0 means DefaultSize.
+func NewCompiler(size int) *Compiler {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Compiler{cache: lru.New(size)}
+}
+
+// Compile converts the LabelSelector, consulting the cache first. As
+// with metav1.LabelSelectorAsSelector, a nil selector matches nothing
+// and an empty one matches everything.
+func (c *Compiler) Compile(selector *metav1.LabelSelector) (labels.Selector, error) {
+	if selector == nil {
+		return labels.Nothing(), nil
+	}
+	key := keyFor(selector)
+	if cached, ok := c.cache.Get(key); ok {
+		c.hits.Add(1)
+		return cached.(labels.Selector), nil
+	}
+	c.misses.Add(1)
+	compiled, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.Add(key, compiled)
+	return compiled, nil
+}
+
+// Matches compiles the selector and evaluates it against the labels.
+func (c *Compiler) Matches(selector *metav1.LabelSelector, set labels.Set) (bool, error) {
+	compiled, err := c.Compile(selector)
+	if err != nil {
+		return false, err
+	}
+	return compiled.Matches(set), nil
+}
+
+// Stats returns the cumulative cache hit and miss counts.
+func (c *Compiler) Stats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// keyFor builds a deterministic cache key. It is deliberately cheaper
+// than the selector's generated String(), which reflects through gogo
+// proto and would cost as much as the compile it is meant to avoid.
+// MatchLabels keys are sorted so map order cannot split the cache;
+// expression order is kept as written, which at worst causes an extra
+// miss, never a wrong hit.
+func keyFor(selector *metav1.LabelSelector) string {
+	var b strings.Builder
+	keys := make([]string, 0, len(selector.MatchLabels))
+	for k := range selector.MatchLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(selector.MatchLabels[k])
+		b.WriteByte(',')
+	}
+	for _, expr := range selector.MatchExpressions {
+		b.WriteString(expr.Key)
+		b.WriteByte(' ')
+		b.WriteString(string(expr.Operator))
+		b.WriteByte(' ')
+		b.WriteString(strings.Join(expr.Values, "|"))
+		b.WriteByte(';')
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectorcache
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func appSelector(app string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": app},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "api"}},
+		},
+	}
+}
+
+func TestCompile(t *testing.T) {
+	compiler := NewCompiler(0)
+
+	compiled, err := compiler.Compile(appSelector("web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compiled.Matches(labels.Set{"app": "web", "tier": "api"}) {
+		t.Error("should match")
+	}
+	if compiled.Matches(labels.Set{"app": "web", "tier": "db"}) {
+		t.Error("tier db is not in the requirement")
+	}
+
+	// Semantically equal selectors hit the cache.
+	if _, err := compiler.Compile(appSelector("web")); err != nil {
+		t.Fatal(err)
+	}
+	hits, misses := compiler.Stats()
+	if hits != 1 || misses != 1 {
+		t.Errorf("hits=%d misses=%d, want 1 and 1", hits, misses)
+	}
+}
+
+func TestCompileEdgeCases(t *testing.T) {
+	compiler := NewCompiler(0)
+
+	nothing, err := compiler.Compile(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nothing.Matches(labels.Set{"any": "thing"}) {
+		t.Error("nil selector matches nothing")
+	}
+
+	everything, err := compiler.Compile(&metav1.LabelSelector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !everything.Matches(labels.Set{}) {
+		t.Error("empty selector matches everything")
+	}
+
+	invalid := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "k", Operator: metav1.LabelSelectorOpIn},
+	}}
+	if _, err := compiler.Compile(invalid); err == nil {
+		t.Error("In with no values must fail to compile")
+	}
+}
+
+func TestEviction(t *testing.T) {
+	compiler := NewCompiler(2)
+	for i := 0; i < 3; i++ {
+		if _, err := compiler.Compile(appSelector(fmt.Sprintf("app-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// app-0 was evicted; recompiling it is a miss.
+	if _, err := compiler.Compile(appSelector("app-0")); err != nil {
+		t.Fatal(err)
+	}
+	if hits, misses := compiler.Stats(); hits != 0 || misses != 4 {
+		t.Errorf("hits=%d misses=%d, want 0 and 4", hits, misses)
+	}
+}
+
+// BenchmarkScanUncached compiles the selector once per object, the way
+// a naive list scan does.
+func BenchmarkScanUncached(b *testing.B) {
+	selector := appSelector("web")
+	set := labels.Set{"app": "web", "tier": "web"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		compiled, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			b.Fatal(err)
+		}
+		compiled.Matches(set)
+	}
+}
+
+// BenchmarkScanCached performs the same scan through the compiler.
+func BenchmarkScanCached(b *testing.B) {
+	compiler := NewCompiler(0)
+	selector := appSelector("web")
+	set := labels.Set{"app": "web", "tier": "web"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := compiler.Matches(selector, set); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package skewcheck compares the base Kubernetes version of the vendored
+// API types against the connected server and warns on unsupported skew.
+// Version skew is silent by default — decoding just drops fields the
+// other side does not know — and that silence has produced subtle
+// surprises in readers that trusted a field to round-trip. The check
+// names the fields likely affected, from the field→version table below,
+// so a warning says what is actually at risk rather than just "versions
+// differ".
+package skewcheck
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// BaseVersion is the Kubernetes minor release the vendored API types are
+// generated from. Bump it together with the types.
+const BaseVersion = "1.26"
+
+// MaxSkew is the supported distance between client and server minor
+// versions, matching the upstream support policy for clients.
+const MaxSkew = 1
+
+// FieldVersion records when a field was introduced, for pinning
+// diagnostics. The table is maintained alongside the vendored types.
+type FieldVersion struct {
+	// Kind is the group/version/kind shorthand, e.g. "v1/Pod".
+	Kind string
+	// Path is the field's JSON path within the kind.
+	Path string
+	// Since is the minor release the field first appeared in.
+	Since string
+}
+
+// fieldVersions lists fields from recent releases that this package's
+// readers consume. An older server never sends them; an older client
+// silently drops them on decode. Kept sorted by Since, newest last.
+var fieldVersions = []FieldVersion{
+	{Kind: "v1/PersistentVolumeClaim", Path: "spec.dataSourceRef", Since: "1.22"},
+	{Kind: "v1/Pod", Path: "spec.os", Since: "1.23"},
+	{Kind: "v1/PersistentVolumeClaim", Path: "spec.dataSourceRef.namespace", Since: "1.24"},
+	{Kind: "v1/Pod", Path: "spec.hostUsers", Since: "1.25"},
+	{Kind: "v1/Pod", Path: "spec.schedulingGates", Since: "1.26"},
+	{Kind: "v1/Pod", Path: "spec.resourceClaims", Since: "1.26"},
+	{Kind: "v1/PersistentVolume", Path: "status.lastPhaseTransitionTime", Since: "1.28"},
+	{Kind: "v1/Pod", Path: "status.hostIPs", Since: "1.28"},
+	{Kind: "v1/Pod", Path: "spec.containers[].restartPolicy", Since: "1.28"},
+}
+
+// Report is the outcome of one skew check.
+type Report struct {
+	ClientVersion string
+	ServerVersion string
+	// Skew is server minor minus client minor; positive means the server
+	// is newer.
+	Skew int
+	// Supported is false when |Skew| exceeds MaxSkew.
+	Supported bool
+	// Warnings are human-readable findings, empty at zero skew.
+	Warnings []string
+	// Affected lists fields that do not round-trip across this skew:
+	// fields newer than the older side of the connection.
+	Affected []FieldVersion
+}
+
+// minorOf extracts major and minor from a version string, tolerating the
+// suffixes real servers report ("1.26", "v1.26.3", "1.26+" on GKE).
+var minorRe = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+func minorOf(v string) (major, minor int, err error) {
+	m := minorRe.FindStringSubmatch(v)
+	if m == nil {
+		return 0, 0, fmt.Errorf("unparseable version %q", v)
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	return major, minor, nil
+}
+
+// Check compares the vendored base version against a server version as
+// reported by the discovery endpoint.
+func Check(server *version.Info) (Report, error) {
+	return CheckVersions(BaseVersion, server.GitVersion)
+}
+
+// CheckVersions is Check with both versions explicit, for callers that
+// cache the server version.
+func CheckVersions(client, server string) (Report, error) {
+	clientMajor, clientMinor, err := minorOf(client)
+	if err != nil {
+		return Report{}, fmt.Errorf("client version: %v", err)
+	}
+	serverMajor, serverMinor, err := minorOf(server)
+	if err != nil {
+		return Report{}, fmt.Errorf("server version: %v", err)
+	}
+	report := Report{ClientVersion: client, ServerVersion: server}
+	if clientMajor != serverMajor {
+		report.Skew = (serverMajor - clientMajor) * 100
+		report.Supported = false
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("major version mismatch: client %s, server %s", client, server))
+		return report, nil
+	}
+
+	report.Skew = serverMinor - clientMinor
+	report.Supported = abs(report.Skew) <= MaxSkew
+	if report.Skew == 0 {
+		return report, nil
+	}
+
+	// Fields newer than the older side of the connection are the ones
+	// that silently fail to round-trip.
+	oldest := clientMinor
+	if serverMinor < oldest {
+		oldest = serverMinor
+	}
+	for _, field := range fieldVersions {
+		_, since, err := minorOf(field.Since)
+		if err != nil || since <= oldest {
+			continue
+		}
+		report.Affected = append(report.Affected, field)
+	}
+
+	switch {
+	case !report.Supported && report.Skew > 0:
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("server %s is more than %d minor ahead of client %s: new fields decode as absent", server, MaxSkew, client))
+	case !report.Supported:
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("server %s is more than %d minor behind client %s: typed fields may never be populated", server, MaxSkew, client))
+	case report.Skew > 0:
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("server %s is ahead of client %s: within policy, but check affected fields", server, client))
+	default:
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("server %s is behind client %s: within policy, but check affected fields", server, client))
+	}
+	for _, field := range report.Affected {
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("%s %s (since %s) does not round-trip across this skew", field.Kind, field.Path, field.Since))
+	}
+	return report, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
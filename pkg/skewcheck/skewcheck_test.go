@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skewcheck
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestCheckVersions(t *testing.T) {
+	testCases := []struct {
+		name          string
+		client        string
+		server        string
+		wantSkew      int
+		wantSupported bool
+		wantAffected  []string
+	}{
+		{
+			name:          "no skew",
+			client:        "1.26",
+			server:        "v1.26.3",
+			wantSkew:      0,
+			wantSupported: true,
+		},
+		{
+			name:          "server one ahead",
+			client:        "1.26",
+			server:        "v1.27.1",
+			wantSkew:      1,
+			wantSupported: true,
+		},
+		{
+			name:          "server one behind lists newer fields",
+			client:        "1.26",
+			server:        "v1.25.9",
+			wantSkew:      -1,
+			wantSupported: true,
+			wantAffected:  []string{"spec.schedulingGates", "spec.resourceClaims"},
+		},
+		{
+			name:          "server two ahead is unsupported",
+			client:        "1.26",
+			server:        "v1.28.0",
+			wantSkew:      2,
+			wantSupported: false,
+			wantAffected:  []string{"status.hostIPs"},
+		},
+		{
+			name:          "gke version suffix parses",
+			client:        "1.26",
+			server:        "v1.27.3-gke.100",
+			wantSkew:      1,
+			wantSupported: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := CheckVersions(tc.client, tc.server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Skew != tc.wantSkew || report.Supported != tc.wantSupported {
+				t.Errorf("got skew=%d supported=%v, want skew=%d supported=%v",
+					report.Skew, report.Supported, tc.wantSkew, tc.wantSupported)
+			}
+			if tc.wantSkew != 0 && len(report.Warnings) == 0 {
+				t.Error("expected warnings at non-zero skew")
+			}
+			if tc.wantSkew == 0 && len(report.Warnings) != 0 {
+				t.Errorf("unexpected warnings at zero skew: %v", report.Warnings)
+			}
+			for _, path := range tc.wantAffected {
+				found := false
+				for _, field := range report.Affected {
+					if field.Path == path {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected %s in affected fields, got %+v", path, report.Affected)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckUsesDiscoveryInfo(t *testing.T) {
+	report, err := Check(&version.Info{GitVersion: "v1.27.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ClientVersion != BaseVersion || report.ServerVersion != "v1.27.2" {
+		t.Errorf("unexpected report versions: %+v", report)
+	}
+}
+
+func TestAffectedFieldsExcludeOldOnes(t *testing.T) {
+	// At one minor of skew around 1.26, fields introduced long before
+	// must not be flagged.
+	report, err := CheckVersions("1.26", "1.25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range report.Affected {
+		if field.Path == "spec.dataSourceRef" {
+			t.Errorf("1.22 field flagged at 1.25/1.26 skew: %+v", field)
+		}
+	}
+}
+
+func TestUnparseableVersions(t *testing.T) {
+	if _, err := CheckVersions("1.26", "weird"); err == nil || !strings.Contains(err.Error(), "server version") {
+		t.Errorf("expected server version parse error, got %v", err)
+	}
+	if _, err := CheckVersions("", "1.26"); err == nil || !strings.Contains(err.Error(), "client version") {
+		t.Errorf("expected client version parse error, got %v", err)
+	}
+}
+
+func TestMajorMismatch(t *testing.T) {
+	report, err := CheckVersions("1.26", "2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Supported {
+		t.Error("major version mismatch must be unsupported")
+	}
+	if len(report.Warnings) == 0 || !strings.Contains(report.Warnings[0], "major version mismatch") {
+		t.Errorf("unexpected warnings %v", report.Warnings)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestLoadDump(t *testing.T) {
+	dump := `{
+		"apiVersion": "v1",
+		"kind": "List",
+		"items": [
+			{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "default", "name": "web-0"}},
+			{"apiVersion": "v1", "kind": "Service", "metadata": {"namespace": "default", "name": "web"}}
+		]
+	}`
+	records, err := LoadDump(strings.NewReader(dump), "dump.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records: %+v", records)
+	}
+	pod, ok := records[0].Object.(*v1.Pod)
+	if !ok || pod.Name != "web-0" {
+		t.Errorf("first record: %+v", records[0])
+	}
+	if records[0].Format != FormatDump || records[0].Source != "dump.json" {
+		t.Errorf("provenance: %+v", records[0])
+	}
+}
+
+func TestLoadAuditLog(t *testing.T) {
+	podJSON := `{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"default","name":"web-0"}}`
+	log := strings.Join([]string{
+		// An event with a response object.
+		fmt.Sprintf(`{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"id-1","stage":"ResponseComplete","verb":"get","user":{"username":"admin"},"requestReceivedTimestamp":"2023-06-01T12:00:00.000000Z","responseObject":%s}`, podJSON),
+		// Metadata-level events carry no object and are skipped.
+		`{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"id-2","stage":"ResponseComplete","verb":"delete","user":{"username":"admin"}}`,
+		"",
+	}, "\n")
+
+	records, err := LoadAuditLog(strings.NewReader(log), "audit.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records: %+v", records)
+	}
+	record := records[0]
+	if _, ok := record.Object.(*v1.Pod); !ok {
+		t.Errorf("object: %T", record.Object)
+	}
+	if record.Format != FormatAuditLog || !strings.Contains(record.Detail, "get admin id-1") {
+		t.Errorf("provenance: %+v", record)
+	}
+	if record.Time.IsZero() {
+		t.Error("audit records should carry the event time")
+	}
+
+	if _, err := LoadAuditLog(strings.NewReader("not json\n"), "bad.log"); err == nil {
+		t.Error("malformed lines must error")
+	}
+}
+
+func TestLoadEtcdSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	writeSnapshot(t, path)
+
+	records, skipped, err := LoadEtcdSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records: %+v, skipped %d", records, skipped)
+	}
+	record := records[0]
+	pod, ok := record.Object.(*v1.Pod)
+	if !ok || pod.Name != "web-0" || pod.Labels["rev"] != "new" {
+		t.Errorf("should decode the latest revision: %+v", record.Object)
+	}
+	if !strings.Contains(record.Detail, "/registry/pods/default/web-0 @5") {
+		t.Errorf("detail: %q", record.Detail)
+	}
+	// The unknown-type value and the tombstone are both skipped.
+	if skipped != 2 {
+		t.Errorf("skipped = %d", skipped)
+	}
+}
+
+// writeSnapshot builds a minimal bbolt file shaped like an etcd
+// backup: a "key" bucket of mvccpb.KeyValue entries, with the pod
+// stored twice at different revisions, one foreign value and one
+// tombstone.
+func writeSnapshot(t *testing.T, path string) {
+	t.Helper()
+	encode := func(labels map[string]string) []byte {
+		pod := &v1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-0", Labels: labels},
+		}
+		serializer := protobuf.NewSerializer(clientsetscheme.Scheme, clientsetscheme.Scheme)
+		var buf bytes.Buffer
+		if err := serializer.Encode(pod, &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	entries := []*mvccpb.KeyValue{
+		{Key: []byte("/registry/pods/default/web-0"), ModRevision: 3, Value: encode(map[string]string{"rev": "old"})},
+		{Key: []byte("/registry/pods/default/web-0"), ModRevision: 5, Value: encode(map[string]string{"rev": "new"})},
+		{Key: []byte("/registry/widgets/default/w"), ModRevision: 4, Value: []byte("not a known type")},
+		{Key: []byte("/registry/pods/default/gone"), ModRevision: 6},
+	}
+
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("key"))
+		if err != nil {
+			return err
+		}
+		for i, kv := range entries {
+			value, err := kv.Marshal()
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(fmt.Sprintf("rev-%d", i)), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
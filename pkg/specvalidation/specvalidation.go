@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package specvalidation validates v1 PodSpecs and volume sources
+// without the apiserver's internal-type validation stack: union rules
+// via pkg/union, plus the basic field constraints — DNS labels, port
+// ranges, probe thresholds — that catch most hand-written manifest
+// mistakes. It is deliberately a subset; an object this package accepts
+// can still be rejected by a real apiserver, but one it rejects is
+// always genuinely broken.
+package specvalidation
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/kubernetes/pkg/union"
+)
+
+// ValidatePodSpec checks volumes, containers and init containers.
+func ValidatePodSpec(spec *v1.PodSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	allErrs = append(allErrs, validateVolumes(spec.Volumes, fldPath.Child("volumes"))...)
+	allErrs = append(allErrs, validateContainers(spec.InitContainers, fldPath.Child("initContainers"))...)
+	allErrs = append(allErrs, validateContainers(spec.Containers, fldPath.Child("containers"))...)
+	if len(spec.Containers) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("containers"), "at least one container is required"))
+	}
+	return allErrs
+}
+
+func validateVolumes(volumes []v1.Volume, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, volume := range volumes {
+		idxPath := fldPath.Index(i)
+		for _, msg := range validation.IsDNS1123Label(volume.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), volume.Name, msg))
+		}
+		if names[volume.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), volume.Name))
+		}
+		names[volume.Name] = true
+		allErrs = append(allErrs, ValidateVolumeSource(&volume.VolumeSource, idxPath)...)
+	}
+	return allErrs
+}
+
+// ValidateVolumeSource enforces the exactly-one-member union rule.
+func ValidateVolumeSource(source *v1.VolumeSource, fldPath *field.Path) field.ErrorList {
+	if err := union.Validate(source); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, "", err.Error())}
+	}
+	return nil
+}
+
+// ValidatePersistentVolumeSource enforces the union rule on PV backends.
+func ValidatePersistentVolumeSource(source *v1.PersistentVolumeSource, fldPath *field.Path) field.ErrorList {
+	if err := union.Validate(source); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, "", err.Error())}
+	}
+	return nil
+}
+
+func validateContainers(containers []v1.Container, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, container := range containers {
+		idxPath := fldPath.Index(i)
+		for _, msg := range validation.IsDNS1123Label(container.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), container.Name, msg))
+		}
+		if names[container.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), container.Name))
+		}
+		names[container.Name] = true
+		if container.Image == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("image"), ""))
+		}
+		allErrs = append(allErrs, ValidateContainerPorts(container.Ports, idxPath.Child("ports"))...)
+		allErrs = append(allErrs, ValidateProbe(container.LivenessProbe, idxPath.Child("livenessProbe"))...)
+		allErrs = append(allErrs, ValidateProbe(container.ReadinessProbe, idxPath.Child("readinessProbe"))...)
+		allErrs = append(allErrs, ValidateProbe(container.StartupProbe, idxPath.Child("startupProbe"))...)
+		// Liveness and startup restarts are only meaningful with a
+		// threshold of one success.
+		for _, probe := range []struct {
+			probe *v1.Probe
+			path  *field.Path
+		}{
+			{container.LivenessProbe, idxPath.Child("livenessProbe")},
+			{container.StartupProbe, idxPath.Child("startupProbe")},
+		} {
+			if probe.probe != nil && probe.probe.SuccessThreshold != 0 && probe.probe.SuccessThreshold != 1 {
+				allErrs = append(allErrs, field.Invalid(probe.path.Child("successThreshold"), probe.probe.SuccessThreshold, "must be 1"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// ValidateContainerPorts checks ranges, name syntax and name uniqueness.
+func ValidateContainerPorts(ports []v1.ContainerPort, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, port := range ports {
+		idxPath := fldPath.Index(i)
+		for _, msg := range validation.IsValidPortNum(int(port.ContainerPort)) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("containerPort"), port.ContainerPort, msg))
+		}
+		if port.HostPort != 0 {
+			for _, msg := range validation.IsValidPortNum(int(port.HostPort)) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("hostPort"), port.HostPort, msg))
+			}
+		}
+		if port.Name != "" {
+			for _, msg := range validation.IsValidPortName(port.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), port.Name, msg))
+			}
+			if names[port.Name] {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), port.Name))
+			}
+			names[port.Name] = true
+		}
+	}
+	return allErrs
+}
+
+// ValidateProbe checks the handler union and threshold ranges. A nil
+// probe is valid.
+func ValidateProbe(probe *v1.Probe, fldPath *field.Path) field.ErrorList {
+	if probe == nil {
+		return nil
+	}
+	allErrs := field.ErrorList{}
+	if err := union.Validate(&probe.ProbeHandler); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, "", err.Error()))
+	}
+	allErrs = append(allErrs, validateNonnegative(int64(probe.InitialDelaySeconds), fldPath.Child("initialDelaySeconds"))...)
+	allErrs = append(allErrs, validateNonnegative(int64(probe.TimeoutSeconds), fldPath.Child("timeoutSeconds"))...)
+	allErrs = append(allErrs, validateNonnegative(int64(probe.PeriodSeconds), fldPath.Child("periodSeconds"))...)
+	allErrs = append(allErrs, validateNonnegative(int64(probe.SuccessThreshold), fldPath.Child("successThreshold"))...)
+	allErrs = append(allErrs, validateNonnegative(int64(probe.FailureThreshold), fldPath.Child("failureThreshold"))...)
+	return allErrs
+}
+
+func validateNonnegative(value int64, fldPath *field.Path) field.ErrorList {
+	if value < 0 {
+		return field.ErrorList{field.Invalid(fldPath, value, "must be greater than or equal to 0")}
+	}
+	return nil
+}
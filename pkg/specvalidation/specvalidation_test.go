@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package specvalidation
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func fieldNames(errs field.ErrorList) []string {
+	names := make([]string, len(errs))
+	for i, err := range errs {
+		names[i] = err.Field
+	}
+	return names
+}
+
+func hasError(errs field.ErrorList, fieldSuffix string) bool {
+	for _, err := range errs {
+		if strings.HasSuffix(err.Field, fieldSuffix) || err.Field == fieldSuffix {
+			return true
+		}
+	}
+	return false
+}
+
+func validSpec() *v1.PodSpec {
+	return &v1.PodSpec{
+		Volumes: []v1.Volume{{
+			Name:         "data",
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		}},
+		Containers: []v1.Container{{
+			Name:  "app",
+			Image: "nginx:1.25",
+			Ports: []v1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+			ReadinessProbe: &v1.Probe{
+				ProbeHandler:     v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/healthz"}},
+				FailureThreshold: 3,
+			},
+		}},
+	}
+}
+
+func TestValidatePodSpecAcceptsValid(t *testing.T) {
+	if errs := ValidatePodSpec(validSpec(), field.NewPath("spec")); len(errs) != 0 {
+		t.Errorf("valid spec rejected: %v", errs)
+	}
+}
+
+func TestValidatePodSpec(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mutate    func(*v1.PodSpec)
+		wantField string
+	}{
+		{
+			name: "empty volume source",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Volumes[0].VolumeSource = v1.VolumeSource{}
+			},
+			wantField: "spec.volumes[0]",
+		},
+		{
+			name: "two volume sources",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Volumes[0].VolumeSource.HostPath = &v1.HostPathVolumeSource{Path: "/tmp"}
+			},
+			wantField: "spec.volumes[0]",
+		},
+		{
+			name: "bad volume name",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Volumes[0].Name = "Not_A_Label"
+			},
+			wantField: "spec.volumes[0].name",
+		},
+		{
+			name: "duplicate container name",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers = append(spec.Containers, spec.Containers[0])
+			},
+			wantField: "spec.containers[1].name",
+		},
+		{
+			name: "missing image",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers[0].Image = ""
+			},
+			wantField: "spec.containers[0].image",
+		},
+		{
+			name: "port out of range",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers[0].Ports[0].ContainerPort = 70000
+			},
+			wantField: "containerPort",
+		},
+		{
+			name: "duplicate port name",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers[0].Ports = append(spec.Containers[0].Ports, v1.ContainerPort{Name: "http", ContainerPort: 9090})
+			},
+			wantField: "ports[1].name",
+		},
+		{
+			name: "probe with no handler",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers[0].ReadinessProbe.ProbeHandler = v1.ProbeHandler{}
+			},
+			wantField: "readinessProbe",
+		},
+		{
+			name: "negative threshold",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers[0].ReadinessProbe.FailureThreshold = -1
+			},
+			wantField: "failureThreshold",
+		},
+		{
+			name: "liveness success threshold",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers[0].LivenessProbe = &v1.Probe{
+					ProbeHandler:     v1.ProbeHandler{TCPSocket: &v1.TCPSocketAction{}},
+					SuccessThreshold: 2,
+				}
+			},
+			wantField: "livenessProbe.successThreshold",
+		},
+		{
+			name: "no containers",
+			mutate: func(spec *v1.PodSpec) {
+				spec.Containers = nil
+			},
+			wantField: "spec.containers",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := validSpec()
+			tc.mutate(spec)
+			errs := ValidatePodSpec(spec, field.NewPath("spec"))
+			if !hasError(errs, tc.wantField) {
+				t.Errorf("expected an error on %s, got %v", tc.wantField, fieldNames(errs))
+			}
+		})
+	}
+}
+
+func TestValidatePersistentVolumeSource(t *testing.T) {
+	valid := &v1.PersistentVolumeSource{
+		CSI: &v1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com", VolumeHandle: "vol-1"},
+	}
+	if errs := ValidatePersistentVolumeSource(valid, field.NewPath("spec")); len(errs) != 0 {
+		t.Errorf("valid source rejected: %v", errs)
+	}
+	both := &v1.PersistentVolumeSource{
+		CSI: valid.CSI,
+		NFS: &v1.NFSVolumeSource{Server: "fs", Path: "/export"},
+	}
+	if errs := ValidatePersistentVolumeSource(both, field.NewPath("spec")); len(errs) != 1 {
+		t.Errorf("double source accepted: %v", errs)
+	}
+}
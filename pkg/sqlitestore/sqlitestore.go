@@ -0,0 +1,336 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sqlitestore exports cached objects as a SQLite-compatible SQL
+// dump with one table per kind, and loads such dumps back for re-serving.
+// Each table carries key columns (namespace, name, uid, resourceVersion),
+// the full object as a JSON blob, and optional per-kind flattened columns
+// for common query fields.
+//
+// The dump is plain SQL rather than a binary database file so that no
+// SQLite driver (and no cgo) is linked into the read tooling; analysts
+// build the database with the stock CLI:
+//
+//	sqlite3 cluster.db < cluster.sql
+package sqlitestore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// baseColumns are present in every kind's table, before any flattened
+// columns registered for the kind.
+var baseColumns = []string{"namespace", "name", "uid", "resourceVersion", "object"}
+
+// FlattenFunc extracts additional column values from an object. It must
+// return one value per registered column name.
+type FlattenFunc func(obj runtime.Object) []string
+
+type flattener struct {
+	columns []string
+	fn      FlattenFunc
+}
+
+// Exporter writes kinds of objects as SQL tables to an output stream.
+type Exporter struct {
+	w          io.Writer
+	flatteners map[string]flattener
+	began      bool
+}
+
+// NewExporter returns an exporter writing to w.
+func NewExporter(w io.Writer) *Exporter {
+	return &Exporter{w: w, flatteners: map[string]flattener{}}
+}
+
+// Flatten registers extra flattened columns for a kind. It must be called
+// before the kind's table is written.
+func (e *Exporter) Flatten(kind string, columns []string, fn FlattenFunc) {
+	e.flatteners[kind] = flattener{columns: columns, fn: fn}
+}
+
+// WriteKind writes one table holding all given objects of the kind. The
+// table is named after the lowercased kind.
+func (e *Exporter) WriteKind(kind string, objs []runtime.Object) error {
+	if !e.began {
+		// A single transaction keeps bulk import fast.
+		if _, err := fmt.Fprintln(e.w, "BEGIN TRANSACTION;"); err != nil {
+			return err
+		}
+		e.began = true
+	}
+
+	table := tableName(kind)
+	columns := baseColumns
+	flat, hasFlat := e.flatteners[kind]
+	if hasFlat {
+		columns = append(append([]string{}, baseColumns...), flat.columns...)
+	}
+	defs := make([]string, len(columns))
+	for i, col := range columns {
+		defs[i] = quoteIdent(col) + " TEXT"
+	}
+	if _, err := fmt.Fprintf(e.w, "CREATE TABLE %s (%s);\n", quoteIdent(table), strings.Join(defs, ", ")); err != nil {
+		return err
+	}
+
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		blob, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("encoding %s %s/%s: %v", kind, accessor.GetNamespace(), accessor.GetName(), err)
+		}
+		values := []string{
+			accessor.GetNamespace(),
+			accessor.GetName(),
+			string(accessor.GetUID()),
+			accessor.GetResourceVersion(),
+			string(blob),
+		}
+		if hasFlat {
+			extra := flat.fn(obj)
+			if len(extra) != len(flat.columns) {
+				return fmt.Errorf("kind %s: flattener returned %d values for %d columns", kind, len(extra), len(flat.columns))
+			}
+			values = append(values, extra...)
+		}
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = quoteValue(v)
+		}
+		if _, err := fmt.Fprintf(e.w, "INSERT INTO %s VALUES(%s);\n", quoteIdent(table), strings.Join(quoted, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close terminates the dump. No tables may be written afterwards.
+func (e *Exporter) Close() error {
+	if !e.began {
+		return nil
+	}
+	_, err := fmt.Fprintln(e.w, "COMMIT;")
+	return err
+}
+
+// Row is one stored object as read back from a dump.
+type Row struct {
+	Namespace       string
+	Name            string
+	UID             string
+	ResourceVersion string
+	// Object is the full JSON encoding of the object.
+	Object []byte
+	// Columns holds the kind's flattened column values by column name.
+	Columns map[string]string
+}
+
+// Dump is the in-memory form of a loaded SQL dump, able to re-serve the
+// stored objects by kind and key.
+type Dump struct {
+	rows map[string][]Row
+}
+
+// Load parses a dump previously produced by an Exporter.
+func Load(r io.Reader) (*Dump, error) {
+	dump := &Dump{rows: map[string][]Row{}}
+	columns := map[string][]string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line == "BEGIN TRANSACTION;" || line == "COMMIT;":
+			continue
+		case strings.HasPrefix(line, "CREATE TABLE "):
+			table, cols, err := parseCreate(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			columns[table] = cols
+			dump.rows[table] = nil
+		case strings.HasPrefix(line, "INSERT INTO "):
+			table, values, err := parseInsert(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			cols, ok := columns[table]
+			if !ok {
+				return nil, fmt.Errorf("line %d: insert into unknown table %q", lineNo, table)
+			}
+			if len(values) != len(cols) {
+				return nil, fmt.Errorf("line %d: %d values for %d columns", lineNo, len(values), len(cols))
+			}
+			row := Row{
+				Namespace:       values[0],
+				Name:            values[1],
+				UID:             values[2],
+				ResourceVersion: values[3],
+				Object:          []byte(values[4]),
+			}
+			if len(cols) > len(baseColumns) {
+				row.Columns = map[string]string{}
+				for i, col := range cols[len(baseColumns):] {
+					row.Columns[col] = values[len(baseColumns)+i]
+				}
+			}
+			dump.rows[table] = append(dump.rows[table], row)
+		default:
+			return nil, fmt.Errorf("line %d: unrecognized statement", lineNo)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// Kinds returns the stored table names, sorted.
+func (d *Dump) Kinds() []string {
+	kinds := make([]string, 0, len(d.rows))
+	for kind := range d.rows {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// List returns all rows of the kind's table.
+func (d *Dump) List(kind string) []Row {
+	return d.rows[tableName(kind)]
+}
+
+// Get returns the row for the given key, if present.
+func (d *Dump) Get(kind, namespace, name string) (Row, bool) {
+	for _, row := range d.rows[tableName(kind)] {
+		if row.Namespace == namespace && row.Name == name {
+			return row, true
+		}
+	}
+	return Row{}, false
+}
+
+func tableName(kind string) string {
+	return strings.ToLower(kind)
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteValue(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+// parseCreate extracts the table and column names from a CREATE TABLE
+// statement in the exact single-line form the exporter emits.
+func parseCreate(line string) (string, []string, error) {
+	rest := strings.TrimPrefix(line, "CREATE TABLE ")
+	open := strings.IndexByte(rest, '(')
+	if open < 0 || !strings.HasSuffix(rest, ");") {
+		return "", nil, fmt.Errorf("malformed CREATE TABLE")
+	}
+	table, err := unquoteIdent(strings.TrimSpace(rest[:open]))
+	if err != nil {
+		return "", nil, err
+	}
+	var cols []string
+	for _, def := range strings.Split(rest[open+1:len(rest)-2], ", ") {
+		name, err := unquoteIdent(strings.TrimSuffix(def, " TEXT"))
+		if err != nil {
+			return "", nil, err
+		}
+		cols = append(cols, name)
+	}
+	return table, cols, nil
+}
+
+// parseInsert extracts the table name and values from an INSERT statement
+// in the exact single-line form the exporter emits.
+func parseInsert(line string) (string, []string, error) {
+	rest := strings.TrimPrefix(line, "INSERT INTO ")
+	open := strings.Index(rest, " VALUES(")
+	if open < 0 || !strings.HasSuffix(rest, ");") {
+		return "", nil, fmt.Errorf("malformed INSERT")
+	}
+	table, err := unquoteIdent(rest[:open])
+	if err != nil {
+		return "", nil, err
+	}
+	values, err := splitQuoted(rest[open+len(" VALUES(") : len(rest)-2])
+	if err != nil {
+		return "", nil, err
+	}
+	return table, values, nil
+}
+
+func unquoteIdent(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("malformed identifier %q", s)
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`), nil
+}
+
+// splitQuoted splits a comma-separated list of single-quoted SQL strings,
+// undoing the '' escaping.
+func splitQuoted(s string) ([]string, error) {
+	var values []string
+	for len(s) > 0 {
+		if s[0] != '\'' {
+			return nil, fmt.Errorf("expected quoted value at %q", s)
+		}
+		var sb strings.Builder
+		i := 1
+		for {
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated quoted value")
+			}
+			if s[i] == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					sb.WriteByte('\'')
+					i += 2
+					continue
+				}
+				break
+			}
+			sb.WriteByte(s[i])
+			i++
+		}
+		values = append(values, sb.String())
+		s = s[i+1:]
+		if len(s) > 0 {
+			if s[0] != ',' {
+				return nil, fmt.Errorf("expected comma at %q", s)
+			}
+			s = s[1:]
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlitestore
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testPod(namespace, name, node string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			UID:             types.UID("uid-" + name),
+			ResourceVersion: "42",
+		},
+		Spec: v1.PodSpec{NodeName: node},
+	}
+}
+
+func TestExportLoadRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewExporter(&buf)
+	exporter.Flatten("Pod", []string{"nodeName", "phase"}, func(obj runtime.Object) []string {
+		pod := obj.(*v1.Pod)
+		return []string{pod.Spec.NodeName, string(pod.Status.Phase)}
+	})
+
+	pods := []runtime.Object{
+		testPod("default", "web-1", "node-a"),
+		testPod("kube-system", "dns", "node-b"),
+	}
+	if err := exporter.WriteKind("Pod", pods); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	namespaces := []runtime.Object{
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", UID: "uid-ns"}},
+	}
+	if err := exporter.WriteKind("Namespace", namespaces); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dump, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	if got, expected := dump.Kinds(), []string{"namespace", "pod"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected kinds %v, got %v", expected, got)
+	}
+	if rows := dump.List("Pod"); len(rows) != 2 {
+		t.Fatalf("expected 2 pod rows, got %d", len(rows))
+	}
+
+	row, ok := dump.Get("Pod", "default", "web-1")
+	if !ok {
+		t.Fatal("expected to find default/web-1")
+	}
+	if row.UID != "uid-web-1" || row.ResourceVersion != "42" {
+		t.Errorf("unexpected key columns: %+v", row)
+	}
+	if row.Columns["nodeName"] != "node-a" {
+		t.Errorf("expected flattened nodeName column, got %v", row.Columns)
+	}
+	var pod v1.Pod
+	if err := json.Unmarshal(row.Object, &pod); err != nil {
+		t.Fatalf("stored object is not valid JSON: %v", err)
+	}
+	if pod.Spec.NodeName != "node-a" {
+		t.Errorf("round-tripped object lost data: %+v", pod.Spec)
+	}
+
+	if _, ok := dump.Get("Pod", "default", "absent"); ok {
+		t.Error("expected lookup miss for absent object")
+	}
+}
+
+func TestExportEscaping(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewExporter(&buf)
+	pod := testPod("default", "quote", "")
+	pod.Annotations = map[string]string{"note": "it's quoted; DROP TABLE \"pods\""}
+	if err := exporter.WriteKind("Pod", []runtime.Object{pod}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dump, err := Load(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	row, ok := dump.Get("Pod", "default", "quote")
+	if !ok {
+		t.Fatal("expected to find default/quote")
+	}
+	var got v1.Pod
+	if err := json.Unmarshal(row.Object, &got); err != nil {
+		t.Fatalf("stored object is not valid JSON: %v", err)
+	}
+	if got.Annotations["note"] != pod.Annotations["note"] {
+		t.Errorf("escaping corrupted annotation: %q", got.Annotations["note"])
+	}
+}
+
+func TestExportDumpShape(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewExporter(&buf)
+	if err := exporter.WriteKind("Pod", []runtime.Object{testPod("default", "web-1", "node-a")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN TRANSACTION;",
+		`CREATE TABLE "pod" ("namespace" TEXT, "name" TEXT, "uid" TEXT, "resourceVersion" TEXT, "object" TEXT);`,
+		"COMMIT;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFlattenerArityMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewExporter(&buf)
+	exporter.Flatten("Pod", []string{"a", "b"}, func(obj runtime.Object) []string {
+		return []string{"only-one"}
+	})
+	err := exporter.WriteKind("Pod", []runtime.Object{testPod("default", "web-1", "")})
+	if err == nil {
+		t.Fatal("expected error for flattener arity mismatch")
+	}
+}
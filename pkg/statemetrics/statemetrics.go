@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statemetrics exposes a curated subset of kube-state-metrics
+// gauges computed from locally cached objects, for embedders who want
+// state metrics on /metrics without deploying KSM. Metrics are produced
+// at scrape time from list funcs (no per-event bookkeeping to leak), and
+// the metric names match KSM's so existing dashboards keep working.
+// Object labels only become metric labels when allowlisted, mirroring
+// KSM's --metric-labels-allowlist posture on cardinality.
+package statemetrics
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Config selects kinds and label propagation.
+type Config struct {
+	// Pods, Nodes and PVCs enable the per-kind metric families.
+	Pods  bool
+	Nodes bool
+	PVCs  bool
+	// LabelAllowlist is the set of object label keys propagated onto
+	// metrics as label_<sanitized_key>.
+	LabelAllowlist []string
+}
+
+// Lists supply current objects at scrape time, typically backed by
+// informer listers. Nil funcs disable their family regardless of Config.
+type Lists struct {
+	Pods  func() []*v1.Pod
+	Nodes func() []*v1.Node
+	PVCs  func() []*v1.PersistentVolumeClaim
+}
+
+// podPhases and pvcPhases are emitted as one 0/1 gauge per phase, the KSM
+// convention that makes phase changes visible as series flips.
+var podPhases = []v1.PodPhase{v1.PodPending, v1.PodRunning, v1.PodSucceeded, v1.PodFailed, v1.PodUnknown}
+var pvcPhases = []v1.PersistentVolumeClaimPhase{v1.ClaimPending, v1.ClaimBound, v1.ClaimLost}
+var nodeConditions = []v1.NodeConditionType{v1.NodeReady, v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure}
+var conditionStatuses = []v1.ConditionStatus{v1.ConditionTrue, v1.ConditionFalse, v1.ConditionUnknown}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// sanitizeLabelName turns an object label key into a valid Prometheus
+// label name, the same way KSM does.
+func sanitizeLabelName(key string) string {
+	return "label_" + invalidLabelChars.ReplaceAllString(key, "_")
+}
+
+// Collector implements prometheus.Collector over the configured lists.
+type Collector struct {
+	config Config
+	lists  Lists
+
+	extraKeys []string // allowlisted object label keys, sorted
+
+	podPhaseDesc    *prometheus.Desc
+	podRestartsDesc *prometheus.Desc
+	pvcPhaseDesc    *prometheus.Desc
+	nodeCondDesc    *prometheus.Desc
+}
+
+// New returns a collector for the config and lists.
+func New(config Config, lists Lists) *Collector {
+	extraKeys := append([]string{}, config.LabelAllowlist...)
+	sort.Strings(extraKeys)
+	extraNames := make([]string, len(extraKeys))
+	for i, key := range extraKeys {
+		extraNames[i] = sanitizeLabelName(key)
+	}
+
+	withExtra := func(base ...string) []string {
+		return append(base, extraNames...)
+	}
+
+	return &Collector{
+		config:    config,
+		lists:     lists,
+		extraKeys: extraKeys,
+		podPhaseDesc: prometheus.NewDesc(
+			"kube_pod_status_phase",
+			"The pod's current phase.",
+			withExtra("namespace", "pod", "phase"), nil,
+		),
+		podRestartsDesc: prometheus.NewDesc(
+			"kube_pod_container_status_restarts_total",
+			"Cumulative container restarts.",
+			withExtra("namespace", "pod", "container"), nil,
+		),
+		pvcPhaseDesc: prometheus.NewDesc(
+			"kube_persistentvolumeclaim_status_phase",
+			"The claim's current phase.",
+			withExtra("namespace", "persistentvolumeclaim", "phase"), nil,
+		),
+		nodeCondDesc: prometheus.NewDesc(
+			"kube_node_status_condition",
+			"The node's condition statuses.",
+			withExtra("node", "condition", "status"), nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	if c.config.Pods {
+		ch <- c.podPhaseDesc
+		ch <- c.podRestartsDesc
+	}
+	if c.config.PVCs {
+		ch <- c.pvcPhaseDesc
+	}
+	if c.config.Nodes {
+		ch <- c.nodeCondDesc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	if c.config.Pods && c.lists.Pods != nil {
+		for _, pod := range c.lists.Pods() {
+			extra := c.extraValues(pod.Labels)
+			for _, phase := range podPhases {
+				value := 0.0
+				if pod.Status.Phase == phase {
+					value = 1
+				}
+				ch <- prometheus.MustNewConstMetric(c.podPhaseDesc, prometheus.GaugeValue, value,
+					append([]string{pod.Namespace, pod.Name, string(phase)}, extra...)...)
+			}
+			for _, status := range pod.Status.ContainerStatuses {
+				ch <- prometheus.MustNewConstMetric(c.podRestartsDesc, prometheus.CounterValue, float64(status.RestartCount),
+					append([]string{pod.Namespace, pod.Name, status.Name}, extra...)...)
+			}
+		}
+	}
+	if c.config.PVCs && c.lists.PVCs != nil {
+		for _, pvc := range c.lists.PVCs() {
+			extra := c.extraValues(pvc.Labels)
+			for _, phase := range pvcPhases {
+				value := 0.0
+				if pvc.Status.Phase == phase {
+					value = 1
+				}
+				ch <- prometheus.MustNewConstMetric(c.pvcPhaseDesc, prometheus.GaugeValue, value,
+					append([]string{pvc.Namespace, pvc.Name, string(phase)}, extra...)...)
+			}
+		}
+	}
+	if c.config.Nodes && c.lists.Nodes != nil {
+		for _, node := range c.lists.Nodes() {
+			extra := c.extraValues(node.Labels)
+			for _, conditionType := range nodeConditions {
+				current := v1.ConditionUnknown
+				for _, condition := range node.Status.Conditions {
+					if condition.Type == conditionType {
+						current = condition.Status
+						break
+					}
+				}
+				for _, status := range conditionStatuses {
+					value := 0.0
+					if current == status {
+						value = 1
+					}
+					// KSM exposes condition statuses lowercased.
+					ch <- prometheus.MustNewConstMetric(c.nodeCondDesc, prometheus.GaugeValue, value,
+						append([]string{node.Name, string(conditionType), strings.ToLower(string(status))}, extra...)...)
+				}
+			}
+		}
+	}
+}
+
+// extraValues resolves the allowlisted label values for one object, in
+// the same order as the descriptor's label names; missing labels yield
+// empty values.
+func (c *Collector) extraValues(labels map[string]string) []string {
+	if len(c.extraKeys) == 0 {
+		return nil
+	}
+	values := make([]string, len(c.extraKeys))
+	for i, key := range c.extraKeys {
+		values[i] = labels[key]
+	}
+	return values
+}
+
+// Handler returns an http.Handler serving the collector's metrics in
+// Prometheus exposition format, on a private registry.
+func (c *Collector) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
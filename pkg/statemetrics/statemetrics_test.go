@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statemetrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func scrape(t *testing.T, c *Collector) string {
+	t.Helper()
+	server := httptest.NewServer(c.Handler())
+	defer server.Close()
+	response, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func testLists() Lists {
+	return Lists{
+		Pods: func() []*v1.Pod {
+			return []*v1.Pod{{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "web",
+					Labels:    map[string]string{"app": "web", "team.example.com/owner": "billing"},
+				},
+				Status: v1.PodStatus{
+					Phase: v1.PodRunning,
+					ContainerStatuses: []v1.ContainerStatus{
+						{Name: "main", RestartCount: 3},
+					},
+				},
+			}}
+		},
+		Nodes: func() []*v1.Node {
+			return []*v1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status: v1.NodeStatus{Conditions: []v1.NodeCondition{
+					{Type: v1.NodeReady, Status: v1.ConditionTrue},
+				}},
+			}}
+		},
+		PVCs: func() []*v1.PersistentVolumeClaim {
+			return []*v1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+				Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+			}}
+		},
+	}
+}
+
+func TestCollectAllFamilies(t *testing.T) {
+	c := New(Config{Pods: true, Nodes: true, PVCs: true}, testLists())
+	body := scrape(t, c)
+
+	for _, want := range []string{
+		`kube_pod_status_phase{namespace="default",phase="Running",pod="web"} 1`,
+		`kube_pod_status_phase{namespace="default",phase="Pending",pod="web"} 0`,
+		`kube_pod_container_status_restarts_total{container="main",namespace="default",pod="web"} 3`,
+		`kube_persistentvolumeclaim_status_phase{namespace="default",persistentvolumeclaim="data",phase="Bound"} 1`,
+		`kube_node_status_condition{condition="Ready",node="node-1",status="true"} 1`,
+		`kube_node_status_condition{condition="Ready",node="node-1",status="false"} 0`,
+		// Conditions the node does not report read as Unknown.
+		`kube_node_status_condition{condition="MemoryPressure",node="node-1",status="unknown"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected exposition to contain %q\ngot:\n%s", want, body)
+		}
+	}
+}
+
+func TestLabelAllowlist(t *testing.T) {
+	c := New(Config{Pods: true, LabelAllowlist: []string{"app", "team.example.com/owner"}}, testLists())
+	body := scrape(t, c)
+
+	if !strings.Contains(body, `label_app="web"`) {
+		t.Errorf("expected allowlisted label, got:\n%s", body)
+	}
+	// Non-alphanumeric characters in the key are sanitized.
+	if !strings.Contains(body, `label_team_example_com_owner="billing"`) {
+		t.Errorf("expected sanitized label name, got:\n%s", body)
+	}
+}
+
+func TestDisabledFamiliesOmitted(t *testing.T) {
+	c := New(Config{Pods: true}, testLists())
+	body := scrape(t, c)
+	if strings.Contains(body, "kube_node_status_condition") {
+		t.Errorf("node family must be disabled, got:\n%s", body)
+	}
+	if strings.Contains(body, "kube_persistentvolumeclaim_status_phase") {
+		t.Errorf("pvc family must be disabled, got:\n%s", body)
+	}
+	if !strings.Contains(body, "kube_pod_status_phase") {
+		t.Errorf("pod family must be enabled, got:\n%s", body)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package storageclassdetail normalizes the provisioner-specific
+// performance hints scattered across StorageClass parameters and PV
+// annotations — IOPS and throughput keys that every provisioner spells
+// differently — into one typed envelope, and joins envelopes with the
+// volumes each pod mounts. Parsers are pluggable per provisioner prefix;
+// the builtin set covers the provisioners our clusters run, and unknown
+// provisioners degrade to an empty envelope rather than an error.
+package storageclassdetail
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// Envelope is the normalized performance description of one volume.
+type Envelope struct {
+	Provisioner string
+	// IOPS is the provisioned or implied IOPS limit; 0 means unknown.
+	IOPS int64
+	// ThroughputMBps is the throughput limit in MiB/s; 0 means unknown.
+	ThroughputMBps int64
+	// Tier is the provisioner's class name for the performance tier
+	// (e.g. "gp3", "premium-rwo"), when one exists.
+	Tier string
+}
+
+// Parser extracts an envelope from StorageClass parameters and PV
+// annotations. Either map may be nil.
+type Parser func(parameters, annotations map[string]string) Envelope
+
+// parsers maps a provisioner name prefix to its parser. Registration is
+// by prefix because CSI driver names carry suffixes per cluster flavor.
+var parsers = map[string]Parser{}
+
+// Register installs a parser for provisioners matching the prefix.
+// Builtin parsers can be overridden by registering the same prefix.
+func Register(provisionerPrefix string, parser Parser) {
+	parsers[provisionerPrefix] = parser
+}
+
+func init() {
+	Register("ebs.csi.aws.com", parseEBS)
+	Register("kubernetes.io/aws-ebs", parseEBS)
+	Register("pd.csi.storage.gke.io", parseGCEPD)
+	Register("kubernetes.io/gce-pd", parseGCEPD)
+	Register("disk.csi.azure.com", parseAzureDisk)
+}
+
+// parseEBS reads the gp3/io2 parameter names.
+func parseEBS(parameters, annotations map[string]string) Envelope {
+	envelope := Envelope{Tier: parameters["type"]}
+	envelope.IOPS = intValue(parameters, "iops", "iopsPerGB")
+	envelope.ThroughputMBps = intValue(parameters, "throughput")
+	return envelope
+}
+
+// parseGCEPD maps the disk type; GCE derives IOPS from size, so only
+// explicit provisioned values appear.
+func parseGCEPD(parameters, annotations map[string]string) Envelope {
+	return Envelope{
+		Tier:           parameters["type"],
+		IOPS:           intValue(parameters, "provisioned-iops-on-create"),
+		ThroughputMBps: intValue(parameters, "provisioned-throughput-on-create"),
+	}
+}
+
+func parseAzureDisk(parameters, annotations map[string]string) Envelope {
+	return Envelope{
+		Tier:           parameters["skuName"],
+		IOPS:           intValue(parameters, "DiskIOPSReadWrite"),
+		ThroughputMBps: intValue(parameters, "DiskMBpsReadWrite"),
+	}
+}
+
+// intValue returns the first parseable integer among the keys, checking
+// parameters case-insensitively the way provisioners do.
+func intValue(parameters map[string]string, keys ...string) int64 {
+	for _, key := range keys {
+		for k, v := range parameters {
+			if !strings.EqualFold(k, key) {
+				continue
+			}
+			v = strings.TrimSuffix(strings.TrimSpace(v), "Mi")
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// Resolve computes the envelope for one PV given its StorageClass (which
+// may be nil for pre-provisioned volumes). PV annotations override class
+// parameters where both are hints for the same volume.
+func Resolve(pv *v1.PersistentVolume, class *storagev1.StorageClass) Envelope {
+	provisioner := ""
+	var parameters map[string]string
+	if class != nil {
+		provisioner = class.Provisioner
+		parameters = class.Parameters
+	}
+	if provisioner == "" && pv != nil {
+		provisioner = pv.Annotations["pv.kubernetes.io/provisioned-by"]
+	}
+
+	// The longest matching prefix wins, so a specific driver
+	// registration beats a family-wide one.
+	var parser Parser
+	best := -1
+	for prefix, candidate := range parsers {
+		if strings.HasPrefix(provisioner, prefix) && len(prefix) > best {
+			parser, best = candidate, len(prefix)
+		}
+	}
+	var envelope Envelope
+	if parser != nil {
+		var annotations map[string]string
+		if pv != nil {
+			annotations = pv.Annotations
+		}
+		envelope = parser(parameters, annotations)
+	}
+	envelope.Provisioner = provisioner
+	return envelope
+}
+
+// PodVolume is one pod-mounted volume with its performance envelope.
+type PodVolume struct {
+	Namespace string
+	Pod       string
+	// Volume is the pod's volume name; Claim and PV identify the
+	// storage behind it.
+	Volume   string
+	Claim    string
+	PV       string
+	Envelope Envelope
+}
+
+// Join resolves every PVC-backed volume of every pod to its envelope.
+// Volumes whose claim or PV is missing from the inputs are skipped; the
+// integrity checker reports those.
+func Join(pods []*v1.Pod, claims []*v1.PersistentVolumeClaim, pvs []*v1.PersistentVolume, classes []*storagev1.StorageClass) []PodVolume {
+	claimByKey := map[string]*v1.PersistentVolumeClaim{}
+	for _, claim := range claims {
+		claimByKey[claim.Namespace+"/"+claim.Name] = claim
+	}
+	pvByName := map[string]*v1.PersistentVolume{}
+	for _, pv := range pvs {
+		pvByName[pv.Name] = pv
+	}
+	classByName := map[string]*storagev1.StorageClass{}
+	for _, class := range classes {
+		classByName[class.Name] = class
+	}
+
+	var result []PodVolume
+	for _, pod := range pods {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil {
+				continue
+			}
+			claim := claimByKey[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName]
+			if claim == nil || claim.Spec.VolumeName == "" {
+				continue
+			}
+			pv := pvByName[claim.Spec.VolumeName]
+			if pv == nil {
+				continue
+			}
+			var class *storagev1.StorageClass
+			if name := pv.Spec.StorageClassName; name != "" {
+				class = classByName[name]
+			}
+			result = append(result, PodVolume{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				Volume:    volume.Name,
+				Claim:     claim.Name,
+				PV:        pv.Name,
+				Envelope:  Resolve(pv, class),
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		return a.Volume < b.Volume
+	})
+	return result
+}
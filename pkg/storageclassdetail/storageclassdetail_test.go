@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclassdetail
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveBuiltinParsers(t *testing.T) {
+	testCases := []struct {
+		name  string
+		class storagev1.StorageClass
+		want  Envelope
+	}{
+		{
+			name: "ebs gp3",
+			class: storagev1.StorageClass{
+				Provisioner: "ebs.csi.aws.com",
+				Parameters:  map[string]string{"type": "gp3", "iops": "3000", "throughput": "125"},
+			},
+			want: Envelope{Provisioner: "ebs.csi.aws.com", Tier: "gp3", IOPS: 3000, ThroughputMBps: 125},
+		},
+		{
+			name: "gce pd",
+			class: storagev1.StorageClass{
+				Provisioner: "pd.csi.storage.gke.io",
+				Parameters:  map[string]string{"type": "hyperdisk-balanced", "provisioned-iops-on-create": "3600"},
+			},
+			want: Envelope{Provisioner: "pd.csi.storage.gke.io", Tier: "hyperdisk-balanced", IOPS: 3600},
+		},
+		{
+			name: "azure disk",
+			class: storagev1.StorageClass{
+				Provisioner: "disk.csi.azure.com",
+				Parameters:  map[string]string{"skuName": "UltraSSD_LRS", "DiskIOPSReadWrite": "20000", "DiskMBpsReadWrite": "400"},
+			},
+			want: Envelope{Provisioner: "disk.csi.azure.com", Tier: "UltraSSD_LRS", IOPS: 20000, ThroughputMBps: 400},
+		},
+		{
+			name:  "unknown provisioner degrades to empty",
+			class: storagev1.StorageClass{Provisioner: "nfs.example.com/v1"},
+			want:  Envelope{Provisioner: "nfs.example.com/v1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Resolve(nil, &tc.class); got != tc.want {
+				t.Errorf("Resolve = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveFallsBackToPVAnnotation(t *testing.T) {
+	pv := &v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{
+		Name:        "pre-provisioned",
+		Annotations: map[string]string{"pv.kubernetes.io/provisioned-by": "ebs.csi.aws.com"},
+	}}
+	got := Resolve(pv, nil)
+	if got.Provisioner != "ebs.csi.aws.com" {
+		t.Errorf("provisioner = %q, want from PV annotation", got.Provisioner)
+	}
+}
+
+func TestRegisterCustomParser(t *testing.T) {
+	Register("test.example.com", func(parameters, annotations map[string]string) Envelope {
+		return Envelope{Tier: "custom", IOPS: 42}
+	})
+	class := &storagev1.StorageClass{Provisioner: "test.example.com/driver"}
+	got := Resolve(nil, class)
+	if got.Tier != "custom" || got.IOPS != 42 {
+		t.Errorf("custom parser not used: %+v", got)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	pods := []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db"},
+		Spec: v1.PodSpec{Volumes: []v1.Volume{
+			{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "db-data",
+				}},
+			},
+			{
+				// Not PVC-backed: skipped.
+				Name:         "tmp",
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			},
+			{
+				// Claim missing from inputs: skipped.
+				Name: "orphan",
+				VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "missing",
+				}},
+			},
+		}},
+	}}
+	claims := []*v1.PersistentVolumeClaim{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db-data"},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+	}}
+	pvs := []*v1.PersistentVolume{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec:       v1.PersistentVolumeSpec{StorageClassName: "fast"},
+	}}
+	classes := []*storagev1.StorageClass{{
+		ObjectMeta:  metav1.ObjectMeta{Name: "fast"},
+		Provisioner: "ebs.csi.aws.com",
+		Parameters:  map[string]string{"type": "gp3", "iops": "3000", "throughput": "125"},
+	}}
+
+	joined := Join(pods, claims, pvs, classes)
+	if len(joined) != 1 {
+		t.Fatalf("expected 1 joined volume, got %+v", joined)
+	}
+	got := joined[0]
+	if got.Pod != "db" || got.Volume != "data" || got.Claim != "db-data" || got.PV != "pv-1" {
+		t.Errorf("unexpected join: %+v", got)
+	}
+	if got.Envelope.IOPS != 3000 || got.Envelope.ThroughputMBps != 125 || got.Envelope.Tier != "gp3" {
+		t.Errorf("envelope not resolved through class: %+v", got.Envelope)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package strictness decodes with a per-GVK choice of schema strictness.
+// Strict decoding (unknown fields, duplicate keys) is the right default
+// for manifests we author, but applying it to third-party objects turns
+// their harmless extra fields into ingest failures. A Decoder carries a
+// default level and per-GVK overrides: Strict fails, Warn decodes but
+// routes the violations to a structured callback, Lenient stays silent.
+// Previously this was a single switch for the whole pipeline.
+package strictness
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// Level selects how schema violations are treated.
+type Level string
+
+const (
+	// Strict fails the decode on any schema violation.
+	Strict Level = "Strict"
+	// Warn decodes leniently but reports each violation.
+	Warn Level = "Warn"
+	// Lenient decodes the way the API server reads requests: unknown
+	// fields are dropped silently.
+	Lenient Level = "Lenient"
+)
+
+// WarningFunc receives the violations of one decode at level Warn.
+type WarningFunc func(gvk schema.GroupVersionKind, violations []string)
+
+// Config sets the default level and per-GVK overrides.
+type Config struct {
+	// Default applies to GVKs without an override; empty means Strict.
+	Default Level
+	// PerGVK overrides the level for specific kinds.
+	PerGVK map[schema.GroupVersionKind]Level
+	// OnWarning receives violations for Warn-level decodes; nil drops
+	// them.
+	OnWarning WarningFunc
+}
+
+// Decoder decodes with per-GVK strictness.
+type Decoder struct {
+	config  Config
+	strict  runtime.Decoder
+	lenient runtime.Decoder
+}
+
+// NewDecoder builds a decoder over the client-go scheme.
+func NewDecoder(config Config) *Decoder {
+	strictCodecs := serializer.NewCodecFactory(clientsetscheme.Scheme, serializer.EnableStrict)
+	return &Decoder{
+		config:  config,
+		strict:  strictCodecs.UniversalDeserializer(),
+		lenient: clientsetscheme.Codecs.UniversalDeserializer(),
+	}
+}
+
+// LevelFor returns the effective level for a GVK.
+func (d *Decoder) LevelFor(gvk schema.GroupVersionKind) Level {
+	if level, ok := d.config.PerGVK[gvk]; ok {
+		return level
+	}
+	if d.config.Default != "" {
+		return d.config.Default
+	}
+	return Strict
+}
+
+// Decode decodes one JSON or YAML payload at the GVK's configured
+// strictness.
+func (d *Decoder) Decode(data []byte) (runtime.Object, schema.GroupVersionKind, error) {
+	obj, gvk, err := d.strict.Decode(data, nil, nil)
+	if err == nil {
+		return obj, derefGVK(gvk), nil
+	}
+	if !runtime.IsStrictDecodingError(err) {
+		return nil, derefGVK(gvk), err
+	}
+
+	resolved := derefGVK(gvk)
+	switch d.LevelFor(resolved) {
+	case Strict:
+		return nil, resolved, fmt.Errorf("strict decoding of %s: %w", resolved.Kind, err)
+	case Warn:
+		if d.config.OnWarning != nil {
+			d.config.OnWarning(resolved, violationsOf(err))
+		}
+	}
+	// Warn and Lenient both fall through to a lenient decode; the strict
+	// serializer's partial object is not guaranteed complete.
+	obj, gvk, err = d.lenient.Decode(data, nil, nil)
+	return obj, derefGVK(gvk), err
+}
+
+func derefGVK(gvk *schema.GroupVersionKind) schema.GroupVersionKind {
+	if gvk == nil {
+		return schema.GroupVersionKind{}
+	}
+	return *gvk
+}
+
+// violationsOf flattens a strict decoding error into its individual
+// messages.
+func violationsOf(err error) []string {
+	if strictErr, ok := runtime.AsStrictDecodingError(err); ok {
+		var violations []string
+		for _, violation := range strictErr.Errors() {
+			violations = append(violations, violation.Error())
+		}
+		return violations
+	}
+	return []string{err.Error()}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strictness
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const podWithUnknownField = `{
+  "apiVersion": "v1",
+  "kind": "Pod",
+  "metadata": {"name": "web", "namespace": "default"},
+  "spec": {"containers": [{"name": "app", "image": "app:v1"}], "madeUpField": true}
+}`
+
+const cleanPod = `{
+  "apiVersion": "v1",
+  "kind": "Pod",
+  "metadata": {"name": "web", "namespace": "default"},
+  "spec": {"containers": [{"name": "app", "image": "app:v1"}]}
+}`
+
+var podGVK = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+func TestCleanObjectDecodesAtAnyLevel(t *testing.T) {
+	for _, level := range []Level{Strict, Warn, Lenient} {
+		decoder := NewDecoder(Config{Default: level})
+		obj, gvk, err := decoder.Decode([]byte(cleanPod))
+		if err != nil {
+			t.Errorf("level %s: %v", level, err)
+			continue
+		}
+		if gvk != podGVK || obj.(*v1.Pod).Name != "web" {
+			t.Errorf("level %s: unexpected decode %v %+v", level, gvk, obj)
+		}
+	}
+}
+
+func TestStrictFailsOnUnknownField(t *testing.T) {
+	decoder := NewDecoder(Config{Default: Strict})
+	_, gvk, err := decoder.Decode([]byte(podWithUnknownField))
+	if err == nil || !strings.Contains(err.Error(), "madeUpField") {
+		t.Fatalf("expected strict failure naming the field, got %v", err)
+	}
+	if gvk != podGVK {
+		t.Errorf("gvk = %v, want %v", gvk, podGVK)
+	}
+}
+
+func TestWarnDecodesAndReports(t *testing.T) {
+	var gotGVK schema.GroupVersionKind
+	var gotViolations []string
+	decoder := NewDecoder(Config{
+		Default: Warn,
+		OnWarning: func(gvk schema.GroupVersionKind, violations []string) {
+			gotGVK, gotViolations = gvk, violations
+		},
+	})
+	obj, _, err := decoder.Decode([]byte(podWithUnknownField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.(*v1.Pod).Spec.Containers[0].Image != "app:v1" {
+		t.Errorf("lenient fallback decode wrong: %+v", obj)
+	}
+	if gotGVK != podGVK || len(gotViolations) == 0 || !strings.Contains(gotViolations[0], "madeUpField") {
+		t.Errorf("warning callback got %v %v", gotGVK, gotViolations)
+	}
+}
+
+func TestLenientStaysSilent(t *testing.T) {
+	decoder := NewDecoder(Config{
+		Default: Lenient,
+		OnWarning: func(schema.GroupVersionKind, []string) {
+			t.Error("lenient decode must not warn")
+		},
+	})
+	obj, _, err := decoder.Decode([]byte(podWithUnknownField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.(*v1.Pod).Name != "web" {
+		t.Errorf("unexpected decode: %+v", obj)
+	}
+}
+
+func TestPerGVKOverride(t *testing.T) {
+	// Strict by default, but lenient for Pods: the third-party shape we
+	// ingest.
+	decoder := NewDecoder(Config{
+		Default: Strict,
+		PerGVK:  map[schema.GroupVersionKind]Level{podGVK: Lenient},
+	})
+	if _, _, err := decoder.Decode([]byte(podWithUnknownField)); err != nil {
+		t.Errorf("per-GVK lenient override ignored: %v", err)
+	}
+	if got := decoder.LevelFor(podGVK); got != Lenient {
+		t.Errorf("LevelFor = %v, want Lenient", got)
+	}
+	if got := decoder.LevelFor(schema.GroupVersionKind{Version: "v1", Kind: "Node"}); got != Strict {
+		t.Errorf("LevelFor unoverridden = %v, want Strict", got)
+	}
+}
+
+func TestMalformedPayloadFailsRegardless(t *testing.T) {
+	decoder := NewDecoder(Config{Default: Lenient})
+	if _, _, err := decoder.Decode([]byte("{not json")); err == nil {
+		t.Error("malformed payload must fail even at Lenient")
+	}
+}
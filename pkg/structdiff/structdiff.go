@@ -0,0 +1,202 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package structdiff computes semantic field-level diffs between two
+// instances of any generated API type, for auditing drift between
+// snapshots or between a manifest and the live object. Server-populated
+// bookkeeping — resourceVersion, managedFields and relatives — is
+// ignored by default so diffs only show what a human or controller
+// actually changed. Where pkg/changelog summarizes changed paths for its
+// index, this package keeps the old and new values for display.
+package structdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is one differing field.
+type Change struct {
+	// Path is the dotted field path, with [i] for list indexes, e.g.
+	// spec.containers[0].image.
+	Path string
+	// Old and New are the values on each side; Old is nil for added
+	// fields and New is nil for removed ones.
+	Old interface{}
+	New interface{}
+}
+
+// DefaultIgnore lists path prefixes the server rewrites on every update;
+// diffing them reports churn, not drift.
+var DefaultIgnore = []string{
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.managedFields",
+	"metadata.uid",
+	"metadata.creationTimestamp",
+	"metadata.selfLink",
+}
+
+// Options tune a diff.
+type Options struct {
+	// Ignore replaces DefaultIgnore: changes at or under these path
+	// prefixes are dropped. Nil keeps the default; an empty non-nil
+	// slice ignores nothing.
+	Ignore []string
+}
+
+// Diff compares two values of the same type with default options. Any
+// value with a JSON encoding works: whole objects, specs, or single
+// fields.
+func Diff(old, new interface{}) ([]Change, error) {
+	return DiffWithOptions(old, new, Options{})
+}
+
+// DiffWithOptions compares two values, returning changes sorted by path.
+func DiffWithOptions(old, new interface{}, opts Options) ([]Change, error) {
+	ignore := opts.Ignore
+	if ignore == nil {
+		ignore = DefaultIgnore
+	}
+	oldVal, err := toJSONValue(old)
+	if err != nil {
+		return nil, fmt.Errorf("encoding old value: %v", err)
+	}
+	newVal, err := toJSONValue(new)
+	if err != nil {
+		return nil, fmt.Errorf("encoding new value: %v", err)
+	}
+	var changes []Change
+	walk(oldVal, newVal, "", &changes)
+	filtered := changes[:0]
+	for _, change := range changes {
+		if !ignored(change.Path, ignore) {
+			filtered = append(filtered, change)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Path < filtered[j].Path })
+	return filtered, nil
+}
+
+// Render formats changes one per line: ~ for updates, + for additions,
+// - for removals, values as compact JSON.
+func Render(changes []Change) string {
+	var b strings.Builder
+	for _, change := range changes {
+		switch {
+		case change.Old == nil:
+			fmt.Fprintf(&b, "+ %s: %s\n", change.Path, compact(change.New))
+		case change.New == nil:
+			fmt.Fprintf(&b, "- %s: %s\n", change.Path, compact(change.Old))
+		default:
+			fmt.Fprintf(&b, "~ %s: %s -> %s\n", change.Path, compact(change.Old), compact(change.New))
+		}
+	}
+	return b.String()
+}
+
+func toJSONValue(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	err = json.Unmarshal(raw, &decoded)
+	return decoded, err
+}
+
+func walk(old, new interface{}, path string, changes *[]Change) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for key := range oldMap {
+			keys[key] = true
+		}
+		for key := range newMap {
+			keys[key] = true
+		}
+		for key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			oldChild, inOld := oldMap[key]
+			newChild, inNew := newMap[key]
+			switch {
+			case !inOld:
+				*changes = append(*changes, Change{Path: child, New: newChild})
+			case !inNew:
+				*changes = append(*changes, Change{Path: child, Old: oldChild})
+			default:
+				walk(oldChild, newChild, child, changes)
+			}
+		}
+		return
+	}
+	oldSlice, oldIsSlice := old.([]interface{})
+	newSlice, newIsSlice := new.([]interface{})
+	if oldIsSlice && newIsSlice {
+		shared := len(oldSlice)
+		if len(newSlice) < shared {
+			shared = len(newSlice)
+		}
+		for i := 0; i < shared; i++ {
+			walk(oldSlice[i], newSlice[i], path+"["+strconv.Itoa(i)+"]", changes)
+		}
+		for i := shared; i < len(oldSlice); i++ {
+			*changes = append(*changes, Change{Path: path + "[" + strconv.Itoa(i) + "]", Old: oldSlice[i]})
+		}
+		for i := shared; i < len(newSlice); i++ {
+			*changes = append(*changes, Change{Path: path + "[" + strconv.Itoa(i) + "]", New: newSlice[i]})
+		}
+		return
+	}
+	if !equalJSON(old, new) {
+		*changes = append(*changes, Change{Path: path, Old: old, New: new})
+	}
+}
+
+// equalJSON compares decoded JSON values; scalars dominate here, so
+// re-encoding the rare mismatched composites is cheap enough.
+func equalJSON(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(rawA) == string(rawB)
+}
+
+func ignored(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func compact(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(raw)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package structdiff
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func basePod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "web",
+			ResourceVersion: "100",
+			Labels:          map[string]string{"app": "web"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app", Image: "nginx:1.24"}},
+		},
+	}
+}
+
+func TestDiffIgnoresServerBookkeeping(t *testing.T) {
+	old := basePod()
+	new := basePod()
+	new.ResourceVersion = "200"
+	new.Generation = 3
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("server-populated churn must be ignored: %+v", changes)
+	}
+}
+
+func TestDiffFindsFieldChanges(t *testing.T) {
+	old := basePod()
+	new := basePod()
+	new.Spec.Containers[0].Image = "nginx:1.25"
+	new.Labels["tier"] = "fe"
+	delete(new.Labels, "app")
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byPath := map[string]Change{}
+	for _, change := range changes {
+		byPath[change.Path] = change
+	}
+	image, ok := byPath["spec.containers[0].image"]
+	if !ok || image.Old != "nginx:1.24" || image.New != "nginx:1.25" {
+		t.Errorf("image change missing or wrong: %+v", changes)
+	}
+	if added, ok := byPath["metadata.labels.tier"]; !ok || added.Old != nil {
+		t.Errorf("added label not reported: %+v", changes)
+	}
+	if removed, ok := byPath["metadata.labels.app"]; !ok || removed.New != nil {
+		t.Errorf("removed label not reported: %+v", changes)
+	}
+}
+
+func TestDiffListGrowth(t *testing.T) {
+	old := basePod()
+	new := basePod()
+	new.Spec.Containers = append(new.Spec.Containers, v1.Container{Name: "sidecar", Image: "envoy"})
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "spec.containers[1]" || changes[0].Old != nil {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+}
+
+func TestDiffSpecValues(t *testing.T) {
+	// Any generated type works, not just whole objects.
+	old := v1.PersistentVolumeSpec{StorageClassName: "standard"}
+	new := v1.PersistentVolumeSpec{StorageClassName: "fast"}
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "storageClassName" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+}
+
+func TestDiffWithOptionsCustomIgnore(t *testing.T) {
+	old := basePod()
+	new := basePod()
+	new.ResourceVersion = "200"
+	new.Status.Phase = v1.PodRunning
+
+	// An empty non-nil ignore list surfaces everything.
+	changes, err := DiffWithOptions(old, new, Options{Ignore: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]bool{}
+	for _, change := range changes {
+		paths[change.Path] = true
+	}
+	if !paths["metadata.resourceVersion"] || !paths["status.phase"] {
+		t.Errorf("custom ignore list not honored: %+v", changes)
+	}
+
+	changes, err = DiffWithOptions(old, new, Options{Ignore: []string{"status", "metadata"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("prefix ignore not honored: %+v", changes)
+	}
+}
+
+func TestRender(t *testing.T) {
+	old := basePod()
+	new := basePod()
+	new.Spec.Containers[0].Image = "nginx:1.25"
+	new.Labels["tier"] = "fe"
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := Render(changes)
+	for _, want := range []string{
+		`+ metadata.labels.tier: "fe"`,
+		`~ spec.containers[0].image: "nginx:1.24" -> "nginx:1.25"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render missing %q:\n%s", want, out)
+		}
+	}
+}
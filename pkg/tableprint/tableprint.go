@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tableprint produces the tables kubectl get shows — NAME,
+// READY, STATUS, RESTARTS, AGE and the resource-specific columns —
+// from external v1 objects. The column logic itself lives with the
+// internal-version printers this package wraps; the work here is the
+// conversion and registration plumbing those printers otherwise only
+// get from the apiserver's table convertor.
+package tableprint
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+	"k8s.io/kubernetes/pkg/printers"
+	printersinternal "k8s.io/kubernetes/pkg/printers/internalversion"
+
+	_ "k8s.io/kubernetes/pkg/apis/apps/install"
+	_ "k8s.io/kubernetes/pkg/apis/batch/install"
+	_ "k8s.io/kubernetes/pkg/apis/core/install"
+)
+
+var generator = printers.NewTableGenerator().With(printersinternal.AddHandlers)
+
+// Options controls column selection.
+type Options struct {
+	// Wide adds the columns kubectl get -o wide shows, e.g. IP and
+	// NODE for pods.
+	Wide bool
+	// NoHeaders drops the header row from Render output.
+	NoHeaders bool
+}
+
+// Generate returns the kubectl get table for the object, which may be
+// a single object or a list. External versions are converted to the
+// internal version the printers are registered for.
+func Generate(obj runtime.Object, options Options) (*metav1.Table, error) {
+	internal, err := legacyscheme.Scheme.ConvertToVersion(obj, runtime.InternalGroupVersioner)
+	if err != nil {
+		return nil, fmt.Errorf("converting %T for printing: %v", obj, err)
+	}
+	return generator.GenerateTable(internal, printers.GenerateOptions{Wide: options.Wide})
+}
+
+// Render generates the table and formats it as aligned text, the way
+// kubectl prints it.
+func Render(obj runtime.Object, options Options) (string, error) {
+	table, err := Generate(obj, options)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	writer := tabwriter.NewWriter(&buf, 3, 8, 3, ' ', 0)
+	if !options.NoHeaders {
+		for i, column := range table.ColumnDefinitions {
+			if i > 0 {
+				fmt.Fprint(writer, "\t")
+			}
+			fmt.Fprint(writer, strings.ToUpper(column.Name))
+		}
+		fmt.Fprintln(writer)
+	}
+	for _, row := range table.Rows {
+		for i, cell := range row.Cells {
+			if i > 0 {
+				fmt.Fprint(writer, "\t")
+			}
+			fmt.Fprintf(writer, "%v", cell)
+		}
+		fmt.Fprintln(writer)
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tableprint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func runningPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+		Spec: v1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []v1.Container{{Name: "app", Image: "app:v1"}},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			PodIP: "10.0.0.1",
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name: "app", Ready: true, RestartCount: 2,
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+func TestGeneratePod(t *testing.T) {
+	table, err := Generate(runningPod("web-0"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Rows) != 1 {
+		t.Fatalf("rows: %+v", table.Rows)
+	}
+	cells := table.Rows[0].Cells
+	// NAME, READY, STATUS, RESTARTS, AGE
+	if cells[0] != "web-0" || cells[1] != "1/1" || cells[2] != "Running" {
+		t.Errorf("cells: %v", cells)
+	}
+	if len(cells) != len(table.ColumnDefinitions) {
+		t.Errorf("%d cells for %d columns", len(cells), len(table.ColumnDefinitions))
+	}
+}
+
+func TestGenerateWide(t *testing.T) {
+	narrow, err := Generate(runningPod("web-0"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wide, err := Generate(runningPod("web-0"), Options{Wide: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wide.ColumnDefinitions) <= len(narrow.ColumnDefinitions) {
+		t.Errorf("wide should add columns: %d vs %d", len(wide.ColumnDefinitions), len(narrow.ColumnDefinitions))
+	}
+	joined := fmt.Sprintf("%v", wide.Rows[0].Cells)
+	if !strings.Contains(joined, "10.0.0.1") || !strings.Contains(joined, "node-1") {
+		t.Errorf("wide cells: %q", joined)
+	}
+}
+
+func TestGenerateList(t *testing.T) {
+	list := &v1.PodList{Items: []v1.Pod{*runningPod("web-0"), *runningPod("web-1")}}
+	table, err := Generate(list, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Rows) != 2 {
+		t.Errorf("rows: %d", len(table.Rows))
+	}
+}
+
+func TestGeneratePVC(t *testing.T) {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		Status: v1.PersistentVolumeClaimStatus{
+			Phase:       v1.ClaimBound,
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Capacity:    v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+		},
+	}
+	table, err := Generate(claim, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := table.Rows[0].Cells
+	if cells[0] != "data" || cells[1] != "Bound" || cells[2] != "pv-1" {
+		t.Errorf("cells: %v", cells)
+	}
+}
+
+func TestRender(t *testing.T) {
+	out, err := Render(runningPod("web-0"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[1], "web-0") {
+		t.Errorf("rendered:\n%s", out)
+	}
+
+	out, err = Render(runningPod("web-0"), Options{NoHeaders: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "NAME") {
+		t.Errorf("headers should be suppressed:\n%s", out)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tainttoleration decides what a node's taints mean for one
+// pod: blocked from scheduling, evicted immediately, evicted after the
+// shortest tolerationSeconds, or unaffected. Matching delegates to
+// v1.Toleration.ToleratesTaint — the same predicate the scheduler and
+// the taint manager use — so a diagnostic built on this package never
+// disagrees with them.
+package tainttoleration
+
+import (
+	v1 "k8s.io/api/core/v1"
+	schedulingcorev1 "k8s.io/component-helpers/scheduling/corev1"
+)
+
+// Decision summarizes a pod against one node's taints.
+type Decision struct {
+	// Schedulable is false when a NoSchedule or NoExecute taint is
+	// untolerated. PreferNoSchedule never blocks.
+	Schedulable bool
+	// EvictedImmediately is true when a NoExecute taint is untolerated:
+	// a running pod would be deleted by the taint manager.
+	EvictedImmediately bool
+	// EvictAfterSeconds is the soonest tolerationSeconds bound among
+	// tolerated NoExecute taints. Nil means the pod may stay
+	// indefinitely.
+	EvictAfterSeconds *int64
+	// Untolerated lists every taint no toleration matches, all effects.
+	Untolerated []v1.Taint
+	// Preferred lists untolerated PreferNoSchedule taints; they lower
+	// scoring but do not block.
+	Preferred []v1.Taint
+}
+
+// Check evaluates the pod spec's tolerations against the node's taints.
+func Check(spec *v1.PodSpec, node *v1.Node) Decision {
+	return CheckTaints(spec.Tolerations, node.Spec.Taints)
+}
+
+// CheckTaints is Check over bare slices, for callers holding taints
+// from somewhere other than a Node object.
+func CheckTaints(tolerations []v1.Toleration, taints []v1.Taint) Decision {
+	decision := Decision{Schedulable: true}
+	for i := range taints {
+		taint := taints[i]
+		if !schedulingcorev1.TolerationsTolerateTaint(tolerations, &taint) {
+			decision.Untolerated = append(decision.Untolerated, taint)
+			switch taint.Effect {
+			case v1.TaintEffectNoSchedule:
+				decision.Schedulable = false
+			case v1.TaintEffectNoExecute:
+				decision.Schedulable = false
+				decision.EvictedImmediately = true
+			case v1.TaintEffectPreferNoSchedule:
+				decision.Preferred = append(decision.Preferred, taint)
+			}
+			continue
+		}
+		if taint.Effect != v1.TaintEffectNoExecute {
+			continue
+		}
+		// Among tolerations matching this NoExecute taint, the largest
+		// tolerationSeconds is the pod's grace for it; across taints the
+		// smallest such grace wins.
+		if bound, bounded := noExecuteBound(tolerations, &taint); bounded {
+			if decision.EvictAfterSeconds == nil || bound < *decision.EvictAfterSeconds {
+				decision.EvictAfterSeconds = &bound
+			}
+		}
+	}
+	return decision
+}
+
+// noExecuteBound returns the longest tolerationSeconds among the
+// tolerations matching the taint, and false when any matching
+// toleration is unbounded.
+func noExecuteBound(tolerations []v1.Toleration, taint *v1.Taint) (int64, bool) {
+	var bound int64
+	bounded := false
+	for i := range tolerations {
+		if !tolerations[i].ToleratesTaint(taint) {
+			continue
+		}
+		if tolerations[i].TolerationSeconds == nil {
+			return 0, false
+		}
+		if seconds := *tolerations[i].TolerationSeconds; !bounded || seconds > bound {
+			bound = seconds
+			bounded = true
+		}
+	}
+	return bound, bounded
+}
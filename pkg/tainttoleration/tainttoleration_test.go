@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tainttoleration
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func seconds(s int64) *int64 { return &s }
+
+func TestCheckTaints(t *testing.T) {
+	noSchedule := v1.Taint{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}
+	noExecute := v1.Taint{Key: "node.kubernetes.io/not-ready", Effect: v1.TaintEffectNoExecute}
+	prefer := v1.Taint{Key: "flaky", Effect: v1.TaintEffectPreferNoSchedule}
+
+	testCases := []struct {
+		name            string
+		tolerations     []v1.Toleration
+		taints          []v1.Taint
+		wantSchedulable bool
+		wantEvicted     bool
+		wantEvictAfter  *int64
+		wantUntolerated int
+	}{
+		{
+			name:            "no taints",
+			wantSchedulable: true,
+		},
+		{
+			name:            "untolerated NoSchedule blocks",
+			taints:          []v1.Taint{noSchedule},
+			wantSchedulable: false,
+			wantUntolerated: 1,
+		},
+		{
+			name: "exact toleration admits",
+			tolerations: []v1.Toleration{{
+				Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "gpu", Effect: v1.TaintEffectNoSchedule,
+			}},
+			taints:          []v1.Taint{noSchedule},
+			wantSchedulable: true,
+		},
+		{
+			name: "Exists toleration without effect matches all effects",
+			tolerations: []v1.Toleration{
+				{Key: "dedicated", Operator: v1.TolerationOpExists},
+				{Key: "node.kubernetes.io/not-ready", Operator: v1.TolerationOpExists},
+			},
+			taints:          []v1.Taint{noSchedule, noExecute},
+			wantSchedulable: true,
+		},
+		{
+			name:            "untolerated NoExecute evicts immediately",
+			taints:          []v1.Taint{noExecute},
+			wantSchedulable: false,
+			wantEvicted:     true,
+			wantUntolerated: 1,
+		},
+		{
+			name: "tolerationSeconds bounds the stay",
+			tolerations: []v1.Toleration{{
+				Key: "node.kubernetes.io/not-ready", Operator: v1.TolerationOpExists,
+				Effect: v1.TaintEffectNoExecute, TolerationSeconds: seconds(300),
+			}},
+			taints:          []v1.Taint{noExecute},
+			wantSchedulable: true,
+			wantEvictAfter:  seconds(300),
+		},
+		{
+			name: "smallest bound across taints wins",
+			tolerations: []v1.Toleration{
+				{Key: "node.kubernetes.io/not-ready", Operator: v1.TolerationOpExists,
+					Effect: v1.TaintEffectNoExecute, TolerationSeconds: seconds(300)},
+				{Key: "other", Operator: v1.TolerationOpExists,
+					Effect: v1.TaintEffectNoExecute, TolerationSeconds: seconds(60)},
+			},
+			taints: []v1.Taint{
+				noExecute,
+				{Key: "other", Effect: v1.TaintEffectNoExecute},
+			},
+			wantSchedulable: true,
+			wantEvictAfter:  seconds(60),
+		},
+		{
+			name: "unbounded toleration beats a bounded one for the same taint",
+			tolerations: []v1.Toleration{
+				{Key: "node.kubernetes.io/not-ready", Operator: v1.TolerationOpExists,
+					Effect: v1.TaintEffectNoExecute, TolerationSeconds: seconds(300)},
+				{Key: "node.kubernetes.io/not-ready", Operator: v1.TolerationOpExists,
+					Effect: v1.TaintEffectNoExecute},
+			},
+			taints:          []v1.Taint{noExecute},
+			wantSchedulable: true,
+			wantEvictAfter:  nil,
+		},
+		{
+			name:            "PreferNoSchedule does not block",
+			taints:          []v1.Taint{prefer},
+			wantSchedulable: true,
+			wantUntolerated: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decision := CheckTaints(tc.tolerations, tc.taints)
+			if decision.Schedulable != tc.wantSchedulable {
+				t.Errorf("Schedulable = %v, want %v", decision.Schedulable, tc.wantSchedulable)
+			}
+			if decision.EvictedImmediately != tc.wantEvicted {
+				t.Errorf("EvictedImmediately = %v, want %v", decision.EvictedImmediately, tc.wantEvicted)
+			}
+			switch {
+			case tc.wantEvictAfter == nil && decision.EvictAfterSeconds != nil:
+				t.Errorf("EvictAfterSeconds = %d, want nil", *decision.EvictAfterSeconds)
+			case tc.wantEvictAfter != nil && (decision.EvictAfterSeconds == nil || *decision.EvictAfterSeconds != *tc.wantEvictAfter):
+				t.Errorf("EvictAfterSeconds = %v, want %d", decision.EvictAfterSeconds, *tc.wantEvictAfter)
+			}
+			if len(decision.Untolerated) != tc.wantUntolerated {
+				t.Errorf("Untolerated = %v, want %d taints", decision.Untolerated, tc.wantUntolerated)
+			}
+		})
+	}
+}
+
+func TestCheckUsesSpecAndNode(t *testing.T) {
+	spec := &v1.PodSpec{Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}}}
+	node := &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{
+		{Key: "anything", Value: "x", Effect: v1.TaintEffectNoSchedule},
+	}}}
+	decision := Check(spec, node)
+	if !decision.Schedulable || len(decision.Untolerated) != 0 {
+		t.Errorf("wildcard toleration should admit: %+v", decision)
+	}
+}
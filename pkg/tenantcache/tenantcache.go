@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tenantcache serves many tenants from one shared object cache by
+// binding each tenant to a view scoped to the namespaces their RBAC lets
+// them read. The permitted set is established at view construction by
+// asking the authorizer (normally SelfSubjectAccessReview through an
+// impersonating client) per candidate namespace, so a view can never
+// leak an object the tenant could not have read directly; denied access
+// surfaces as the taxonomy's Forbidden error, not as not-found.
+package tenantcache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	authorizationclient "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/kubernetes/pkg/rerrors"
+)
+
+// Authorizer answers whether the tenant behind it may read a namespace.
+type Authorizer interface {
+	CanReadNamespace(ctx context.Context, namespace string) (bool, error)
+}
+
+// SSARAuthorizer authorizes via SelfSubjectAccessReview; construct it
+// from a clientset whose config impersonates the tenant.
+type SSARAuthorizer struct {
+	Client authorizationclient.SelfSubjectAccessReviewInterface
+	// Resource is the group/resource access is probed against.
+	Resource schema.GroupResource
+}
+
+// CanReadNamespace implements Authorizer using the list verb, the
+// broadest read a cache view grants.
+func (a *SSARAuthorizer) CanReadNamespace(ctx context.Context, namespace string) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Group:     a.Resource.Group,
+				Resource:  a.Resource.Resource,
+			},
+		},
+	}
+	result, err := a.Client.Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("access review for namespace %s: %v", namespace, err)
+	}
+	return result.Status.Allowed, nil
+}
+
+// Store is the shared namespace-indexed object cache views are carved
+// from. It is safe for concurrent use.
+type Store struct {
+	mu sync.RWMutex
+	// objects is namespace → name → object.
+	objects map[string]map[string]runtime.Object
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{objects: map[string]map[string]runtime.Object{}}
+}
+
+// Replace stores or overwrites one object under its namespace and name.
+func (s *Store) Replace(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	namespace := accessor.GetNamespace()
+	if s.objects[namespace] == nil {
+		s.objects[namespace] = map[string]runtime.Object{}
+	}
+	s.objects[namespace][accessor.GetName()] = obj
+	return nil
+}
+
+// Delete removes one object.
+func (s *Store) Delete(namespace, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects[namespace], name)
+}
+
+func (s *Store) get(namespace, name string) (runtime.Object, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, present := s.objects[namespace][name]
+	return obj, present
+}
+
+func (s *Store) list(namespace string) []runtime.Object {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.objects[namespace]))
+	for name := range s.objects[namespace] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	objs := make([]runtime.Object, 0, len(names))
+	for _, name := range names {
+		objs = append(objs, s.objects[namespace][name])
+	}
+	return objs
+}
+
+// View is one tenant's scoped window onto a store.
+type View struct {
+	store     *Store
+	resource  string
+	permitted map[string]bool
+}
+
+// NewView resolves the tenant's permitted subset of candidates and
+// returns a view restricted to it. resource names the served resource in
+// errors. Candidates the authorizer denies are simply excluded; an
+// authorizer error aborts construction rather than risking a too-wide
+// view.
+func NewView(ctx context.Context, store *Store, authorizer Authorizer, resource string, candidates []string) (*View, error) {
+	permitted := map[string]bool{}
+	for _, namespace := range candidates {
+		allowed, err := authorizer.CanReadNamespace(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			permitted[namespace] = true
+		}
+	}
+	return &View{store: store, resource: resource, permitted: permitted}, nil
+}
+
+// Namespaces returns the view's permitted namespaces, sorted.
+func (v *View) Namespaces() []string {
+	namespaces := make([]string, 0, len(v.permitted))
+	for namespace := range v.permitted {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// Get returns one object, Forbidden for namespaces outside the view, and
+// NotFound for permitted namespaces without the object.
+func (v *View) Get(namespace, name string) (runtime.Object, error) {
+	if !v.permitted[namespace] {
+		return nil, rerrors.Forbidden(v.resource, namespace+"/"+name,
+			fmt.Errorf("namespace %q is outside this tenant's view", namespace))
+	}
+	obj, present := v.store.get(namespace, name)
+	if !present {
+		return nil, rerrors.NotFound(v.resource, namespace+"/"+name,
+			fmt.Errorf("%s %q not found in namespace %q", v.resource, name, namespace))
+	}
+	return obj, nil
+}
+
+// List returns the objects of one permitted namespace, or Forbidden.
+func (v *View) List(namespace string) ([]runtime.Object, error) {
+	if !v.permitted[namespace] {
+		return nil, rerrors.Forbidden(v.resource, namespace,
+			fmt.Errorf("namespace %q is outside this tenant's view", namespace))
+	}
+	return v.store.list(namespace), nil
+}
+
+// ListAll returns the objects of every permitted namespace, namespace
+// order then name order.
+func (v *View) ListAll() []runtime.Object {
+	var objs []runtime.Object
+	for _, namespace := range v.Namespaces() {
+		objs = append(objs, v.store.list(namespace)...)
+	}
+	return objs
+}
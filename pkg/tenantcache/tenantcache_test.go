@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/kubernetes/pkg/rerrors"
+)
+
+// allowList authorizes a fixed namespace set.
+type allowList map[string]bool
+
+func (a allowList) CanReadNamespace(_ context.Context, namespace string) (bool, error) {
+	return a[namespace], nil
+}
+
+func storeWithPods(t *testing.T) *Store {
+	t.Helper()
+	store := NewStore()
+	for _, pod := range []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "web"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "db"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-b", Name: "secret-workload"}},
+	} {
+		if err := store.Replace(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestViewIsolation(t *testing.T) {
+	store := storeWithPods(t)
+	view, err := NewView(context.Background(), store, allowList{"tenant-a": true}, "pods",
+		[]string{"tenant-a", "tenant-b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if namespaces := view.Namespaces(); len(namespaces) != 1 || namespaces[0] != "tenant-a" {
+		t.Fatalf("unexpected permitted namespaces: %v", namespaces)
+	}
+
+	// Permitted namespace reads work.
+	obj, err := view.Get("tenant-a", "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accessor, _ := meta.Accessor(obj); accessor.GetName() != "web" {
+		t.Errorf("unexpected object: %+v", obj)
+	}
+	objs, err := view.List("tenant-a")
+	if err != nil || len(objs) != 2 {
+		t.Fatalf("expected 2 objects, got %v, %v", objs, err)
+	}
+
+	// Other tenants' namespaces are Forbidden, not NotFound — the view
+	// must not reveal whether objects exist there.
+	if _, err := view.Get("tenant-b", "secret-workload"); !rerrors.IsClass(err, rerrors.ClassForbidden) {
+		t.Errorf("expected Forbidden, got %v", err)
+	}
+	if _, err := view.List("tenant-b"); !rerrors.IsClass(err, rerrors.ClassForbidden) {
+		t.Errorf("expected Forbidden, got %v", err)
+	}
+	if all := view.ListAll(); len(all) != 2 {
+		t.Errorf("ListAll must cover only permitted namespaces, got %d objects", len(all))
+	}
+
+	// Missing object in a permitted namespace is NotFound.
+	if _, err := view.Get("tenant-a", "ghost"); !rerrors.IsClass(err, rerrors.ClassNotFound) {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestViewSeesStoreUpdates(t *testing.T) {
+	store := storeWithPods(t)
+	view, err := NewView(context.Background(), store, allowList{"tenant-a": true}, "pods", []string{"tenant-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Delete("tenant-a", "db")
+	if objs, _ := view.List("tenant-a"); len(objs) != 1 {
+		t.Errorf("expected view to track store deletes, got %d objects", len(objs))
+	}
+}
+
+// erroring simulates an authorizer outage.
+type erroring struct{}
+
+func (erroring) CanReadNamespace(context.Context, string) (bool, error) {
+	return false, errors.New("webhook timeout")
+}
+
+func TestViewConstructionFailsClosed(t *testing.T) {
+	if _, err := NewView(context.Background(), NewStore(), erroring{}, "pods", []string{"tenant-a"}); err == nil {
+		t.Fatal("expected authorizer errors to abort view construction")
+	}
+}
+
+func TestSSARAuthorizer(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			attrs := review.Spec.ResourceAttributes
+			if attrs.Verb != "list" || attrs.Resource != "pods" {
+				t.Errorf("unexpected review attributes: %+v", attrs)
+			}
+			review = review.DeepCopy()
+			review.Status.Allowed = attrs.Namespace == "tenant-a"
+			return true, review, nil
+		})
+
+	authorizer := &SSARAuthorizer{
+		Client:   client.AuthorizationV1().SelfSubjectAccessReviews(),
+		Resource: schema.GroupResource{Resource: "pods"},
+	}
+	allowed, err := authorizer.CanReadNamespace(context.Background(), "tenant-a")
+	if err != nil || !allowed {
+		t.Errorf("expected tenant-a allowed, got %v, %v", allowed, err)
+	}
+	allowed, err = authorizer.CanReadNamespace(context.Background(), "tenant-b")
+	if err != nil || allowed {
+		t.Errorf("expected tenant-b denied, got %v, %v", allowed, err)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package termmsg collects container termination details for postmortems.
+// Terminations are recorded from pod updates (current and lastState, so a
+// crash-looping container's previous exit is not lost between events),
+// kept in a sliding time window, and reported grouped by workload and
+// exit code — the cut a postmortem actually starts from, instead of
+// grepping kubectl describe output pod by pod.
+package termmsg
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Termination is one recorded container exit.
+type Termination struct {
+	Namespace string
+	// Workload is the controlling owner (kind/name), or Pod/<name> for
+	// bare pods.
+	Workload  string
+	Pod       string
+	Container string
+	ExitCode  int32
+	Signal    int32
+	Reason    string
+	// Message is the termination message, sourced per the container's
+	// TerminationMessagePolicy (file content, or last log lines under
+	// FallbackToLogsOnError).
+	Message    string
+	FinishedAt time.Time
+}
+
+// Group is one workload+exit-code bucket of terminations.
+type Group struct {
+	Namespace string
+	Workload  string
+	ExitCode  int32
+	// Terminations are the members, newest first.
+	Terminations []Termination
+}
+
+// Collector accumulates terminations inside a sliding window.
+type Collector struct {
+	mu     sync.Mutex
+	window time.Duration
+	// seen deduplicates by pod/container/finishedAt so repeated status
+	// updates for the same exit record it once.
+	seen map[string]bool
+	all  []Termination
+}
+
+// NewCollector keeps terminations for the given window.
+func NewCollector(window time.Duration) *Collector {
+	return &Collector{window: window, seen: map[string]bool{}}
+}
+
+// Observe records terminations visible in one pod status update. Feed it
+// every Added/Modified pod event.
+func (c *Collector) Observe(pod *v1.Pod) {
+	workload := "Pod/" + pod.Name
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			workload = ref.Kind + "/" + ref.Name
+			break
+		}
+	}
+
+	statuses := append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, status := range statuses {
+		// The current state and the previous one: for restarting
+		// containers the interesting exit usually lives in LastState by
+		// the time we see the update.
+		for _, terminated := range []*v1.ContainerStateTerminated{
+			status.State.Terminated,
+			status.LastTerminationState.Terminated,
+		} {
+			if terminated == nil {
+				continue
+			}
+			key := pod.Namespace + "/" + pod.Name + "/" + status.Name + "/" + terminated.FinishedAt.Rfc3339Copy().String()
+			if c.seen[key] {
+				continue
+			}
+			c.seen[key] = true
+			c.all = append(c.all, Termination{
+				Namespace:  pod.Namespace,
+				Workload:   workload,
+				Pod:        pod.Name,
+				Container:  status.Name,
+				ExitCode:   terminated.ExitCode,
+				Signal:     terminated.Signal,
+				Reason:     terminated.Reason,
+				Message:    terminated.Message,
+				FinishedAt: terminated.FinishedAt.Time,
+			})
+		}
+	}
+}
+
+// Report returns failures (non-zero exits) from the window ending at now,
+// grouped by namespace, workload and exit code. Groups are ordered by
+// descending size, then namespace/workload for stability; members are
+// newest first.
+func (c *Collector) Report(now time.Time) []Group {
+	cutoff := now.Add(-c.window)
+
+	c.mu.Lock()
+	// Prune aged-out entries while we are here.
+	kept := c.all[:0]
+	for _, termination := range c.all {
+		if !termination.FinishedAt.Before(cutoff) {
+			kept = append(kept, termination)
+		}
+	}
+	c.all = kept
+	terminations := append([]Termination{}, c.all...)
+	c.mu.Unlock()
+
+	type groupKey struct {
+		namespace, workload string
+		exitCode            int32
+	}
+	groups := map[groupKey]*Group{}
+	for _, termination := range terminations {
+		if termination.ExitCode == 0 {
+			continue
+		}
+		key := groupKey{termination.Namespace, termination.Workload, termination.ExitCode}
+		group := groups[key]
+		if group == nil {
+			group = &Group{
+				Namespace: termination.Namespace,
+				Workload:  termination.Workload,
+				ExitCode:  termination.ExitCode,
+			}
+			groups[key] = group
+		}
+		group.Terminations = append(group.Terminations, termination)
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.Terminations, func(i, j int) bool {
+			return group.Terminations[i].FinishedAt.After(group.Terminations[j].FinishedAt)
+		})
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if len(a.Terminations) != len(b.Terminations) {
+			return len(a.Terminations) > len(b.Terminations)
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+		return a.ExitCode < b.ExitCode
+	})
+	return result
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package termmsg
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func terminatedPod(name, container string, exitCode int32, message string, finishedAt time.Time) *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind: "Job", Name: "batch-job", Controller: &controller,
+			}},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name: container,
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					ExitCode:   exitCode,
+					Reason:     "Error",
+					Message:    message,
+					FinishedAt: metav1.NewTime(finishedAt),
+				}},
+			}},
+		},
+	}
+}
+
+func TestReportGroupsByWorkloadAndExitCode(t *testing.T) {
+	base := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCollector(6 * time.Hour)
+
+	c.Observe(terminatedPod("batch-job-a", "main", 137, "oom", base.Add(-time.Hour)))
+	c.Observe(terminatedPod("batch-job-b", "main", 137, "oom", base.Add(-30*time.Minute)))
+	c.Observe(terminatedPod("batch-job-c", "main", 1, "config missing", base.Add(-2*time.Hour)))
+	// Clean exits are not failures.
+	c.Observe(terminatedPod("batch-job-d", "main", 0, "", base.Add(-time.Hour)))
+
+	groups := c.Report(base)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	first := groups[0]
+	if first.ExitCode != 137 || first.Workload != "Job/batch-job" || len(first.Terminations) != 2 {
+		t.Errorf("unexpected first group: %+v", first)
+	}
+	// Newest first within a group.
+	if first.Terminations[0].Pod != "batch-job-b" {
+		t.Errorf("expected newest termination first, got %+v", first.Terminations)
+	}
+	if first.Terminations[0].Message != "oom" || first.Terminations[0].Reason != "Error" {
+		t.Errorf("termination details lost: %+v", first.Terminations[0])
+	}
+	if groups[1].ExitCode != 1 || len(groups[1].Terminations) != 1 {
+		t.Errorf("unexpected second group: %+v", groups[1])
+	}
+}
+
+func TestObserveDeduplicatesAndReadsLastState(t *testing.T) {
+	base := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCollector(6 * time.Hour)
+
+	// A crash-looping container: currently running, previous exit in
+	// LastTerminationState.
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "looper"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "main",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					ExitCode:   2,
+					FinishedAt: metav1.NewTime(base.Add(-time.Minute)),
+				}},
+			}},
+		},
+	}
+	// The same status delivered twice records once.
+	c.Observe(pod)
+	c.Observe(pod)
+
+	groups := c.Report(base)
+	if len(groups) != 1 || len(groups[0].Terminations) != 1 {
+		t.Fatalf("expected one deduplicated termination, got %+v", groups)
+	}
+	if groups[0].Workload != "Pod/looper" {
+		t.Errorf("bare pods group under their own name, got %+v", groups[0])
+	}
+}
+
+func TestReportWindowPrunes(t *testing.T) {
+	base := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCollector(time.Hour)
+
+	c.Observe(terminatedPod("old", "main", 1, "", base.Add(-3*time.Hour)))
+	c.Observe(terminatedPod("recent", "main", 1, "", base.Add(-10*time.Minute)))
+
+	groups := c.Report(base)
+	if len(groups) != 1 || len(groups[0].Terminations) != 1 || groups[0].Terminations[0].Pod != "recent" {
+		t.Errorf("expected only the recent termination, got %+v", groups)
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package topologyspread measures the skew a TopologySpreadConstraint
+// sees right now: matching pods per topology domain over the nodes the
+// constraint considers eligible. Reading the current skew tells an
+// operator how close a rollout is to DoNotSchedule failures before the
+// scheduler starts rejecting pods.
+package topologyspread
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	schedulingcorev1 "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+)
+
+// Domain is one value of the constraint's topology key.
+type Domain struct {
+	// Value is the topology label value, e.g. a zone name.
+	Value string
+	// Nodes is how many eligible nodes carry this value.
+	Nodes int
+	// Pods counts the matching pods on those nodes.
+	Pods int
+}
+
+// Report is the current state of one constraint.
+type Report struct {
+	// TopologyKey echoes the constraint's key.
+	TopologyKey string
+	// Domains is sorted by value.
+	Domains []Domain
+	// GlobalMin is the smallest pod count among eligible domains, or
+	// zero when fewer domains exist than MinDomains.
+	GlobalMin int
+	// ActualSkew is the largest domain count minus GlobalMin.
+	ActualSkew int32
+	// Violated is true when ActualSkew exceeds the constraint's
+	// MaxSkew. Only DoNotSchedule constraints block scheduling on it.
+	Violated bool
+	// MinDomainsSatisfied is false when fewer domains exist than the
+	// constraint's minDomains.
+	MinDomainsSatisfied bool
+}
+
+// Analyze measures one constraint for the pod against the snapshot.
+// The pod supplies the namespace, the labels MatchLabelKeys reads, and
+// the node affinity and tolerations the inclusion policies honor.
+func Analyze(pod *v1.Pod, constraint v1.TopologySpreadConstraint, nodes []*v1.Node, pods []*v1.Pod) (Report, error) {
+	selector, err := constraintSelector(pod, constraint)
+	if err != nil {
+		return Report{}, err
+	}
+
+	honorAffinity := constraint.NodeAffinityPolicy == nil || *constraint.NodeAffinityPolicy == v1.NodeInclusionPolicyHonor
+	honorTaints := constraint.NodeTaintsPolicy != nil && *constraint.NodeTaintsPolicy == v1.NodeInclusionPolicyHonor
+	requiredAffinity := nodeaffinity.GetRequiredNodeAffinity(pod)
+
+	eligible := map[string]*Domain{}
+	nodeDomain := map[string]string{}
+	for _, node := range nodes {
+		value, ok := node.Labels[constraint.TopologyKey]
+		if !ok {
+			continue
+		}
+		if honorAffinity {
+			if match, err := requiredAffinity.Match(node); err != nil {
+				return Report{}, fmt.Errorf("node affinity against %s: %v", node.Name, err)
+			} else if !match {
+				continue
+			}
+		}
+		if honorTaints {
+			if _, untolerated := schedulingcorev1.FindMatchingUntoleratedTaint(node.Spec.Taints, pod.Spec.Tolerations, doNotScheduleTaint); untolerated {
+				continue
+			}
+		}
+		domain, ok := eligible[value]
+		if !ok {
+			domain = &Domain{Value: value}
+			eligible[value] = domain
+		}
+		domain.Nodes++
+		nodeDomain[node.Name] = value
+	}
+
+	for _, existing := range pods {
+		if existing.Namespace != pod.Namespace || existing.Spec.NodeName == "" {
+			continue
+		}
+		value, ok := nodeDomain[existing.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		if selector.Matches(labels.Set(existing.Labels)) {
+			eligible[value].Pods++
+		}
+	}
+
+	report := Report{TopologyKey: constraint.TopologyKey, MinDomainsSatisfied: true}
+	for _, domain := range eligible {
+		report.Domains = append(report.Domains, *domain)
+	}
+	sort.Slice(report.Domains, func(i, j int) bool { return report.Domains[i].Value < report.Domains[j].Value })
+
+	if len(report.Domains) == 0 {
+		return report, nil
+	}
+
+	minDomains := 1
+	if constraint.MinDomains != nil {
+		minDomains = int(*constraint.MinDomains)
+	}
+	maxCount := 0
+	report.GlobalMin = report.Domains[0].Pods
+	for _, domain := range report.Domains {
+		if domain.Pods > maxCount {
+			maxCount = domain.Pods
+		}
+		if domain.Pods < report.GlobalMin {
+			report.GlobalMin = domain.Pods
+		}
+	}
+	if len(report.Domains) < minDomains {
+		report.MinDomainsSatisfied = false
+		report.GlobalMin = 0
+	}
+	report.ActualSkew = int32(maxCount - report.GlobalMin)
+	report.Violated = report.ActualSkew > constraint.MaxSkew
+	return report, nil
+}
+
+// AnalyzeAll measures every constraint on the pod spec.
+func AnalyzeAll(pod *v1.Pod, nodes []*v1.Node, pods []*v1.Pod) ([]Report, error) {
+	reports := make([]Report, 0, len(pod.Spec.TopologySpreadConstraints))
+	for i, constraint := range pod.Spec.TopologySpreadConstraints {
+		report, err := Analyze(pod, constraint, nodes, pods)
+		if err != nil {
+			return nil, fmt.Errorf("constraint %d: %v", i, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// constraintSelector combines the constraint's labelSelector with
+// matchLabelKeys requirements taken from the pod's own labels.
+func constraintSelector(pod *v1.Pod, constraint v1.TopologySpreadConstraint) (labels.Selector, error) {
+	selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid labelSelector: %v", err)
+	}
+	for _, key := range constraint.MatchLabelKeys {
+		value, ok := pod.Labels[key]
+		if !ok {
+			// A key the pod does not carry matches nothing, mirroring
+			// the scheduler's handling.
+			return labels.Nothing(), nil
+		}
+		requirement, err := labels.NewRequirement(key, selection.Equals, []string{value})
+		if err != nil {
+			return nil, fmt.Errorf("matchLabelKeys key %s: %v", key, err)
+		}
+		selector = selector.Add(*requirement)
+	}
+	return selector, nil
+}
+
+func doNotScheduleTaint(taint *v1.Taint) bool {
+	return taint.Effect == v1.TaintEffectNoSchedule || taint.Effect == v1.TaintEffectNoExecute
+}
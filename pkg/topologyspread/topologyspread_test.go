@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topologyspread
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const zoneKey = "topology.kubernetes.io/zone"
+
+func zoneNode(name, zone string, taints ...v1.Taint) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{zoneKey: zone}},
+		Spec:       v1.NodeSpec{Taints: taints},
+	}
+}
+
+func webPod(name, node string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{"app": "web"}},
+		Spec:       v1.PodSpec{NodeName: node},
+	}
+}
+
+func webConstraint(maxSkew int32) v1.TopologySpreadConstraint {
+	return v1.TopologySpreadConstraint{
+		MaxSkew:           maxSkew,
+		TopologyKey:       zoneKey,
+		WhenUnsatisfiable: v1.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+	}
+}
+
+func candidate() *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-new", Labels: map[string]string{"app": "web"}}}
+}
+
+func TestAnalyzeSkew(t *testing.T) {
+	nodes := []*v1.Node{
+		zoneNode("a1", "zone-a"), zoneNode("a2", "zone-a"),
+		zoneNode("b1", "zone-b"),
+		zoneNode("c1", "zone-c"),
+	}
+	pods := []*v1.Pod{
+		webPod("w0", "a1"), webPod("w1", "a2"), webPod("w2", "a1"),
+		webPod("w3", "b1"),
+	}
+	report, err := Analyze(candidate(), webConstraint(1), nodes, pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Domains) != 3 {
+		t.Fatalf("domains: %+v", report.Domains)
+	}
+	// zone-a has 3 pods, zone-b 1, zone-c 0.
+	if report.Domains[0].Pods != 3 || report.Domains[1].Pods != 1 || report.Domains[2].Pods != 0 {
+		t.Errorf("counts: %+v", report.Domains)
+	}
+	if report.GlobalMin != 0 || report.ActualSkew != 3 {
+		t.Errorf("skew = %d (min %d), want 3 (min 0)", report.ActualSkew, report.GlobalMin)
+	}
+	if !report.Violated {
+		t.Error("skew 3 > maxSkew 1 should be violated")
+	}
+}
+
+func TestAnalyzeMinDomains(t *testing.T) {
+	minDomains := int32(3)
+	constraint := webConstraint(2)
+	constraint.MinDomains = &minDomains
+	nodes := []*v1.Node{zoneNode("a1", "zone-a"), zoneNode("b1", "zone-b")}
+	pods := []*v1.Pod{webPod("w0", "a1"), webPod("w1", "a1"), webPod("w2", "b1"), webPod("w3", "b1")}
+
+	report, err := Analyze(candidate(), constraint, nodes, pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MinDomainsSatisfied {
+		t.Error("2 domains < minDomains 3")
+	}
+	// Global min becomes 0, so skew is the full max count.
+	if report.GlobalMin != 0 || report.ActualSkew != 2 {
+		t.Errorf("skew = %d (min %d), want 2 (min 0)", report.ActualSkew, report.GlobalMin)
+	}
+	if report.Violated {
+		t.Error("skew 2 is within maxSkew 2")
+	}
+}
+
+func TestAnalyzeNodeTaintsPolicy(t *testing.T) {
+	honor := v1.NodeInclusionPolicyHonor
+	constraint := webConstraint(1)
+	constraint.NodeTaintsPolicy = &honor
+	tainted := v1.Taint{Key: "dedicated", Effect: v1.TaintEffectNoSchedule}
+	nodes := []*v1.Node{
+		zoneNode("a1", "zone-a"),
+		zoneNode("b1", "zone-b", tainted),
+	}
+	pods := []*v1.Pod{webPod("w0", "a1")}
+
+	report, err := Analyze(candidate(), constraint, nodes, pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Domains) != 1 || report.Domains[0].Value != "zone-a" {
+		t.Errorf("tainted zone should be excluded: %+v", report.Domains)
+	}
+
+	// The default (Ignore) keeps the tainted node's domain.
+	report, err = Analyze(candidate(), webConstraint(1), nodes, pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Domains) != 2 {
+		t.Errorf("default taint policy should include zone-b: %+v", report.Domains)
+	}
+}
+
+func TestAnalyzeNodeAffinityPolicy(t *testing.T) {
+	pod := candidate()
+	pod.Spec.NodeSelector = map[string]string{"pool": "apps"}
+	nodes := []*v1.Node{
+		zoneNode("a1", "zone-a"),
+		zoneNode("b1", "zone-b"),
+	}
+	nodes[0].Labels["pool"] = "apps"
+
+	report, err := Analyze(pod, webConstraint(1), nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Domains) != 1 || report.Domains[0].Value != "zone-a" {
+		t.Errorf("default affinity policy honors nodeSelector: %+v", report.Domains)
+	}
+
+	ignore := v1.NodeInclusionPolicyIgnore
+	constraint := webConstraint(1)
+	constraint.NodeAffinityPolicy = &ignore
+	report, err = Analyze(pod, constraint, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Domains) != 2 {
+		t.Errorf("Ignore policy should keep both zones: %+v", report.Domains)
+	}
+}
+
+func TestAnalyzeMatchLabelKeys(t *testing.T) {
+	constraint := webConstraint(1)
+	constraint.MatchLabelKeys = []string{"pod-template-hash"}
+	pod := candidate()
+	pod.Labels["pod-template-hash"] = "v2"
+
+	oldRS := webPod("old-0", "a1")
+	oldRS.Labels["pod-template-hash"] = "v1"
+	newRS := webPod("new-0", "b1")
+	newRS.Labels["pod-template-hash"] = "v2"
+
+	nodes := []*v1.Node{zoneNode("a1", "zone-a"), zoneNode("b1", "zone-b")}
+	report, err := Analyze(pod, constraint, nodes, []*v1.Pod{oldRS, newRS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the pod from the same template hash counts.
+	if report.Domains[0].Pods != 0 || report.Domains[1].Pods != 1 {
+		t.Errorf("matchLabelKeys should scope counts to the new ReplicaSet: %+v", report.Domains)
+	}
+}
+
+func TestAnalyzeAll(t *testing.T) {
+	pod := candidate()
+	pod.Spec.TopologySpreadConstraints = []v1.TopologySpreadConstraint{
+		webConstraint(1),
+		{
+			MaxSkew:           1,
+			TopologyKey:       "kubernetes.io/hostname",
+			WhenUnsatisfiable: v1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+	reports, err := AnalyzeAll(pod, []*v1.Node{zoneNode("a1", "zone-a")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports", len(reports))
+	}
+	if reports[1].TopologyKey != "kubernetes.io/hostname" || len(reports[1].Domains) != 0 {
+		t.Errorf("hostname constraint over unlabeled nodes: %+v", reports[1])
+	}
+}
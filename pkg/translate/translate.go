@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package translate converts simple Pods into artifacts runnable outside
+// the cluster for edge debugging: a docker-compose file (with configMap
+// volumes rendered to local files) and a podman-based systemd unit. The
+// translation is read-only — it consumes cluster state and emits files,
+// and deliberately rejects Pod features that have no faithful local
+// equivalent rather than translating them loosely.
+package translate
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigMapGetter provides the ConfigMaps referenced by the Pod's volumes.
+type ConfigMapGetter interface {
+	GetConfigMap(namespace, name string) (*v1.ConfigMap, error)
+}
+
+// ComposeResult is a rendered docker-compose translation.
+type ComposeResult struct {
+	// Compose is the docker-compose YAML.
+	Compose []byte
+	// Files maps relative paths (next to the compose file) to content that
+	// must be written for bind mounts of rendered configMap volumes.
+	Files map[string][]byte
+}
+
+type composeFile struct {
+	Services map[string]composeService `json:"services"`
+	Volumes  map[string]struct{}       `json:"volumes,omitempty"`
+}
+
+type composeService struct {
+	Image       string   `json:"image"`
+	Entrypoint  []string `json:"entrypoint,omitempty"`
+	Command     []string `json:"command,omitempty"`
+	WorkingDir  string   `json:"working_dir,omitempty"`
+	Environment []string `json:"environment,omitempty"`
+	Ports       []string `json:"ports,omitempty"`
+	Volumes     []string `json:"volumes,omitempty"`
+}
+
+// ToCompose translates the Pod into a docker-compose file with one service
+// per container. Volumes are limited to hostPath (bind mount), emptyDir
+// (named volume) and configMap (rendered to files and bind mounted);
+// anything else, and env vars using valueFrom, fail the translation.
+func ToCompose(pod *v1.Pod, getter ConfigMapGetter) (*ComposeResult, error) {
+	result := &ComposeResult{Files: map[string][]byte{}}
+	file := composeFile{Services: map[string]composeService{}}
+
+	// volumeMounts maps volume name to the compose-side source (bind path
+	// or named volume) prepared from the Pod's volumes.
+	volumeSources := map[string]string{}
+	for i := range pod.Spec.Volumes {
+		vol := &pod.Spec.Volumes[i]
+		switch {
+		case vol.HostPath != nil:
+			volumeSources[vol.Name] = vol.HostPath.Path
+		case vol.EmptyDir != nil:
+			if file.Volumes == nil {
+				file.Volumes = map[string]struct{}{}
+			}
+			file.Volumes[vol.Name] = struct{}{}
+			volumeSources[vol.Name] = vol.Name
+		case vol.ConfigMap != nil:
+			dir, err := renderConfigMapVolume(pod.Namespace, vol.ConfigMap, getter, result.Files)
+			if err != nil {
+				return nil, fmt.Errorf("volume %q: %v", vol.Name, err)
+			}
+			volumeSources[vol.Name] = dir
+		default:
+			return nil, fmt.Errorf("volume %q: only hostPath, emptyDir and configMap volumes can be translated", vol.Name)
+		}
+	}
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		service, err := translateContainer(c, volumeSources)
+		if err != nil {
+			return nil, fmt.Errorf("container %q: %v", c.Name, err)
+		}
+		file.Services[c.Name] = service
+	}
+
+	compose, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, err
+	}
+	result.Compose = compose
+	return result, nil
+}
+
+func translateContainer(c *v1.Container, volumeSources map[string]string) (composeService, error) {
+	service := composeService{
+		Image:      c.Image,
+		Entrypoint: c.Command,
+		Command:    c.Args,
+		WorkingDir: c.WorkingDir,
+	}
+
+	for _, env := range c.Env {
+		if env.ValueFrom != nil {
+			return service, fmt.Errorf("env %q uses valueFrom, which cannot be translated", env.Name)
+		}
+		service.Environment = append(service.Environment, env.Name+"="+env.Value)
+	}
+	sort.Strings(service.Environment)
+
+	for _, port := range c.Ports {
+		if port.HostPort != 0 {
+			service.Ports = append(service.Ports, fmt.Sprintf("%d:%d", port.HostPort, port.ContainerPort))
+		} else {
+			service.Ports = append(service.Ports, fmt.Sprintf("%d", port.ContainerPort))
+		}
+	}
+
+	for _, mount := range c.VolumeMounts {
+		source, ok := volumeSources[mount.Name]
+		if !ok {
+			return service, fmt.Errorf("mount references undeclared volume %q", mount.Name)
+		}
+		target := mount.MountPath
+		if mount.SubPath != "" {
+			source = path.Join(source, mount.SubPath)
+		}
+		spec := source + ":" + target
+		if mount.ReadOnly {
+			spec += ":ro"
+		}
+		service.Volumes = append(service.Volumes, spec)
+	}
+	return service, nil
+}
+
+// renderConfigMapVolume writes the configMap's keys into the result's file
+// set and returns the relative directory to bind mount.
+func renderConfigMapVolume(namespace string, source *v1.ConfigMapVolumeSource, getter ConfigMapGetter, files map[string][]byte) (string, error) {
+	if getter == nil {
+		return "", fmt.Errorf("configMap volume requires a ConfigMapGetter")
+	}
+	configMap, err := getter.GetConfigMap(namespace, source.Name)
+	if err != nil {
+		return "", fmt.Errorf("configmap %q: %v", source.Name, err)
+	}
+	dir := "./configmaps/" + source.Name
+	render := func(key string, content []byte) {
+		files[path.Join("configmaps", source.Name, key)] = content
+	}
+	if len(source.Items) > 0 {
+		for _, item := range source.Items {
+			if data, ok := configMap.Data[item.Key]; ok {
+				render(item.Path, []byte(data))
+			} else if data, ok := configMap.BinaryData[item.Key]; ok {
+				render(item.Path, data)
+			} else {
+				return "", fmt.Errorf("configmap %q: key %q not found", source.Name, item.Key)
+			}
+		}
+		return dir, nil
+	}
+	for key, data := range configMap.Data {
+		render(key, []byte(data))
+	}
+	for key, data := range configMap.BinaryData {
+		render(key, data)
+	}
+	return dir, nil
+}
+
+// SystemdUnit renders a systemd service unit that runs the Pod's manifest
+// through "podman kube play" for podman-capable edge hosts. manifestPath is
+// the on-host path of the Pod manifest the unit should play.
+func SystemdUnit(pod *v1.Pod, manifestPath string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\n")
+	fmt.Fprintf(&b, "Description=Pod %s/%s (translated)\n", pod.Namespace, pod.Name)
+	fmt.Fprintf(&b, "Wants=network-online.target\n")
+	fmt.Fprintf(&b, "After=network-online.target\n")
+	fmt.Fprintf(&b, "\n[Service]\n")
+	fmt.Fprintf(&b, "Type=oneshot\n")
+	fmt.Fprintf(&b, "RemainAfterExit=true\n")
+	fmt.Fprintf(&b, "ExecStart=podman kube play --replace %s\n", manifestPath)
+	fmt.Fprintf(&b, "ExecStop=podman kube down %s\n", manifestPath)
+	fmt.Fprintf(&b, "\n[Install]\n")
+	fmt.Fprintf(&b, "WantedBy=multi-user.target\n")
+	return []byte(b.String())
+}
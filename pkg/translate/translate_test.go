@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package translate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type fakeConfigMaps map[string]*v1.ConfigMap
+
+func (f fakeConfigMaps) GetConfigMap(namespace, name string) (*v1.ConfigMap, error) {
+	if cm, ok := f[namespace+"/"+name]; ok {
+		return cm, nil
+	}
+	return nil, fmt.Errorf("configmap %s/%s not found", namespace, name)
+}
+
+func translatablePod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "edge"},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{Name: "host-logs", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/var/log"}}},
+				{Name: "scratch", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+				{Name: "conf", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{Name: "app-conf"},
+				}}},
+			},
+			Containers: []v1.Container{{
+				Name:    "app",
+				Image:   "registry.example.com/app:v1",
+				Command: []string{"/app"},
+				Args:    []string{"--verbose"},
+				Env: []v1.EnvVar{
+					{Name: "B", Value: "2"},
+					{Name: "A", Value: "1"},
+				},
+				Ports: []v1.ContainerPort{
+					{ContainerPort: 8080, HostPort: 80},
+					{ContainerPort: 9090},
+				},
+				VolumeMounts: []v1.VolumeMount{
+					{Name: "host-logs", MountPath: "/logs", ReadOnly: true},
+					{Name: "scratch", MountPath: "/tmp/scratch"},
+					{Name: "conf", MountPath: "/etc/app"},
+				},
+			}},
+		},
+	}
+}
+
+func TestToCompose(t *testing.T) {
+	getter := fakeConfigMaps{
+		"default/app-conf": {Data: map[string]string{"app.yaml": "key: value"}},
+	}
+	result, err := ToCompose(translatablePod(), getter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var file composeFile
+	if err := yaml.Unmarshal(result.Compose, &file); err != nil {
+		t.Fatalf("emitted compose is not valid YAML: %v", err)
+	}
+	service, ok := file.Services["app"]
+	if !ok {
+		t.Fatalf("expected service for container app, got %v", file.Services)
+	}
+	if service.Image != "registry.example.com/app:v1" {
+		t.Errorf("unexpected image %q", service.Image)
+	}
+	if !reflect.DeepEqual(service.Entrypoint, []string{"/app"}) || !reflect.DeepEqual(service.Command, []string{"--verbose"}) {
+		t.Errorf("unexpected entrypoint/command: %+v", service)
+	}
+	if !reflect.DeepEqual(service.Environment, []string{"A=1", "B=2"}) {
+		t.Errorf("expected sorted environment, got %v", service.Environment)
+	}
+	if !reflect.DeepEqual(service.Ports, []string{"80:8080", "9090"}) {
+		t.Errorf("unexpected ports: %v", service.Ports)
+	}
+	expectedVolumes := []string{
+		"/var/log:/logs:ro",
+		"scratch:/tmp/scratch",
+		"./configmaps/app-conf:/etc/app",
+	}
+	if !reflect.DeepEqual(service.Volumes, expectedVolumes) {
+		t.Errorf("expected volumes %v, got %v", expectedVolumes, service.Volumes)
+	}
+	if _, ok := file.Volumes["scratch"]; !ok {
+		t.Errorf("expected named volume for emptyDir, got %v", file.Volumes)
+	}
+
+	content, ok := result.Files["configmaps/app-conf/app.yaml"]
+	if !ok || string(content) != "key: value" {
+		t.Errorf("expected rendered configMap file, got %v", result.Files)
+	}
+}
+
+func TestToComposeRejectsUntranslatable(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mutate func(*v1.Pod)
+	}{
+		{
+			name: "secret volume",
+			mutate: func(pod *v1.Pod) {
+				pod.Spec.Volumes = []v1.Volume{{
+					Name:         "creds",
+					VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "creds"}},
+				}}
+				pod.Spec.Containers[0].VolumeMounts = nil
+			},
+		},
+		{
+			name: "env valueFrom",
+			mutate: func(pod *v1.Pod) {
+				pod.Spec.Containers[0].Env = []v1.EnvVar{{
+					Name:      "FIELD",
+					ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+				}}
+			},
+		},
+		{
+			name: "mount of undeclared volume",
+			mutate: func(pod *v1.Pod) {
+				pod.Spec.Volumes = nil
+				pod.Spec.Containers[0].VolumeMounts = []v1.VolumeMount{{Name: "ghost", MountPath: "/x"}}
+			},
+		},
+		{
+			name: "missing configmap",
+			mutate: func(pod *v1.Pod) {
+				pod.Spec.Volumes[2].ConfigMap.Name = "absent"
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := translatablePod()
+			tc.mutate(pod)
+			getter := fakeConfigMaps{
+				"default/app-conf": {Data: map[string]string{"app.yaml": "key: value"}},
+			}
+			if _, err := ToCompose(pod, getter); err == nil {
+				t.Fatal("expected translation error, got none")
+			}
+		})
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	pod := translatablePod()
+	unit := string(SystemdUnit(pod, "/etc/edge/edge-pod.yaml"))
+	for _, want := range []string{
+		"Description=Pod default/edge (translated)",
+		"ExecStart=podman kube play --replace /etc/edge/edge-pod.yaml",
+		"ExecStop=podman kube down /etc/edge/edge-pod.yaml",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q:\n%s", want, unit)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package uidindex maintains a memory-efficient identity index keyed by
+// object UID, tracking a bounded ring of resourceVersion/generation
+// observations per identity. Consumers use it to tell apart situations
+// that look identical through a keyed cache: an object recreated under the
+// same name, a generation jump implying missed updates, and a deleted UID
+// showing up again. Controller-side bookkeeping needs these distinctions
+// to avoid acting on stale identity assumptions.
+package uidindex
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ChangeKind classifies what an observation revealed.
+type ChangeKind string
+
+const (
+	// Added is the first observation of a UID under a fresh key.
+	Added ChangeKind = "Added"
+	// Updated is a subsequent observation of a known, live UID.
+	Updated ChangeKind = "Updated"
+	// Recreated means the key is already held by a different UID: the
+	// object was deleted and recreated under the same name.
+	Recreated ChangeKind = "Recreated"
+	// Resurrected means a UID that was observed as deleted reappeared.
+	Resurrected ChangeKind = "Resurrected"
+)
+
+// Event describes the outcome of one observation.
+type Event struct {
+	Kind ChangeKind
+	// GenerationJump is true when the object's generation advanced by
+	// more than one since the previous observation, implying updates were
+	// missed.
+	GenerationJump bool
+	// PreviousUID is set for Recreated: the UID that previously held the
+	// key.
+	PreviousUID types.UID
+}
+
+// Observation is one recorded version of an identity.
+type Observation struct {
+	ResourceVersion string
+	Generation      int64
+}
+
+// identity is the tracked state of one UID.
+type identity struct {
+	key     string
+	deleted bool
+	// history is a bounded ring; next points at the slot the next
+	// observation overwrites once the ring is full.
+	history []Observation
+	next    int
+	full    bool
+}
+
+func (id *identity) observe(o Observation, capacity int) {
+	if len(id.history) < capacity {
+		id.history = append(id.history, o)
+		return
+	}
+	id.history[id.next] = o
+	id.next = (id.next + 1) % capacity
+	id.full = true
+}
+
+func (id *identity) latest() (Observation, bool) {
+	if len(id.history) == 0 {
+		return Observation{}, false
+	}
+	if id.full {
+		return id.history[(id.next+len(id.history)-1)%len(id.history)], true
+	}
+	return id.history[len(id.history)-1], true
+}
+
+// ordered returns the ring's observations oldest first.
+func (id *identity) ordered() []Observation {
+	if !id.full {
+		return append([]Observation(nil), id.history...)
+	}
+	out := make([]Observation, 0, len(id.history))
+	out = append(out, id.history[id.next:]...)
+	out = append(out, id.history[:id.next]...)
+	return out
+}
+
+// defaultTombstones bounds how many deleted identities are remembered.
+const defaultTombstones = 1024
+
+// Index is the UID identity index. It is safe for concurrent use.
+type Index struct {
+	mu          sync.Mutex
+	byUID       map[types.UID]*identity
+	byKey       map[string]types.UID
+	historySize int
+	// tombstones remembers deleted UIDs in FIFO order so resurrection is
+	// detectable without unbounded growth.
+	tombstones    []types.UID
+	maxTombstones int
+}
+
+// New returns an index keeping up to historySize observations per identity.
+func New(historySize int) *Index {
+	if historySize < 1 {
+		historySize = 1
+	}
+	return &Index{
+		byUID:         map[types.UID]*identity{},
+		byKey:         map[string]types.UID{},
+		historySize:   historySize,
+		maxTombstones: defaultTombstones,
+	}
+}
+
+func objectKey(obj runtime.Object) (string, types.UID, Observation, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", "", Observation{}, err
+	}
+	key := accessor.GetName()
+	if namespace := accessor.GetNamespace(); namespace != "" {
+		key = namespace + "/" + key
+	}
+	return key, accessor.GetUID(), Observation{
+		ResourceVersion: accessor.GetResourceVersion(),
+		Generation:      accessor.GetGeneration(),
+	}, nil
+}
+
+// Observe records a live sighting of the object and classifies it.
+func (x *Index) Observe(obj runtime.Object) (Event, error) {
+	key, uid, observation, err := objectKey(obj)
+	if err != nil {
+		return Event{}, err
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	event := Event{Kind: Added}
+	if id, known := x.byUID[uid]; known {
+		if id.deleted {
+			event.Kind = Resurrected
+			id.deleted = false
+		} else {
+			event.Kind = Updated
+		}
+		if previous, ok := id.latest(); ok &&
+			observation.Generation > previous.Generation+1 {
+			event.GenerationJump = true
+		}
+		id.key = key
+		id.observe(observation, x.historySize)
+	} else {
+		if holder, taken := x.byKey[key]; taken && holder != uid {
+			event.Kind = Recreated
+			event.PreviousUID = holder
+			if old := x.byUID[holder]; old != nil {
+				old.deleted = true
+				x.addTombstone(holder)
+			}
+		}
+		id := &identity{key: key}
+		id.observe(observation, x.historySize)
+		x.byUID[uid] = id
+	}
+	x.byKey[key] = uid
+	return event, nil
+}
+
+// Delete records the object's deletion. Its history is retained (bounded)
+// so a later reappearance of the UID is classified as Resurrected.
+func (x *Index) Delete(obj runtime.Object) error {
+	key, uid, _, err := objectKey(obj)
+	if err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if id, ok := x.byUID[uid]; ok && !id.deleted {
+		id.deleted = true
+		x.addTombstone(uid)
+	}
+	if x.byKey[key] == uid {
+		delete(x.byKey, key)
+	}
+	return nil
+}
+
+// addTombstone must be called with the lock held.
+func (x *Index) addTombstone(uid types.UID) {
+	x.tombstones = append(x.tombstones, uid)
+	for len(x.tombstones) > x.maxTombstones {
+		evicted := x.tombstones[0]
+		x.tombstones = x.tombstones[1:]
+		if id, ok := x.byUID[evicted]; ok && id.deleted {
+			delete(x.byUID, evicted)
+		}
+	}
+}
+
+// History returns the retained observations for a UID, oldest first.
+func (x *Index) History(uid types.UID) []Observation {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if id, ok := x.byUID[uid]; ok {
+		return id.ordered()
+	}
+	return nil
+}
+
+// CurrentUID returns the UID currently holding the namespace/name key.
+func (x *Index) CurrentUID(namespace, name string) (types.UID, bool) {
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	uid, ok := x.byKey[key]
+	return uid, ok
+}
+
+// Len returns the number of tracked identities, including tombstoned ones.
+func (x *Index) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.byUID)
+}
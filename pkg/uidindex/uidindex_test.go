@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uidindex
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func indexedPod(uid types.UID, rv string, generation int64) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "default",
+		Name:            "web",
+		UID:             uid,
+		ResourceVersion: rv,
+		Generation:      generation,
+	}}
+}
+
+func mustObserve(t *testing.T, x *Index, pod *v1.Pod) Event {
+	t.Helper()
+	event, err := x.Observe(pod)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return event
+}
+
+func TestObserveLifecycle(t *testing.T) {
+	x := New(8)
+
+	if event := mustObserve(t, x, indexedPod("uid-1", "100", 1)); event.Kind != Added {
+		t.Errorf("expected Added, got %+v", event)
+	}
+	if event := mustObserve(t, x, indexedPod("uid-1", "101", 2)); event.Kind != Updated || event.GenerationJump {
+		t.Errorf("expected plain Updated, got %+v", event)
+	}
+	if event := mustObserve(t, x, indexedPod("uid-1", "110", 5)); event.Kind != Updated || !event.GenerationJump {
+		t.Errorf("expected Updated with generation jump, got %+v", event)
+	}
+
+	uid, ok := x.CurrentUID("default", "web")
+	if !ok || uid != "uid-1" {
+		t.Errorf("expected current UID uid-1, got %q ok=%v", uid, ok)
+	}
+}
+
+func TestRecreateWithSameName(t *testing.T) {
+	x := New(8)
+	mustObserve(t, x, indexedPod("uid-1", "100", 1))
+
+	// Same key, different UID, without an intervening delete event.
+	event := mustObserve(t, x, indexedPod("uid-2", "200", 1))
+	if event.Kind != Recreated || event.PreviousUID != "uid-1" {
+		t.Errorf("expected Recreated from uid-1, got %+v", event)
+	}
+	uid, _ := x.CurrentUID("default", "web")
+	if uid != "uid-2" {
+		t.Errorf("expected key moved to uid-2, got %q", uid)
+	}
+}
+
+func TestResurrection(t *testing.T) {
+	x := New(8)
+	pod := indexedPod("uid-1", "100", 1)
+	mustObserve(t, x, pod)
+	if err := x.Delete(pod); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := x.CurrentUID("default", "web"); ok {
+		t.Error("expected key released after delete")
+	}
+
+	event := mustObserve(t, x, indexedPod("uid-1", "150", 2))
+	if event.Kind != Resurrected {
+		t.Errorf("expected Resurrected, got %+v", event)
+	}
+}
+
+func TestHistoryRing(t *testing.T) {
+	x := New(3)
+	for i := 1; i <= 5; i++ {
+		mustObserve(t, x, indexedPod("uid-1", fmt.Sprintf("%d", 100+i), int64(i)))
+	}
+	history := x.History("uid-1")
+	expected := []Observation{
+		{ResourceVersion: "103", Generation: 3},
+		{ResourceVersion: "104", Generation: 4},
+		{ResourceVersion: "105", Generation: 5},
+	}
+	if !reflect.DeepEqual(history, expected) {
+		t.Errorf("expected bounded history %v, got %v", expected, history)
+	}
+	if history := x.History("unknown"); history != nil {
+		t.Errorf("expected nil history for unknown UID, got %v", history)
+	}
+}
+
+func TestTombstoneEviction(t *testing.T) {
+	x := New(2)
+	x.maxTombstones = 2
+
+	for i := 0; i < 4; i++ {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      fmt.Sprintf("pod-%d", i),
+			UID:       types.UID(fmt.Sprintf("uid-%d", i)),
+		}}
+		mustObserve(t, x, pod)
+		if err := x.Delete(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the two most recent tombstones survive.
+	if x.Len() != 2 {
+		t.Errorf("expected 2 retained identities, got %d", x.Len())
+	}
+	if history := x.History("uid-0"); history != nil {
+		t.Errorf("expected evicted tombstone uid-0, got %v", history)
+	}
+	if history := x.History("uid-3"); history == nil {
+		t.Error("expected retained tombstone uid-3")
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package union validates one-of union structs (VolumeSource,
+// ProbeHandler, EnvVarSource, ClaimSource, ...) by deriving the member
+// set from struct shape instead of a hand-maintained list: every exported
+// pointer field of a union struct is a member. Because the member set is
+// computed from the type at runtime, fields added in new releases are
+// covered automatically — the failure mode this replaces is union checks
+// that silently miss new members.
+package union
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Members returns the union member names of v's struct type, in field
+// order. Members are the exported pointer fields; names come from the
+// JSON tag when present. v may be a struct or pointer to struct.
+func Members(v interface{}) ([]string, error) {
+	t, _, err := structOf(v)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !isMember(field) {
+			continue
+		}
+		names = append(names, memberName(field))
+	}
+	return names, nil
+}
+
+// SetMembers returns the names of members that are non-nil in v, sorted.
+func SetMembers(v interface{}) ([]string, error) {
+	t, value, err := structOf(v)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !isMember(field) {
+			continue
+		}
+		if !value.Field(i).IsNil() {
+			names = append(names, memberName(field))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// WhichIsSet returns the single set member's name. It errors when no
+// member or more than one member is set.
+func WhichIsSet(v interface{}) (string, error) {
+	set, err := SetMembers(v)
+	if err != nil {
+		return "", err
+	}
+	switch len(set) {
+	case 1:
+		return set[0], nil
+	case 0:
+		return "", fmt.Errorf("%T: no union member is set", v)
+	default:
+		return "", fmt.Errorf("%T: multiple union members are set: %s", v, strings.Join(set, ", "))
+	}
+}
+
+// Validate enforces exactly-one-of across v's members.
+func Validate(v interface{}) error {
+	_, err := WhichIsSet(v)
+	return err
+}
+
+// ValidateOptional enforces at-most-one-of across v's members, for unions
+// where "none" is a valid state (e.g. an optional handler).
+func ValidateOptional(v interface{}) error {
+	set, err := SetMembers(v)
+	if err != nil {
+		return err
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("%T: multiple union members are set: %s", v, strings.Join(set, ", "))
+	}
+	return nil
+}
+
+// isMember reports whether the field participates in the union: exported
+// and pointer-typed. Non-pointer fields (discriminators, inlined scalars)
+// are not members.
+func isMember(field reflect.StructField) bool {
+	if field.PkgPath != "" {
+		return false
+	}
+	return field.Type.Kind() == reflect.Pointer
+}
+
+// memberName prefers the JSON wire name so errors match what users wrote.
+func memberName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag != "" {
+		if name := strings.Split(tag, ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func structOf(v interface{}) (reflect.Type, reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil, reflect.Value{}, fmt.Errorf("union value is nil")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, reflect.Value{}, fmt.Errorf("union value must be a struct, got %T", v)
+	}
+	return value.Type(), value, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package union
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestMembersFromStructShape(t *testing.T) {
+	members, err := Members(&v1.EnvVarSource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"fieldRef", "resourceFieldRef", "configMapKeyRef", "secretKeyRef"}
+	if len(members) != len(want) {
+		t.Fatalf("got members %v, want %v", members, want)
+	}
+	for i, name := range want {
+		if members[i] != name {
+			t.Errorf("member %d = %q, want %q", i, members[i], name)
+		}
+	}
+
+	// VolumeSource members are derived too — no hand-maintained list to
+	// fall out of date. Spot-check a few known members.
+	volumeMembers, err := Members(v1.VolumeSource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	have := map[string]bool{}
+	for _, name := range volumeMembers {
+		have[name] = true
+	}
+	for _, name := range []string{"hostPath", "emptyDir", "persistentVolumeClaim", "projected", "ephemeral"} {
+		if !have[name] {
+			t.Errorf("expected VolumeSource member %q in %v", name, volumeMembers)
+		}
+	}
+}
+
+func TestWhichIsSet(t *testing.T) {
+	source := &v1.EnvVarSource{
+		SecretKeyRef: &v1.SecretKeySelector{Key: "token"},
+	}
+	name, err := WhichIsSet(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "secretKeyRef" {
+		t.Errorf("got %q, want secretKeyRef", name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(&v1.VolumeSource{
+		EmptyDir: &v1.EmptyDirVolumeSource{},
+	}); err != nil {
+		t.Errorf("single member must validate: %v", err)
+	}
+
+	err := Validate(&v1.VolumeSource{})
+	if err == nil || !strings.Contains(err.Error(), "no union member") {
+		t.Errorf("expected no-member error, got %v", err)
+	}
+
+	err = Validate(&v1.VolumeSource{
+		EmptyDir: &v1.EmptyDirVolumeSource{},
+		HostPath: &v1.HostPathVolumeSource{Path: "/tmp"},
+	})
+	if err == nil {
+		t.Fatal("expected multi-member error")
+	}
+	// The error names the offending members by wire name.
+	if !strings.Contains(err.Error(), "emptyDir") || !strings.Contains(err.Error(), "hostPath") {
+		t.Errorf("expected members named in error, got %v", err)
+	}
+}
+
+func TestValidateOptional(t *testing.T) {
+	// ProbeHandler-style unions allow the empty state.
+	if err := ValidateOptional(&v1.LifecycleHandler{}); err != nil {
+		t.Errorf("empty optional union must validate: %v", err)
+	}
+	if err := ValidateOptional(&v1.LifecycleHandler{
+		Exec:    &v1.ExecAction{Command: []string{"true"}},
+		HTTPGet: &v1.HTTPGetAction{},
+	}); err == nil {
+		t.Error("expected multi-member error")
+	}
+}
+
+func TestNonStructRejected(t *testing.T) {
+	if _, err := Members(42); err == nil {
+		t.Error("expected error for non-struct")
+	}
+	var nilSource *v1.EnvVarSource
+	if _, err := SetMembers(nilSource); err == nil {
+		t.Error("expected error for nil pointer")
+	}
+}
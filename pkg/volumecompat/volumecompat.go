@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package volumecompat presents persistent volumes that still carry a
+// deprecated in-tree source (GCEPersistentDisk, AWSElasticBlockStore,
+// Cinder, AzureDisk, ...) in their modern CSI form, and CSI volumes in
+// their legacy form, using the same csi-translation-lib rules the
+// kubelet and controllers apply during CSI migration. Tools reading
+// mixed-age cluster dumps can then show one representation throughout.
+package volumecompat
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	csitrans "k8s.io/csi-translation-lib"
+)
+
+var translator = csitrans.New()
+
+// Translation is the result of converting a PV between its in-tree and
+// CSI representations.
+type Translation struct {
+	// PV is the translated volume. The input is never modified.
+	PV *v1.PersistentVolume
+	// InTreePlugin is the legacy plugin name, e.g.
+	// "kubernetes.io/aws-ebs".
+	InTreePlugin string
+	// CSIDriver is the superseding CSI driver name, e.g.
+	// "ebs.csi.aws.com".
+	CSIDriver string
+	// AlreadyModern is true when the input already had a CSI source
+	// and no translation was needed.
+	AlreadyModern bool
+}
+
+// ToCSI returns the CSI representation of the volume. A PV that
+// already has a CSI source is returned as-is; a PV whose in-tree
+// source has no migration logic is an error.
+func ToCSI(pv *v1.PersistentVolume) (*Translation, error) {
+	if pv == nil {
+		return nil, fmt.Errorf("persistent volume was nil")
+	}
+	if pv.Spec.CSI != nil {
+		result := &Translation{PV: pv, CSIDriver: pv.Spec.CSI.Driver, AlreadyModern: true}
+		if inTree, err := translator.GetInTreeNameFromCSIName(pv.Spec.CSI.Driver); err == nil {
+			result.InTreePlugin = inTree
+		}
+		return result, nil
+	}
+	if !translator.IsPVMigratable(pv) {
+		return nil, fmt.Errorf("volume %s has no CSI translation for its source", pv.Name)
+	}
+	inTree, err := translator.GetInTreePluginNameFromSpec(pv, nil)
+	if err != nil {
+		return nil, err
+	}
+	translated, err := translator.TranslateInTreePVToCSI(pv)
+	if err != nil {
+		return nil, fmt.Errorf("translating volume %s: %v", pv.Name, err)
+	}
+	driver, err := translator.GetCSINameFromInTreeName(inTree)
+	if err != nil {
+		return nil, err
+	}
+	return &Translation{PV: translated, InTreePlugin: inTree, CSIDriver: driver}, nil
+}
+
+// ToInTree returns the legacy in-tree representation of a CSI volume,
+// for display alongside volumes that never migrated. A PV that already
+// has an in-tree source is returned as-is.
+func ToInTree(pv *v1.PersistentVolume) (*Translation, error) {
+	if pv == nil {
+		return nil, fmt.Errorf("persistent volume was nil")
+	}
+	if pv.Spec.CSI == nil {
+		result := &Translation{PV: pv}
+		if inTree, err := translator.GetInTreePluginNameFromSpec(pv, nil); err == nil {
+			result.InTreePlugin = inTree
+			result.CSIDriver, _ = translator.GetCSINameFromInTreeName(inTree)
+		}
+		return result, nil
+	}
+	driver := pv.Spec.CSI.Driver
+	inTree, err := translator.GetInTreeNameFromCSIName(driver)
+	if err != nil {
+		return nil, fmt.Errorf("volume %s: driver %s has no in-tree equivalent", pv.Name, driver)
+	}
+	translated, err := translator.TranslateCSIPVToInTree(pv)
+	if err != nil {
+		return nil, fmt.Errorf("translating volume %s: %v", pv.Name, err)
+	}
+	return &Translation{PV: translated, InTreePlugin: inTree, CSIDriver: driver, AlreadyModern: true}, nil
+}
+
+// Describe returns a one-line summary of how the volume would be
+// presented, e.g. "kubernetes.io/gce-pd (migrated to pd.csi.storage.gke.io)".
+func Describe(pv *v1.PersistentVolume) string {
+	if pv == nil {
+		return ""
+	}
+	if pv.Spec.CSI != nil {
+		if inTree, err := translator.GetInTreeNameFromCSIName(pv.Spec.CSI.Driver); err == nil {
+			return fmt.Sprintf("%s (formerly %s)", pv.Spec.CSI.Driver, inTree)
+		}
+		return pv.Spec.CSI.Driver
+	}
+	if inTree, err := translator.GetInTreePluginNameFromSpec(pv, nil); err == nil {
+		if driver, err := translator.GetCSINameFromInTreeName(inTree); err == nil {
+			return fmt.Sprintf("%s (migrated to %s)", inTree, driver)
+		}
+		return inTree
+	}
+	return "unrecognized source"
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumecompat
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func ebsPV() *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-ebs"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				AWSElasticBlockStore: &v1.AWSElasticBlockStoreVolumeSource{
+					VolumeID: "vol-0123456789abcdef0",
+					FSType:   "ext4",
+				},
+			},
+		},
+	}
+}
+
+func TestToCSI(t *testing.T) {
+	original := ebsPV()
+	result, err := ToCSI(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.InTreePlugin != "kubernetes.io/aws-ebs" || result.CSIDriver != "ebs.csi.aws.com" {
+		t.Errorf("names: %+v", result)
+	}
+	if result.PV.Spec.CSI == nil || result.PV.Spec.CSI.VolumeHandle != "vol-0123456789abcdef0" {
+		t.Errorf("CSI source: %+v", result.PV.Spec.PersistentVolumeSource)
+	}
+	if original.Spec.AWSElasticBlockStore == nil {
+		t.Error("input must not be modified")
+	}
+
+	// Already-CSI volumes pass through.
+	again, err := ToCSI(result.PV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.AlreadyModern || again.InTreePlugin != "kubernetes.io/aws-ebs" {
+		t.Errorf("pass-through: %+v", again)
+	}
+}
+
+func TestToCSIUntranslatable(t *testing.T) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-local"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				HostPath: &v1.HostPathVolumeSource{Path: "/data"},
+			},
+		},
+	}
+	if _, err := ToCSI(pv); err == nil {
+		t.Error("hostPath has no CSI translation and should error")
+	}
+}
+
+func TestToInTreeRoundTrip(t *testing.T) {
+	modern, err := ToCSI(ebsPV())
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := ToInTree(modern.PV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy.PV.Spec.AWSElasticBlockStore == nil {
+		t.Fatalf("reverse translation: %+v", legacy.PV.Spec.PersistentVolumeSource)
+	}
+	if legacy.PV.Spec.AWSElasticBlockStore.VolumeID != "vol-0123456789abcdef0" {
+		t.Errorf("volume ID: %q", legacy.PV.Spec.AWSElasticBlockStore.VolumeID)
+	}
+
+	unknown := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-custom"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: "example.com/custom", VolumeHandle: "h"},
+			},
+		},
+	}
+	if _, err := ToInTree(unknown); err == nil {
+		t.Error("driver without in-tree equivalent should error")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if got := Describe(ebsPV()); !strings.Contains(got, "kubernetes.io/aws-ebs") || !strings.Contains(got, "ebs.csi.aws.com") {
+		t.Errorf("Describe in-tree = %q", got)
+	}
+	modern, err := ToCSI(ebsPV())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Describe(modern.PV); !strings.Contains(got, "formerly") {
+		t.Errorf("Describe CSI = %q", got)
+	}
+}
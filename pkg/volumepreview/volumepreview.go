@@ -0,0 +1,242 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package volumepreview renders the file tree a secret, configMap,
+// downwardAPI or projected volume would mount, from the objects alone:
+// keyToPath mappings, per-item and default modes, optional handling and
+// projected path collisions all behave as the kubelet's mounters do.
+// The one thing it cannot produce is a serviceAccountToken, which is
+// minted at mount time; such paths are listed with empty content.
+package volumepreview
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/downward"
+)
+
+// File is one entry of the rendered tree.
+type File struct {
+	// Content is the file's bytes; nil for a serviceAccountToken path.
+	Content []byte
+	// Mode is the file mode the mounter would set.
+	Mode int32
+	// Source names where the content came from, e.g. "secret/creds
+	// key tls.key" or "downwardAPI metadata.labels".
+	Source string
+}
+
+// Tree maps volume-relative paths to files.
+type Tree map[string]File
+
+// Paths returns the tree's paths, sorted.
+func (t Tree) Paths() []string {
+	paths := make([]string, 0, len(t))
+	for p := range t {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+const defaultMode = int32(0644)
+
+// Preview renders the volume. The pod supplies downwardAPI values;
+// configMaps and secrets are the referenced objects by name. An
+// optional reference to a missing object yields an empty tree.
+func Preview(pod *v1.Pod, volume v1.Volume, configMaps map[string]*v1.ConfigMap, secrets map[string]*v1.Secret) (Tree, error) {
+	switch {
+	case volume.Secret != nil:
+		return previewSecret(volume.Secret, secrets)
+	case volume.ConfigMap != nil:
+		return previewConfigMap(volume.ConfigMap, configMaps)
+	case volume.DownwardAPI != nil:
+		return previewDownwardAPI(pod, volume.DownwardAPI.Items, volume.DownwardAPI.DefaultMode)
+	case volume.Projected != nil:
+		return previewProjected(pod, volume.Projected, configMaps, secrets)
+	default:
+		return nil, fmt.Errorf("volume %s is not a secret, configMap, downwardAPI or projected volume", volume.Name)
+	}
+}
+
+func previewSecret(source *v1.SecretVolumeSource, secrets map[string]*v1.Secret) (Tree, error) {
+	secret, ok := secrets[source.SecretName]
+	if !ok {
+		if optional(source.Optional) {
+			return Tree{}, nil
+		}
+		return nil, fmt.Errorf("secret %q not found", source.SecretName)
+	}
+	data := make(map[string][]byte, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	return renderKeys(data, source.Items, source.DefaultMode, optional(source.Optional), "secret/"+source.SecretName)
+}
+
+func previewConfigMap(source *v1.ConfigMapVolumeSource, configMaps map[string]*v1.ConfigMap) (Tree, error) {
+	configMap, ok := configMaps[source.Name]
+	if !ok {
+		if optional(source.Optional) {
+			return Tree{}, nil
+		}
+		return nil, fmt.Errorf("configmap %q not found", source.Name)
+	}
+	data := make(map[string][]byte, len(configMap.Data)+len(configMap.BinaryData))
+	for key, value := range configMap.Data {
+		data[key] = []byte(value)
+	}
+	for key, value := range configMap.BinaryData {
+		data[key] = value
+	}
+	return renderKeys(data, source.Items, source.DefaultMode, optional(source.Optional), "configmap/"+source.Name)
+}
+
+// renderKeys applies the shared keyToPath semantics: no items mounts
+// every key under its own name; items select and rename, and a missing
+// selected key is an error unless the source is optional.
+func renderKeys(data map[string][]byte, items []v1.KeyToPath, mode *int32, sourceOptional bool, source string) (Tree, error) {
+	tree := Tree{}
+	if len(items) == 0 {
+		for key, value := range data {
+			tree[key] = File{Content: value, Mode: fileMode(nil, mode), Source: source + " key " + key}
+		}
+		return tree, nil
+	}
+	for _, item := range items {
+		value, ok := data[item.Key]
+		if !ok {
+			if sourceOptional {
+				continue
+			}
+			return nil, fmt.Errorf("%s has no key %q", source, item.Key)
+		}
+		target, err := cleanPath(item.Path)
+		if err != nil {
+			return nil, fmt.Errorf("item for key %q: %v", item.Key, err)
+		}
+		tree[target] = File{Content: value, Mode: fileMode(item.Mode, mode), Source: source + " key " + item.Key}
+	}
+	return tree, nil
+}
+
+func previewDownwardAPI(pod *v1.Pod, items []v1.DownwardAPIVolumeFile, mode *int32) (Tree, error) {
+	tree := Tree{}
+	for _, item := range items {
+		target, err := cleanPath(item.Path)
+		if err != nil {
+			return nil, err
+		}
+		var value string
+		var source string
+		switch {
+		case item.FieldRef != nil:
+			value, err = downward.EvalVolumeFieldRef(pod, item.FieldRef)
+			source = "downwardAPI " + item.FieldRef.FieldPath
+		case item.ResourceFieldRef != nil:
+			value, err = downward.EvalResourceFieldRef(pod, item.ResourceFieldRef.ContainerName, item.ResourceFieldRef, nil)
+			source = "downwardAPI " + item.ResourceFieldRef.Resource
+		default:
+			err = fmt.Errorf("item %s has neither fieldRef nor resourceFieldRef", item.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		tree[target] = File{Content: []byte(value), Mode: fileMode(item.Mode, mode), Source: source}
+	}
+	return tree, nil
+}
+
+func previewProjected(pod *v1.Pod, projected *v1.ProjectedVolumeSource, configMaps map[string]*v1.ConfigMap, secrets map[string]*v1.Secret) (Tree, error) {
+	tree := Tree{}
+	for i, source := range projected.Sources {
+		var part Tree
+		var err error
+		switch {
+		case source.Secret != nil:
+			part, err = previewSecret(&v1.SecretVolumeSource{
+				SecretName:  source.Secret.Name,
+				Items:       source.Secret.Items,
+				Optional:    source.Secret.Optional,
+				DefaultMode: projected.DefaultMode,
+			}, secrets)
+		case source.ConfigMap != nil:
+			part, err = previewConfigMap(&v1.ConfigMapVolumeSource{
+				LocalObjectReference: source.ConfigMap.LocalObjectReference,
+				Items:                source.ConfigMap.Items,
+				Optional:             source.ConfigMap.Optional,
+				DefaultMode:          projected.DefaultMode,
+			}, configMaps)
+		case source.DownwardAPI != nil:
+			part, err = previewDownwardAPI(pod, source.DownwardAPI.Items, projected.DefaultMode)
+		case source.ServiceAccountToken != nil:
+			target, cleanErr := cleanPath(source.ServiceAccountToken.Path)
+			if cleanErr != nil {
+				err = cleanErr
+				break
+			}
+			part = Tree{target: File{
+				Mode:   fileMode(nil, projected.DefaultMode),
+				Source: "serviceAccountToken (minted at mount time)",
+			}}
+		default:
+			err = fmt.Errorf("empty projected source")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("projected source %d: %v", i, err)
+		}
+		for target, file := range part {
+			if existing, ok := tree[target]; ok {
+				return nil, fmt.Errorf("projected path %q provided by both %s and %s", target, existing.Source, file.Source)
+			}
+			tree[target] = file
+		}
+	}
+	return tree, nil
+}
+
+// cleanPath enforces the API's rules for volume-relative paths.
+func cleanPath(p string) (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("path must not be empty")
+	}
+	if path.IsAbs(p) {
+		return "", fmt.Errorf("path %q must be relative", p)
+	}
+	cleaned := path.Clean(p)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("path %q must not escape the volume", p)
+	}
+	return cleaned, nil
+}
+
+func fileMode(itemMode, volumeDefault *int32) int32 {
+	if itemMode != nil {
+		return *itemMode
+	}
+	if volumeDefault != nil {
+		return *volumeDefault
+	}
+	return defaultMode
+}
+
+func optional(flag *bool) bool {
+	return flag != nil && *flag
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumepreview
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+func boolPtr(b bool) *bool    { return &b }
+
+func previewPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "web",
+			Labels: map[string]string{"app": "web"},
+		},
+	}
+}
+
+func testConfigMaps() map[string]*v1.ConfigMap {
+	return map[string]*v1.ConfigMap{
+		"app-config": {
+			Data:       map[string]string{"config.yaml": "debug: true", "extra": "x"},
+			BinaryData: map[string][]byte{"blob.bin": {0x1, 0x2}},
+		},
+	}
+}
+
+func testSecrets() map[string]*v1.Secret {
+	return map[string]*v1.Secret{
+		"tls": {Data: map[string][]byte{"tls.crt": []byte("CERT"), "tls.key": []byte("KEY")}},
+	}
+}
+
+func TestPreviewSecretVolume(t *testing.T) {
+	volume := v1.Volume{Name: "tls", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{
+		SecretName:  "tls",
+		DefaultMode: int32Ptr(0600),
+		Items: []v1.KeyToPath{
+			{Key: "tls.crt", Path: "certs/server.crt"},
+			{Key: "tls.key", Path: "certs/server.key", Mode: int32Ptr(0400)},
+		},
+	}}}
+	tree, err := Preview(previewPod(), volume, nil, testSecrets())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tree.Paths(), []string{"certs/server.crt", "certs/server.key"}) {
+		t.Fatalf("paths: %v", tree.Paths())
+	}
+	crt := tree["certs/server.crt"]
+	if string(crt.Content) != "CERT" || crt.Mode != 0600 {
+		t.Errorf("server.crt: %+v", crt)
+	}
+	if key := tree["certs/server.key"]; key.Mode != 0400 {
+		t.Errorf("item mode should override default: %+v", key)
+	}
+}
+
+func TestPreviewConfigMapVolume(t *testing.T) {
+	volume := v1.Volume{Name: "cfg", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+		LocalObjectReference: v1.LocalObjectReference{Name: "app-config"},
+	}}}
+	tree, err := Preview(previewPod(), volume, testConfigMaps(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No items mounts every key, including binaryData, at 0644.
+	if len(tree) != 3 {
+		t.Fatalf("paths: %v", tree.Paths())
+	}
+	if file := tree["config.yaml"]; string(file.Content) != "debug: true" || file.Mode != 0644 {
+		t.Errorf("config.yaml: %+v", file)
+	}
+	if file := tree["blob.bin"]; len(file.Content) != 2 {
+		t.Errorf("blob.bin: %+v", file)
+	}
+}
+
+func TestPreviewOptionalHandling(t *testing.T) {
+	missingRequired := v1.Volume{Name: "cfg", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+		LocalObjectReference: v1.LocalObjectReference{Name: "nope"},
+	}}}
+	if _, err := Preview(previewPod(), missingRequired, nil, nil); err == nil {
+		t.Error("missing required configmap should error")
+	}
+
+	missingOptional := v1.Volume{Name: "cfg", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+		LocalObjectReference: v1.LocalObjectReference{Name: "nope"},
+		Optional:             boolPtr(true),
+	}}}
+	tree, err := Preview(previewPod(), missingOptional, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 0 {
+		t.Errorf("optional missing source should mount nothing: %v", tree.Paths())
+	}
+
+	badKey := v1.Volume{Name: "cfg", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+		LocalObjectReference: v1.LocalObjectReference{Name: "app-config"},
+		Items:                []v1.KeyToPath{{Key: "absent", Path: "x"}},
+	}}}
+	if _, err := Preview(previewPod(), badKey, testConfigMaps(), nil); err == nil || !strings.Contains(err.Error(), "absent") {
+		t.Errorf("missing selected key: %v", err)
+	}
+}
+
+func TestPreviewProjectedVolume(t *testing.T) {
+	volume := v1.Volume{Name: "bundle", VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{
+		DefaultMode: int32Ptr(0640),
+		Sources: []v1.VolumeProjection{
+			{ConfigMap: &v1.ConfigMapProjection{
+				LocalObjectReference: v1.LocalObjectReference{Name: "app-config"},
+				Items:                []v1.KeyToPath{{Key: "config.yaml", Path: "config.yaml"}},
+			}},
+			{Secret: &v1.SecretProjection{
+				LocalObjectReference: v1.LocalObjectReference{Name: "tls"},
+				Items:                []v1.KeyToPath{{Key: "tls.crt", Path: "ca.crt"}},
+			}},
+			{DownwardAPI: &v1.DownwardAPIProjection{Items: []v1.DownwardAPIVolumeFile{{
+				Path:     "labels",
+				FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.labels"},
+			}}}},
+			{ServiceAccountToken: &v1.ServiceAccountTokenProjection{Path: "token"}},
+		},
+	}}}
+	tree, err := Preview(previewPod(), volume, testConfigMaps(), testSecrets())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ca.crt", "config.yaml", "labels", "token"}
+	if !reflect.DeepEqual(tree.Paths(), want) {
+		t.Fatalf("paths: %v", tree.Paths())
+	}
+	if file := tree["labels"]; string(file.Content) != `app="web"` || file.Mode != 0640 {
+		t.Errorf("labels: %+v", file)
+	}
+	token := tree["token"]
+	if token.Content != nil || !strings.Contains(token.Source, "minted at mount time") {
+		t.Errorf("token: %+v", token)
+	}
+}
+
+func TestPreviewProjectedCollision(t *testing.T) {
+	volume := v1.Volume{Name: "bundle", VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{
+		Sources: []v1.VolumeProjection{
+			{ConfigMap: &v1.ConfigMapProjection{
+				LocalObjectReference: v1.LocalObjectReference{Name: "app-config"},
+				Items:                []v1.KeyToPath{{Key: "extra", Path: "same"}},
+			}},
+			{Secret: &v1.SecretProjection{
+				LocalObjectReference: v1.LocalObjectReference{Name: "tls"},
+				Items:                []v1.KeyToPath{{Key: "tls.crt", Path: "same"}},
+			}},
+		},
+	}}}
+	if _, err := Preview(previewPod(), volume, testConfigMaps(), testSecrets()); err == nil || !strings.Contains(err.Error(), "same") {
+		t.Errorf("collision: %v", err)
+	}
+}
+
+func TestPreviewRejectsEscapingPaths(t *testing.T) {
+	volume := v1.Volume{Name: "cfg", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+		LocalObjectReference: v1.LocalObjectReference{Name: "app-config"},
+		Items:                []v1.KeyToPath{{Key: "extra", Path: "../escape"}},
+	}}}
+	if _, err := Preview(previewPod(), volume, testConfigMaps(), nil); err == nil {
+		t.Error("paths escaping the volume must be rejected")
+	}
+}
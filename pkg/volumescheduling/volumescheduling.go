@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package volumescheduling evaluates a PersistentVolume's node affinity
+// against a Node and says why each selector term did or did not match.
+// The scheduler only reports the boolean; when a PV refuses to attach,
+// the per-requirement reasons here point at the label or value that is
+// off.
+package volumescheduling
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// TermResult explains one NodeSelectorTerm. Terms are ORed, so a
+// single matching term attaches the volume.
+type TermResult struct {
+	// Index is the term's position in Required.NodeSelectorTerms.
+	Index int
+	// Matches reports whether every requirement in the term held.
+	Matches bool
+	// Reasons holds one line per failed requirement; empty on match.
+	Reasons []string
+}
+
+// CheckVolumeNodeAffinity evaluates the PV's spec.nodeAffinity against
+// the node. A PV without node affinity matches every node.
+func CheckVolumeNodeAffinity(pv *v1.PersistentVolume, node *v1.Node) (bool, []TermResult) {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return true, nil
+	}
+	return CheckNodeSelector(pv.Spec.NodeAffinity.Required, node)
+}
+
+// CheckNodeSelector evaluates the selector's terms against the node.
+func CheckNodeSelector(selector *v1.NodeSelector, node *v1.Node) (bool, []TermResult) {
+	results := make([]TermResult, 0, len(selector.NodeSelectorTerms))
+	matched := false
+	for i, term := range selector.NodeSelectorTerms {
+		result := TermResult{Index: i}
+		for _, req := range term.MatchExpressions {
+			if reason := checkRequirement(req, node.Labels); reason != "" {
+				result.Reasons = append(result.Reasons, reason)
+			}
+		}
+		for _, req := range term.MatchFields {
+			if reason := checkFieldRequirement(req, node); reason != "" {
+				result.Reasons = append(result.Reasons, reason)
+			}
+		}
+		result.Matches = len(result.Reasons) == 0
+		if result.Matches {
+			matched = true
+		}
+		results = append(results, result)
+	}
+	return matched, results
+}
+
+// Explain renders the term results as one line per failed requirement,
+// suitable for an event message or CLI output.
+func Explain(results []TermResult) string {
+	var lines []string
+	for _, result := range results {
+		if result.Matches {
+			lines = append(lines, fmt.Sprintf("term %d: matches", result.Index))
+			continue
+		}
+		for _, reason := range result.Reasons {
+			lines = append(lines, fmt.Sprintf("term %d: %s", result.Index, reason))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func checkRequirement(req v1.NodeSelectorRequirement, labels map[string]string) string {
+	value, present := labels[req.Key]
+	switch req.Operator {
+	case v1.NodeSelectorOpIn:
+		if !present {
+			return fmt.Sprintf("label %s not present, requirement In %v", req.Key, req.Values)
+		}
+		if !contains(req.Values, value) {
+			return fmt.Sprintf("label %s has value %q, requirement In %v", req.Key, value, req.Values)
+		}
+	case v1.NodeSelectorOpNotIn:
+		if present && contains(req.Values, value) {
+			return fmt.Sprintf("label %s has value %q, requirement NotIn %v", req.Key, value, req.Values)
+		}
+	case v1.NodeSelectorOpExists:
+		if !present {
+			return fmt.Sprintf("label %s not present, requirement Exists", req.Key)
+		}
+	case v1.NodeSelectorOpDoesNotExist:
+		if present {
+			return fmt.Sprintf("label %s present with value %q, requirement DoesNotExist", req.Key, value)
+		}
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		return checkNumericRequirement(req, value, present)
+	default:
+		return fmt.Sprintf("label %s: unknown operator %q", req.Key, req.Operator)
+	}
+	return ""
+}
+
+// checkNumericRequirement handles Gt and Lt, which compare a single
+// integer value against an integer-valued label.
+func checkNumericRequirement(req v1.NodeSelectorRequirement, value string, present bool) string {
+	if len(req.Values) != 1 {
+		return fmt.Sprintf("label %s: %s requires exactly one value, got %v", req.Key, req.Operator, req.Values)
+	}
+	if !present {
+		return fmt.Sprintf("label %s not present, requirement %s %s", req.Key, req.Operator, req.Values[0])
+	}
+	bound, err := strconv.ParseInt(req.Values[0], 10, 64)
+	if err != nil {
+		return fmt.Sprintf("label %s: requirement value %q is not an integer", req.Key, req.Values[0])
+	}
+	labelValue, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Sprintf("label %s has non-integer value %q, requirement %s %d", req.Key, value, req.Operator, bound)
+	}
+	if req.Operator == v1.NodeSelectorOpGt && labelValue <= bound {
+		return fmt.Sprintf("label %s has value %d, requirement Gt %d", req.Key, labelValue, bound)
+	}
+	if req.Operator == v1.NodeSelectorOpLt && labelValue >= bound {
+		return fmt.Sprintf("label %s has value %d, requirement Lt %d", req.Key, labelValue, bound)
+	}
+	return ""
+}
+
+// checkFieldRequirement handles matchFields; metadata.name is the only
+// field selector the API validates.
+func checkFieldRequirement(req v1.NodeSelectorRequirement, node *v1.Node) string {
+	if req.Key != "metadata.name" {
+		return fmt.Sprintf("field %s: unsupported field selector", req.Key)
+	}
+	switch req.Operator {
+	case v1.NodeSelectorOpIn:
+		if !contains(req.Values, node.Name) {
+			return fmt.Sprintf("node name %q, requirement In %v", node.Name, req.Values)
+		}
+	case v1.NodeSelectorOpNotIn:
+		if contains(req.Values, node.Name) {
+			return fmt.Sprintf("node name %q, requirement NotIn %v", node.Name, req.Values)
+		}
+	default:
+		return fmt.Sprintf("field %s: operator %s not supported for fields", req.Key, req.Operator)
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumescheduling
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func node(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func pvWithTerms(terms ...v1.NodeSelectorTerm) *v1.PersistentVolume {
+	return &v1.PersistentVolume{Spec: v1.PersistentVolumeSpec{
+		NodeAffinity: &v1.VolumeNodeAffinity{
+			Required: &v1.NodeSelector{NodeSelectorTerms: terms},
+		},
+	}}
+}
+
+func TestCheckVolumeNodeAffinity(t *testing.T) {
+	zoneNode := node("node-1", map[string]string{
+		"topology.kubernetes.io/zone": "us-east-1a",
+		"disks":                       "4",
+	})
+	testCases := []struct {
+		name       string
+		pv         *v1.PersistentVolume
+		node       *v1.Node
+		want       bool
+		wantReason string
+	}{
+		{
+			name: "no affinity matches everything",
+			pv:   &v1.PersistentVolume{},
+			node: zoneNode,
+			want: true,
+		},
+		{
+			name: "zone In matches",
+			pv: pvWithTerms(v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+				Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"us-east-1a", "us-east-1b"},
+			}}}),
+			node: zoneNode,
+			want: true,
+		},
+		{
+			name: "zone In mismatch names the value",
+			pv: pvWithTerms(v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+				Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"us-west-2a"},
+			}}}),
+			node:       zoneNode,
+			want:       false,
+			wantReason: `has value "us-east-1a"`,
+		},
+		{
+			name: "missing label named in reason",
+			pv: pvWithTerms(v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+				Key: "topology.kubernetes.io/region", Operator: v1.NodeSelectorOpExists,
+			}}}),
+			node:       zoneNode,
+			want:       false,
+			wantReason: "not present",
+		},
+		{
+			name: "Gt compares integers",
+			pv: pvWithTerms(v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+				Key: "disks", Operator: v1.NodeSelectorOpGt, Values: []string{"2"},
+			}}}),
+			node: zoneNode,
+			want: true,
+		},
+		{
+			name: "Lt failure reports both numbers",
+			pv: pvWithTerms(v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+				Key: "disks", Operator: v1.NodeSelectorOpLt, Values: []string{"4"},
+			}}}),
+			node:       zoneNode,
+			want:       false,
+			wantReason: "requirement Lt 4",
+		},
+		{
+			name: "Gt against a non-integer label",
+			pv: pvWithTerms(v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+				Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpGt, Values: []string{"2"},
+			}}}),
+			node:       zoneNode,
+			want:       false,
+			wantReason: "non-integer value",
+		},
+		{
+			name: "terms are ORed",
+			pv: pvWithTerms(
+				v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+					Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"us-west-2a"},
+				}}},
+				v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{
+					Key: "disks", Operator: v1.NodeSelectorOpExists,
+				}}},
+			),
+			node: zoneNode,
+			want: true,
+		},
+		{
+			name: "matchFields on node name",
+			pv: pvWithTerms(v1.NodeSelectorTerm{MatchFields: []v1.NodeSelectorRequirement{{
+				Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node-2"},
+			}}}),
+			node:       zoneNode,
+			want:       false,
+			wantReason: `node name "node-1"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, results := CheckVolumeNodeAffinity(tc.pv, tc.node)
+			if matches != tc.want {
+				t.Errorf("matches = %v, want %v\n%s", matches, tc.want, Explain(results))
+			}
+			if tc.wantReason != "" && !strings.Contains(Explain(results), tc.wantReason) {
+				t.Errorf("explanation %q does not contain %q", Explain(results), tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestExplainListsEveryFailedRequirement(t *testing.T) {
+	pv := pvWithTerms(v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+		{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+		{Key: "region", Operator: v1.NodeSelectorOpExists},
+	}})
+	matches, results := CheckVolumeNodeAffinity(pv, node("n", nil))
+	if matches {
+		t.Fatal("expected mismatch")
+	}
+	if len(results) != 1 || len(results[0].Reasons) != 2 {
+		t.Errorf("expected both requirements explained, got %+v", results)
+	}
+}
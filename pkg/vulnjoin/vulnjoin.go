@@ -0,0 +1,345 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vulnjoin correlates externally produced image scan results with
+// the image inventory derived from running pods. Scan results are keyed by
+// image digest (the only stable join key across registries and tags) and
+// joined against container status ImageIDs, yielding per-namespace and
+// per-workload exposure reports. No scanning happens in this package;
+// results come in as Trivy or Grype JSON.
+package vulnjoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Severity is a normalized vulnerability severity.
+type Severity string
+
+const (
+	SeverityCritical   Severity = "Critical"
+	SeverityHigh       Severity = "High"
+	SeverityMedium     Severity = "Medium"
+	SeverityLow        Severity = "Low"
+	SeverityNegligible Severity = "Negligible"
+	SeverityUnknown    Severity = "Unknown"
+)
+
+// severityRank orders severities for sorting, most severe first.
+var severityRank = map[Severity]int{
+	SeverityCritical:   0,
+	SeverityHigh:       1,
+	SeverityMedium:     2,
+	SeverityLow:        3,
+	SeverityNegligible: 4,
+	SeverityUnknown:    5,
+}
+
+// normalizeSeverity maps scanner spellings onto the Severity constants.
+func normalizeSeverity(s string) Severity {
+	switch strings.ToLower(s) {
+	case "critical":
+		return SeverityCritical
+	case "high":
+		return SeverityHigh
+	case "medium", "moderate":
+		return SeverityMedium
+	case "low":
+		return SeverityLow
+	case "negligible":
+		return SeverityNegligible
+	default:
+		return SeverityUnknown
+	}
+}
+
+// Vulnerability is one finding from a scan.
+type Vulnerability struct {
+	// ID is the advisory identifier, e.g. CVE-2023-1234.
+	ID       string
+	Severity Severity
+	// Package and InstalledVersion identify the affected artifact.
+	Package          string
+	InstalledVersion string
+	// FixedVersion is empty when no fix is available.
+	FixedVersion string
+}
+
+// ScanResult is the parsed scan output for one image.
+type ScanResult struct {
+	// Digest is the image digest, e.g. sha256:abc...; the join key.
+	Digest          string
+	Vulnerabilities []Vulnerability
+}
+
+// WorkloadExposure aggregates findings for one top-level workload.
+type WorkloadExposure struct {
+	Namespace string
+	// Kind and Name identify the controlling workload from the pod's
+	// owner reference, or Kind "Pod" for bare pods.
+	Kind string
+	Name string
+	// Images are the scanned digests this workload runs.
+	Images []string
+	// Counts is the number of findings per severity across those images.
+	Counts map[Severity]int
+}
+
+// NamespaceExposure aggregates findings for one namespace.
+type NamespaceExposure struct {
+	Namespace string
+	Counts    map[Severity]int
+}
+
+// Report is the joined exposure view.
+type Report struct {
+	Namespaces []NamespaceExposure
+	Workloads  []WorkloadExposure
+	// Unscanned lists running image digests with no scan result, so
+	// callers can tell "no findings" from "no data".
+	Unscanned []string
+}
+
+// Join correlates scan results with the pods' running images.
+func Join(pods []*v1.Pod, scans []ScanResult) Report {
+	scansByDigest := map[string]ScanResult{}
+	for _, scan := range scans {
+		scansByDigest[scan.Digest] = scan
+	}
+
+	type workloadKey struct {
+		namespace, kind, name string
+	}
+	workloads := map[workloadKey]*WorkloadExposure{}
+	namespaces := map[string]map[Severity]int{}
+	unscanned := map[string]bool{}
+
+	for _, pod := range pods {
+		kind, name := ownerWorkload(pod)
+		key := workloadKey{pod.Namespace, kind, name}
+
+		statuses := append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			digest := DigestFromImageID(status.ImageID)
+			if digest == "" {
+				continue
+			}
+			scan, scanned := scansByDigest[digest]
+			if !scanned {
+				unscanned[digest] = true
+				continue
+			}
+
+			exposure := workloads[key]
+			if exposure == nil {
+				exposure = &WorkloadExposure{
+					Namespace: pod.Namespace,
+					Kind:      kind,
+					Name:      name,
+					Counts:    map[Severity]int{},
+				}
+				workloads[key] = exposure
+			}
+			if !containsString(exposure.Images, digest) {
+				exposure.Images = append(exposure.Images, digest)
+				for _, vuln := range scan.Vulnerabilities {
+					exposure.Counts[vuln.Severity]++
+					if namespaces[pod.Namespace] == nil {
+						namespaces[pod.Namespace] = map[Severity]int{}
+					}
+					namespaces[pod.Namespace][vuln.Severity]++
+				}
+			}
+		}
+	}
+
+	report := Report{}
+	for _, exposure := range workloads {
+		sort.Strings(exposure.Images)
+		report.Workloads = append(report.Workloads, *exposure)
+	}
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i], report.Workloads[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	for namespace, counts := range namespaces {
+		report.Namespaces = append(report.Namespaces, NamespaceExposure{Namespace: namespace, Counts: counts})
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+	for digest := range unscanned {
+		report.Unscanned = append(report.Unscanned, digest)
+	}
+	sort.Strings(report.Unscanned)
+	return report
+}
+
+// ownerWorkload resolves a pod to its controlling workload identity; bare
+// pods count as their own workload.
+func ownerWorkload(pod *v1.Pod) (kind, name string) {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return ref.Kind, ref.Name
+		}
+	}
+	return "Pod", pod.Name
+}
+
+// DigestFromImageID extracts the sha256 digest from a container status
+// ImageID, tolerating the docker-pullable:// prefix and plain digests.
+func DigestFromImageID(imageID string) string {
+	if i := strings.Index(imageID, "@"); i >= 0 {
+		imageID = imageID[i+1:]
+	}
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+	return ""
+}
+
+// SortVulnerabilities orders findings most severe first, then by ID, for
+// stable presentation.
+func SortVulnerabilities(vulns []Vulnerability) {
+	sort.Slice(vulns, func(i, j int) bool {
+		if severityRank[vulns[i].Severity] != severityRank[vulns[j].Severity] {
+			return severityRank[vulns[i].Severity] < severityRank[vulns[j].Severity]
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+}
+
+// trivyReport mirrors the subset of Trivy's JSON output we consume.
+type trivyReport struct {
+	Metadata struct {
+		RepoDigests []string `json:"RepoDigests"`
+	} `json:"Metadata"`
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// ParseTrivy parses `trivy image --format json` output into a ScanResult.
+func ParseTrivy(data []byte) (ScanResult, error) {
+	var report trivyReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return ScanResult{}, fmt.Errorf("parsing trivy report: %v", err)
+	}
+	result := ScanResult{}
+	for _, repoDigest := range report.Metadata.RepoDigests {
+		if digest := DigestFromImageID(repoDigest); digest != "" {
+			result.Digest = digest
+			break
+		}
+	}
+	if result.Digest == "" {
+		return ScanResult{}, fmt.Errorf("trivy report has no repo digest to join on")
+	}
+	for _, target := range report.Results {
+		for _, vuln := range target.Vulnerabilities {
+			result.Vulnerabilities = append(result.Vulnerabilities, Vulnerability{
+				ID:               vuln.VulnerabilityID,
+				Severity:         normalizeSeverity(vuln.Severity),
+				Package:          vuln.PkgName,
+				InstalledVersion: vuln.InstalledVersion,
+				FixedVersion:     vuln.FixedVersion,
+			})
+		}
+	}
+	SortVulnerabilities(result.Vulnerabilities)
+	return result, nil
+}
+
+// grypeReport mirrors the subset of Grype's JSON output we consume.
+type grypeReport struct {
+	Source struct {
+		Target struct {
+			RepoDigests []string `json:"repoDigests"`
+		} `json:"target"`
+	} `json:"source"`
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+			Fix      struct {
+				Versions []string `json:"versions"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// ParseGrype parses `grype -o json` output into a ScanResult.
+func ParseGrype(data []byte) (ScanResult, error) {
+	var report grypeReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return ScanResult{}, fmt.Errorf("parsing grype report: %v", err)
+	}
+	result := ScanResult{}
+	for _, repoDigest := range report.Source.Target.RepoDigests {
+		if digest := DigestFromImageID(repoDigest); digest != "" {
+			result.Digest = digest
+			break
+		}
+	}
+	if result.Digest == "" {
+		return ScanResult{}, fmt.Errorf("grype report has no repo digest to join on")
+	}
+	for _, match := range report.Matches {
+		vuln := Vulnerability{
+			ID:               match.Vulnerability.ID,
+			Severity:         normalizeSeverity(match.Vulnerability.Severity),
+			Package:          match.Artifact.Name,
+			InstalledVersion: match.Artifact.Version,
+		}
+		if len(match.Vulnerability.Fix.Versions) > 0 {
+			vuln.FixedVersion = match.Vulnerability.Fix.Versions[0]
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+	}
+	SortVulnerabilities(result.Vulnerabilities)
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnjoin
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func runningPod(namespace, name, imageID string, owner *metav1.OwnerReference) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{Name: "main", ImageID: imageID}},
+		},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func TestJoin(t *testing.T) {
+	controller := true
+	owner := &metav1.OwnerReference{Kind: "ReplicaSet", Name: "web-abc123", Controller: &controller}
+
+	pods := []*v1.Pod{
+		runningPod("prod", "web-abc123-x", "registry.example.com/web@"+digestA, owner),
+		runningPod("prod", "web-abc123-y", "registry.example.com/web@"+digestA, owner),
+		runningPod("dev", "scratch", "docker-pullable://registry.example.com/tool@"+digestB, nil),
+	}
+	scans := []ScanResult{{
+		Digest: digestA,
+		Vulnerabilities: []Vulnerability{
+			{ID: "CVE-2023-0001", Severity: SeverityCritical, Package: "openssl"},
+			{ID: "CVE-2023-0002", Severity: SeverityLow, Package: "bash"},
+		},
+	}}
+
+	report := Join(pods, scans)
+
+	if len(report.Workloads) != 1 {
+		t.Fatalf("expected 1 workload, got %+v", report.Workloads)
+	}
+	w := report.Workloads[0]
+	if w.Kind != "ReplicaSet" || w.Name != "web-abc123" || w.Namespace != "prod" {
+		t.Errorf("unexpected workload identity: %+v", w)
+	}
+	// Two replicas of the same image count the image once.
+	if len(w.Images) != 1 || w.Counts[SeverityCritical] != 1 || w.Counts[SeverityLow] != 1 {
+		t.Errorf("unexpected workload exposure: %+v", w)
+	}
+
+	if len(report.Namespaces) != 1 || report.Namespaces[0].Namespace != "prod" {
+		t.Fatalf("unexpected namespace exposure: %+v", report.Namespaces)
+	}
+	if report.Namespaces[0].Counts[SeverityCritical] != 1 {
+		t.Errorf("unexpected namespace counts: %+v", report.Namespaces[0].Counts)
+	}
+
+	if len(report.Unscanned) != 1 || report.Unscanned[0] != digestB {
+		t.Errorf("expected %s to be reported unscanned, got %v", digestB, report.Unscanned)
+	}
+}
+
+func TestDigestFromImageID(t *testing.T) {
+	testCases := []struct {
+		imageID string
+		want    string
+	}{
+		{"registry.example.com/web@" + digestA, digestA},
+		{"docker-pullable://registry.example.com/web@" + digestA, digestA},
+		{digestA, digestA},
+		{"registry.example.com/web:v1", ""},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		if got := DigestFromImageID(tc.imageID); got != tc.want {
+			t.Errorf("DigestFromImageID(%q) = %q, want %q", tc.imageID, got, tc.want)
+		}
+	}
+}
+
+func TestParseTrivy(t *testing.T) {
+	data := []byte(`{
+		"Metadata": {"RepoDigests": ["registry.example.com/web@` + digestA + `"]},
+		"Results": [{
+			"Vulnerabilities": [
+				{"VulnerabilityID": "CVE-2023-0002", "PkgName": "bash", "InstalledVersion": "5.1", "Severity": "LOW"},
+				{"VulnerabilityID": "CVE-2023-0001", "PkgName": "openssl", "InstalledVersion": "3.0.1", "FixedVersion": "3.0.2", "Severity": "CRITICAL"}
+			]
+		}]
+	}`)
+	result, err := ParseTrivy(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Digest != digestA {
+		t.Errorf("unexpected digest %q", result.Digest)
+	}
+	if len(result.Vulnerabilities) != 2 || result.Vulnerabilities[0].ID != "CVE-2023-0001" {
+		t.Fatalf("expected findings sorted most severe first, got %+v", result.Vulnerabilities)
+	}
+	if result.Vulnerabilities[0].Severity != SeverityCritical || result.Vulnerabilities[0].FixedVersion != "3.0.2" {
+		t.Errorf("unexpected finding: %+v", result.Vulnerabilities[0])
+	}
+
+	if _, err := ParseTrivy([]byte(`{"Results": []}`)); err == nil {
+		t.Error("expected error for report without repo digest")
+	}
+}
+
+func TestParseGrype(t *testing.T) {
+	data := []byte(`{
+		"source": {"target": {"repoDigests": ["registry.example.com/web@` + digestA + `"]}},
+		"matches": [{
+			"vulnerability": {"id": "GHSA-xxxx", "severity": "Moderate", "fix": {"versions": ["1.2.3"]}},
+			"artifact": {"name": "libfoo", "version": "1.2.0"}
+		}]
+	}`)
+	result, err := ParseGrype(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Digest != digestA || len(result.Vulnerabilities) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	vuln := result.Vulnerabilities[0]
+	if vuln.Severity != SeverityMedium || vuln.FixedVersion != "1.2.3" || vuln.Package != "libfoo" {
+		t.Errorf("unexpected finding: %+v", vuln)
+	}
+}
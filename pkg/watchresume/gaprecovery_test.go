@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchresume
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanRecovery(t *testing.T) {
+	c, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("Pod", "quiet", "500") // at the gap point: resume
+	c.Set("Pod", "busy", "80")   // behind the gap: relist
+	c.Set("Pod", "ahead", "900") // past the gap: resume
+	c.Set("Pod", "weird", "not-a-number")
+
+	plan := PlanRecovery(c, "Pod", []string{"quiet", "busy", "ahead", "weird", "new"}, "500")
+	if !reflect.DeepEqual(plan.Resume, []string{"ahead", "quiet"}) {
+		t.Errorf("resume = %v, want [ahead quiet]", plan.Resume)
+	}
+	// "new" has no checkpoint, "weird" is not comparable: both relist.
+	if !reflect.DeepEqual(plan.Relist, []string{"busy", "new", "weird"}) {
+		t.Errorf("relist = %v, want [busy new weird]", plan.Relist)
+	}
+}
+
+func TestPlanRecoveryUnparseableGapRelistsEverything(t *testing.T) {
+	c, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("Pod", "a", "999999")
+	plan := PlanRecovery(c, "Pod", []string{"a", "b"}, "")
+	if len(plan.Resume) != 0 || !reflect.DeepEqual(plan.Relist, []string{"a", "b"}) {
+		t.Errorf("unknown gap point must relist all shards, got %+v", plan)
+	}
+}
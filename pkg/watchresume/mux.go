@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchresume
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Mux fans the events of one checkpointed upstream watch out to any
+// number of consumers, so a pipeline with several readers of the same
+// kind costs the apiserver a single connection. The Resumer underneath
+// still owns resourceVersion bookkeeping — bookmarks advance the
+// checkpoint and are forwarded, 410 Gone relists transparently — and
+// consumers just see an ordinary watch.Interface.
+type Mux struct {
+	resumer     *Resumer
+	broadcaster *watch.Broadcaster
+}
+
+// NewMux builds a multiplexer for one kind. Events are delivered to
+// every consumer in order; a consumer that stops draining stalls the
+// fan-out rather than silently losing events, matching the upstream
+// watch's own back-pressure.
+func NewMux(kind string, checkpoints *Checkpoints, list ListFunc, watchFn WatchFunc) *Mux {
+	m := &Mux{
+		broadcaster: watch.NewBroadcaster(watchQueueLength, watch.WaitIfChannelFull),
+	}
+	m.resumer = &Resumer{
+		Kind:        kind,
+		Checkpoints: checkpoints,
+		List:        list,
+		Watch:       watchFn,
+		OnEvent: func(event watch.Event) {
+			// Action only fails after Shutdown, when consumers are gone.
+			m.broadcaster.Action(event.Type, event.Object)
+		},
+	}
+	return m
+}
+
+// watchQueueLength buffers in-order delivery per consumer before
+// back-pressure reaches the fan-out.
+const watchQueueLength = 25
+
+// Subscribe registers a consumer. Consumers receive events observed
+// after they subscribe; one that needs current state should subscribe
+// before Run so the initial list's events are not missed. Stop the
+// returned watch to unsubscribe.
+func (m *Mux) Subscribe() (watch.Interface, error) {
+	return m.broadcaster.Watch()
+}
+
+// Broadcast injects an event into the fan-out. A ListFunc that replays
+// current objects through Broadcast after a relist gives late consumers
+// and post-410 recovery the same view an informer would.
+func (m *Mux) Broadcast(event watch.Event) {
+	m.broadcaster.Action(event.Type, event.Object)
+}
+
+// Run drives the upstream watch for one shard until the context ends,
+// then closes every consumer's channel.
+func (m *Mux) Run(ctx context.Context, shard string) error {
+	defer m.broadcaster.Shutdown()
+	return m.resumer.Run(ctx, shard)
+}
+
+// Stats exposes the underlying resume counters.
+func (m *Mux) Stats() Stats {
+	return m.resumer.Stats()
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchresume
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func muxPod(name, rv string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, ResourceVersion: rv}}
+}
+
+func TestMuxFansOutToAllConsumers(t *testing.T) {
+	upstream := watch.NewFakeWithChanSize(4, false)
+	checkpoints, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := NewMux("Pod", checkpoints,
+		func(ctx context.Context, shard string) (string, error) { return "10", nil },
+		func(ctx context.Context, shard, rv string) (watch.Interface, error) { return upstream, nil },
+	)
+	first, err := mux.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := mux.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mux.Run(ctx, "")
+	}()
+
+	upstream.Add(muxPod("web", "11"))
+	upstream.Modify(muxPod("web", "12"))
+
+	for _, consumer := range []watch.Interface{first, second} {
+		added := <-consumer.ResultChan()
+		modified := <-consumer.ResultChan()
+		if added.Type != watch.Added || modified.Type != watch.Modified {
+			t.Errorf("consumer saw %s then %s", added.Type, modified.Type)
+		}
+	}
+	if rv := checkpoints.Get("Pod", ""); rv != "12" {
+		t.Errorf("checkpoint = %q, want 12", rv)
+	}
+
+	cancel()
+	<-done
+	// Shutdown closes every consumer channel.
+	if _, ok := <-first.ResultChan(); ok {
+		t.Error("consumer channel still open after Run returned")
+	}
+}
+
+func TestMuxUnsubscribeDoesNotAffectOthers(t *testing.T) {
+	upstream := watch.NewFakeWithChanSize(4, false)
+	checkpoints, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := NewMux("Pod", checkpoints,
+		func(ctx context.Context, shard string) (string, error) { return "10", nil },
+		func(ctx context.Context, shard, rv string) (watch.Interface, error) { return upstream, nil },
+	)
+	leaver, err := mux.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stayer, err := mux.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mux.Run(ctx, "")
+
+	leaver.Stop()
+	upstream.Add(muxPod("web", "11"))
+
+	select {
+	case event := <-stayer.ResultChan():
+		if event.Type != watch.Added {
+			t.Errorf("unexpected event %s", event.Type)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("remaining consumer starved after another unsubscribed")
+	}
+}
+
+func TestMuxRelistsOnExpiredAndKeepsConsumers(t *testing.T) {
+	checkpoints, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoints.Set("Pod", "", "5")
+
+	var lists int64
+	second := watch.NewFakeWithChanSize(4, false)
+	watches := make(chan watch.Interface, 2)
+	expired := watch.NewFakeWithChanSize(1, false)
+	expired.Error(&metav1.Status{Reason: metav1.StatusReasonExpired, Code: 410})
+	watches <- expired
+	watches <- second
+
+	var mux *Mux
+	mux = NewMux("Pod", checkpoints,
+		func(ctx context.Context, shard string) (string, error) {
+			atomic.AddInt64(&lists, 1)
+			// Replay current state into the stream for consumers.
+			mux.Broadcast(watch.Event{Type: watch.Added, Object: muxPod("survivor", "20")})
+			return "20", nil
+		},
+		func(ctx context.Context, shard, rv string) (watch.Interface, error) { return <-watches, nil },
+	)
+	consumer, err := mux.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mux.Run(ctx, "")
+
+	event := <-consumer.ResultChan()
+	if event.Type != watch.Added {
+		t.Errorf("expected replayed ADDED after relist, got %s", event.Type)
+	}
+	second.Modify(muxPod("survivor", "21"))
+	event = <-consumer.ResultChan()
+	if event.Type != watch.Modified {
+		t.Errorf("expected MODIFIED from the fresh watch, got %s", event.Type)
+	}
+	if atomic.LoadInt64(&lists) != 1 {
+		t.Errorf("expected exactly one relist, got %d", lists)
+	}
+	if stats := mux.Stats(); stats.Expired != 1 || stats.Relists != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
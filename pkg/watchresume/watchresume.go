@@ -0,0 +1,257 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package watchresume resumes watches from persisted resourceVersions
+// across process restarts. Every deploy of a reader that starts from
+// scratch costs the apiserver a full relist per kind; checkpointing the
+// last-observed resourceVersion per kind and namespace shard turns a
+// restart into a delta watch instead. The relist path remains as the
+// fallback for checkpoints the apiserver has already compacted away
+// (410 Gone), and counters track how often resumption actually works so
+// the win is measurable.
+package watchresume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const checkpointFile = "watch-checkpoints.json"
+
+// Checkpoints persists last-observed resourceVersions, keyed by kind and
+// namespace shard, in a directory shared with the disk cache.
+type Checkpoints struct {
+	path string
+
+	mu  sync.Mutex
+	rvs map[string]string
+}
+
+// OpenCheckpoints loads any existing checkpoint file from dir. A missing
+// or unreadable file starts empty — losing checkpoints only costs a
+// relist, never correctness.
+func OpenCheckpoints(dir string) (*Checkpoints, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &Checkpoints{path: filepath.Join(dir, checkpointFile), rvs: map[string]string{}}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c, nil
+	}
+	if err := json.Unmarshal(data, &c.rvs); err != nil {
+		c.rvs = map[string]string{}
+	}
+	return c, nil
+}
+
+func checkpointKey(kind, shard string) string {
+	if shard == "" {
+		shard = metav1.NamespaceAll
+	}
+	return kind + "/" + shard
+}
+
+// Get returns the checkpointed resourceVersion, empty if none.
+func (c *Checkpoints) Get(kind, shard string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rvs[checkpointKey(kind, shard)]
+}
+
+// Set records a resourceVersion in memory; Flush writes it out. The
+// split keeps per-event bookkeeping off the disk.
+func (c *Checkpoints) Set(kind, shard, rv string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rv == "" {
+		delete(c.rvs, checkpointKey(kind, shard))
+		return
+	}
+	c.rvs[checkpointKey(kind, shard)] = rv
+}
+
+// Flush persists the current checkpoints with an atomic rename.
+func (c *Checkpoints) Flush() error {
+	c.mu.Lock()
+	data, err := json.Marshal(c.rvs)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// ListFunc relists one shard and returns the list's resourceVersion to
+// watch from.
+type ListFunc func(ctx context.Context, shard string) (rv string, err error)
+
+// WatchFunc opens a watch on one shard from the given resourceVersion.
+type WatchFunc func(ctx context.Context, shard, rv string) (watch.Interface, error)
+
+// Stats counts resume outcomes. Attempts that become Resumed delivered a
+// delta watch from a checkpoint; Relists paid for a full list, either
+// because no checkpoint existed or because Expired checkpoints were
+// compacted away server-side.
+type Stats struct {
+	Attempts uint64
+	Resumed  uint64
+	Relists  uint64
+	Expired  uint64
+}
+
+// Resumer runs a checkpointed watch loop for one kind.
+type Resumer struct {
+	Kind        string
+	Checkpoints *Checkpoints
+	List        ListFunc
+	Watch       WatchFunc
+	// OnEvent receives every watch event after its resourceVersion has
+	// been checkpointed in memory.
+	OnEvent func(watch.Event)
+	// FlushInterval bounds checkpoint staleness on disk; 0 means 10s.
+	FlushInterval time.Duration
+
+	attempts, resumed, relists, expired uint64
+}
+
+// Stats returns a snapshot of the resume counters.
+func (r *Resumer) Stats() Stats {
+	return Stats{
+		Attempts: atomic.LoadUint64(&r.attempts),
+		Resumed:  atomic.LoadUint64(&r.resumed),
+		Relists:  atomic.LoadUint64(&r.relists),
+		Expired:  atomic.LoadUint64(&r.expired),
+	}
+}
+
+// Run watches one shard until the context ends, resuming from the
+// persisted checkpoint when there is one and relisting only when the
+// checkpoint is missing or too old. Checkpoints are flushed periodically
+// and on exit.
+func (r *Resumer) Run(ctx context.Context, shard string) error {
+	flushInterval := r.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	defer r.Checkpoints.Flush()
+
+	rv := r.Checkpoints.Get(r.Kind, shard)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		atomic.AddUint64(&r.attempts, 1)
+		if rv == "" {
+			listed, err := r.List(ctx, shard)
+			if err != nil {
+				return fmt.Errorf("relisting %s: %w", r.Kind, err)
+			}
+			atomic.AddUint64(&r.relists, 1)
+			rv = listed
+			r.Checkpoints.Set(r.Kind, shard, rv)
+		} else {
+			atomic.AddUint64(&r.resumed, 1)
+		}
+
+		w, err := r.Watch(ctx, shard, rv)
+		if err != nil {
+			if isExpired(err) {
+				r.noteExpired(shard, &rv)
+				continue
+			}
+			return fmt.Errorf("watching %s from %q: %w", r.Kind, rv, err)
+		}
+		expired, err := r.consume(ctx, shard, w, &rv, ticker.C)
+		if err != nil {
+			return err
+		}
+		if expired {
+			r.noteExpired(shard, &rv)
+		}
+	}
+}
+
+// consume drains one watch connection, checkpointing event
+// resourceVersions. It returns expired=true when the server signalled
+// that rv was compacted and the caller must relist.
+func (r *Resumer) consume(ctx context.Context, shard string, w watch.Interface, rv *string, flush <-chan time.Time) (expired bool, err error) {
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-flush:
+			if err := r.Checkpoints.Flush(); err != nil {
+				return false, err
+			}
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				// Connection closed; resume from the last checkpoint.
+				return false, nil
+			}
+			if event.Type == watch.Error {
+				if isExpiredStatus(event.Object) {
+					return true, nil
+				}
+				return false, fmt.Errorf("watch error on %s: %v", r.Kind, event.Object)
+			}
+			if accessor, err := meta.Accessor(event.Object); err == nil && accessor.GetResourceVersion() != "" {
+				*rv = accessor.GetResourceVersion()
+				r.Checkpoints.Set(r.Kind, shard, *rv)
+			}
+			if r.OnEvent != nil {
+				r.OnEvent(event)
+			}
+		}
+	}
+}
+
+func (r *Resumer) noteExpired(shard string, rv *string) {
+	atomic.AddUint64(&r.expired, 1)
+	*rv = ""
+	r.Checkpoints.Set(r.Kind, shard, "")
+}
+
+func isExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+func isExpiredStatus(obj interface{}) bool {
+	status, ok := obj.(*metav1.Status)
+	if !ok {
+		return false
+	}
+	return status.Reason == metav1.StatusReasonExpired || status.Reason == metav1.StatusReasonGone ||
+		status.Code == 410
+}
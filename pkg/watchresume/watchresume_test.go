@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchresume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func podAt(rv string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "web", ResourceVersion: rv,
+	}}
+}
+
+func TestCheckpointsPersistAcrossOpens(t *testing.T) {
+	dir := t.TempDir()
+	c, err := OpenCheckpoints(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("Pod", "default", "100")
+	c.Set("Node", "", "200")
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenCheckpoints(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Get("Pod", "default"); got != "100" {
+		t.Errorf("Pod checkpoint = %q, want 100", got)
+	}
+	if got := reopened.Get("Node", ""); got != "200" {
+		t.Errorf("Node checkpoint = %q, want 200", got)
+	}
+	if got := reopened.Get("Pod", "other"); got != "" {
+		t.Errorf("unexpected checkpoint for unseen shard: %q", got)
+	}
+}
+
+func TestRunResumesFromCheckpoint(t *testing.T) {
+	c, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("Pod", "default", "100")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var watchedFrom []string
+	resumer := &Resumer{
+		Kind:        "Pod",
+		Checkpoints: c,
+		List: func(context.Context, string) (string, error) {
+			t.Error("resume from a checkpoint must not relist")
+			return "", nil
+		},
+		Watch: func(_ context.Context, _, rv string) (watch.Interface, error) {
+			watchedFrom = append(watchedFrom, rv)
+			w := watch.NewFakeWithChanSize(2, false)
+			w.Add(podAt("101"))
+			w.Modify(podAt("102"))
+			go func() {
+				// Give the consumer time to drain, then end the test.
+				time.Sleep(50 * time.Millisecond)
+				cancel()
+			}()
+			return w, nil
+		},
+	}
+
+	if err := resumer.Run(ctx, "default"); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(watchedFrom) == 0 || watchedFrom[0] != "100" {
+		t.Errorf("expected first watch from checkpoint 100, got %v", watchedFrom)
+	}
+	if got := c.Get("Pod", "default"); got != "102" {
+		t.Errorf("checkpoint after events = %q, want 102", got)
+	}
+	stats := resumer.Stats()
+	if stats.Resumed != 1 || stats.Relists != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRunRelistsWhenCheckpointExpired(t *testing.T) {
+	c, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("Pod", "default", "100")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	relisted := 0
+	resumer := &Resumer{
+		Kind:        "Pod",
+		Checkpoints: c,
+		List: func(context.Context, string) (string, error) {
+			relisted++
+			return "500", nil
+		},
+		Watch: func(_ context.Context, _, rv string) (watch.Interface, error) {
+			if rv == "100" {
+				// The checkpoint was compacted away server-side.
+				return nil, apierrorsExpired()
+			}
+			w := watch.NewFakeWithChanSize(1, false)
+			w.Add(podAt("501"))
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				cancel()
+			}()
+			return w, nil
+		},
+	}
+
+	if err := resumer.Run(ctx, "default"); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if relisted != 1 {
+		t.Errorf("expected exactly one relist, got %d", relisted)
+	}
+	stats := resumer.Stats()
+	if stats.Expired != 1 || stats.Relists != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if got := c.Get("Pod", "default"); got != "501" {
+		t.Errorf("checkpoint = %q, want 501", got)
+	}
+}
+
+func TestConsumeTreatsExpiredStatusAsRelist(t *testing.T) {
+	c, err := OpenCheckpoints(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("Pod", "default", "100")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	relisted := 0
+	watches := 0
+	resumer := &Resumer{
+		Kind:        "Pod",
+		Checkpoints: c,
+		List: func(context.Context, string) (string, error) {
+			relisted++
+			return "500", nil
+		},
+		Watch: func(context.Context, string, string) (watch.Interface, error) {
+			watches++
+			w := watch.NewFakeWithChanSize(1, false)
+			if watches == 1 {
+				// Expiry can also arrive as an Error event mid-stream.
+				w.Error(&metav1.Status{Reason: metav1.StatusReasonExpired, Code: 410})
+				w.Stop()
+			} else {
+				w.Add(podAt("501"))
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					cancel()
+				}()
+			}
+			return w, nil
+		},
+	}
+
+	if err := resumer.Run(ctx, "default"); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if relisted != 1 || watches != 2 {
+		t.Errorf("expected relist after expired stream, got relists=%d watches=%d", relisted, watches)
+	}
+}
+
+func apierrorsExpired() error {
+	return &expiredError{}
+}
+
+type expiredError struct{}
+
+func (*expiredError) Error() string { return "too old resource version" }
+func (*expiredError) Status() metav1.Status {
+	return metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    410,
+		Reason:  metav1.StatusReasonExpired,
+		Message: "too old resource version: 100",
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package widerender renders typed objects as "kubectl get -o wide"
+// compatible rows. Column sets are table-driven — a column is a name plus
+// a cell function — so per-kind renderers stay declarative and each cell
+// is testable on its own. Columns and cell values intentionally track
+// kubectl's, so output is drop-in familiar to anyone who reads kubectl
+// output all day.
+package widerender
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	v1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+)
+
+// Column is one output column for a kind.
+type Column[T any] struct {
+	Name string
+	Cell func(obj T, now time.Time) string
+}
+
+// Renderer renders objects of one kind into rows.
+type Renderer[T any] struct {
+	Columns []Column[T]
+}
+
+// Headers returns the column names in order.
+func (r Renderer[T]) Headers() []string {
+	headers := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		headers[i] = column.Name
+	}
+	return headers
+}
+
+// Row renders one object's cells in column order.
+func (r Renderer[T]) Row(obj T, now time.Time) []string {
+	row := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		row[i] = column.Cell(obj, now)
+	}
+	return row
+}
+
+// Write renders a header and one row per object as tab-aligned text, the
+// same layout kubectl produces.
+func (r Renderer[T]) Write(w io.Writer, objs []T, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 6, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(r.Headers(), "\t"))
+	for _, obj := range objs {
+		fmt.Fprintln(tw, strings.Join(r.Row(obj, now), "\t"))
+	}
+	return tw.Flush()
+}
+
+// Age formats a creation-to-now interval the way kubectl does.
+func Age(created time.Time, now time.Time) string {
+	if created.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(now.Sub(created))
+}
+
+func emptyDash(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// PodRenderer returns the wide column set for pods: the default columns
+// plus IP, NODE, NOMINATED NODE and READINESS GATES.
+func PodRenderer() Renderer[*v1.Pod] {
+	return Renderer[*v1.Pod]{Columns: []Column[*v1.Pod]{
+		{Name: "NAME", Cell: func(pod *v1.Pod, _ time.Time) string { return pod.Name }},
+		{Name: "READY", Cell: func(pod *v1.Pod, _ time.Time) string { return podReady(pod) }},
+		{Name: "STATUS", Cell: func(pod *v1.Pod, _ time.Time) string { return PodStatus(pod) }},
+		{Name: "RESTARTS", Cell: func(pod *v1.Pod, now time.Time) string { return podRestarts(pod, now) }},
+		{Name: "AGE", Cell: func(pod *v1.Pod, now time.Time) string { return Age(pod.CreationTimestamp.Time, now) }},
+		{Name: "IP", Cell: func(pod *v1.Pod, _ time.Time) string { return emptyDash(pod.Status.PodIP) }},
+		{Name: "NODE", Cell: func(pod *v1.Pod, _ time.Time) string { return emptyDash(pod.Spec.NodeName) }},
+		{Name: "NOMINATED NODE", Cell: func(pod *v1.Pod, _ time.Time) string { return emptyDash(pod.Status.NominatedNodeName) }},
+		{Name: "READINESS GATES", Cell: func(pod *v1.Pod, _ time.Time) string { return podReadinessGates(pod) }},
+	}}
+}
+
+// PodStatus computes the STATUS cell for a pod, following kubectl's
+// precedence: status.reason, then init container progress, then the most
+// significant container waiting/terminated reason, then phase, with
+// Terminating overriding for deleted pods.
+func PodStatus(pod *v1.Pod) string {
+	reason := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		reason = pod.Status.Reason
+	}
+
+	initializing := false
+	for i, status := range pod.Status.InitContainerStatuses {
+		switch {
+		case status.State.Terminated != nil && status.State.Terminated.ExitCode == 0:
+			continue
+		case status.State.Terminated != nil:
+			if status.State.Terminated.Reason != "" {
+				reason = "Init:" + status.State.Terminated.Reason
+			} else if status.State.Terminated.Signal != 0 {
+				reason = fmt.Sprintf("Init:Signal:%d", status.State.Terminated.Signal)
+			} else {
+				reason = fmt.Sprintf("Init:ExitCode:%d", status.State.Terminated.ExitCode)
+			}
+			initializing = true
+		case status.State.Waiting != nil && status.State.Waiting.Reason != "" && status.State.Waiting.Reason != "PodInitializing":
+			reason = "Init:" + status.State.Waiting.Reason
+			initializing = true
+		default:
+			reason = fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+			initializing = true
+		}
+		break
+	}
+	if !initializing {
+		for i := len(pod.Status.ContainerStatuses) - 1; i >= 0; i-- {
+			status := pod.Status.ContainerStatuses[i]
+			switch {
+			case status.State.Waiting != nil && status.State.Waiting.Reason != "":
+				reason = status.State.Waiting.Reason
+			case status.State.Terminated != nil && status.State.Terminated.Reason != "":
+				reason = status.State.Terminated.Reason
+			case status.State.Terminated != nil:
+				if status.State.Terminated.Signal != 0 {
+					reason = fmt.Sprintf("Signal:%d", status.State.Terminated.Signal)
+				} else {
+					reason = fmt.Sprintf("ExitCode:%d", status.State.Terminated.ExitCode)
+				}
+			}
+		}
+	}
+
+	if pod.DeletionTimestamp != nil {
+		if pod.Status.Reason == "NodeLost" {
+			return "Unknown"
+		}
+		return "Terminating"
+	}
+	return reason
+}
+
+func podReady(pod *v1.Pod) string {
+	ready := 0
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
+}
+
+// podRestarts renders the restart total, annotated with the time of the
+// most recent container exit when there is one, e.g. "3 (5m ago)".
+func podRestarts(pod *v1.Pod, now time.Time) string {
+	restarts := 0
+	var lastFinished time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += int(status.RestartCount)
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.FinishedAt.Time.After(lastFinished) {
+			lastFinished = terminated.FinishedAt.Time
+		}
+	}
+	if restarts > 0 && !lastFinished.IsZero() {
+		return fmt.Sprintf("%d (%s ago)", restarts, duration.HumanDuration(now.Sub(lastFinished)))
+	}
+	return strconv.Itoa(restarts)
+}
+
+func podReadinessGates(pod *v1.Pod) string {
+	total := len(pod.Spec.ReadinessGates)
+	if total == 0 {
+		return "<none>"
+	}
+	trueConditions := 0
+	for _, gate := range pod.Spec.ReadinessGates {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == gate.ConditionType && condition.Status == v1.ConditionTrue {
+				trueConditions++
+				break
+			}
+		}
+	}
+	return fmt.Sprintf("%d/%d", trueConditions, total)
+}
+
+// PersistentVolumeRenderer returns the wide column set for persistent
+// volumes.
+func PersistentVolumeRenderer() Renderer[*v1.PersistentVolume] {
+	return Renderer[*v1.PersistentVolume]{Columns: []Column[*v1.PersistentVolume]{
+		{Name: "NAME", Cell: func(pv *v1.PersistentVolume, _ time.Time) string { return pv.Name }},
+		{Name: "CAPACITY", Cell: func(pv *v1.PersistentVolume, _ time.Time) string {
+			capacity := pv.Spec.Capacity[v1.ResourceStorage]
+			return capacity.String()
+		}},
+		{Name: "ACCESS MODES", Cell: func(pv *v1.PersistentVolume, _ time.Time) string {
+			return v1helper.GetAccessModesAsString(pv.Spec.AccessModes)
+		}},
+		{Name: "RECLAIM POLICY", Cell: func(pv *v1.PersistentVolume, _ time.Time) string {
+			return string(pv.Spec.PersistentVolumeReclaimPolicy)
+		}},
+		{Name: "STATUS", Cell: func(pv *v1.PersistentVolume, _ time.Time) string { return string(pv.Status.Phase) }},
+		{Name: "CLAIM", Cell: func(pv *v1.PersistentVolume, _ time.Time) string {
+			if pv.Spec.ClaimRef == nil {
+				return ""
+			}
+			return pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+		}},
+		{Name: "STORAGECLASS", Cell: func(pv *v1.PersistentVolume, _ time.Time) string { return pv.Spec.StorageClassName }},
+		{Name: "REASON", Cell: func(pv *v1.PersistentVolume, _ time.Time) string { return pv.Status.Reason }},
+		{Name: "AGE", Cell: func(pv *v1.PersistentVolume, now time.Time) string { return Age(pv.CreationTimestamp.Time, now) }},
+		{Name: "VOLUMEMODE", Cell: func(pv *v1.PersistentVolume, _ time.Time) string {
+			if pv.Spec.VolumeMode == nil {
+				return string(v1.PersistentVolumeFilesystem)
+			}
+			return string(*pv.Spec.VolumeMode)
+		}},
+	}}
+}
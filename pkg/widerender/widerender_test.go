@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package widerender
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodRow(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "web-0",
+			CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour)),
+		},
+		Spec: v1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []v1.Container{{Name: "app"}, {Name: "sidecar"}},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			PodIP: "10.0.0.5",
+			ContainerStatuses: []v1.ContainerStatus{
+				{Name: "app", Ready: true, RestartCount: 2, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+						FinishedAt: metav1.NewTime(now.Add(-5 * time.Minute)),
+					}}},
+				{Name: "sidecar", Ready: false, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+			},
+		},
+	}
+
+	row := PodRenderer().Row(pod, now)
+	want := []string{"web-0", "1/2", "Running", "2 (5m ago)", "3h", "10.0.0.5", "node-1", "<none>", "<none>"}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("got row %v, want %v", row, want)
+	}
+}
+
+func TestPodStatus(t *testing.T) {
+	deleted := metav1.Now()
+	testCases := []struct {
+		name string
+		pod  v1.Pod
+		want string
+	}{
+		{
+			name: "phase only",
+			pod:  v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending}},
+			want: "Pending",
+		},
+		{
+			name: "waiting reason wins over phase",
+			pod: v1.Pod{Status: v1.PodStatus{
+				Phase: v1.PodPending,
+				ContainerStatuses: []v1.ContainerStatus{{
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			}},
+			want: "ImagePullBackOff",
+		},
+		{
+			name: "terminated reason",
+			pod: v1.Pod{Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{{
+					State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled"}},
+				}},
+			}},
+			want: "OOMKilled",
+		},
+		{
+			name: "init progress",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{InitContainers: []v1.Container{{Name: "a"}, {Name: "b"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					InitContainerStatuses: []v1.ContainerStatus{{
+						State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					}},
+				},
+			},
+			want: "Init:0/2",
+		},
+		{
+			name: "init failure",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{InitContainers: []v1.Container{{Name: "a"}}},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					InitContainerStatuses: []v1.ContainerStatus{{
+						State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1}},
+					}},
+				},
+			},
+			want: "Init:ExitCode:1",
+		},
+		{
+			name: "terminating",
+			pod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleted},
+				Status:     v1.PodStatus{Phase: v1.PodRunning},
+			},
+			want: "Terminating",
+		},
+		{
+			name: "node lost",
+			pod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleted},
+				Status:     v1.PodStatus{Phase: v1.PodRunning, Reason: "NodeLost"},
+			},
+			want: "Unknown",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := PodStatus(&tc.pod); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPersistentVolumeRow(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	block := v1.PersistentVolumeBlock
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pv-1",
+			CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)),
+		},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity:                      v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			AccessModes:                   []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimRetain,
+			StorageClassName:              "fast",
+			VolumeMode:                    &block,
+			ClaimRef:                      &v1.ObjectReference{Namespace: "default", Name: "data"},
+		},
+		Status: v1.PersistentVolumeStatus{Phase: v1.VolumeBound},
+	}
+
+	row := PersistentVolumeRenderer().Row(pv, now)
+	want := []string{"pv-1", "10Gi", "RWO", "Retain", "Bound", "default/data", "fast", "", "2d", "Block"}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("got row %v, want %v", row, want)
+	}
+}
+
+func TestWriteAlignsColumns(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	pods := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "c"}}},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		},
+	}
+	var buf bytes.Buffer
+	if err := PodRenderer().Write(&buf, pods, now); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[0], "READINESS GATES") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "a") || !strings.Contains(lines[1], "Running") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+}
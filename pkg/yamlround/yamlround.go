@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package yamlround round-trips manifests through typed objects without
+// destroying the comments humans wrote in them. A manifest is parsed
+// into a yaml.v3 node tree (which carries comments and document
+// structure) alongside a typed decode; after analysis or normalization
+// mutates the typed object, Update merges the changed values back into
+// the node tree in place — touched scalars change, everything else keeps
+// its comments and order — and Marshal re-emits the file. Lint-and-fix
+// workflows that previously flattened files through plain Unmarshal can
+// now write back reviewable diffs.
+package yamlround
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Document is one YAML document with its comment-bearing node tree.
+type Document struct {
+	node yaml.Node
+}
+
+// Load parses a (possibly multi-document) manifest, keeping comments.
+func Load(data []byte) ([]*Document, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []*Document
+	for {
+		doc := &Document{}
+		err := decoder.Decode(&doc.node)
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing document %d: %v", len(docs)+1, err)
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// Decode unmarshals the document into a typed object, honoring json
+// tags the way all Kubernetes YAML handling does.
+func (d *Document) Decode(into interface{}) error {
+	raw, err := yaml.Marshal(&d.node)
+	if err != nil {
+		return err
+	}
+	return sigsyaml.Unmarshal(raw, into)
+}
+
+// Update merges the object's current values back into the node tree.
+// Scalars whose values changed are rewritten in place; keys the object
+// dropped are removed; new keys are appended at the end of their
+// mapping. Comments attached to surviving nodes are untouched.
+func (d *Document) Update(obj interface{}) error {
+	raw, err := sigsyaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("encoding updated object: %v", err)
+	}
+	var desired yaml.Node
+	if err := yaml.Unmarshal(raw, &desired); err != nil {
+		return err
+	}
+	if len(desired.Content) == 0 {
+		return fmt.Errorf("updated object encoded to an empty document")
+	}
+	if len(d.node.Content) == 0 {
+		d.node = desired
+		return nil
+	}
+	syncNode(d.node.Content[0], desired.Content[0])
+	return nil
+}
+
+// Marshal re-emits the document with comments intact, at the standard
+// two-space indent.
+func (d *Document) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&d.node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalAll re-emits several documents separated by "---".
+func MarshalAll(docs []*Document) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(&doc.node); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// syncNode rewrites current to carry desired's values while preserving
+// current's comments and key order wherever a node survives.
+func syncNode(current, desired *yaml.Node) {
+	if current.Kind != desired.Kind {
+		// Shape changed (e.g. scalar became a mapping): take the new
+		// subtree but keep the comments anchored at this position.
+		head, line, foot := current.HeadComment, current.LineComment, current.FootComment
+		*current = *desired
+		current.HeadComment, current.LineComment, current.FootComment = head, line, foot
+		return
+	}
+	switch current.Kind {
+	case yaml.ScalarNode:
+		current.Value = desired.Value
+		current.Tag = desired.Tag
+		// Keep the author's style (quoting, block scalars) unless the
+		// value's type changed out from under it.
+		if current.Style == yaml.LiteralStyle || current.Style == yaml.FoldedStyle {
+			if desired.Style != yaml.LiteralStyle && desired.Style != yaml.FoldedStyle {
+				current.Style = desired.Style
+			}
+		}
+	case yaml.MappingNode:
+		syncMapping(current, desired)
+	case yaml.SequenceNode:
+		syncSequence(current, desired)
+	}
+}
+
+func syncMapping(current, desired *yaml.Node) {
+	desiredByKey := map[string]*yaml.Node{}
+	desiredOrder := make([]string, 0, len(desired.Content)/2)
+	for i := 0; i+1 < len(desired.Content); i += 2 {
+		desiredByKey[desired.Content[i].Value] = desired.Content[i+1]
+		desiredOrder = append(desiredOrder, desired.Content[i].Value)
+	}
+
+	// Keep surviving keys in their original order, updating values.
+	var merged []*yaml.Node
+	kept := map[string]bool{}
+	for i := 0; i+1 < len(current.Content); i += 2 {
+		key, value := current.Content[i], current.Content[i+1]
+		desiredValue, ok := desiredByKey[key.Value]
+		if !ok {
+			continue
+		}
+		syncNode(value, desiredValue)
+		merged = append(merged, key, value)
+		kept[key.Value] = true
+	}
+	// Append keys the update introduced.
+	for _, key := range desiredOrder {
+		if kept[key] {
+			continue
+		}
+		merged = append(merged,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			desiredByKey[key])
+	}
+	current.Content = merged
+}
+
+func syncSequence(current, desired *yaml.Node) {
+	// Items are matched by position; list reordering loses comments on
+	// moved items, which is the cost of not guessing identity.
+	n := len(desired.Content)
+	if len(current.Content) > n {
+		current.Content = current.Content[:n]
+	}
+	for i, item := range current.Content {
+		syncNode(item, desired.Content[i])
+	}
+	for i := len(current.Content); i < n; i++ {
+		current.Content = append(current.Content, desired.Content[i])
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlround
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const manifest = `# Deployed by the platform team; do not edit by hand.
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web # canonical name
+  namespace: default
+spec:
+  containers:
+    # The main serving container.
+    - name: app
+      image: registry.example.com/app:v1.2.3
+`
+
+func TestRoundTripPreservesComments(t *testing.T) {
+	docs, err := Load([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 document, got %d", len(docs))
+	}
+
+	out, err := docs[0].Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, comment := range []string{"do not edit by hand", "# canonical name", "# The main serving container."} {
+		if !strings.Contains(string(out), comment) {
+			t.Errorf("comment %q lost in round trip:\n%s", comment, out)
+		}
+	}
+}
+
+func TestDecodeTyped(t *testing.T) {
+	docs, err := Load([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pod v1.Pod
+	if err := docs[0].Decode(&pod); err != nil {
+		t.Fatal(err)
+	}
+	if pod.Name != "web" || len(pod.Spec.Containers) != 1 || pod.Spec.Containers[0].Image != "registry.example.com/app:v1.2.3" {
+		t.Errorf("unexpected decode: %+v", pod)
+	}
+}
+
+func TestUpdateKeepsCommentsOnChangedValues(t *testing.T) {
+	docs, err := Load([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pod v1.Pod
+	if err := docs[0].Decode(&pod); err != nil {
+		t.Fatal(err)
+	}
+
+	// The kind of normalization our lint workflow applies.
+	pod.Spec.Containers[0].Image = "registry.example.com/app:v1.2.4"
+	pod.Labels = map[string]string{"team": "platform"}
+
+	if err := docs[0].Update(&pod); err != nil {
+		t.Fatal(err)
+	}
+	out, err := docs[0].Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := string(out)
+	if !strings.Contains(text, "app:v1.2.4") || strings.Contains(text, "app:v1.2.3") {
+		t.Errorf("image not updated:\n%s", text)
+	}
+	if !strings.Contains(text, "team: platform") {
+		t.Errorf("new labels not added:\n%s", text)
+	}
+	for _, comment := range []string{"do not edit by hand", "# canonical name", "# The main serving container."} {
+		if !strings.Contains(text, comment) {
+			t.Errorf("comment %q lost across update:\n%s", comment, text)
+		}
+	}
+	// The typed round trip still parses.
+	var again v1.Pod
+	redocs, err := Load(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := redocs[0].Decode(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.Spec.Containers[0].Image != "registry.example.com/app:v1.2.4" || again.Labels["team"] != "platform" {
+		t.Errorf("re-decoded object wrong: %+v", again)
+	}
+}
+
+func TestMultiDocument(t *testing.T) {
+	multi := manifest + "---\n# second doc\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n"
+	docs, err := Load([]byte(multi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	out, err := MarshalAll(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "---") || !strings.Contains(string(out), "# second doc") {
+		t.Errorf("multi-document round trip broken:\n%s", out)
+	}
+}
+
+func TestUpdateRemovesDroppedKeys(t *testing.T) {
+	docs, err := Load([]byte("metadata:\n  name: web\n  namespace: default\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type meta struct {
+		Name string `json:"name"`
+	}
+	type obj struct {
+		Metadata meta `json:"metadata"`
+	}
+	if err := docs[0].Update(&obj{Metadata: meta{Name: "web"}}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := docs[0].Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "namespace") {
+		t.Errorf("dropped key survived:\n%s", out)
+	}
+}
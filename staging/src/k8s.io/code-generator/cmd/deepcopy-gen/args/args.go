@@ -22,6 +22,8 @@ import (
 	"github.com/spf13/pflag"
 	"k8s.io/gengo/args"
 	"k8s.io/gengo/examples/deepcopy-gen/generators"
+
+	"k8s.io/code-generator/pkg/commentfilter"
 )
 
 // CustomArgs is used by the gengo framework to pass args specific to this generator.
@@ -42,6 +44,37 @@ func (ca *CustomArgs) AddFlags(fs *pflag.FlagSet) {
 		"Comma-separated list of import paths which bound the types for which deep-copies will be generated.")
 }
 
+// TransformArgs holds comment-transform options. They live beside
+// CustomArgs because CustomArgs is cast to gengo's struct and cannot
+// grow fields.
+type TransformArgs struct {
+	// StripNonASCIIComments filters bilingual comment lines out of the
+	// header boilerplate so generated files match canonical upstream
+	// output byte for byte.
+	StripNonASCIIComments bool
+}
+
+// AddFlags add the transform flags to the flag set.
+func (ta *TransformArgs) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&ta.StripNonASCIIComments, "strip-non-ascii-comments", ta.StripNonASCIIComments,
+		"If true, drop comment lines containing non-ASCII runes from the generated file header.")
+}
+
+// ApplyTo rewrites the header file path to a transformed copy when any
+// transform is enabled. It must run after flag parsing and before the
+// generator executes.
+func (ta *TransformArgs) ApplyTo(genericArgs *args.GeneratorArgs) error {
+	if !ta.StripNonASCIIComments {
+		return nil
+	}
+	transformed, err := commentfilter.ApplyToFile(genericArgs.GoHeaderFilePath, commentfilter.StripNonASCII)
+	if err != nil {
+		return fmt.Errorf("transforming header file %q: %v", genericArgs.GoHeaderFilePath, err)
+	}
+	genericArgs.GoHeaderFilePath = transformed
+	return nil
+}
+
 // Validate checks the given arguments.
 func Validate(genericArgs *args.GeneratorArgs) error {
 	_ = genericArgs.CustomArgs.(*generators.CustomArgs)
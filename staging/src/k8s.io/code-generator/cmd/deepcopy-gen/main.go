@@ -72,9 +72,11 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	genericArgs, customArgs := generatorargs.NewDefaults()
+	transformArgs := &generatorargs.TransformArgs{}
 
 	genericArgs.AddFlags(pflag.CommandLine)
 	customArgs.AddFlags(pflag.CommandLine)
+	transformArgs.AddFlags(pflag.CommandLine)
 	flag.Set("logtostderr", "true")
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
@@ -82,6 +84,9 @@ func main() {
 	if err := generatorargs.Validate(genericArgs); err != nil {
 		klog.Fatalf("Error: %v", err)
 	}
+	if err := transformArgs.ApplyTo(genericArgs); err != nil {
+		klog.Fatalf("Error: %v", err)
+	}
 
 	// Run it.
 	if err := genericArgs.Execute(
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commentfilter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripNonASCIIDropsTranslatedCommentLines(t *testing.T) {
+	in := strings.Join([]string{
+		"// deepcopy-gen is a tool for auto-generating DeepCopy functions.",
+		"// deepcopy-gen是一个自动生成DeepCopy函数的工具。",
+		"//",
+		"// Given a list of input directories, it will generate functions.",
+	}, "\n")
+	out := string(StripNonASCII([]byte(in)))
+	if strings.Contains(out, "工具") {
+		t.Errorf("translated line survived:\n%s", out)
+	}
+	for _, want := range []string{"auto-generating", "Given a list"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ASCII line %q dropped:\n%s", want, out)
+		}
+	}
+}
+
+func TestStripNonASCIIBlockComments(t *testing.T) {
+	in := strings.Join([]string{
+		"/*",
+		"Copyright 2023 The Kubernetes Authors.",
+		"版权所有。",
+		"*/",
+		"",
+		`const s = "值"`,
+	}, "\n")
+	out := string(StripNonASCII([]byte(in)))
+	if strings.Contains(out, "版权") {
+		t.Errorf("translated block line survived:\n%s", out)
+	}
+	if !strings.Contains(out, "Copyright 2023") || !strings.Contains(out, "*/") {
+		t.Errorf("block structure damaged:\n%s", out)
+	}
+	// Non-comment lines keep their non-ASCII content.
+	if !strings.Contains(out, `"值"`) {
+		t.Errorf("code line was modified:\n%s", out)
+	}
+}
+
+func TestChain(t *testing.T) {
+	upper := func(content []byte) []byte { return []byte(strings.ToUpper(string(content))) }
+	double := func(content []byte) []byte { return append(content, content...) }
+	out := Chain(upper, double)([]byte("ab"))
+	if string(out) != "ABAB" {
+		t.Errorf("chain order wrong: %q", out)
+	}
+}
+
+func TestApplyToFile(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "boilerplate.go.txt")
+	original := "// keep\n// 丢弃\n"
+	if err := os.WriteFile(src, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ApplyToFile(src, StripNonASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out)
+
+	transformed, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(transformed), "丢弃") || !strings.Contains(string(transformed), "keep") {
+		t.Errorf("unexpected output: %q", transformed)
+	}
+	untouched, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(untouched) != original {
+		t.Errorf("source file was modified: %q", untouched)
+	}
+}
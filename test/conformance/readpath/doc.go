@@ -0,0 +1,28 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package readpath holds the read-path conformance suite. It exercises
+// list, watch and strict decode of every listable kind against one or more
+// live clusters (typically kind clusters running several recent Kubernetes
+// versions) and fails on decode errors or fields the vendored types would
+// silently drop.
+//
+// The suite needs a cluster and is guarded by a build tag. Point it at the
+// clusters under test and run:
+//
+//	export READPATH_CONFORMANCE_KUBECONFIGS=$HOME/.kube/kind-1.25,$HOME/.kube/kind-1.26
+//	go test -tags conformance ./test/conformance/readpath
+package readpath
@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build conformance
+
+package readpath
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimejson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeconfigsEnv names the environment variable listing the kubeconfig
+// files of the clusters under test, comma separated.
+const kubeconfigsEnv = "READPATH_CONFORMANCE_KUBECONFIGS"
+
+// watchDrainTimeout bounds how long each per-resource watch is held open to
+// exercise the watch decode path.
+const watchDrainTimeout = 2 * time.Second
+
+func TestReadPathConformance(t *testing.T) {
+	env := os.Getenv(kubeconfigsEnv)
+	if env == "" {
+		t.Skipf("%s not set; skipping read-path conformance", kubeconfigsEnv)
+	}
+	for _, kubeconfig := range strings.Split(env, ",") {
+		kubeconfig := kubeconfig
+		t.Run(filepath.Base(kubeconfig), func(t *testing.T) {
+			runSuite(t, kubeconfig)
+		})
+	}
+}
+
+func runSuite(t *testing.T, kubeconfig string) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		t.Fatalf("loading kubeconfig %s: %v", kubeconfig, err)
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		t.Fatalf("building discovery client: %v", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		t.Fatalf("building dynamic client: %v", err)
+	}
+
+	if version, err := discoveryClient.ServerVersion(); err == nil {
+		t.Logf("server version %s", version.GitVersion)
+	}
+
+	resourceLists, err := discoveryClient.ServerPreferredResources()
+	if err != nil {
+		// Partial discovery (e.g. a broken aggregated API) is tolerated;
+		// whatever was discovered is still exercised.
+		t.Logf("partial discovery: %v", err)
+	}
+
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			t.Errorf("unparsable groupVersion %q: %v", resourceList.GroupVersion, err)
+			continue
+		}
+		for i := range resourceList.APIResources {
+			resource := resourceList.APIResources[i]
+			if !hasVerb(resource, "list") {
+				continue
+			}
+			gvr := gv.WithResource(resource.Name)
+			t.Run(gvr.String(), func(t *testing.T) {
+				exerciseResource(t, dynamicClient, gvr, hasVerb(resource, "watch"))
+			})
+		}
+	}
+}
+
+func exerciseResource(t *testing.T, client dynamic.Interface, gvr schema.GroupVersionResource, watchable bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	list, err := client.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	for i := range list.Items {
+		strictDecode(t, &list.Items[i])
+	}
+
+	if !watchable {
+		return
+	}
+	timeout := int64(watchDrainTimeout / time.Second)
+	watcher, err := client.Resource(gvr).Watch(ctx, metav1.ListOptions{
+		ResourceVersion: list.GetResourceVersion(),
+		TimeoutSeconds:  &timeout,
+	})
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	defer watcher.Stop()
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error {
+			t.Errorf("watch error event: %v", event.Object)
+			continue
+		}
+		if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+			strictDecode(t, obj)
+		}
+	}
+}
+
+// strictDecode re-decodes the object's JSON into the vendored typed structs
+// with strict unknown-field checking, so that fields the server sent but
+// this copy of the types would drop show up as failures.
+func strictDecode(t *testing.T, obj *unstructured.Unstructured) {
+	gvk := obj.GroupVersionKind()
+	if !clientsetscheme.Scheme.Recognizes(gvk) {
+		// Aggregated and custom resources have no typed counterpart here;
+		// the unstructured decode above is all the coverage they get.
+		return
+	}
+	raw, err := json.Marshal(obj.Object)
+	if err != nil {
+		t.Errorf("%s %s/%s: re-encoding failed: %v", gvk.Kind, obj.GetNamespace(), obj.GetName(), err)
+		return
+	}
+	serializer := runtimejson.NewSerializerWithOptions(
+		runtimejson.DefaultMetaFactory, clientsetscheme.Scheme, clientsetscheme.Scheme,
+		runtimejson.SerializerOptions{Strict: true},
+	)
+	typed, err := clientsetscheme.Scheme.New(gvk)
+	if err != nil {
+		t.Errorf("%s: instantiating typed object: %v", gvk, err)
+		return
+	}
+	if _, _, err := serializer.Decode(raw, &gvk, typed); err != nil {
+		if runtime.IsStrictDecodingError(err) {
+			t.Errorf("%s %s/%s: dropped fields: %v", gvk.Kind, obj.GetNamespace(), obj.GetName(), err)
+		} else {
+			t.Errorf("%s %s/%s: decode error: %v", gvk.Kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+}
+
+func hasVerb(resource metav1.APIResource, verb string) bool {
+	for _, v := range resource.Verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}